@@ -2,23 +2,50 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-coders/check-gpt/internal/apiconfig"
 	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/internal/bench"
+	"github.com/go-coders/check-gpt/internal/billing"
+	"github.com/go-coders/check-gpt/internal/capability"
+	"github.com/go-coders/check-gpt/internal/discovery"
+	"github.com/go-coders/check-gpt/internal/image"
+	"github.com/go-coders/check-gpt/internal/monitor"
+	"github.com/go-coders/check-gpt/internal/preflight"
+	"github.com/go-coders/check-gpt/internal/profile"
+	"github.com/go-coders/check-gpt/internal/relayinfo"
+	"github.com/go-coders/check-gpt/internal/report"
+	"github.com/go-coders/check-gpt/internal/runlog"
 	"github.com/go-coders/check-gpt/internal/server"
 	"github.com/go-coders/check-gpt/internal/server/trace"
+	"github.com/go-coders/check-gpt/internal/store"
+	"github.com/go-coders/check-gpt/internal/tunnel"
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/internal/verify"
 	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/httpclient"
 	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/schema"
 	"github.com/go-coders/check-gpt/pkg/util"
 )
 
 func startServer(ctx context.Context, srv *server.Server) error {
-	util.ClearConsole()
-
 	// Start server in goroutine
 	errChan := make(chan error, 1)
 	go func() {
@@ -36,9 +63,27 @@ func startServer(ctx context.Context, srv *server.Server) error {
 	}
 }
 
+// readPassphrase prompts for the passphrase of the encrypted profile storage
+func readPassphrase(confirm bool) (string, error) {
+	passphrase, err := util.ReadPassword("请输入配置文件口令: ")
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+	again, err := util.ReadPassword("请再次输入口令: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != again {
+		return "", fmt.Errorf("两次输入的口令不一致")
+	}
+	return passphrase, nil
+}
+
 func runApiTest(item util.MenuItem, cfg *config.Config) error {
 	util.ClearConsole()
 	configReader := apiconfig.NewConfigReader(os.Stdin, os.Stdout)
+	configReader.Profiles = profile.Default(readPassphrase)
+	configReader.ModelCache = discovery.LoadCache(config.DefaultModelCachePath())
 	configReader.Printer.PrintTitle(item.Label, item.Emoji)
 
 	apiCfg, err := configReader.ReadValidTestConfig()
@@ -46,76 +91,421 @@ func runApiTest(item util.MenuItem, cfg *config.Config) error {
 		return fmt.Errorf("错误: %v", err)
 	}
 
+	// Large runs fire hundreds of requests, let the user review them first
+	switch {
+	case cfg.Yes:
+	case util.IsInteractive() && util.GetVerbosity() == util.VerbosityNormal:
+		ok, err := configReader.ConfirmRun(apiCfg, apiconfig.RunPlan{
+			Concurrency: cfg.MaxConcurrency,
+			Runs:        cfg.Runs(),
+			PerTest:     cfg.RequestsPerTest(),
+			MaxRequests: cfg.MaxRequests,
+		})
+		if err != nil {
+			return fmt.Errorf("错误: %v", err)
+		}
+		if !ok {
+			configReader.Printer.Printf("%s已取消测试%s\n", util.ColorGray, util.ColorReset)
+			return nil
+		}
+	default:
+		if err := checkRequestLimit(cfg, apiCfg.Requests()*cfg.Runs()*cfg.RequestsPerTest()); err != nil {
+			return err
+		}
+	}
+
 	var channels []*apitest.Channel
 	for i, key := range apiCfg.Keys {
+		channelType, keyURL, models := apiCfg.KeyChannel(key)
 		channel := &apitest.Channel{
-			Type:      apitest.ChannelType(apiCfg.Type),
+			Type:      channelType,
 			Key:       key,
-			TestModel: apiCfg.ValidTestModel,
-			URL:       apiCfg.URL,
+			TestModel: models,
+			URL:       keyURL,
+			Protocol:  cfg.Protocol,
 		}
 		channels = append(channels, channel)
-		logger.Debug("Created channel #%d with key: %s", i+1, util.MaskKey(key, 8, 8))
+		logger.Debug("Created channel #%d with key: %s", i+1, util.MaskKey(key))
 	}
 
 	//  configs
 	util.ClearConsole()
 	configReader.ShowConfig(apiCfg)
+	// Ctrl+C cancels the requests in flight and shows the results collected so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if apiCfg.DiscoverModels {
+		if err := discoverChannelModels(ctx, configReader.Printer, cfg, configReader.ModelCache, channels); err != nil {
+			return err
+		}
+	}
+	pre := preflight.Run(ctx, apiCfg.URL)
+	pre.Print(configReader.Printer)
 	configReader.Printer.PrintTesting()
-	ct := apitest.NewApiTest(cfg.MaxConcurrency)
-	results := ct.TestAllApis(channels)
+	var output bytes.Buffer
+	control, stopControl := runControl(configReader.Printer)
+	ct := apitest.NewApiTest(cfg.MaxConcurrency, testOptions(cfg, apitest.WithPrinter(util.NewPrinter(&output)), apitest.WithControl(control))...)
+	results := ct.TestAllApis(ctx, channels)
+	stopControl()
+
+	// Thousands of keys are paged interactively instead of being dumped at once
+	paginate := cfg.PageSize > 0 && len(apiCfg.Keys) > cfg.PageSize &&
+		util.IsInteractive() && util.GetVerbosity() == util.VerbosityNormal
+	if paginate {
+		apitest.PageResults(configReader.Printer, os.Stdin, results, cfg.PageSize)
+	} else {
+		ct.PrintResults(results)
+	}
+	conn := endpointConnection(cfg, apiCfg.URL)
+	var software *relayinfo.Software
+	var relay *billing.Relay
+	// Official endpoints are not relays
+	if apiCfg.Type == types.ChannelTypeOpenAI {
+		if relay, err = billing.NewClient(cfg.Timeout).DetectRelay(ctx, apiCfg.URL); err != nil {
+			logger.Debug("Relay detection skipped: %v", err)
+		}
+		software = relayinfo.Detect(ctx, apiCfg.URL, relay)
+	}
+	showConnection(util.NewPrinter(&output), conn, software)
+	if relay != nil {
+		showRelayQuota(ctx, util.NewPrinter(&output), cfg, apiCfg, relay)
+	}
 
-	ct.PrintResults(results)
+	capabilities := runProbes(ctx, util.NewPrinter(&output), cfg, apiCfg, results)
+
+	var vantages []apitest.Vantage
+	if len(cfg.Proxies) > 0 {
+		vantages = runVantages(ctx, cfg, channels, results)
+		apitest.PrintMatrix(util.NewPrinter(&output), apiCfg.Keys, vantages)
+	}
+	stop()
+	weights := apitest.SuggestWeights(apiCfg.Keys, results)
+	if len(weights) > 1 {
+		apitest.PrintWeights(util.NewPrinter(&output), weights)
+	}
+	if cfg.NoPager || util.GetVerbosity() < util.VerbosityNormal {
+		configReader.Printer.Write(output.Bytes())
+	} else if err := util.Page(output.String()); err != nil {
+		logger.Debug("Pager failed: %v", err)
+	}
+
+	if util.IsInteractive() && util.GetVerbosity() == util.VerbosityNormal {
+		apitest.Inspect(configReader.Printer, os.Stdin, results)
+	}
+
+	if err := runlog.New(cfg.RunLogPath).Append(runlog.FromResults(apiCfg.URL, results)); err != nil {
+		logger.Debug("Failed to write run log: %v", err)
+	}
+
+	if cfg.WeightPath != "" {
+		if err := report.WriteWeights(cfg.WeightPath, apiCfg.URL, weights); err != nil {
+			configReader.Printer.PrintError(fmt.Sprintf("错误: %v", err))
+		} else {
+			configReader.Printer.Printf("\n权重建议已导出: %s\n", cfg.WeightPath)
+		}
+	}
+
+	if cfg.ReportPath != "" {
+		r := report.FromResults(apiCfg.URL, results)
+		r.Connection = conn
+		r.Software = software
+		r.Preflight = pre
+		r.Capability = capabilities
+		// The first vantage is the direct run already in the report
+		for i := 1; i < len(vantages); i++ {
+			r.AddResults(vantages[i].Name, vantages[i].Results)
+		}
+		if err := exportReport(configReader.Printer, cfg, r); err != nil {
+			configReader.Printer.PrintError(fmt.Sprintf("错误: %v", err))
+		}
+	}
 
 	configReader.Printer.PrintSuccess("测试完毕")
-	printTime := time.Now()
 
-	configReader.Printer.Printf("\n%s按回车键继续...%s", util.ColorGray, util.ColorReset)
+	if err := configReader.PromptSaveProfile(apiCfg); err != nil {
+		return err
+	}
 
-	for {
-		bufio.NewReader(os.Stdin).ReadString('\n')
-		if time.Since(printTime) < 10*time.Millisecond {
-			continue
+	return nil
+}
+
+// runControl forwards the pause, resume and abort key presses to the running test,
+// stop ends listening and restores the terminal
+func runControl(printer *util.Printer) (<-chan apitest.Command, func()) {
+	if util.GetVerbosity() != util.VerbosityNormal {
+		return nil, func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	keys := util.ListenKeys(ctx)
+	if keys == nil {
+		cancel()
+		return nil, func() {}
+	}
+	printer.Printf("%s按 %c 暂停, %c 继续, %c 中止并显示已完成的结果%s\n",
+		util.ColorGray, apitest.KeyPause, apitest.KeyResume, apitest.KeyAbort, util.ColorReset)
+
+	control := make(chan apitest.Command)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for key := range keys {
+			cmd, ok := apitest.ParseCommand(key)
+			if !ok {
+				continue
+			}
+			select {
+			case control <- cmd:
+			case <-ctx.Done():
+				continue
+			}
+			switch cmd {
+			case apitest.CommandPause:
+				printer.Printf("%s已暂停, 进行中的请求完成后不再发送新请求, 按 %c 继续%s\n", util.ColorYellow, apitest.KeyResume, util.ColorReset)
+			case apitest.CommandResume:
+				printer.Printf("%s继续测试%s\n", util.ColorGray, util.ColorReset)
+			case apitest.CommandAbort:
+				printer.Printf("%s正在中止, 等待进行中的请求完成...%s\n", util.ColorYellow, util.ColorReset)
+			}
 		}
-		break
+	}()
+	return control, func() {
+		cancel()
+		<-done
 	}
+}
 
+// testOptions adds the API test options selected by the command line flags to opts
+func testOptions(cfg *config.Config, opts ...apitest.ChannelTestOption) []apitest.ChannelTestOption {
+	if cfg.FailFastPerKey {
+		opts = append(opts, apitest.WithFailFastPerKey())
+	}
+	if cfg.NoKeyCheck {
+		opts = append(opts, apitest.WithoutKeyCheck())
+	}
+	if cfg.ToolCheck {
+		opts = append(opts, apitest.WithToolCheck())
+	}
+	if cfg.VisionCheck {
+		opts = append(opts, apitest.WithVisionCheck())
+	}
+	if cfg.StreamTest {
+		opts = append(opts, apitest.WithStreamTest())
+	}
+	if cfg.Protocol == apitest.ProtocolResponses {
+		opts = append(opts, apitest.WithProtocol(apitest.ProtocolResponses))
+	}
+	return opts
+}
+
+// checkRequestLimit refuses runs above -max-requests that were not confirmed with -yes
+func checkRequestLimit(cfg *config.Config, requests int) error {
+	if cfg.ExceedsRequestLimit(requests) {
+		return fmt.Errorf("预计请求数 %d (约 %d tokens) 超过 -max-requests %d, 确认后加上 -yes 重新运行",
+			requests, requests*apitest.EstimatedTokensPerRequest, cfg.MaxRequests)
+	}
 	return nil
 }
 
-func runDetection(ctx context.Context, srv *server.Server, cfg *config.Config, item util.MenuItem) error {
-	var apiCfg *apiconfig.Config
-	var err error
-	configReader := apiconfig.NewConfigReader(os.Stdin, os.Stdout)
-	util.ClearConsole()
-	configReader.Printer.PrintTitle(item.Label, item.Emoji)
+// discoverChannelModels replaces the models of every key with the list its endpoint exposes at /v1/models,
+// the matrix it builds is checked against -max-requests again since the confirmation only saw an estimate
+func discoverChannelModels(ctx context.Context, printer *util.Printer, cfg *config.Config, cache *discovery.Cache, channels []*apitest.Channel) error {
+	printer.Printf("%s正在逐个 Key 获取模型列表...%s\n", util.ColorGray, util.ColorReset)
+	var mu sync.Mutex
+	cachedAt := make(map[string]time.Time)
+	errs := apitest.DiscoverModels(ctx, channels, cfg.MaxConcurrency, func(ctx context.Context, apiURL, key string) ([]string, error) {
+		models, cached, err := cache.Models(ctx, apiURL, key)
+		mu.Lock()
+		cachedAt[key] = cached
+		mu.Unlock()
+		return models, err
+	})
+	for i, c := range channels {
+		if err, failed := errs[i]; failed {
+			printer.PrintWarning(fmt.Sprintf("%s 获取模型列表失败, 改为测试默认模型: %v", util.MaskKey(c.Key), err))
+			continue
+		}
+		key := c.Key
+		if c.Type == apitest.ChannelTypeLocal {
+			key = ""
+		}
+		note := ""
+		if at := cachedAt[key]; !at.IsZero() {
+			note = " (" + discovery.CachedAgo(at, time.Now()) + ")"
+		}
+		printer.Printf("%s%s: %d 个模型%s%s\n", util.ColorGray, util.MaskKey(c.Key), len(c.TestModel), note, util.ColorReset)
+	}
+	if cfg.Yes {
+		return nil
+	}
+	return checkRequestLimit(cfg, apitest.CountRequests(channels)*cfg.Runs()*cfg.RequestsPerTest())
+}
 
-	// Get API configuration from user input
-	apiCfg, err = apiconfig.GetLinkConfig(os.Stdin)
+// runProbes runs the selected capability probes with the first working key and model
+func runProbes(ctx context.Context, printer *util.Printer, cfg *config.Config, apiCfg *apiconfig.Config, results []apitest.TestResult) []capability.Result {
+	if cfg.Probes == "" || len(apiCfg.Keys) == 0 {
+		return nil
+	}
+	probes, err := capability.Parse(cfg.Probes)
 	if err != nil {
-		return fmt.Errorf("错误: %v", err)
+		printer.PrintError(err.Error())
+		return nil
 	}
-	// clearn the console
-	util.ClearConsole()
-	// show the config
 
-	apiCfg.ImageURL = srv.GetTunnelImageUrl()
+	key := apiCfg.Keys[0]
+	model := ""
+	if len(apiCfg.ValidTestModel) > 0 {
+		model = apiCfg.ValidTestModel[0]
+	}
+	for _, result := range results {
+		if result.Success && result.Channel != nil {
+			key, model = result.Channel.Key, result.Model
+			break
+		}
+	}
 
-	configReader.ShowConfig(apiCfg)
+	if apiCfg.Type == types.ChannelTypeLocal {
+		key = ""
+	}
+	client := capability.NewClient(apiCfg.URL, key, model, cfg.Timeout)
+	capabilities := capability.Run(ctx, client, probes)
+	capability.Print(printer, client, capabilities)
+	return capabilities
+}
 
-	configReader.Printer.PrintTesting()
+// runVantages runs the same tests through every proxy in the pool, the direct results come first
+func runVantages(ctx context.Context, cfg *config.Config, channels []*apitest.Channel, direct []apitest.TestResult) []apitest.Vantage {
+	vantages := []apitest.Vantage{{Name: "直连", Results: direct}}
+	for _, p := range cfg.Proxies {
+		proxy, err := url.Parse(p.URL)
+		if err != nil {
+			logger.Debug("Skipping invalid proxy %s: %v", p.Name, err)
+			continue
+		}
+		ct := apitest.NewApiTest(cfg.MaxConcurrency, testOptions(cfg, apitest.WithProxy(proxy))...)
+		vantages = append(vantages, apitest.Vantage{Name: p.Name, Results: ct.TestAllApis(ctx, channels)})
+	}
+	return vantages
+}
 
-	// Create trace manager
-	tracer := trace.New(srv, trace.WithConfig(cfg))
+// endpointConnection returns the connection used to reach the API host during the test
+func endpointConnection(cfg *config.Config, apiURL string) *report.Connection {
+	u, err := url.Parse(util.NormalizeURL(apiURL))
+	if err != nil {
+		return nil
+	}
+	c, ok := httpclient.LastConnection(u.Hostname())
+	if !ok {
+		return nil
+	}
+	return &report.Connection{Connection: c, Network: cfg.IPNetwork(c.IP)}
+}
 
-	// Start trace manager
-	tracer.Start(ctx)
+// showConnection prints the IP, network and certificate of the endpoint connection and the detected relay software
+func showConnection(printer *util.Printer, conn *report.Connection, software *relayinfo.Software) {
+	if conn == nil && software == nil {
+		return
+	}
+	printer.PrintTitle("连接信息", util.EmojiLink)
 
-	// Start API request in background using first key
-	if len(apiCfg.Keys) > 0 {
-		go srv.SendPostRequest(ctx, apiCfg.URL, apiCfg.Keys[0], apiCfg.LinkTestModel, cfg.Stream)
-	} else {
-		return fmt.Errorf(config.ErrorNoAPIKey)
+	if software != nil {
+		if software.Name != "" {
+			printer.Printf("│ 中转程序: %s\n", software)
+		}
+		if software.Server != "" {
+			printer.Printf("│ 服务器: %s\n", software.Server)
+		}
+	}
+	if conn == nil {
+		return
+	}
+
+	network := conn.Network
+	if network == "" {
+		network = "未知网段"
+	}
+	printer.Printf("│ 地址: %s -> %s (%s)\n", conn.Host, conn.IP, network)
+	if conn.TLSVersion == "" {
+		printer.Printf("│ %s%s 未使用 TLS%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+		return
+	}
+	printer.Printf("│ SNI: %s (%s)\n", conn.SNI, conn.TLSVersion)
+	printer.Printf("│ 证书: %s, 颁发者: %s\n", conn.Subject, conn.Issuer)
+}
+
+// showRelayQuota prints the token quota of every key of the one-api/new-api relay
+func showRelayQuota(ctx context.Context, printer *util.Printer, cfg *config.Config, apiCfg *apiconfig.Config, relay *billing.Relay) {
+	client := billing.NewClient(cfg.Timeout)
+	logger.Debug("Detected relay %s %s", relay.System, relay.Version)
+	printer.PrintTitle("中转额度", util.EmojiKey)
+
+	ratiosShown := false
+	for _, key := range apiCfg.Keys {
+		quota, err := client.QueryRelay(ctx, relay, apiCfg.URL, key)
+		if err != nil {
+			printer.Printf("│ %s%s %s 额度查询失败: %s%s\n", util.ColorYellow, util.EmojiWarning,
+				util.MaskKey(key), util.MaskSecrets(err.Error(), key), util.ColorReset)
+			continue
+		}
+
+		amount := fmt.Sprintf("剩余 $%.2f (总额 $%.2f，已用 $%.2f)", quota.Remaining, quota.Total, quota.Used)
+		if quota.Unlimited {
+			amount = fmt.Sprintf("无限额度 (已用 $%.2f)", quota.Used)
+		}
+		if !quota.ExpiresAt.IsZero() {
+			amount += fmt.Sprintf("，有效期至 %s", quota.ExpiresAt.Format("2006-01-02"))
+		}
+		if ratio, ok := quota.GroupRatios[quota.Group]; ok {
+			amount += fmt.Sprintf("，分组 %s (倍率 %g)", quota.Group, ratio)
+		} else if quota.Group != "" {
+			amount += fmt.Sprintf("，分组 %s", quota.Group)
+		}
+		printer.Printf("│ %s: %s\n", util.MaskKey(key), amount)
+
+		if quota.Group == "" && len(quota.GroupRatios) > 0 && !ratiosShown {
+			groups := make([]string, 0, len(quota.GroupRatios))
+			for group, ratio := range quota.GroupRatios {
+				groups = append(groups, fmt.Sprintf("%s=%g", group, ratio))
+			}
+			sort.Strings(groups)
+			printer.Printf("│ 分组倍率: %s\n", strings.Join(groups, ", "))
+			ratiosShown = true
+		}
+	}
+}
+
+// exportReport writes the report and its optional manifest and signature
+func exportReport(printer *util.Printer, cfg *config.Config, r *report.Report) error {
+	if err := report.Write(cfg.ReportPath, r); err != nil {
+		return err
+	}
+	printer.Printf("\n报告已导出: %s\n", cfg.ReportPath)
+
+	if cfg.Manifest || cfg.SignKeyPath != "" {
+		manifestPath, err := report.WriteManifest(cfg.ReportPath)
+		if err != nil {
+			return err
+		}
+		printer.Printf("校验文件: %s\n", manifestPath)
+	}
+
+	if cfg.SignKeyPath != "" {
+		sigPath, err := report.Sign(cfg.ReportPath, cfg.SignKeyPath)
+		if err != nil {
+			return err
+		}
+		printer.Printf("签名文件: %s\n", sigPath)
+	}
+	return nil
+}
+
+func runDetection(ctx context.Context, srv *server.Server, cfg *config.Config, item util.MenuItem) error {
+	printer := util.NewPrinter(os.Stdout)
+	util.ClearConsole()
+	printer.PrintTitle(item.Label, item.Emoji)
+
+	tracer, apiCfg, err := startDetection(ctx, srv, cfg, os.Stdout)
+	if err != nil {
+		return err
 	}
 
 	logger.Debug("Waiting for trace completion or context cancellation")
@@ -124,9 +514,10 @@ func runDetection(ctx context.Context, srv *server.Server, cfg *config.Config, i
 		logger.Debug("Context cancelled in runDetection")
 		return fmt.Errorf("context cancelled")
 	case <-tracer.Done():
-		configReader.Printer.PrintSuccess("测试完成")
+		logTrace(cfg, apiCfg, tracer)
+		printer.PrintSuccess("测试完成")
 		finalShowTime := time.Now()
-		configReader.Printer.Printf("\n%s按回车键继续...%s", util.ColorGray, util.ColorReset)
+		printer.Printf("\n%s按回车键继续...%s", util.ColorGray, util.ColorReset)
 
 		for {
 			bufio.NewReader(os.Stdin).ReadString('\n')
@@ -141,6 +532,714 @@ func runDetection(ctx context.Context, srv *server.Server, cfg *config.Config, i
 	}
 }
 
+// startDetection reads the link configuration and starts the trace
+func startDetection(ctx context.Context, srv *server.Server, cfg *config.Config, out io.Writer) (*trace.Manager, *apiconfig.Config, error) {
+	var apiCfg *apiconfig.Config
+	var err error
+	configReader := apiconfig.NewConfigReader(os.Stdin, out)
+
+	// Get API configuration from the cURL template or user input
+	if cfg.FromCurl != "" {
+		// The flag was validated at startup
+		template, _ := util.ParseCurl(cfg.FromCurl)
+		apiCfg = &apiconfig.Config{Keys: []string{template.Key}, URL: template.URL, LinkTestModel: template.Model}
+	} else {
+		apiCfg, err = configReader.ReadLinkConfig()
+		if err != nil {
+			return nil, nil, fmt.Errorf("错误: %v", err)
+		}
+	}
+	// clearn the console
+	if !cfg.Trace {
+		util.ClearConsole()
+	}
+	tracer, err := beginTrace(ctx, srv, cfg, apiCfg, out)
+	return tracer, apiCfg, err
+}
+
+// beginTrace shows the link configuration and sends the detection request, the trace runs in the background
+// and prints to out
+func beginTrace(ctx context.Context, srv *server.Server, cfg *config.Config, apiCfg *apiconfig.Config, out io.Writer) (*trace.Manager, error) {
+	configReader := apiconfig.NewConfigReader(os.Stdin, out)
+	apiCfg.ImageURL = srv.GetTunnelImageUrl()
+
+	configReader.ShowConfig(apiCfg)
+	if cfg.FromCurl != "" {
+		configReader.Printer.Printf("请求模板: cURL 命令 (重放其请求头和请求体字段)\n")
+	}
+	preflight.Run(ctx, apiCfg.URL).Print(configReader.Printer)
+
+	// Create trace manager
+	// The flag was validated at startup
+	signature, _ := trace.ParseNodeSignature(cfg.NodeMatch)
+	tracer := trace.New(srv, trace.WithConfig(cfg), trace.WithNodeSignature(signature), trace.WithOutputWriter(out))
+	srv.SetEvents(tracer.WebSocketHandler())
+	configReader.Printer.Printf("%s实时查看: %s%s\n", util.ColorGray, srv.EventsURL(), util.ColorReset)
+
+	configReader.Printer.PrintTesting()
+
+	// Start trace manager
+	tracer.Start(ctx)
+
+	// Start API request in background using first key
+	if len(apiCfg.Keys) == 0 {
+		return nil, fmt.Errorf(config.ErrorNoAPIKey)
+	}
+	go srv.SendPostRequest(ctx, apiCfg.URL, apiCfg.Keys[0], apiCfg.LinkTestModel, cfg.Stream)
+	return tracer, nil
+}
+
+// Exit codes of -trace
+const (
+	exitTraceError  = 1 // 检测出错或超时
+	exitTraceFailed = 2 // 结论不满足 -trace-policy
+)
+
+// runTrace runs one link detection without the menu and returns the exit code of its verdict.
+// With -output json the progress goes to stderr so stdout holds only the JSON result.
+func runTrace(cfg *config.Config, policy trace.Policy) int {
+	out := io.Writer(os.Stdout)
+	if cfg.Output == config.OutputJSON {
+		out = os.Stderr
+	}
+	printer := util.NewPrinter(out)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	srv := server.New(cfg)
+	defer srv.Shutdown()
+	result := trace.Result{Failed: []string{}}
+	tracer, apiCfg, err := func() (*trace.Manager, *apiconfig.Config, error) {
+		if err := startServer(ctx, srv); err != nil {
+			return nil, nil, err
+		}
+		return startDetection(ctx, srv, cfg, out)
+	}()
+	if err == nil {
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("检测已取消")
+		case <-tracer.Done():
+			logTrace(cfg, apiCfg, tracer)
+			result = tracer.Result(policy, apiCfg.URL, apiCfg.LinkTestModel)
+			result.Error = util.MaskSecrets(result.Error, apiCfg.Keys[0])
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	if cfg.Output == config.OutputJSON {
+		result.SchemaVersion = schema.Version
+		result.Run = schema.NewRun(result.Endpoint)
+		data, _ := json.MarshalIndent(result, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else if err != nil {
+		printer.PrintError(fmt.Sprintf("错误: %v", err))
+	} else if len(result.Failed) > 0 {
+		printer.PrintWarning(fmt.Sprintf("未通过判定条件: %s", strings.Join(result.Failed, ", ")))
+	}
+
+	switch {
+	case result.Pass:
+		return 0
+	case result.Verdict == nil || !result.Verdict.Answered:
+		return exitTraceError
+	case result.Inconclusive && len(result.Failed) == 0:
+		// The policy holds nothing against the answer, but without an image fetch there is no chain to judge
+		return exitTraceError
+	default:
+		return exitTraceFailed
+	}
+}
+
+// runVerify re-traces the relays registered in the config file and compares each verdict with the baseline
+// taken at its first verification, it returns exit code 2 when a relay degraded
+func runVerify(cfg *config.Config, policy trace.Policy) int {
+	printer := util.NewPrinter(os.Stdout)
+	relays, err := selectRelays(cfg)
+	if err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+	store, err := verify.Load(cfg.VerifyState)
+	if err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	var outcomes []verify.Outcome
+	// Consecutive relays share one tunnel
+	session := &server.Session{}
+	defer session.Close()
+	for _, relay := range relays {
+		if ctx.Err() != nil {
+			break
+		}
+		printer.PrintTitle("复检 "+relay.Name, util.EmojiAPI)
+		result := verifyRelay(ctx, cfg, policy, relay, session)
+		purchased, _ := relay.Purchased()
+		outcomes = append(outcomes, store.Add(relay.Name, purchased, verify.FromResult(result, time.Now())))
+	}
+	if err := store.Save(); err != nil {
+		printer.PrintWarning(err.Error())
+	}
+
+	if verify.Print(printer, outcomes) > 0 {
+		return exitTraceFailed
+	}
+	return 0
+}
+
+// selectRelays returns the registered relays named on the command line, all of them with --all
+func selectRelays(cfg *config.Config) ([]config.RelayItem, error) {
+	if len(cfg.Relays) == 0 {
+		return nil, fmt.Errorf("中转列表为空，请在配置文件的 relays 中添加: %s", cfg.ConfigPath)
+	}
+	if cfg.VerifyAll {
+		return cfg.Relays, nil
+	}
+	if len(cfg.VerifyNames) == 0 {
+		return nil, fmt.Errorf("请指定中转名称或使用 check-gpt verify --all")
+	}
+	var relays []config.RelayItem
+	for _, name := range cfg.VerifyNames {
+		found := false
+		for _, relay := range cfg.Relays {
+			if relay.Name == name {
+				relays = append(relays, relay)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("中转列表中没有: %s", name)
+		}
+	}
+	return relays, nil
+}
+
+// verifyRelay runs one link detection against a registered relay through the tunnel of session
+func verifyRelay(ctx context.Context, cfg *config.Config, policy trace.Policy, relay config.RelayItem, session *server.Session) trace.Result {
+	model := relay.Model
+	if model == "" {
+		model = config.LinkTestDefaultModel
+	}
+	apiCfg := &apiconfig.Config{
+		Keys:          []string{relay.Key},
+		URL:           util.ResolveEndpoint(ctx, relay.URL),
+		LinkTestModel: model,
+	}
+	result := trace.Result{Failed: []string{}, Endpoint: apiCfg.URL, Model: model}
+
+	srv := server.New(cfg, server.WithSession(session))
+	defer srv.Shutdown()
+	if err := startServer(ctx, srv); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	tracer, err := beginTrace(ctx, srv, cfg, apiCfg, os.Stdout)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	select {
+	case <-ctx.Done():
+		result.Error = "检测已取消"
+		return result
+	case <-tracer.Done():
+	}
+	logTrace(cfg, apiCfg, tracer)
+	result = tracer.Result(policy, apiCfg.URL, model)
+	result.Error = util.MaskSecrets(result.Error, relay.Key)
+	return result
+}
+
+// logTrace appends the outcome of a link detection to the run log
+func logTrace(cfg *config.Config, apiCfg *apiconfig.Config, tracer *trace.Manager) {
+	e := runlog.Entry{
+		Mode:     "trace",
+		Endpoint: apiCfg.URL,
+		Keys:     1,
+		Models:   1,
+		Success:  1,
+		Verdict:  runlog.VerdictOK,
+		Message:  fmt.Sprintf("%d nodes, %d hops", len(tracer.GetNodes()), len(tracer.GetHops())),
+	}
+	if failure := tracer.Failure(); failure != "" {
+		e.Success, e.Failed = 0, 1
+		e.Verdict = runlog.VerdictFailed
+		e.Message = util.MaskSecrets(failure, apiCfg.Keys[0])
+		if tracer.TimedOut() {
+			e.Message = fmt.Sprintf("%d nodes, %s", len(tracer.GetNodes()), e.Message)
+		}
+	}
+	e.Details = []runlog.KeyVerdict{{
+		Key:     util.MaskKey(apiCfg.Keys[0]),
+		KeyID:   runlog.KeyID(apiCfg.Keys[0]),
+		Verdict: e.Verdict,
+	}}
+	if err := runlog.New(cfg.RunLogPath).Append(e); err != nil {
+		logger.Debug("Failed to write run log: %v", err)
+	}
+}
+
+// runBench sends the same workload to the two endpoints of bench --compare and prints their comparison
+func runBench(cfg *config.Config) int {
+	printer := util.NewPrinter(os.Stdout)
+	if len(cfg.BenchCompare) != 2 {
+		printer.PrintError("用法: check-gpt [参数] bench --compare <中转名称或 URL> <中转名称或 URL>")
+		return exitTraceError
+	}
+	if cfg.BenchRequests < 2 {
+		printer.PrintError("-bench-requests 至少为 2")
+		return exitTraceError
+	}
+	if err := checkRequestLimit(cfg, 2*cfg.BenchRequests); err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	var stats []bench.Stats
+	for _, target := range cfg.BenchCompare {
+		channel, model, err := targetChannel(ctx, cfg, target)
+		if err != nil {
+			printer.PrintError(err.Error())
+			return exitTraceError
+		}
+		printer.PrintTitle(fmt.Sprintf("基准测试 %s (%s, %d 次请求)", target, model, cfg.BenchRequests), util.EmojiAPI)
+		request := benchRequest(apitest.NewApiTest(cfg.MaxConcurrency), channel, model)
+		stats = append(stats, bench.Run(ctx, target, cfg.BenchRequests, cfg.MaxConcurrency, request))
+	}
+	if ctx.Err() != nil {
+		printer.PrintWarning("基准测试已取消, 以下为已完成的请求")
+	}
+	bench.Print(printer, bench.Compare(stats[0], stats[1]))
+	return 0
+}
+
+// benchRequest sends the model test request of the channel as one benchmark request
+func benchRequest(tester apitest.APITester, channel *apitest.Channel, model string) bench.Request {
+	return func(ctx context.Context) bench.Sample {
+		opts := apitest.DefaultRequestOptions()
+		opts.Protocol = channel.Protocol
+		result := tester.TestChannel(ctx, &apitest.TestConfig{Channel: channel, Model: model, RequestOpts: opts})
+		if result.Error != nil {
+			logger.Debug("Bench request to %s failed: %v", channel.Endpoint, result.Error)
+		}
+		return bench.Sample{OK: result.Success, Latency: result.Latency, Reset: bench.IsConnReset(result.Error)}
+	}
+}
+
+// runLoad sends requests to one endpoint at the -rps rate for -duration and prints how it held up
+func runLoad(cfg *config.Config) int {
+	printer := util.NewPrinter(os.Stdout)
+	if cfg.LoadTarget == "" {
+		printer.PrintError("用法: check-gpt -rps 5 -duration 60s load <中转名称或 URL>")
+		return exitTraceError
+	}
+	if !bench.ValidRPS(cfg.LoadRPS) {
+		printer.PrintError(fmt.Sprintf("-rps 应大于 0 且不超过 %d", bench.MaxLoadRPS))
+		return exitTraceError
+	}
+	if cfg.LoadDuration <= 0 || cfg.LoadDuration > bench.MaxLoadDuration {
+		printer.PrintError(fmt.Sprintf("-duration 应大于 0 且不超过 %s", bench.MaxLoadDuration))
+		return exitTraceError
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	channel, model, err := targetChannel(ctx, cfg, cfg.LoadTarget)
+	if err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+	requests := int(math.Ceil(cfg.LoadRPS * cfg.LoadDuration.Seconds()))
+	if err := checkRequestLimit(cfg, requests); err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+	if err := confirmLoad(cfg, printer, channel, requests); err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+
+	printer.PrintTitle(fmt.Sprintf("压测 %s (%s, %.2f 次/秒, %s)", cfg.LoadTarget, model, cfg.LoadRPS, cfg.LoadDuration), util.EmojiAPI)
+	result := bench.Load(ctx, cfg.LoadTarget, cfg.LoadRPS, cfg.LoadDuration, benchRequest(apitest.NewApiTest(bench.MaxLoadRPS), channel, model))
+	if ctx.Err() != nil {
+		printer.PrintWarning("压测已取消, 以下为已完成的请求")
+	}
+	bench.PrintLoad(printer, result)
+	return 0
+}
+
+// runRelayServe runs the relay -relay clients expose their link detection server through until Ctrl+C
+func runRelayServe(cfg *config.Config) int {
+	printer := util.NewPrinter(os.Stdout)
+	token := cfg.RelayToken
+	if token == "" {
+		b := make([]byte, 16)
+		if _, err := cryptorand.Read(b); err != nil {
+			printer.PrintError(fmt.Sprintf("生成中继令牌失败: %v", err))
+			return 1
+		}
+		token = hex.EncodeToString(b)
+	}
+
+	relay := tunnel.NewRelayServer(token)
+	// Bounds the headers of public requests, the WebSocket connections stay open once upgraded
+	srv := &http.Server{Addr: cfg.RelayServe, Handler: relay, ReadHeaderTimeout: 10 * time.Second}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	printer.PrintTitle(fmt.Sprintf("中继已启动: %s", cfg.RelayServe), util.EmojiLink)
+	printer.Printf("在需要链路检测的机器上运行: check-gpt -relay ws://<本机公网地址>%s -relay-token %s\n", tunnel.RelayConnectPath, token)
+	printer.Printf("经 HTTPS 反向代理暴露时使用 wss://, Ctrl+C 退出\n")
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		printer.PrintError(fmt.Sprintf("启动中继失败: %v", err))
+		return 1
+	}
+	return 0
+}
+
+// runSoak keeps a small steady request flow to one endpoint for hours, rewriting the checkpoint report as it goes
+func runSoak(cfg *config.Config) int {
+	printer := util.NewPrinter(os.Stdout)
+	if cfg.SoakTarget == "" {
+		printer.PrintError("用法: check-gpt -duration 6h soak <中转名称或 URL>")
+		return exitTraceError
+	}
+	if cfg.SoakInterval < time.Second || cfg.SoakCheckpoint < cfg.SoakInterval || cfg.SoakDuration <= 0 {
+		printer.PrintError("-soak-interval 至少为 1s, -soak-checkpoint 不小于 -soak-interval, -duration 应大于 0")
+		return exitTraceError
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	channel, model, err := targetChannel(ctx, cfg, cfg.SoakTarget)
+	if err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+	if err := checkRequestLimit(cfg, int(cfg.SoakDuration/cfg.SoakInterval)+1); err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+
+	host := ""
+	if u, err := url.Parse(channel.URL); err == nil {
+		host = u.Hostname()
+	}
+	printer.PrintTitle(fmt.Sprintf("稳定性测试 %s (%s, 每 %s 一次请求, 持续 %s)", cfg.SoakTarget, model, cfg.SoakInterval, cfg.SoakDuration), util.EmojiAPI)
+	printer.Printf("每 %s 写入检查点报告: %s, Ctrl+C 提前结束\n", cfg.SoakCheckpoint, cfg.SoakReport)
+	soak := &bench.Soak{
+		Name:       cfg.SoakTarget,
+		Host:       host,
+		Interval:   cfg.SoakInterval,
+		Duration:   cfg.SoakDuration,
+		Checkpoint: cfg.SoakCheckpoint,
+		Request:    benchRequest(apitest.NewApiTest(1), channel, model),
+		Resolve:    httpclient.LookupHost,
+		ReportPath: cfg.SoakReport,
+		Printer:    printer,
+	}
+	bench.PrintSoak(printer, soak.Run(ctx))
+	return 0
+}
+
+// runHistory lists the latest runs saved in the history store
+func runHistory(cfg *config.Config, st store.Store) int {
+	printer := util.NewPrinter(os.Stdout)
+	if st == nil {
+		printer.PrintError("history 命令需要指定历史记录存储 (-store 或配置文件中的 store)")
+		return exitTraceError
+	}
+	switch cfg.HistoryMode {
+	case "", "apitest", "trace", "monitor":
+	default:
+		printer.PrintError(fmt.Sprintf("无效的运行模式: %s (可选: apitest, trace, monitor)", cfg.HistoryMode))
+		return exitTraceError
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	entries, err := st.List(ctx, store.Query{Mode: cfg.HistoryMode, Limit: cfg.HistoryLimit})
+	if err != nil {
+		printer.PrintError(err.Error())
+		return exitTraceError
+	}
+	if cfg.Output == config.OutputJSON {
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		printer.Printf("%s\n", data)
+		return 0
+	}
+	if len(entries) == 0 {
+		printer.Printf("没有历史记录\n")
+		return 0
+	}
+	for _, e := range entries {
+		color := util.ColorGreen
+		switch e.Verdict {
+		case runlog.VerdictPartial:
+			color = util.ColorYellow
+		case runlog.VerdictFailed, runlog.VerdictError:
+			color = util.ColorRed
+		}
+		printer.Printf("%s  %-8s %s%-8s%s %d/%d  %s", util.FormatTime(e.Time), e.Mode, color, e.Verdict, util.ColorReset,
+			e.Success, e.Success+e.Failed, e.Endpoint)
+		if e.Message != "" {
+			printer.Printf("  %s%s%s", util.ColorGray, e.Message, util.ColorReset)
+		}
+		printer.Printf("\n")
+	}
+	return 0
+}
+
+// confirmLoad asks for the host of the endpoint to be typed before a load test starts, -yes confirms without asking
+func confirmLoad(cfg *config.Config, printer *util.Printer, channel *apitest.Channel, requests int) error {
+	host := channel.URL
+	if u, err := url.Parse(channel.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	printer.PrintWarning(fmt.Sprintf("将以 %.2f 次/秒 向 %s 持续发送 %s, 共约 %d 次请求 (约 %d tokens), 请只压测自己运营或已获授权的端点",
+		cfg.LoadRPS, host, cfg.LoadDuration, requests, requests*apitest.EstimatedTokensPerRequest))
+	if cfg.Yes {
+		return nil
+	}
+	if !util.IsInteractive() {
+		return fmt.Errorf("非交互运行时需加上 -yes 确认压测")
+	}
+	printer.Printf("输入主机名 %s 确认压测: ", host)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf(config.ErrorReadFailed, err)
+	}
+	if strings.TrimSpace(line) != host {
+		return fmt.Errorf("主机名不一致, 已取消压测")
+	}
+	return nil
+}
+
+// targetChannel resolves the target of bench and load, the name of a registered relay or a URL whose key is prompted for
+func targetChannel(ctx context.Context, cfg *config.Config, target string) (*apitest.Channel, string, error) {
+	relay := config.RelayItem{URL: target, Model: cfg.BenchModel}
+	found := false
+	for _, r := range cfg.Relays {
+		if r.Name == target {
+			relay, found = r, true
+			break
+		}
+	}
+	if !found {
+		if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			return nil, "", fmt.Errorf("中转列表中没有: %s", target)
+		}
+		if !util.IsInteractive() {
+			return nil, "", fmt.Errorf("无法输入 %s 的 API Key, 请在配置文件的 relays 中登记后按名称比较", target)
+		}
+		key, err := util.ReadPassword(fmt.Sprintf("请输入 %s 的 API Key: ", target))
+		if err != nil {
+			return nil, "", err
+		}
+		relay.Key = strings.TrimSpace(key)
+	}
+	if relay.Model == "" {
+		relay.Model = cfg.BenchModel
+	}
+
+	channel := &apitest.Channel{Type: apitest.ChannelTypeOpenAI, Key: relay.Key, Endpoint: target, Protocol: cfg.Protocol}
+	switch {
+	case apitest.IsCohereURL(relay.URL):
+		channel.Type, channel.URL = apitest.ChannelTypeCohere, config.CohereTestUrl
+	case apitest.IsDashScopeURL(relay.URL):
+		channel.Type, channel.URL = apitest.ChannelTypeDashScope, apitest.DashScopeURL(relay.URL)
+	case apitest.IsZhipuURL(relay.URL):
+		channel.Type, channel.URL = apitest.ChannelTypeZhipu, config.ZhipuTestUrl
+	case apitest.IsOpenRouterURL(relay.URL):
+		channel.Type, channel.URL = apitest.ChannelTypeOpenRouter, config.OpenRouterTestUrl
+	case apitest.IsGroqURL(relay.URL):
+		channel.Type, channel.URL = apitest.ChannelTypeGroq, config.GroqTestUrl
+	case relay.Key == util.NoAuthKey:
+		channel.Type, channel.URL = apitest.ChannelTypeLocal, util.ResolveEndpoint(ctx, relay.URL)
+	case strings.HasPrefix(relay.Key, apitest.GeminiKeyPrefix):
+		channel.Type, channel.URL = apitest.ChannelTypeGemini, util.NormalizeURL(relay.URL)
+	case strings.HasPrefix(relay.Key, apitest.AnthropicKeyPrefix):
+		channel.Type, channel.URL = apitest.ChannelTypeAnthropic, util.NormalizeURL(relay.URL)
+	default:
+		channel.URL = util.ResolveEndpoint(ctx, relay.URL)
+	}
+	return channel, relay.Model, nil
+}
+
+func runMonitor(item util.MenuItem, cfg *config.Config) error {
+	util.ClearConsole()
+	printer := util.NewPrinter(os.Stdout)
+	printer.PrintTitle(item.Label, item.Emoji)
+
+	if len(cfg.Watchlist) == 0 {
+		return fmt.Errorf("监控列表为空，请在配置文件中添加: %s", cfg.ConfigPath)
+	}
+
+	printer.PrintTesting()
+	m := monitor.New(cfg, os.Stdout, monitor.WithRunLog(runlog.New(cfg.RunLogPath)))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	reports := m.Check(ctx)
+	stop()
+	m.PrintReports(reports)
+
+	printer.Printf("\n%s按回车键继续...%s", util.ColorGray, util.ColorReset)
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	return nil
+}
+
+// runChannels tests every endpoint and key of the channels file and reports them grouped by endpoint
+func runChannels(cfg *config.Config) error {
+	endpoints, err := config.LoadChannels(cfg.ChannelsPath)
+	if err != nil {
+		return err
+	}
+
+	var channels []*apitest.Channel
+	for _, e := range endpoints {
+		cohere, dashscope, zhipu := apitest.IsCohereURL(e.URL), apitest.IsDashScopeURL(e.URL), apitest.IsZhipuURL(e.URL)
+		openrouter, groq := apitest.IsOpenRouterURL(e.URL), apitest.IsGroqURL(e.URL)
+		protocol := cfg.Protocol
+		if e.Protocol != "" && !cfg.PinProtocol {
+			if protocol, err = apitest.ParseProtocol(e.Protocol); err != nil {
+				return fmt.Errorf("渠道文件中 %s: %v", e.URL, err)
+			}
+		}
+		models := e.Models
+		if len(models) == 0 {
+			switch {
+			case cohere:
+				models = config.CohereModelGroups[0].Models
+			case dashscope:
+				models = config.QwenModelGroups[0].Models
+			case zhipu:
+				models = config.GLMModelGroups[0].Models
+			case openrouter:
+				models = config.OpenRouterModelGroups[0].Models
+			case groq:
+				models = config.GroqModelGroups[0].Models
+			default:
+				models = config.ModelGroups[0].Models
+			}
+		}
+		url := util.NormalizeURL(e.URL)
+		switch {
+		case cohere:
+			// Cohere serves its own chat path, not /v1/chat/completions
+			url = config.CohereTestUrl
+		case dashscope:
+			url = apitest.DashScopeURL(e.URL)
+		case zhipu:
+			url = config.ZhipuTestUrl
+		case openrouter:
+			url = config.OpenRouterTestUrl
+		case groq:
+			url = config.GroqTestUrl
+		}
+		// Azure endpoints list their deployments as the models
+		resource, deployment, apiVersion, azure := apitest.ParseAzureURL(e.URL)
+		if azure {
+			url = apitest.AzureURL(resource, apiVersion)
+			if len(e.Models) == 0 && deployment != "" {
+				models = []string{deployment}
+			}
+		}
+		keys, _ := apiconfig.DedupeKeys(e.Keys)
+		for _, key := range keys {
+			channelType := apitest.ChannelTypeOpenAI
+			switch {
+			case azure:
+				channelType = apitest.ChannelTypeAzure
+			case cohere:
+				channelType = apitest.ChannelTypeCohere
+			case dashscope:
+				channelType = apitest.ChannelTypeDashScope
+			case zhipu:
+				channelType = apitest.ChannelTypeZhipu
+			case openrouter:
+				channelType = apitest.ChannelTypeOpenRouter
+			case groq:
+				channelType = apitest.ChannelTypeGroq
+			case key == util.NoAuthKey:
+				channelType = apitest.ChannelTypeLocal
+			case apitest.IsServiceAccountPath(key):
+				channelType = apitest.ChannelTypeVertex
+			case strings.HasPrefix(key, apitest.GeminiKeyPrefix):
+				channelType = apitest.ChannelTypeGemini
+			case strings.HasPrefix(key, apitest.AnthropicKeyPrefix):
+				channelType = apitest.ChannelTypeAnthropic
+			}
+			channelURL := url
+			if channelType == apitest.ChannelTypeVertex && !apitest.IsVertexURL(e.URL) {
+				// Without a Vertex AI URL the project of the service account is tested in the default region,
+				// unreadable files are reported by the key check
+				if sa, err := apitest.LoadServiceAccount(key); err == nil {
+					channelURL = apitest.VertexURL(sa.ProjectID, "")
+				}
+			}
+			channels = append(channels, &apitest.Channel{
+				Type:      channelType,
+				Key:       key,
+				TestModel: models,
+				URL:       channelURL,
+				Endpoint:  e.Name,
+				Protocol:  protocol,
+			})
+		}
+	}
+
+	if err := checkRequestLimit(cfg, apitest.CountRequests(channels)*cfg.RequestsPerTest()); err != nil {
+		return err
+	}
+
+	printer := util.NewPrinter(os.Stdout)
+	printer.PrintTesting()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	results := apitest.NewApiTest(cfg.MaxConcurrency, testOptions(cfg)...).TestAllApis(ctx, channels)
+	stop()
+	groups := apitest.GroupByEndpoint(results)
+
+	var output bytes.Buffer
+	apitest.PrintEndpoints(util.NewPrinter(&output), groups)
+	if cfg.NoPager || util.GetVerbosity() < util.VerbosityNormal {
+		printer.Write(output.Bytes())
+	} else if err := util.Page(output.String()); err != nil {
+		logger.Debug("Pager failed: %v", err)
+	}
+
+	runLog := runlog.New(cfg.RunLogPath)
+	for _, g := range groups {
+		if err := runLog.Append(runlog.FromResults(g.URL, g.Results)); err != nil {
+			logger.Debug("Failed to write run log: %v", err)
+		}
+	}
+
+	if cfg.ReportPath != "" {
+		r := report.FromEndpoints(results)
+		endpoints := make([]string, len(groups))
+		for i, g := range groups {
+			endpoints[i] = g.URL
+		}
+		r.Run = schema.NewRun(strings.Join(endpoints, ","))
+		if err := exportReport(printer, cfg, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func runUpdate() error {
 	reader := apiconfig.NewConfigReader(os.Stdin, os.Stdout)
 	updated, err := reader.CheckUpdate()
@@ -159,8 +1258,34 @@ func runUpdate() error {
 	return nil
 }
 
+// printSchema prints the named JSON Schema document, or lists the schemas when name is empty
+func printSchema(printer *util.Printer, name string) error {
+	if name == "" {
+		printer.Printf("schema_version: %s\n", schema.Version)
+		for _, n := range schema.Names() {
+			printer.Printf("  %s\n", n)
+		}
+		printer.Printf("使用 check-gpt schema <名称> 打印对应的 JSON Schema\n")
+		return nil
+	}
+	data, err := schema.Get(name)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
 func main() {
 	cfg := config.New()
+	switch {
+	case cfg.Quiet:
+		util.SetVerbosity(util.VerbosityQuiet)
+	case cfg.Summary:
+		util.SetVerbosity(util.VerbositySummary)
+	}
+	util.SetASCII(cfg.ASCII)
+	util.SetTimeFormat(cfg.TimeFormat)
 	printer := util.NewPrinter(os.Stdout)
 
 	if cfg.Debug {
@@ -173,6 +1298,206 @@ func main() {
 		os.Exit(0)
 	}
 
+	if cfg.Schema {
+		if err := printSchema(printer, cfg.SchemaName); err != nil {
+			printer.PrintError(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		printer.PrintWarning(err.Error())
+	}
+	schema.SetRun(apiconfig.Version, cfg.SettingsDigest())
+	if cfg.Probes != "" {
+		if _, err := capability.LoadPlugins(cfg.ProbeDir); err != nil {
+			printer.PrintWarning(err.Error())
+		}
+	}
+
+	maskMode, err := util.ParseMaskMode(cfg.MaskMode)
+	if err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if err := cfg.ValidateMask(); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	util.SetMaskPolicy(util.MaskPolicy{
+		Mode:  maskMode,
+		First: cfg.MaskFirst,
+		Last:  cfg.MaskLast,
+	})
+
+	normalization := util.URLNormalization{Raw: cfg.RawURL}
+	for _, r := range cfg.URLRules {
+		rule, err := util.NewURLRule(r.Match, r.Replace)
+		if err != nil {
+			printer.PrintError(err.Error())
+			os.Exit(1)
+		}
+		normalization.Rules = append(normalization.Rules, rule)
+	}
+	util.SetURLNormalization(normalization)
+
+	if _, err := util.ParseRequestShape(cfg.Shape); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+
+	if cfg.Protocol, err = apitest.ParseProtocol(cfg.Protocol); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+
+	if _, err := trace.ParseNodeSignature(cfg.NodeMatch); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	policy, err := trace.ParsePolicy(cfg.TracePolicy)
+	if err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if cfg.Output != config.OutputText && cfg.Output != config.OutputJSON {
+		printer.PrintError(fmt.Sprintf("无效的输出格式: %s (可选: text, json)", cfg.Output))
+		os.Exit(1)
+	}
+
+	if _, err := image.Charset(cfg.CaptchaCharset); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if err := cfg.ValidateCaptcha(); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if err := cfg.ValidateTraceTimeout(); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if _, err := image.NewRenderer(cfg.ProbeImage, cfg); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if cfg.Rounds < 1 || cfg.Rounds > config.MaxRounds {
+		printer.PrintError(fmt.Sprintf("链路检测轮数无效: %d (1-%d)", cfg.Rounds, config.MaxRounds))
+		os.Exit(1)
+	}
+	if cfg.Images < 1 || cfg.Images > config.MaxImages {
+		printer.PrintError(fmt.Sprintf("链路检测图片数无效: %d (1-%d)", cfg.Images, config.MaxImages))
+		os.Exit(1)
+	}
+	if cfg.FromCurl != "" {
+		if _, err := util.ParseCurl(cfg.FromCurl); err != nil {
+			printer.PrintError(fmt.Sprintf("-from-curl: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	if cfg.TrustedProxies, err = config.ParseTrustedProxies(cfg.TrustedProxyList); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	cfg.RemoteIPHeaders = config.ParseRemoteIPHeaders(cfg.RemoteIPHeaderList)
+	if cfg.Relay != "" && cfg.FRPServer != "" {
+		printer.PrintError("-relay 与 -frp-server 只能选择一个")
+		os.Exit(1)
+	}
+
+	if cfg.DNS != "" {
+		r, err := httpclient.ParseResolver(cfg.DNS)
+		if err != nil {
+			printer.PrintError(err.Error())
+			os.Exit(1)
+		}
+		httpclient.SetResolver(r, cfg.DNS)
+	}
+
+	if _, err := capability.Parse(cfg.Probes); err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+
+	if cfg.ProxyList != "" {
+		if cfg.Proxies, err = config.ParseProxies(cfg.ProxyList); err != nil {
+			printer.PrintError(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	ipVersion, err := httpclient.ParseIPVersion(cfg.IPVersion)
+	if err != nil {
+		printer.PrintError(err.Error())
+		os.Exit(1)
+	}
+	httpclient.SetIPVersion(ipVersion)
+
+	if cfg.MirrorPath != "" {
+		m, err := httpclient.OpenMirror(cfg.MirrorPath)
+		if err != nil {
+			printer.PrintError(err.Error())
+			os.Exit(1)
+		}
+		defer m.Close()
+		httpclient.SetMirror(m)
+	}
+
+	var st store.Store
+	if cfg.Store != "" {
+		if st, err = store.Open(cfg.Store); err != nil {
+			printer.PrintError(err.Error())
+			os.Exit(1)
+		}
+		defer st.Close()
+		runlog.SetStore(st)
+	}
+	if cfg.History {
+		os.Exit(runHistory(cfg, st))
+	}
+
+	if cfg.RelayServe != "" {
+		os.Exit(runRelayServe(cfg))
+	}
+	if cfg.Trace {
+		os.Exit(runTrace(cfg, policy))
+	}
+	if cfg.Verify {
+		os.Exit(runVerify(cfg, policy))
+	}
+	if cfg.Bench {
+		os.Exit(runBench(cfg))
+	}
+	if cfg.Load {
+		os.Exit(runLoad(cfg))
+	}
+	if cfg.Soak {
+		os.Exit(runSoak(cfg))
+	}
+
+	if cfg.ChannelsPath != "" {
+		if err := runChannels(cfg); err != nil {
+			printer.PrintError(fmt.Sprintf("错误: %v", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Run the watchlist monitor without the interactive menu
+	if cfg.Monitor {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := monitor.New(cfg, os.Stdout, monitor.WithRunLog(runlog.New(cfg.RunLogPath))).Run(ctx); err != nil {
+			printer.PrintError(fmt.Sprintf("错误: %v", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Link detections of the session reuse the tunnel of the first one
+	session := &server.Session{}
 	for {
 		util.ClearConsole()
 		// 显示主菜单
@@ -189,8 +1514,9 @@ func main() {
 			}
 		case 2: // Link Detection
 			ctx, cancel := context.WithCancel(context.Background())
-			srv := server.New(cfg)
+			srv := server.New(cfg, server.WithSession(session))
 
+			util.ClearConsole()
 			if err := startServer(ctx, srv); err != nil {
 				printer.PrintError(fmt.Sprintf("错误: %v", err))
 				cancel()
@@ -212,12 +1538,20 @@ func main() {
 			srv.Shutdown()
 			cancel()
 
-		case 3: // Check Update
+		case 3: // Key Monitor
+			if err := runMonitor(choice, cfg); err != nil {
+				printer.PrintError(fmt.Sprintf("错误: %v", err))
+				printer.Printf("\n%s按回车键继续...%s", util.ColorGray, util.ColorReset)
+				bufio.NewReader(os.Stdin).ReadString('\n')
+			}
+
+		case 4: // Check Update
 			if err := runUpdate(); err != nil {
 				printer.PrintError(fmt.Sprintf("错误: %v", err))
 			}
 
-		case 4: // Exit
+		case 5: // Exit
+			session.Close()
 			printer.Printf("\n%s 再见！\n", util.EmojiWave)
 			os.Exit(0)
 		}
@@ -0,0 +1,65 @@
+package apiconfig
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// AzureKeyword entered at the URL prompt configures an Azure OpenAI resource step by step
+const AzureKeyword = "azure"
+
+// azureResource matches Azure resource names, the subdomain of openai.azure.com
+var azureResource = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{0,62}$`)
+
+// isAzureInput reports whether the URL input selects Azure OpenAI
+func isAzureInput(input string) bool {
+	return strings.EqualFold(input, AzureKeyword) || apitest.IsAzureURL(input)
+}
+
+// readAzure completes an Azure OpenAI configuration from the keyword or a portal URL,
+// prompting for the resource, deployments and api-version the input does not carry.
+// The deployments are tested as the models of the channel.
+func (r *ConfigReader) readAzure(reader *bufio.Reader, input string) (string, []string, error) {
+	resource, deployment, apiVersion, _ := apitest.ParseAzureURL(input)
+
+	for resource == "" {
+		line, err := r.readAnswer(reader, config.InputPromptAzureResource)
+		if err != nil {
+			return "", nil, err
+		}
+		// The whole endpoint is often pasted instead of the name
+		if name, _, _, ok := apitest.ParseAzureURL(line); ok {
+			line = name
+		}
+		if !azureResource.MatchString(line) {
+			r.Printer.Printf("%s%s 无效的资源名称，请重新输入%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+			continue
+		}
+		resource = strings.ToLower(line)
+	}
+
+	deployments := strings.Fields(deployment)
+	for len(deployments) == 0 {
+		line, err := r.readAnswer(reader, config.InputPromptAzureDeployment)
+		if err != nil {
+			return "", nil, err
+		}
+		deployments = deduplicateModels(strings.Fields(strings.ReplaceAll(line, ",", " ")))
+	}
+
+	if apiVersion == "" {
+		line, err := r.readAnswer(reader, fmt.Sprintf(config.InputPromptAzureAPIVersion, config.AzureAPIVersion))
+		if err != nil {
+			return "", nil, err
+		}
+		apiVersion = line
+	}
+
+	return apitest.AzureURL(resource, apiVersion), deployments, nil
+}
@@ -0,0 +1,33 @@
+package apiconfig
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAzure(t *testing.T) {
+	var out strings.Builder
+	read := func(input, answers string) (string, []string, error) {
+		r := NewConfigReader(strings.NewReader(""), &out)
+		return r.readAzure(bufio.NewReader(strings.NewReader(answers)), input)
+	}
+
+	url, deployments, err := read(AzureKeyword, "bad_name!\nmyres\ngpt-4o, gpt-4o-mini gpt-4o\n\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://myres.openai.azure.com/openai/deployments?api-version="+config.AzureAPIVersion, url)
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, deployments)
+	assert.Contains(t, out.String(), "无效的资源名称")
+
+	// A portal URL carries everything
+	url, deployments, err = read("https://myres.openai.azure.com/openai/deployments/prod/chat/completions?api-version=2024-06-01", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://myres.openai.azure.com/openai/deployments?api-version=2024-06-01", url)
+	assert.Equal(t, []string{"prod"}, deployments)
+
+	_, _, err = read(AzureKeyword, "myres\n")
+	assert.Error(t, err, "running out of input")
+}
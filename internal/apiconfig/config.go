@@ -2,6 +2,7 @@ package apiconfig
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/internal/discovery"
+	"github.com/go-coders/check-gpt/internal/profile"
 	"github.com/go-coders/check-gpt/internal/types"
 	"github.com/go-coders/check-gpt/pkg/config"
 	"github.com/go-coders/check-gpt/pkg/logger"
@@ -33,6 +37,18 @@ type Config struct {
 	Type           types.ChannelType
 	URL            string
 	ImageURL       string
+	Profile        string      // 加载的配置名称
+	MergedKeys     []MergedKey // 去重或清理过的输入
+	DiscoverModels bool        // 测试前逐个 Key 获取 /v1/models, 以实际开放的模型作为测试模型
+}
+
+// KeyChannel returns the channel type, URL and models key is tested with. A Cohere key pasted
+// together with relay keys is tested at the official Cohere endpoint instead of the relay.
+func (c *Config) KeyChannel(key string) (types.ChannelType, string, []string) {
+	if c.Type == types.ChannelTypeOpenAI && apitest.IsCohereKey(key) {
+		return types.ChannelTypeCohere, config.CohereTestUrl, config.CohereModelGroups[0].Models
+	}
+	return c.Type, c.URL, c.ValidTestModel
 }
 
 // ConfigReader handles the configuration reading process
@@ -40,9 +56,24 @@ type ConfigReader struct {
 	input      io.Reader
 	output     io.Writer
 	Printer    *util.Printer
+	Profiles   *profile.Manager
+	ModelCache *discovery.Cache // 缓存发现的模型列表, 接口不可用时沿用, 为空时不缓存
 	lastReadAt time.Time
+
+	// discover lists the models the key can access and the time a cached list was fetched,
+	// nil when the menu has no discovery entry
+	discover func() ([]string, time.Time, error)
+	// perKey is set when the "D" menu entry asks every key for its own model list before testing
+	perKey bool
 }
 
+// Quick select entries of the model menu
+const (
+	SelectAllCommon = "0" // 全部常见模型
+	SelectAllAccess = "A" // 该 Key 可访问的所有模型
+	SelectDiscover  = "D" // 测试前逐个 Key 自动发现模型
+)
+
 // NewConfigReader creates a new ConfigReader
 func NewConfigReader(input io.Reader, output io.Writer) *ConfigReader {
 	if output == nil {
@@ -58,7 +89,7 @@ func NewConfigReader(input io.Reader, output io.Writer) *ConfigReader {
 
 // readKeys reads API keys from input with proper cancellation support
 func (r *ConfigReader) readKeys(reader *bufio.Reader) ([]string, error) {
-
+	r.printSavedProfiles()
 	r.Printer.Printf(config.InputPromptOpenAIKey + " ")
 reqInputKey:
 	line, err := reader.ReadString('\n')
@@ -73,8 +104,8 @@ reqInputKey:
 	}
 
 	if strings.HasPrefix(line, "http://") {
-		r.Printer.Printf("%s%s 你输入的是 URL，请输入 API Key%s\n",
-			util.ColorYellow, util.EmojiWarning, util.ColorReset)
+		r.Printer.Printf("%s%s 你输入的是 URL，请输入 API Key (本地服务无需 Key, 请输入 %s)%s\n",
+			util.ColorYellow, util.EmojiWarning, util.NoAuthKey, util.ColorReset)
 		goto reqInputKey
 	}
 
@@ -114,6 +145,41 @@ reinputUrl:
 		goto reinputUrl
 	}
 
+	// Azure resources are completed by readAzure, they are not relays to probe
+	if isAzureInput(url) {
+		r.lastReadAt = time.Now()
+		return url, nil
+	}
+	// DashScope is tested in its OpenAI compatible mode, "dashscope" selects the mainland endpoint
+	if strings.EqualFold(url, config.DashScopePreset) {
+		r.lastReadAt = time.Now()
+		return config.DashScopeTestUrl, nil
+	}
+	if apitest.IsDashScopeURL(url) {
+		r.lastReadAt = time.Now()
+		return apitest.DashScopeURL(url), nil
+	}
+	// OpenRouter has a single OpenAI compatible endpoint, "openrouter" selects it
+	if strings.EqualFold(url, config.OpenRouterPreset) || apitest.IsOpenRouterURL(url) {
+		r.lastReadAt = time.Now()
+		return config.OpenRouterTestUrl, nil
+	}
+	// Groq serves the OpenAI API under /openai, "groq" selects it
+	if strings.EqualFold(url, config.GroqPreset) || apitest.IsGroqURL(url) {
+		r.lastReadAt = time.Now()
+		return config.GroqTestUrl, nil
+	}
+	// Zhipu is tested at its v4 chat endpoint whatever path was entered
+	if apitest.IsZhipuURL(url) {
+		r.lastReadAt = time.Now()
+		return config.ZhipuTestUrl, nil
+	}
+	// Cohere has a single chat endpoint, not the OpenAI path
+	if apitest.IsCohereURL(url) {
+		r.lastReadAt = time.Now()
+		return config.CohereTestUrl, nil
+	}
+
 	// check if the url is a valid domain
 	if !util.IsValidURL(url) {
 		r.Printer.Printf("%s%s 无效的 URL，请重新输入%s\n",
@@ -121,14 +187,24 @@ reinputUrl:
 		goto reinputUrl
 	}
 
-	// normalize the url
-	url = util.NormalizeURL(url)
+	// normalize the url, probing which endpoint the relay serves
+	url = r.resolveURL(url)
 
 	r.lastReadAt = time.Now()
 
 	return url, nil
 }
 
+// resolveURL returns the endpoint the relay serves for the input URL,
+// telling the user when it differs from the standard /v1/chat/completions path
+func (r *ConfigReader) resolveURL(input string) string {
+	url := util.ResolveEndpoint(context.Background(), input)
+	if url != util.NormalizeURL(input) {
+		r.Printer.Printf("%s已识别接口地址: %s%s\n", util.ColorGray, url, util.ColorReset)
+	}
+	return url
+}
+
 // deduplicateModels removes duplicate models while maintaining order
 func deduplicateModels(models []string) []string {
 	seen := make(map[string]bool)
@@ -160,6 +236,7 @@ start:
 		goto start
 	}
 	r.lastReadAt = time.Now()
+	r.perKey = false
 
 	var defaualtSelect = "1"
 	choice := strings.TrimSpace(line)
@@ -192,16 +269,37 @@ start:
 			continue
 		}
 
+		if c == SelectAllCommon {
+			for _, group := range modelGroup {
+				selectedModels = append(selectedModels, group.Models...)
+			}
+			selectedModels = append(selectedModels, modelList...)
+			continue
+		}
+		if strings.EqualFold(c, SelectDiscover) && r.discover != nil {
+			r.perKey = true
+			continue
+		}
+		if strings.EqualFold(c, SelectAllAccess) {
+			models, err := r.discoverModels()
+			if err != nil {
+				r.Printer.Printf("%s%s %v%s\n", util.ColorYellow, util.EmojiWarning, err, util.ColorReset)
+				continue
+			}
+			selectedModels = append(selectedModels, models...)
+			continue
+		}
+
 		// Try to parse as number first
 		if num, err := strconv.Atoi(c); err == nil {
-			if num > 0 && num <= len(config.ModelGroups) {
+			if num > 0 && num <= len(modelGroup) {
 				// If it's a group number, add all models from that group
-				selectedModels = append(selectedModels, config.ModelGroups[num-1].Models...)
+				selectedModels = append(selectedModels, modelGroup[num-1].Models...)
 				continue
 			}
-			if num > len(config.ModelGroups) && num <= len(config.ModelGroups)+len(modelList) {
+			if num > len(modelGroup) && num <= len(modelGroup)+len(modelList) {
 				// If it's a model number, add the corresponding model
-				selectedModels = append(selectedModels, modelList[num-len(config.ModelGroups)-1])
+				selectedModels = append(selectedModels, modelList[num-len(modelGroup)-1])
 				continue
 			}
 			r.Printer.Printf("%s%s 忽略无效的数字选择: %s%s\n",
@@ -212,14 +310,55 @@ start:
 		selectedModels = append(selectedModels, c)
 	}
 
+	// Keys whose model list cannot be fetched are tested with the default group
+	if r.perKey && len(selectedModels) == 0 && len(modelGroup) > 0 {
+		selectedModels = append(selectedModels, modelGroup[0].Models...)
+	}
+	if len(selectedModels) == 0 && len(choices) > 0 {
+		r.Printer.Printf("%s> %s", util.ColorBold, util.ColorReset)
+		goto start
+	}
+
 	// Remove duplicates while maintaining order
 	return deduplicateModels(selectedModels), nil
 }
 
+// discoverModels lists the models the key can access for the "A" menu entry
+func (r *ConfigReader) discoverModels() ([]string, error) {
+	if r.discover == nil {
+		return nil, fmt.Errorf("当前无法获取 Key 可访问的模型")
+	}
+	r.Printer.Printf("%s正在获取模型列表...%s\n", util.ColorGray, util.ColorReset)
+	models, cached, err := r.discover()
+	if err != nil {
+		return nil, err
+	}
+	if !cached.IsZero() {
+		r.Printer.Printf("%s%s 获取模型列表失败, 使用%s的 %d 个模型%s\n",
+			util.ColorYellow, util.EmojiWarning, discovery.CachedAgo(cached, time.Now()), len(models), util.ColorReset)
+		return models, nil
+	}
+	r.Printer.Printf("%s获取到 %d 个模型%s\n", util.ColorGray, len(models), util.ColorReset)
+	return models, nil
+}
+
+// listModels returns the discover function of the model menu for the endpoint and key,
+// served from the model cache when the endpoint's list is down
+func (r *ConfigReader) listModels(url, key string) func() ([]string, time.Time, error) {
+	return func() ([]string, time.Time, error) {
+		if r.ModelCache != nil {
+			return r.ModelCache.Models(context.Background(), url, key)
+		}
+		models, err := discovery.Models(context.Background(), url, key)
+		return models, time.Time{}, err
+	}
+}
+
 // ReadConfig reads API configuration from user input
 func (r *ConfigReader) ReadValidTestConfig() (*Config, error) {
 	var channelType = types.ChannelTypeOpenAI
 	var testUrl string
+	var profileName string
 
 	bufReader := bufio.NewReader(r.input)
 	keys, err := r.readKeys(bufReader)
@@ -228,18 +367,106 @@ func (r *ConfigReader) ReadValidTestConfig() (*Config, error) {
 		return nil, err
 	}
 
-	if channelType == types.ChannelTypeOpenAI {
+	// A single @name input loads the keys and URL of a saved profile
+	if len(keys) == 1 && strings.HasPrefix(keys[0], profile.Prefix) && r.Profiles != nil {
+		p, err := r.Profiles.Load(strings.TrimPrefix(keys[0], profile.Prefix))
+		if err != nil {
+			return nil, err
+		}
+		keys = p.Keys
+		testUrl = p.URL
+		profileName = p.Name
+		r.Printer.Printf("%s已加载配置 %s: %d 个 Key, %s%s\n",
+			util.ColorGreen, p.Name, len(p.Keys), p.URL, util.ColorReset)
+	}
+
+	// Bulk pastes often repeat keys or carry invisible characters
+	keys, merged := DedupeKeys(keys)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf(config.ErrorNoAPIKey)
+	}
+
+	// Gemini, Anthropic and Cohere keys are tested against the official endpoint, no URL is needed
+	switch {
+	case isLocalKeys(keys):
+		// Ollama, llama.cpp and LM Studio take no key, the placeholder only keeps one channel to test
+		channelType = types.ChannelTypeLocal
+		keys = []string{util.NoAuthKey}
+		if testUrl == "" {
+			if testUrl, err = r.readLocalURL(bufReader); err != nil {
+				return nil, err
+			}
+		}
+	case isVertexKeys(keys):
+		// Service accounts are tested at the regional endpoint of their project
+		channelType = types.ChannelTypeVertex
+		if !apitest.IsVertexURL(testUrl) {
+			if testUrl, err = r.readVertex(bufReader, keys); err != nil {
+				return nil, err
+			}
+		}
+	case isGeminiKeys(keys) && (testUrl == "" || testUrl == config.GeminiTestUrl):
+		channelType = types.ChannelTypeGemini
+		testUrl = config.GeminiTestUrl
+	case isAnthropicKeys(keys) && (testUrl == "" || testUrl == config.AnthropicTestUrl):
+		channelType = types.ChannelTypeAnthropic
+		testUrl = config.AnthropicTestUrl
+	case isCohereKeys(keys) && (testUrl == "" || apitest.IsCohereURL(testUrl)):
+		channelType = types.ChannelTypeCohere
+		testUrl = config.CohereTestUrl
+	case isZhipuKeys(keys) && (testUrl == "" || apitest.IsZhipuURL(testUrl)):
+		channelType = types.ChannelTypeZhipu
+		testUrl = config.ZhipuTestUrl
+	case isOpenRouterKeys(keys) && (testUrl == "" || apitest.IsOpenRouterURL(testUrl)):
+		channelType = types.ChannelTypeOpenRouter
+		testUrl = config.OpenRouterTestUrl
+	case isGroqKeys(keys) && (testUrl == "" || apitest.IsGroqURL(testUrl)):
+		channelType = types.ChannelTypeGroq
+		testUrl = config.GroqTestUrl
+	}
+
+	if channelType == types.ChannelTypeOpenAI && testUrl == "" {
 		url, err := r.readURL(bufReader)
 		if err != nil {
 			return nil, err
 		}
 		testUrl = url
 	}
-
-	// Set default models based on key type
-	model, err := r.readModel(r.input, config.CommonOpenAIModels, config.ModelGroups)
-	if err != nil {
-		return nil, err
+	switch {
+	case apitest.IsCohereURL(testUrl):
+		channelType = types.ChannelTypeCohere
+	case apitest.IsDashScopeURL(testUrl):
+		channelType = types.ChannelTypeDashScope
+	case apitest.IsZhipuURL(testUrl):
+		channelType = types.ChannelTypeZhipu
+	case apitest.IsOpenRouterURL(testUrl):
+		channelType = types.ChannelTypeOpenRouter
+	case apitest.IsGroqURL(testUrl):
+		channelType = types.ChannelTypeGroq
+	}
+
+	var model []string
+	if isAzureInput(testUrl) {
+		// Azure deployments take the place of the model menu
+		channelType = types.ChannelTypeAzure
+		testUrl, model, err = r.readAzure(bufReader, testUrl)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Set default models based on key type
+		modelList, modelGroups := modelMenu(channelType, keys)
+		// Vertex AI has no model list for a service account, Cohere lists its models in
+		// its own format and Zhipu takes a signed token, the menu has no discovery entry for them
+		if channelType != types.ChannelTypeVertex && channelType != types.ChannelTypeCohere && channelType != types.ChannelTypeZhipu {
+			key := discoveryKey(channelType, keys)
+			r.discover = r.listModels(testUrl, key)
+			defer func() { r.discover = nil }()
+		}
+		model, err = r.readModel(r.input, modelList, modelGroups)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Create config
@@ -248,11 +475,206 @@ func (r *ConfigReader) ReadValidTestConfig() (*Config, error) {
 		ValidTestModel: model,
 		Type:           channelType,
 		URL:            testUrl,
+		Profile:        profileName,
+		MergedKeys:     merged,
+		DiscoverModels: r.perKey,
 	}
 
 	return cfg, nil
 }
 
+// printMergedKeys reports the inputs that were merged or cleaned before testing
+func (r *ConfigReader) printMergedKeys(merged []MergedKey) {
+	if len(merged) == 0 {
+		return
+	}
+	r.Printer.Printf("%s%s 以下输入已去重或清除空白/不可见字符:%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+	for _, m := range merged {
+		r.Printer.Printf("%s  %s%s\n", util.ColorGray, m, util.ColorReset)
+	}
+}
+
+// modelMenu returns the model list and groups matching the provider of the keys,
+// each provider has its own numbered menu
+func modelMenu(channelType types.ChannelType, keys []string) ([]string, []config.ModelGroup) {
+	switch {
+	case channelType == types.ChannelTypeGemini || channelType == types.ChannelTypeVertex:
+		return config.CommonGeminiModels, config.GeminiModelGroups
+	case channelType == types.ChannelTypeAnthropic || isAnthropicKeys(keys):
+		return config.CommonClaudeModels, config.ClaudeModelGroups
+	case channelType == types.ChannelTypeCohere:
+		return config.CommonCohereModels, config.CohereModelGroups
+	case channelType == types.ChannelTypeDashScope:
+		return config.CommonQwenModels, config.QwenModelGroups
+	case channelType == types.ChannelTypeZhipu:
+		return config.CommonGLMModels, config.GLMModelGroups
+	case channelType == types.ChannelTypeOpenRouter:
+		return config.CommonOpenRouterModels, config.OpenRouterModelGroups
+	case channelType == types.ChannelTypeGroq:
+		return config.CommonGroqModels, config.GroqModelGroups
+	default:
+		return config.CommonOpenAIModels, config.ModelGroups
+	}
+}
+
+// isAnthropicKeys reports whether all keys are Anthropic API keys
+func isAnthropicKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, apitest.AnthropicKeyPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isCohereKeys reports whether all keys have the shape of Cohere API keys
+func isCohereKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !apitest.IsCohereKey(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// isOpenRouterKeys reports whether all keys are OpenRouter API keys
+func isOpenRouterKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, apitest.OpenRouterKeyPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isGroqKeys reports whether all keys are Groq API keys
+func isGroqKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, apitest.GroqKeyPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isZhipuKeys reports whether all keys have the shape of Zhipu API keys
+func isZhipuKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !apitest.IsZhipuKey(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// isGeminiKeys reports whether all keys are Google AI Studio keys
+func isGeminiKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "AIza") {
+			return false
+		}
+	}
+	return true
+}
+
+// printSavedProfiles prints the names of saved profiles as a hint
+func (r *ConfigReader) printSavedProfiles() {
+	if r.Profiles == nil {
+		return
+	}
+	profiles, err := r.Profiles.List()
+	if err != nil {
+		logger.Debug("Failed to list profiles: %v", err)
+		return
+	}
+	if len(profiles) == 0 {
+		return
+	}
+	var names []string
+	for _, p := range profiles {
+		names = append(names, profile.Prefix+p.Name)
+	}
+	r.Printer.Printf("%s已保存的配置 (输入名称直接使用): %s%s\n",
+		util.ColorGray, strings.Join(names, ", "), util.ColorReset)
+}
+
+// readAnswer prints the prompt and reads a trimmed line of a follow-up question
+func (r *ConfigReader) readAnswer(reader *bufio.Reader, prompt string) (string, error) {
+	r.Printer.Printf(prompt + " ")
+	line, err := reader.ReadString('\n')
+	// A last line without newline is still an answer, running out of input is not
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf(config.ErrorReadFailed, err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPromptLine reads a line, skipping lines that were already buffered before the prompt
+func (r *ConfigReader) readPromptLine(prompt string) (string, error) {
+	r.Printer.Printf(prompt)
+	promptAt := time.Now()
+	reader := bufio.NewReader(r.input)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf(config.ErrorReadFailed, err)
+		}
+		// in case paste mutiple lines before the prompt
+		if time.Since(promptAt) < 10*time.Millisecond && err == nil {
+			continue
+		}
+		return strings.TrimSpace(line), nil
+	}
+}
+
+// PromptSaveProfile asks for a name and saves the keys and URL of cfg as a profile
+func (r *ConfigReader) PromptSaveProfile(cfg *Config) error {
+	continuePrompt := fmt.Sprintf("\n%s按回车键继续...%s", util.ColorGray, util.ColorReset)
+	if r.Profiles == nil || cfg.Profile != "" {
+		_, err := r.readPromptLine(continuePrompt)
+		return err
+	}
+
+	line, err := r.readPromptLine(fmt.Sprintf("\n%s输入名称保存本次 Key 和 URL (直接回车继续): %s", util.ColorGray, util.ColorReset))
+	if err != nil {
+		return err
+	}
+	name := strings.TrimPrefix(line, profile.Prefix)
+	if name == "" {
+		return nil
+	}
+
+	err = r.Profiles.Save(&profile.Profile{
+		Name: name,
+		URL:  cfg.URL,
+		Keys: cfg.Keys,
+	})
+	if err != nil {
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+	r.Printer.PrintSuccess(fmt.Sprintf("已保存配置 %s%s (Key 存储于 %s)", profile.Prefix, name, r.Profiles.StoreName()))
+	_, err = r.readPromptLine(continuePrompt)
+	return err
+}
+
 // ReadLinkConfig reads configuration for link detection
 func (r *ConfigReader) ReadLinkConfig() (*Config, error) {
 	bufReader := bufio.NewReader(r.input)
@@ -306,7 +728,7 @@ reinputUrl:
 		goto reinputUrl
 	}
 
-	url = util.NormalizeURL(line)
+	url = r.resolveURL(line)
 
 	r.lastReadAt = time.Now()
 
@@ -347,19 +769,45 @@ func GetLinkConfig(reader io.Reader) (*Config, error) {
 // ShowConfig displays the configuration information
 func (r *ConfigReader) ShowConfig(cfg *Config) {
 	r.Printer.PrintTitle("API 测试信息", util.EmojiAPI)
+	switch cfg.Type {
+	case types.ChannelTypeGemini:
+		r.Printer.Printf(config.ConfigTypeGemini + "\n")
+	case types.ChannelTypeAnthropic:
+		r.Printer.Printf(config.ConfigTypeAnthropic + "\n")
+	case types.ChannelTypeAzure:
+		r.Printer.Printf(config.ConfigTypeAzure + "\n")
+	case types.ChannelTypeVertex:
+		r.Printer.Printf(config.ConfigTypeVertex + "\n")
+	case types.ChannelTypeCohere:
+		r.Printer.Printf(config.ConfigTypeCohere + "\n")
+	case types.ChannelTypeLocal:
+		r.Printer.Printf(config.ConfigTypeLocal + "\n")
+	case types.ChannelTypeDashScope:
+		r.Printer.Printf(config.ConfigTypeDashScope + "\n")
+	case types.ChannelTypeZhipu:
+		r.Printer.Printf(config.ConfigTypeZhipu + "\n")
+	case types.ChannelTypeOpenRouter:
+		r.Printer.Printf(config.ConfigTypeOpenRouter + "\n")
+	case types.ChannelTypeGroq:
+		r.Printer.Printf(config.ConfigTypeGroq + "\n")
+	}
 	r.Printer.Printf(config.ConfigURL+"\n", cfg.URL)
 	maskedKeys := []string{}
 	for _, key := range cfg.Keys {
-		maskedKeys = append(maskedKeys, util.MaskKey(key, 4, 4))
+		maskedKeys = append(maskedKeys, util.MaskKey(key))
 	}
 	keys := strings.Join(maskedKeys, ", ")
 	r.Printer.Printf(config.ConfigKeyMasked+"\n", keys)
+	r.printMergedKeys(cfg.MergedKeys)
 
 	if cfg.LinkTestModel != "" {
 		r.Printer.Printf(config.ConfigModel+"\n", cfg.LinkTestModel)
 	}
 
-	if cfg.ValidTestModel != nil {
+	switch {
+	case cfg.DiscoverModels:
+		r.Printer.Printf(config.ConfigModelDiscover+"\n", strings.Join(cfg.ValidTestModel, ", "))
+	case cfg.ValidTestModel != nil:
 		r.Printer.Printf(config.ConfigModel+"\n", strings.Join(cfg.ValidTestModel, ", "))
 	}
 
@@ -438,8 +886,15 @@ func (r *ConfigReader) PrintModelMenu(title string, models []string, modelGroup
 	r.Printer.Print("--------------------\n")
 
 	// Print model groups dynamically
-	for i, group := range config.ModelGroups {
-		r.Printer.Printf("%d. %s: %s\n", i+1, group.Title, strings.Join(group.Models, ", "))
+	width := util.TerminalWidth()
+	r.Printer.Printf("%s. 全部常见模型\n", SelectAllCommon)
+	for i, group := range modelGroup {
+		line := fmt.Sprintf("%d. %s: %s", i+1, group.Title, strings.Join(group.Models, ", "))
+		r.Printer.Printf("%s\n", util.Truncate(line, width))
+	}
+	if r.discover != nil {
+		r.Printer.Printf("%s. 该Key可访问的所有模型\n", SelectAllAccess)
+		r.Printer.Printf("%s. 自动发现模型 (测试前逐个 Key 获取 /v1/models)\n", SelectDiscover)
 	}
 	r.Printer.Printf("\n")
 
@@ -447,26 +902,27 @@ func (r *ConfigReader) PrintModelMenu(title string, models []string, modelGroup
 	r.Printer.Printf("常见模型列表\n")
 	r.Printer.Printf("--------------------\n")
 
-	// Find max length of model names
-	maxLen := 0
-	for _, model := range models {
-		if len(model) > maxLen {
-			maxLen = len(model)
+	// Find max width of model names
+	spacing := util.MaxWidth(models)
+	groupCount := len(modelGroup)
+
+	// Print individual models in two columns, or one column when the terminal is too narrow
+	// "NN. " + name + " " + "NN. " + name
+	if 2*(spacing+4)+1 > width {
+		for i, model := range models {
+			r.Printer.Printf("%-2d. %s\n", i+groupCount+1, util.Truncate(model, width-4))
 		}
-	}
-	// Add padding for better spacing
-	spacing := maxLen
-	// Print individual models in two columns
-	groupCount := len(config.ModelGroups)
-	for i := 0; i < len(models); i += 2 {
-		leftNum := i + groupCount + 1
-		leftModel := models[i]
-		if i+1 < len(models) {
-			rightNum := i + groupCount + 2
-			rightModel := models[i+1]
-			r.Printer.Printf("%-2d. %-*s %-2d. %s\n", leftNum, spacing, leftModel, rightNum, rightModel)
-		} else {
-			r.Printer.Printf("%-2d. %s\n", leftNum, leftModel)
+	} else {
+		for i := 0; i < len(models); i += 2 {
+			leftNum := i + groupCount + 1
+			leftModel := models[i]
+			if i+1 < len(models) {
+				rightNum := i + groupCount + 2
+				rightModel := models[i+1]
+				r.Printer.Printf("%-2d. %s %-2d. %s\n", leftNum, util.PadRight(leftModel, spacing), rightNum, rightModel)
+			} else {
+				r.Printer.Printf("%-2d. %s\n", leftNum, leftModel)
+			}
 		}
 	}
 
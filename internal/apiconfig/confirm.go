@@ -0,0 +1,278 @@
+package apiconfig
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Choices of the run confirmation prompt
+const (
+	ConfirmKeys   = "k" // 重新输入 Key
+	ConfirmURL    = "u" // 修改 URL
+	ConfirmModels = "m" // 重新选择模型
+	ConfirmQuit   = "q" // 放弃本次测试
+)
+
+// ConfirmYes starts a run that exceeds the request limit
+const ConfirmYes = "y"
+
+// RunPlan describes how the confirmed configuration is run
+type RunPlan struct {
+	Concurrency int
+	Runs        int // 每个 Key 和模型的测试轮数, 直连加每个代理各一轮
+	PerTest     int // 每个 Key 和模型每轮的请求数, 含测试成功后的工具调用和图片输入检测
+	MaxRequests int // 预计请求数超过后需输入 y 确认, 0 为不限制
+}
+
+// Requests returns the number of test requests the configuration fires in one run, one per key and model
+func (c *Config) Requests() int {
+	return len(c.Keys) * len(c.ValidTestModel)
+}
+
+// requests returns the number of requests of the whole run, counting the checks of every model as passing
+func (p RunPlan) requests(cfg *Config) int {
+	return cfg.Requests() * max(p.Runs, 1) * max(p.PerTest, 1)
+}
+
+// exceeded reports whether the run needs an explicit confirmation
+func (p RunPlan) exceeded(cfg *Config) bool {
+	return p.MaxRequests > 0 && p.requests(cfg) > p.MaxRequests
+}
+
+// printRunSummary prints the resolved configuration of a test run
+func (r *ConfigReader) printRunSummary(cfg *Config, plan RunPlan) {
+	r.Printer.PrintTitle("运行确认", util.EmojiGear)
+	r.Printer.Printf(config.ConfigURL+"\n", cfg.URL)
+	r.Printer.Printf("API Keys: %d 个\n", len(cfg.Keys))
+	r.Printer.Printf("模型: %d 个 (%s)\n", len(cfg.ValidTestModel), strings.Join(cfg.ValidTestModel, ", "))
+	r.Printer.Printf("并发数: %d\n", plan.Concurrency)
+	requests := plan.requests(cfg)
+	switch {
+	case plan.PerTest > 1:
+		r.Printer.Printf("预计请求数: 最多 %d (%d 个 Key × %d 个模型 × %d 轮 × 每个模型 %d 次请求)\n",
+			requests, len(cfg.Keys), len(cfg.ValidTestModel), max(plan.Runs, 1), plan.PerTest)
+	case plan.Runs > 1:
+		r.Printer.Printf("预计请求数: %d (%d 个 Key × %d 个模型 × %d 轮)\n", requests, len(cfg.Keys), len(cfg.ValidTestModel), plan.Runs)
+	default:
+		r.Printer.Printf("预计请求数: %d\n", requests)
+	}
+	r.Printer.Printf("预计消耗: 约 %d tokens\n", requests*apitest.EstimatedTokensPerRequest)
+	if cfg.DiscoverModels {
+		r.Printer.Printf("%s测试前逐个 Key 获取模型列表, 实际请求数以获取到的模型为准%s\n", util.ColorGray, util.ColorReset)
+	}
+	if plan.exceeded(cfg) {
+		r.Printer.Printf("%s%s 预计请求数超过 %d, 按量计费的 Key 会产生费用%s\n",
+			util.ColorYellow, util.EmojiWarning, plan.MaxRequests, util.ColorReset)
+	}
+}
+
+// ConfirmRun shows the resolved configuration before the test starts and lets the user
+// edit the keys, URL or models, it returns false if the user aborts the run.
+// A run above the request limit of the plan only starts after typing y.
+func (r *ConfigReader) ConfirmRun(cfg *Config, plan RunPlan) (bool, error) {
+	for {
+		r.printRunSummary(cfg, plan)
+		start := "回车开始测试"
+		if plan.exceeded(cfg) {
+			start = ConfirmYes + " 开始测试"
+		}
+		line, err := r.readPromptLine(fmt.Sprintf("\n%s%s, %s 重新输入 Key, %s 修改 URL, %s 重新选择模型, %s 放弃: %s",
+			util.ColorGray, start, ConfirmKeys, ConfirmURL, ConfirmModels, ConfirmQuit, util.ColorReset))
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(line) {
+		case "", ConfirmYes:
+			if line == "" && plan.exceeded(cfg) {
+				r.Printer.Printf("%s%s 请输入 %s 确认开始测试%s\n", util.ColorYellow, util.EmojiWarning, ConfirmYes, util.ColorReset)
+				continue
+			}
+			return true, nil
+		case ConfirmQuit:
+			return false, nil
+		case ConfirmKeys:
+			if err := r.editKeys(cfg); err != nil {
+				return false, err
+			}
+		case ConfirmURL:
+			switch cfg.Type {
+			case types.ChannelTypeGemini:
+				r.Printer.Printf("%s%s Gemini Key 使用官方接口, 无需修改 URL%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+				continue
+			case types.ChannelTypeAnthropic:
+				r.Printer.Printf("%s%s Anthropic Key 使用官方接口, 无需修改 URL%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+				continue
+			case types.ChannelTypeCohere:
+				r.Printer.Printf("%s%s Cohere Key 使用官方接口, 无需修改 URL%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+				continue
+			case types.ChannelTypeVertex:
+				// Only the region of the Vertex AI endpoint can change
+				url, err := r.readVertex(bufio.NewReader(r.input), cfg.Keys)
+				if err != nil {
+					return false, err
+				}
+				cfg.URL = url
+				continue
+			}
+			if err := r.editURL(cfg); err != nil {
+				return false, err
+			}
+		case ConfirmModels:
+			if err := r.editModels(cfg); err != nil {
+				return false, err
+			}
+		default:
+			r.Printer.Printf("%s%s 无效的选择: %s%s\n", util.ColorYellow, util.EmojiWarning, line, util.ColorReset)
+		}
+	}
+}
+
+// editKeys re-reads the keys of cfg, switching the channel type when the provider changes
+func (r *ConfigReader) editKeys(cfg *Config) error {
+	bufReader := bufio.NewReader(r.input)
+	keys, err := r.readKeys(bufReader)
+	if err != nil {
+		return err
+	}
+	keys, merged := DedupeKeys(keys)
+	if len(keys) == 0 {
+		r.Printer.Printf("%s%s %s%s\n", util.ColorYellow, util.EmojiWarning, config.ErrorNoAPIKey, util.ColorReset)
+		return nil
+	}
+	cfg.Keys = keys
+	cfg.MergedKeys = merged
+	cfg.Profile = ""
+
+	switch {
+	case isLocalKeys(keys):
+		cfg.Keys = []string{util.NoAuthKey}
+		if cfg.Type != types.ChannelTypeLocal {
+			url, err := r.readLocalURL(bufReader)
+			if err != nil {
+				return err
+			}
+			cfg.Type = types.ChannelTypeLocal
+			cfg.URL = url
+		}
+	case isVertexKeys(keys):
+		if cfg.Type != types.ChannelTypeVertex {
+			url, err := r.readVertex(bufReader, keys)
+			if err != nil {
+				return err
+			}
+			cfg.Type = types.ChannelTypeVertex
+			cfg.URL = url
+		}
+	case isGeminiKeys(keys):
+		cfg.Type = types.ChannelTypeGemini
+		cfg.URL = config.GeminiTestUrl
+	case isAnthropicKeys(keys):
+		cfg.Type = types.ChannelTypeAnthropic
+		cfg.URL = config.AnthropicTestUrl
+	case isCohereKeys(keys):
+		cfg.Type = types.ChannelTypeCohere
+		cfg.URL = config.CohereTestUrl
+	case isZhipuKeys(keys):
+		cfg.Type = types.ChannelTypeZhipu
+		cfg.URL = config.ZhipuTestUrl
+	case isOpenRouterKeys(keys):
+		cfg.Type = types.ChannelTypeOpenRouter
+		cfg.URL = config.OpenRouterTestUrl
+	case isGroqKeys(keys):
+		cfg.Type = types.ChannelTypeGroq
+		cfg.URL = config.GroqTestUrl
+	case cfg.Type == types.ChannelTypeAzure || cfg.Type == types.ChannelTypeDashScope:
+		// Azure keys have no prefix and DashScope keys look like relay keys, the endpoint stays the same
+	case cfg.Type != types.ChannelTypeOpenAI:
+		// Leaving an official endpoint needs a relay URL
+		cfg.Type = types.ChannelTypeOpenAI
+		url, err := r.readURL(bufReader)
+		if err != nil {
+			return err
+		}
+		cfg.URL = url
+	}
+	return nil
+}
+
+// editURL re-reads the URL of cfg, switching between relays and Azure OpenAI as entered,
+// a local endpoint stays local
+func (r *ConfigReader) editURL(cfg *Config) error {
+	bufReader := bufio.NewReader(r.input)
+	if cfg.Type == types.ChannelTypeLocal {
+		url, err := r.readLocalURL(bufReader)
+		if err != nil {
+			return err
+		}
+		cfg.URL = url
+		return nil
+	}
+	url, err := r.readURL(bufReader)
+	if err != nil {
+		return err
+	}
+	if !isAzureInput(url) {
+		leavingAzure := cfg.Type == types.ChannelTypeAzure
+		cfg.Type = types.ChannelTypeOpenAI
+		switch {
+		case apitest.IsCohereURL(url):
+			cfg.Type = types.ChannelTypeCohere
+		case apitest.IsDashScopeURL(url):
+			cfg.Type = types.ChannelTypeDashScope
+		case apitest.IsZhipuURL(url):
+			cfg.Type = types.ChannelTypeZhipu
+		case apitest.IsOpenRouterURL(url):
+			cfg.Type = types.ChannelTypeOpenRouter
+		case apitest.IsGroqURL(url):
+			cfg.Type = types.ChannelTypeGroq
+		}
+		cfg.URL = url
+		if leavingAzure {
+			// Deployment names mean nothing to a relay
+			return r.editModels(cfg)
+		}
+		return nil
+	}
+	url, deployments, err := r.readAzure(bufReader, url)
+	if err != nil {
+		return err
+	}
+	cfg.Type = types.ChannelTypeAzure
+	cfg.URL = url
+	cfg.ValidTestModel = deployments
+	return nil
+}
+
+// editModels shows the model menu for the keys of cfg again, Azure deployments are re-entered instead
+func (r *ConfigReader) editModels(cfg *Config) error {
+	if cfg.Type == types.ChannelTypeAzure {
+		line, err := r.readPromptLine(config.InputPromptAzureDeployment + " ")
+		if err != nil {
+			return err
+		}
+		if deployments := deduplicateModels(strings.Fields(strings.ReplaceAll(line, ",", " "))); len(deployments) > 0 {
+			cfg.ValidTestModel = deployments
+		}
+		return nil
+	}
+	modelList, modelGroups := modelMenu(cfg.Type, cfg.Keys)
+	url, key := cfg.URL, discoveryKey(cfg.Type, cfg.Keys)
+	if cfg.Type != types.ChannelTypeVertex && cfg.Type != types.ChannelTypeCohere && cfg.Type != types.ChannelTypeZhipu {
+		r.discover = r.listModels(url, key)
+		defer func() { r.discover = nil }()
+	}
+	models, err := r.readModel(r.input, modelList, modelGroups)
+	if err != nil {
+		return err
+	}
+	cfg.ValidTestModel = models
+	cfg.DiscoverModels = r.perKey
+	return nil
+}
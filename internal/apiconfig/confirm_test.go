@@ -0,0 +1,62 @@
+package apiconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmRun(t *testing.T) {
+	var out strings.Builder
+	cfg := &Config{
+		Keys:           []string{"sk-1", "sk-2", "sk-3"},
+		ValidTestModel: []string{"gpt-4o", "gpt-4o-mini"},
+		URL:            "https://api.example.com/v1/chat/completions",
+	}
+	assert.Equal(t, 6, cfg.Requests())
+
+	r := NewConfigReader(strings.NewReader("q"), &out)
+	ok, err := r.ConfirmRun(cfg, RunPlan{Concurrency: 5})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "API Keys: 3 个")
+	assert.Contains(t, out.String(), "并发数: 5")
+	assert.Contains(t, out.String(), "预计请求数: 6")
+
+	r = NewConfigReader(strings.NewReader(""), &out)
+	ok, err = r.ConfirmRun(cfg, RunPlan{Concurrency: 5})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestConfirmRunLimit(t *testing.T) {
+	var out strings.Builder
+	cfg := &Config{Keys: []string{"sk-1", "sk-2"}, ValidTestModel: []string{"gpt-4o"}}
+	plan := RunPlan{Concurrency: 1, Runs: 3, MaxRequests: 5}
+
+	r := NewConfigReader(strings.NewReader("y"), &out)
+	ok, err := r.ConfirmRun(cfg, plan)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Contains(t, out.String(), "预计请求数: 6 (2 个 Key × 1 个模型 × 3 轮)")
+	assert.Contains(t, out.String(), "预计请求数超过 5")
+	assert.Contains(t, out.String(), "y 开始测试")
+
+	// Below the limit no warning is shown
+	out.Reset()
+	plan.MaxRequests = 6
+	r = NewConfigReader(strings.NewReader("q"), &out)
+	_, err = r.ConfirmRun(cfg, plan)
+	assert.NoError(t, err)
+	assert.NotContains(t, out.String(), "预计请求数超过")
+
+	// The checks of passing models count against the limit too
+	out.Reset()
+	plan.PerTest = 2
+	r = NewConfigReader(strings.NewReader("y"), &out)
+	_, err = r.ConfirmRun(cfg, plan)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "预计请求数: 最多 12 (2 个 Key × 1 个模型 × 3 轮 × 每个模型 2 次请求)")
+	assert.Contains(t, out.String(), "预计请求数超过 6")
+}
@@ -0,0 +1,69 @@
+package apiconfig
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// MergedKey describes inputs that were merged into one key before testing
+type MergedKey struct {
+	Key     string
+	Inputs  []int // 输入中的序号，从 1 开始
+	Cleaned bool  // 输入包含空白或不可见字符
+}
+
+// String formats the merge as "sk-a...b: 第 1, 3 个输入 (已清除不可见字符)"
+func (m MergedKey) String() string {
+	inputs := make([]string, len(m.Inputs))
+	for i, n := range m.Inputs {
+		inputs[i] = fmt.Sprint(n)
+	}
+	s := fmt.Sprintf("%s: 第 %s 个输入", util.MaskKey(m.Key), strings.Join(inputs, ", "))
+	if m.Cleaned {
+		s += " (已清除不可见字符)"
+	}
+	return s
+}
+
+// CleanKey removes whitespace and invisible format characters such as zero-width spaces and BOMs,
+// which are common in keys copied from spreadsheets and chat apps
+func CleanKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, key)
+}
+
+// DedupeKeys cleans the keys and merges duplicates, keeping the order of first appearance.
+// It returns the unique keys and the inputs that were merged or cleaned.
+func DedupeKeys(keys []string) ([]string, []MergedKey) {
+	var unique []string
+	merges := make(map[string]*MergedKey)
+	for i, input := range keys {
+		key := CleanKey(input)
+		if key == "" {
+			continue
+		}
+		m, ok := merges[key]
+		if !ok {
+			m = &MergedKey{Key: key}
+			merges[key] = m
+			unique = append(unique, key)
+		}
+		m.Inputs = append(m.Inputs, i+1)
+		m.Cleaned = m.Cleaned || key != input
+	}
+
+	var merged []MergedKey
+	for _, key := range unique {
+		if m := merges[key]; len(m.Inputs) > 1 || m.Cleaned {
+			merged = append(merged, *m)
+		}
+	}
+	return unique, merged
+}
@@ -0,0 +1,31 @@
+package apiconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanKey(t *testing.T) {
+	assert.Equal(t, "sk-abc123", CleanKey("\ufeffsk-abc\u200b123 "))
+	assert.Equal(t, "sk-abc123", CleanKey("sk-abc123"))
+	assert.Equal(t, "", CleanKey("\u200b"))
+}
+
+func TestDedupeKeys(t *testing.T) {
+	keys, merged := DedupeKeys([]string{
+		"sk-aaaaaaaaaaaa",
+		"sk-bbbbbbbbbbbb",
+		"sk-aaaaaaaaaaaa",
+		"sk-bbbbbb\u200bbbbbbb",
+		"\u200b",
+		"sk-cccccccccccc\u2060",
+	})
+
+	assert.Equal(t, []string{"sk-aaaaaaaaaaaa", "sk-bbbbbbbbbbbb", "sk-cccccccccccc"}, keys)
+	assert.Equal(t, []MergedKey{
+		{Key: "sk-aaaaaaaaaaaa", Inputs: []int{1, 3}},
+		{Key: "sk-bbbbbbbbbbbb", Inputs: []int{2, 4}, Cleaned: true},
+		{Key: "sk-cccccccccccc", Inputs: []int{6}, Cleaned: true},
+	}, merged)
+}
@@ -0,0 +1,42 @@
+package apiconfig
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// isLocalKeys reports whether the input selects a local endpoint that takes no key
+func isLocalKeys(keys []string) bool {
+	return len(keys) == 1 && strings.EqualFold(keys[0], util.NoAuthKey)
+}
+
+// discoveryKey returns the key the model list is requested with, local endpoints are asked without one
+func discoveryKey(channelType types.ChannelType, keys []string) string {
+	if channelType == types.ChannelTypeLocal {
+		return ""
+	}
+	return keys[0]
+}
+
+// readLocalURL returns the endpoint of a local OpenAI compatible server, Ollama when nothing is entered
+func (r *ConfigReader) readLocalURL(reader *bufio.Reader) (string, error) {
+	for {
+		url, err := r.readAnswer(reader, fmt.Sprintf(config.InputPromptLocalURL, config.LocalTestUrl))
+		if err != nil {
+			return "", err
+		}
+		if url == "" {
+			return config.LocalTestUrl, nil
+		}
+		if !util.IsValidURL(url) {
+			r.Printer.Printf("%s%s 无效的 URL，请重新输入%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+			continue
+		}
+		return r.resolveURL(url), nil
+	}
+}
@@ -0,0 +1,29 @@
+package apiconfig
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLocalURL(t *testing.T) {
+	assert.True(t, isLocalKeys([]string{"LOCAL"}))
+	assert.False(t, isLocalKeys([]string{"local", "sk-abc"}))
+	assert.Equal(t, "", discoveryKey(types.ChannelTypeLocal, []string{"local"}))
+	assert.Equal(t, "sk-abc", discoveryKey(types.ChannelTypeOpenAI, []string{"sk-abc"}))
+
+	var out strings.Builder
+	r := NewConfigReader(strings.NewReader(""), &out)
+	url, err := r.readLocalURL(bufio.NewReader(strings.NewReader("\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, config.LocalTestUrl, url)
+
+	url, err = r.readLocalURL(bufio.NewReader(strings.NewReader("not a url\nhttp://127.0.0.1:1/v1/chat/completions\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:1/v1/chat/completions", url)
+	assert.Contains(t, out.String(), "无效的 URL")
+}
@@ -0,0 +1,51 @@
+package apiconfig
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// vertexRegion matches Vertex AI locations such as us-central1, europe-west4 or global
+var vertexRegion = regexp.MustCompile(`^[a-z]+(-[a-z]+[0-9]+)?$`)
+
+// isVertexKeys reports whether all keys are service account files
+func isVertexKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !apitest.IsServiceAccountPath(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// readVertex returns the Vertex AI URL of the project of the first service account,
+// prompting for the region
+func (r *ConfigReader) readVertex(reader *bufio.Reader, keys []string) (string, error) {
+	sa, err := apitest.LoadServiceAccount(keys[0])
+	if err != nil {
+		return "", err
+	}
+	if sa.ProjectID == "" {
+		return "", fmt.Errorf("服务账号文件中缺少 project_id: %s", keys[0])
+	}
+
+	for {
+		region, err := r.readAnswer(reader, fmt.Sprintf(config.InputPromptVertexRegion, config.VertexRegion))
+		if err != nil {
+			return "", err
+		}
+		if region != "" && !vertexRegion.MatchString(region) {
+			r.Printer.Printf("%s%s 无效的区域，请重新输入%s\n", util.ColorYellow, util.EmojiWarning, util.ColorReset)
+			continue
+		}
+		return apitest.VertexURL(sa.ProjectID, region), nil
+	}
+}
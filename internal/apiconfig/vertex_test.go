@@ -0,0 +1,29 @@
+package apiconfig
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadVertex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sa.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"type":"service_account","project_id":"my-project","client_email":"a@b","private_key":"x"}`), 0o600))
+	assert.True(t, isVertexKeys([]string{path}))
+	assert.False(t, isVertexKeys([]string{path, "sk-abc"}))
+
+	var out strings.Builder
+	r := NewConfigReader(strings.NewReader(""), &out)
+	url, err := r.readVertex(bufio.NewReader(strings.NewReader("Europe West\neurope-west4\n")), []string{path})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://europe-west4-aiplatform.googleapis.com/v1/projects/my-project/locations/europe-west4/publishers/google/models", url)
+	assert.Contains(t, out.String(), "无效的区域")
+
+	url, err = r.readVertex(bufio.NewReader(strings.NewReader("\n")), []string{path})
+	assert.NoError(t, err)
+	assert.Contains(t, url, "us-central1-aiplatform.googleapis.com")
+}
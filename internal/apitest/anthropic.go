@@ -0,0 +1,68 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// AnthropicKeyPrefix marks Anthropic API keys
+const AnthropicKeyPrefix = "sk-ant-"
+
+// AnthropicRequest represents a request to the Anthropic Messages API
+type AnthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"` // Messages API 必填
+	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+// AnthropicResponse represents the parts of a Messages API response used to validate it
+type AnthropicResponse struct {
+	Type    string `json:"type"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// AnthropicError represents the error structure returned by the Anthropic API
+type AnthropicError struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+	RequestID string `json:"request_id"`
+}
+
+// anthropicEndpoint returns the Messages API URL, the official one when baseURL is empty
+func anthropicEndpoint(baseURL string) string {
+	if baseURL == "" {
+		return config.AnthropicTestUrl
+	}
+	return baseURL
+}
+
+// formatAnthropicError formats a Claude error body, ok is false for other bodies
+func formatAnthropicError(status int, errBody string) (string, bool) {
+	var e AnthropicError
+	if err := json.Unmarshal([]byte(errBody), &e); err != nil || e.Type != "error" || e.Error.Message == "" {
+		return "", false
+	}
+	parts := []string{fmt.Sprintf("code: %d", status), fmt.Sprintf("message: %s", e.Error.Message)}
+	if e.Error.Type != "" {
+		parts = append(parts, fmt.Sprintf("type: %s", e.Error.Type))
+	}
+	if e.RequestID != "" {
+		parts = append(parts, fmt.Sprintf("request_id: %s", e.RequestID))
+	}
+	return strings.Join(parts, " "), true
+}
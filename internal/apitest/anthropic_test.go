@@ -0,0 +1,62 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAnthropicRequest(t *testing.T) {
+	cfg := &TestConfig{
+		Channel: &Channel{Type: ChannelTypeAnthropic, Key: "sk-ant-api03-test"},
+		Model:   "claude-3-5-haiku-20241022",
+	}
+	req, err := NewRequestBuilder().BuildRequest(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, config.AnthropicTestUrl, req.URL.String())
+	assert.Equal(t, "sk-ant-api03-test", req.Header.Get("x-api-key"))
+	assert.Equal(t, config.AnthropicVersion, req.Header.Get("anthropic-version"))
+	assert.Empty(t, req.Header.Get("Authorization"))
+
+	var body AnthropicRequest
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+	assert.Equal(t, "claude-3-5-haiku-20241022", body.Model)
+	assert.Equal(t, 1, body.MaxTokens)
+	assert.Equal(t, "hi", body.Messages[0].Content)
+}
+
+func TestProcessAnthropicResponse(t *testing.T) {
+	respond := func(status int, body string) TestResult {
+		return NewResultProcessor("sk-ant-api03-test", "claude-3-5-haiku-20241022").ProcessResponse(&http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		})
+	}
+
+	result := respond(http.StatusOK, `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}],"stop_reason":"max_tokens","usage":{"input_tokens":8,"output_tokens":1}}`)
+	assert.True(t, result.Success)
+	if anthropicResp, ok := result.Response.(AnthropicResponse); assert.True(t, ok, "%T", result.Response) {
+		if assert.Len(t, anthropicResp.Content, 1) {
+			assert.Equal(t, "Hi", anthropicResp.Content[0].Text)
+		}
+		assert.Equal(t, "max_tokens", anthropicResp.StopReason)
+		assert.Equal(t, 8, anthropicResp.Usage.InputTokens)
+		assert.Equal(t, 1, anthropicResp.Usage.OutputTokens)
+	}
+
+	result = respond(http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"},"request_id":"req_011"}`)
+	assert.False(t, result.Success)
+	assert.Equal(t, "code: 401 message: invalid x-api-key type: authentication_error request_id: req_011", result.Error.Error())
+}
+
+func TestValidateAnthropicKey(t *testing.T) {
+	assert.NoError(t, ValidateKey(ChannelTypeAnthropic, "sk-ant-api03-"+strings.Repeat("a", 95)))
+	assert.ErrorIs(t, ValidateKey(ChannelTypeAnthropic, "sk-proj-"+strings.Repeat("a", 40)), ErrMalformedKey)
+	assert.ErrorIs(t, ValidateKey(ChannelTypeAnthropic, "sk-ant-abc"), ErrMalformedKey)
+}
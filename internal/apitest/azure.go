@@ -0,0 +1,51 @@
+package apitest
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// AzureURL returns the deployments URL of an Azure OpenAI resource, the channel URL of Azure channels.
+// The deployment to test is added to the path per request, like the model of Gemini requests.
+func AzureURL(resource, apiVersion string) string {
+	if apiVersion == "" {
+		apiVersion = config.AzureAPIVersion
+	}
+	return "https://" + resource + config.AzureHostSuffix + "/openai/deployments?api-version=" + url.QueryEscape(apiVersion)
+}
+
+// IsAzureURL reports whether rawURL points to an Azure OpenAI resource
+func IsAzureURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Hostname()), config.AzureHostSuffix)
+}
+
+// ParseAzureURL splits a URL copied from the Azure portal, such as
+// https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21,
+// into the resource, deployment and api-version, the last two are empty when the URL has none
+func ParseAzureURL(rawURL string) (resource, deployment, apiVersion string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !IsAzureURL(rawURL) {
+		return "", "", "", false
+	}
+	resource = strings.TrimSuffix(strings.ToLower(u.Hostname()), config.AzureHostSuffix)
+	if rest, found := strings.CutPrefix(u.Path, "/openai/deployments/"); found {
+		deployment, _, _ = strings.Cut(rest, "/")
+	}
+	return resource, deployment, u.Query().Get("api-version"), true
+}
+
+// azureEndpoint returns the chat completions URL of a deployment
+func azureEndpoint(channelURL, deployment string) string {
+	u, err := url.Parse(channelURL)
+	if err != nil {
+		return channelURL
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + url.PathEscape(deployment) + "/chat/completions"
+	return u.String()
+}
@@ -0,0 +1,48 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAzureRequest(t *testing.T) {
+	key := strings.Repeat("0123456789abcdef", 2)
+	cfg := &TestConfig{
+		Channel: &Channel{Type: ChannelTypeAzure, Key: key, URL: AzureURL("myres", "")},
+		Model:   "gpt-4o-prod",
+	}
+	req, err := NewRequestBuilder().BuildRequest(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://myres.openai.azure.com/openai/deployments/gpt-4o-prod/chat/completions?api-version="+config.AzureAPIVersion, req.URL.String())
+	assert.Equal(t, key, req.Header.Get("api-key"))
+	assert.Empty(t, req.Header.Get("Authorization"))
+
+	var body OpenAIRequest
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+	assert.Equal(t, "hi", body.Messages[0].Content)
+
+	assert.NoError(t, ValidateKey(ChannelTypeAzure, key))
+	assert.ErrorIs(t, ValidateKey(ChannelTypeAzure, "abc"), ErrMalformedKey)
+}
+
+func TestParseAzureURL(t *testing.T) {
+	resource, deployment, version, ok := ParseAzureURL("https://MyRes.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-06-01")
+	assert.True(t, ok)
+	assert.Equal(t, "myres", resource)
+	assert.Equal(t, "gpt-4o", deployment)
+	assert.Equal(t, "2024-06-01", version)
+
+	resource, deployment, version, ok = ParseAzureURL(AzureURL("myres", "2024-06-01"))
+	assert.True(t, ok)
+	assert.Equal(t, "myres", resource)
+	assert.Empty(t, deployment, "the channel URL has no deployment")
+	assert.Equal(t, "2024-06-01", version)
+
+	_, _, _, ok = ParseAzureURL("https://api.openai.com/v1/chat/completions")
+	assert.False(t, ok)
+}
@@ -0,0 +1,94 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// CohereKeyLength is the length of Cohere API keys, which have no prefix
+const CohereKeyLength = 40
+
+// CohereRequest represents a request to the Cohere v2 Chat API
+type CohereRequest struct {
+	Model     string    `json:"model"`
+	Messages  []Message `json:"messages"`
+	MaxTokens int       `json:"max_tokens,omitempty"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+// CohereResponse represents the parts of a v2 Chat response used to validate it
+type CohereResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      *struct {
+		Role string `json:"role"`
+	} `json:"message"`
+	Usage *struct {
+		BilledUnits struct {
+			InputTokens  float64 `json:"input_tokens"`
+			OutputTokens float64 `json:"output_tokens"`
+		} `json:"billed_units"`
+	} `json:"usage"`
+}
+
+// CohereError represents the error body of the Cohere API, a message without the OpenAI error object
+type CohereError struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// IsCohereKey reports whether key has the shape of a Cohere API key: 40 letters and digits without a prefix
+func IsCohereKey(key string) bool {
+	if len(key) != CohereKeyLength || hasKeyPrefix(key) {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// IsCohereURL reports whether rawURL points to the Cohere API
+func IsCohereURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "api.cohere.com" || host == "api.cohere.ai"
+}
+
+// cohereEndpoint returns the v2 Chat URL, the official one when baseURL is empty
+func cohereEndpoint(baseURL string) string {
+	if baseURL == "" {
+		return config.CohereTestUrl
+	}
+	return baseURL
+}
+
+// formatCohereError formats a Cohere error body, ok is false for other bodies
+func formatCohereError(status int, errBody string) (string, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(errBody), &fields); err != nil {
+		return "", false
+	}
+	// OpenAI and Google errors wrap the message in an error object
+	if _, wrapped := fields["error"]; wrapped {
+		return "", false
+	}
+	var e CohereError
+	if err := json.Unmarshal([]byte(errBody), &e); err != nil || e.Message == "" {
+		return "", false
+	}
+	parts := []string{fmt.Sprintf("code: %d", status), fmt.Sprintf("message: %s", e.Message)}
+	if e.ID != "" {
+		parts = append(parts, fmt.Sprintf("id: %s", e.ID))
+	}
+	return strings.Join(parts, " "), true
+}
@@ -0,0 +1,66 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCohereKey = "abcdefghijABCDEFGHIJ0123456789abcdefghij"
+
+func TestBuildCohereRequest(t *testing.T) {
+	cfg := &TestConfig{
+		Channel:     &Channel{Type: ChannelTypeCohere, Key: testCohereKey},
+		Model:       "command-r-08-2024",
+		RequestOpts: DefaultRequestOptions(),
+	}
+	req, err := NewRequestBuilder().BuildRequest(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, config.CohereTestUrl, req.URL.String())
+	assert.Equal(t, "Bearer "+testCohereKey, req.Header.Get("Authorization"))
+
+	var body CohereRequest
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+	assert.Equal(t, "command-r-08-2024", body.Model)
+	assert.Equal(t, 1, body.MaxTokens)
+	assert.Equal(t, "hi", body.Messages[0].Content)
+}
+
+func TestProcessCohereResponse(t *testing.T) {
+	respond := func(status int, body string) TestResult {
+		return NewResultProcessor(testCohereKey, "command-r-08-2024").ProcessResponse(&http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		})
+	}
+
+	result := respond(http.StatusOK, `{"id":"c1","finish_reason":"MAX_TOKENS","message":{"role":"assistant","content":[{"type":"text","text":"Hi"}]},"usage":{"billed_units":{"input_tokens":1,"output_tokens":1},"tokens":{"input_tokens":67,"output_tokens":1}}}`)
+	assert.True(t, result.Success)
+	assert.IsType(t, CohereResponse{}, result.Response)
+
+	result = respond(http.StatusUnauthorized, `{"id":"5d1d7f0a","message":"invalid api token"}`)
+	assert.False(t, result.Success)
+	assert.Equal(t, "code: 401 message: invalid api token id: 5d1d7f0a", result.Error.Error())
+
+	// OpenAI errors in the same batch keep their own format
+	result = respond(http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`)
+	assert.Equal(t, "code: 401 message: Incorrect API key provided type: invalid_request_error code: invalid_api_key", result.Error.Error())
+}
+
+func TestCohereKeyAndURL(t *testing.T) {
+	assert.True(t, IsCohereKey(testCohereKey))
+	assert.False(t, IsCohereKey("sk-"+testCohereKey[3:]))
+	assert.False(t, IsCohereKey(testCohereKey[:39]+"-"))
+	assert.NoError(t, ValidateKey(ChannelTypeCohere, testCohereKey))
+	assert.ErrorIs(t, ValidateKey(ChannelTypeCohere, "abc123"), ErrMalformedKey)
+
+	assert.True(t, IsCohereURL("https://api.cohere.com/v2/chat"))
+	assert.True(t, IsCohereURL("https://api.cohere.ai"))
+	assert.False(t, IsCohereURL("https://relay.example.com/v1/chat/completions"))
+}
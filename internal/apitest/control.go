@@ -0,0 +1,114 @@
+package apitest
+
+import (
+	"context"
+	"sync"
+)
+
+// Command controls a running test
+type Command int
+
+const (
+	CommandPause  Command = iota // 暂停派发新的测试
+	CommandResume                // 继续派发
+	CommandAbort                 // 中止, 未开始的测试标记为未测试
+)
+
+// Keys of the run controls
+const (
+	KeyPause  = 'p'
+	KeyResume = 'r'
+	KeyAbort  = 'q'
+)
+
+// ParseCommand returns the command bound to a key press
+func ParseCommand(key byte) (Command, bool) {
+	switch key {
+	case KeyPause, 'P':
+		return CommandPause, true
+	case KeyResume, 'R':
+		return CommandResume, true
+	case KeyAbort, 'Q':
+		return CommandAbort, true
+	default:
+		return 0, false
+	}
+}
+
+// gate holds back the dispatch of tests while paused and rejects them once aborted
+type gate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // 暂停时非 nil, 继续时关闭
+	aborted chan struct{}
+	abort   sync.Once
+}
+
+// newGate returns a gate driven by the commands of control until stop is closed,
+// a nil control never pauses
+func newGate(control <-chan Command, stop <-chan struct{}) *gate {
+	g := &gate{aborted: make(chan struct{})}
+	if control == nil {
+		return g
+	}
+	go func() {
+		for {
+			select {
+			case cmd, ok := <-control:
+				if !ok {
+					return
+				}
+				g.apply(cmd)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return g
+}
+
+// apply changes the state of the gate
+func (g *gate) apply(cmd Command) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch cmd {
+	case CommandPause:
+		if g.resumed == nil {
+			g.resumed = make(chan struct{})
+		}
+	case CommandResume:
+		if g.resumed != nil {
+			close(g.resumed)
+			g.resumed = nil
+		}
+	case CommandAbort:
+		g.abort.Do(func() { close(g.aborted) })
+	}
+}
+
+// wait blocks while the gate is paused, it returns false when the run was aborted or ctx is done
+func (g *gate) wait(ctx context.Context) bool {
+	for {
+		select {
+		case <-g.aborted:
+			return false
+		case <-ctx.Done():
+			return false
+		default:
+		}
+
+		g.mu.Lock()
+		resumed := g.resumed
+		g.mu.Unlock()
+		if resumed == nil {
+			return true
+		}
+
+		select {
+		case <-resumed:
+		case <-g.aborted:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
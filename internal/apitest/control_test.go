@@ -0,0 +1,106 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatePauseResume(t *testing.T) {
+	control := make(chan Command)
+	stop := make(chan struct{})
+	defer close(stop)
+	g := newGate(control, stop)
+	assert.True(t, g.wait(context.Background()))
+
+	control <- CommandPause
+	control <- CommandPause // the gate has applied the first command once the second one is received
+	passed := make(chan bool)
+	go func() { passed <- g.wait(context.Background()) }()
+	select {
+	case <-passed:
+		t.Fatal("paused gate let a test through")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	control <- CommandResume
+	assert.True(t, <-passed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, g.wait(ctx))
+}
+
+func TestAbortKeepsPartialResults(t *testing.T) {
+	control := make(chan Command)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			close(started)
+			<-release
+		}
+		w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	go func() {
+		<-started
+		control <- CommandAbort
+		control <- CommandAbort
+		close(release)
+	}()
+
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: []string{"gpt-4o", "gpt-4o-mini", "gpt-3.5-turbo"}}}
+	results := NewApiTest(1, WithControl(control)).TestAllApis(context.Background(), channels)
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	skipped := 0
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+		} else {
+			assert.True(t, result.Success, "the test in flight finishes")
+		}
+	}
+	assert.Equal(t, 2, skipped)
+}
+
+func TestParseCommand(t *testing.T) {
+	cmd, ok := ParseCommand('P')
+	assert.True(t, ok)
+	assert.Equal(t, CommandPause, cmd)
+	_, ok = ParseCommand('x')
+	assert.False(t, ok)
+}
+
+func TestCanceledRunSkipsRemaining(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: []string{"gpt-4o", "gpt-4o-mini", "gpt-3.5-turbo"}}}
+	start := time.Now()
+	results := NewApiTest(1).TestAllApis(ctx, channels)
+
+	assert.Less(t, time.Since(start), 5*time.Second, "the request in flight is cancelled")
+	assert.Len(t, results, 3)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	for _, result := range results {
+		assert.True(t, result.Skipped)
+	}
+}
@@ -0,0 +1,104 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// DashScope hosts, the international site serves keys created outside mainland China
+const (
+	DashScopeHost     = "dashscope.aliyuncs.com"
+	DashScopeIntlHost = "dashscope-intl.aliyuncs.com"
+)
+
+// DashScopeError represents the native error body of DashScope, a top-level code such as InvalidApiKey or Arrearage
+type DashScopeError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// dashScopeCodes explains the DashScope error codes a key test commonly runs into
+var dashScopeCodes = map[string]string{
+	"InvalidApiKey":                "Key 无效",
+	"invalid_api_key":              "Key 无效",
+	"Arrearage":                    "账户欠费, 请充值",
+	"AllocationQuota.FreeTierOnly": "免费额度已用完且仅允许使用免费额度",
+	"Throttling.RateQuota":         "请求频率超限",
+	"Throttling.AllocationQuota":   "Token 配额超限",
+	"AccessDenied.Unpurchased":     "未开通百炼模型服务",
+	"Model.AccessDenied":           "无权访问该模型",
+	"DataInspectionFailed":         "输入或输出未通过内容安全审核",
+	"data_inspection_failed":       "输入或输出未通过内容安全审核",
+	"model_not_found":              "模型不存在",
+	"InvalidParameter":             "请求参数无效",
+	"RequestTimeOut":               "请求超时",
+	"InternalError.Algo":           "模型服务内部错误",
+}
+
+// IsDashScopeURL reports whether rawURL points to Alibaba Cloud DashScope
+func IsDashScopeURL(rawURL string) bool {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == DashScopeHost || host == DashScopeIntlHost
+}
+
+// DashScopeURL returns the OpenAI compatible chat endpoint on the DashScope host of rawURL,
+// the native /api/v1 routes take a different request format
+func DashScopeURL(rawURL string) string {
+	host := DashScopeHost
+	if strings.Contains(strings.ToLower(rawURL), DashScopeIntlHost) {
+		host = DashScopeIntlHost
+	}
+	return "https://" + host + config.DashScopeCompatPath
+}
+
+// formatDashScopeError formats a DashScope error body, native or in compatible mode, ok is false for other bodies
+func formatDashScopeError(status int, errBody string) (string, bool) {
+	var native DashScopeError
+	if err := json.Unmarshal([]byte(errBody), &native); err == nil && native.Code != "" && native.RequestID != "" {
+		return dashScopeMessage(status, native.Code, native.Message, native.RequestID), true
+	}
+
+	// Compatible mode wraps the error the OpenAI way next to a request_id, which OpenAI errors lack,
+	// only known DashScope codes are recognized
+	var compat struct {
+		OpenAIError
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal([]byte(errBody), &compat); err != nil || compat.Error.Message == "" || compat.RequestID == "" {
+		return "", false
+	}
+	code := compat.Error.Code
+	if _, known := dashScopeCodes[code]; !known {
+		if _, known := dashScopeCodes[compat.Error.Type]; !known {
+			return "", false
+		}
+		code = compat.Error.Type
+	}
+	return dashScopeMessage(status, code, compat.Error.Message, compat.RequestID), true
+}
+
+// dashScopeMessage formats the status, message and code of a DashScope error with the meaning of the code
+func dashScopeMessage(status int, code, message, requestID string) string {
+	parts := []string{fmt.Sprintf("code: %d", status), fmt.Sprintf("message: %s", message)}
+	if hint, ok := dashScopeCodes[code]; ok {
+		parts = append(parts, fmt.Sprintf("code: %s (%s)", code, hint))
+	} else {
+		parts = append(parts, fmt.Sprintf("code: %s", code))
+	}
+	if requestID != "" {
+		parts = append(parts, fmt.Sprintf("request_id: %s", requestID))
+	}
+	return strings.Join(parts, " ")
+}
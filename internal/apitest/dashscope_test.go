@@ -0,0 +1,42 @@
+package apitest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashScopeURL(t *testing.T) {
+	assert.True(t, IsDashScopeURL("https://dashscope.aliyuncs.com/api/v1"))
+	assert.True(t, IsDashScopeURL("dashscope-intl.aliyuncs.com"))
+	assert.False(t, IsDashScopeURL("https://api.openai.com/v1"))
+
+	assert.Equal(t, config.DashScopeTestUrl, DashScopeURL("https://dashscope.aliyuncs.com/api/v1"))
+	assert.Equal(t, "https://dashscope-intl.aliyuncs.com/compatible-mode/v1/chat/completions", DashScopeURL("dashscope-intl.aliyuncs.com"))
+}
+
+func TestDashScopeError(t *testing.T) {
+	respond := func(status int, body string) TestResult {
+		return NewResultProcessor("sk-test", "qwen-plus").ProcessResponse(&http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		})
+	}
+
+	result := respond(http.StatusBadRequest, `{"code":"Arrearage","message":"Access denied, please make sure your account is in good standing.","request_id":"b9a1"}`)
+	assert.Equal(t, "code: 400 message: Access denied, please make sure your account is in good standing. code: Arrearage (账户欠费, 请充值) request_id: b9a1", result.Error.Error())
+
+	result = respond(http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided.","type":"invalid_request_error","param":null,"code":"invalid_api_key"},"request_id":"c2d4"}`)
+	assert.Equal(t, "code: 401 message: Incorrect API key provided. code: invalid_api_key (Key 无效) request_id: c2d4", result.Error.Error())
+
+	// Unknown codes in the OpenAI shape are left to the OpenAI formatter
+	result = respond(http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+	assert.Equal(t, "code: 429 message: Rate limit reached type: requests code: rate_limit_exceeded", result.Error.Error())
+
+	assert.Error(t, ValidateKey(ChannelTypeDashScope, "ak-0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, ValidateKey(ChannelTypeDashScope, "sk-0123456789abcdef0123456789abcdef"))
+}
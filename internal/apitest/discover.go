@@ -0,0 +1,53 @@
+package apitest
+
+import (
+	"context"
+	"sync"
+)
+
+// ListModels returns the models the key can access at the endpoint of apiURL
+type ListModels func(ctx context.Context, apiURL, key string) ([]string, error)
+
+// DiscoverModels asks the endpoint of every channel for the models its key can access and makes them the
+// models the channel is tested with. Channels whose list cannot be fetched or is empty keep their models,
+// the errors are returned by channel index.
+func DiscoverModels(ctx context.Context, channels []*Channel, concurrency int, list ListModels) map[int]error {
+	errs := make(map[int]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(concurrency, 1))
+	for i, c := range channels {
+		wg.Add(1)
+		go func(i int, c *Channel) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			key := c.Key
+			// Local endpoints are asked without a key
+			if c.Type == ChannelTypeLocal {
+				key = ""
+			}
+			models, err := list(ctx, c.URL, key)
+			if err != nil {
+				mu.Lock()
+				errs[i] = err
+				mu.Unlock()
+				return
+			}
+			if len(models) > 0 {
+				c.TestModel = models
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	return errs
+}
+
+// CountRequests returns the number of test requests the channels fire in one run, one per key and model
+func CountRequests(channels []*Channel) int {
+	n := 0
+	for _, c := range channels {
+		n += len(c.TestModel)
+	}
+	return n
+}
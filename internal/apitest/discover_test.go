@@ -0,0 +1,40 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverModels(t *testing.T) {
+	lists := map[string][]string{
+		"sk-a": {"gpt-4o", "gpt-4o-mini"},
+		"sk-b": {},
+		"":     {"llama3"},
+	}
+	list := func(ctx context.Context, apiURL, key string) ([]string, error) {
+		models, ok := lists[key]
+		if !ok {
+			return nil, fmt.Errorf("401")
+		}
+		return models, nil
+	}
+	channels := []*Channel{
+		{Key: "sk-a", TestModel: []string{"gpt-3.5-turbo"}},
+		{Key: "sk-b", TestModel: []string{"gpt-3.5-turbo"}},
+		{Key: "sk-c", TestModel: []string{"gpt-3.5-turbo"}},
+		{Key: "local", Type: ChannelTypeLocal, TestModel: []string{"gpt-3.5-turbo"}},
+	}
+
+	errs := DiscoverModels(context.Background(), channels, 2, list)
+	assert.Len(t, errs, 1)
+	assert.Error(t, errs[2])
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, channels[0].TestModel)
+	// Empty lists and failures keep the selected models, local endpoints are asked without a key
+	assert.Equal(t, []string{"gpt-3.5-turbo"}, channels[1].TestModel)
+	assert.Equal(t, []string{"gpt-3.5-turbo"}, channels[2].TestModel)
+	assert.Equal(t, []string{"llama3"}, channels[3].TestModel)
+	assert.Equal(t, 5, CountRequests(channels))
+}
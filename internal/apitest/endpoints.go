@@ -0,0 +1,101 @@
+package apitest
+
+import (
+	"fmt"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// EndpointResults holds the results of all keys tested against one endpoint
+type EndpointResults struct {
+	Name    string
+	URL     string
+	Results []TestResult
+}
+
+// EndpointStats are the aggregate results of an endpoint
+type EndpointStats struct {
+	Keys    int
+	Success int
+	Total   int
+	Latency float64 // 成功请求的平均延迟 (秒)
+}
+
+// SuccessRate returns the share of successful requests
+func (s EndpointStats) SuccessRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Success) / float64(s.Total)
+}
+
+// Stats computes the aggregate results of the endpoint
+func (e EndpointResults) Stats() EndpointStats {
+	var s EndpointStats
+	keys := make(map[string]bool)
+	for _, result := range e.Results {
+		keys[result.Channel.Key] = true
+		s.Total++
+		if result.Success {
+			s.Success++
+			s.Latency += result.Latency
+		}
+	}
+	s.Keys = len(keys)
+	if s.Success > 0 {
+		s.Latency /= float64(s.Success)
+	}
+	return s
+}
+
+// GroupByEndpoint groups the results by channel URL, keeping the order of first appearance
+func GroupByEndpoint(results []TestResult) []EndpointResults {
+	index := make(map[string]int)
+	var groups []EndpointResults
+	for _, result := range results {
+		if result.Channel == nil {
+			continue
+		}
+		i, ok := index[result.Channel.URL]
+		if !ok {
+			i = len(groups)
+			index[result.Channel.URL] = i
+			groups = append(groups, EndpointResults{Name: result.Channel.Endpoint, URL: result.Channel.URL})
+		}
+		groups[i].Results = append(groups[i].Results, result)
+	}
+	return groups
+}
+
+// PrintEndpoints prints the aggregate stats and key results of every endpoint
+func PrintEndpoints(printer *util.Printer, endpoints []EndpointResults) {
+	printer.PrintTitle("端点汇总", util.EmojiRocket)
+	for i, e := range endpoints {
+		s := e.Stats()
+		color := util.ColorGreen
+		switch {
+		case s.Success == 0:
+			color = util.ColorRed
+		case s.Success < s.Total:
+			color = util.ColorYellow
+		}
+		printer.Printf("%s[%d] %s%s %s%d 个 Key, 成功 %d/%d (%.0f%%), 平均延迟 %s%s\n",
+			util.ColorBlue, i+1, util.ColorReset, endpointLabel(e), color,
+			s.Keys, s.Success, s.Total, s.SuccessRate()*100, util.FormatLatency(s.Latency), util.ColorReset)
+	}
+
+	for _, e := range endpoints {
+		keys := groupByKey(e.Results)
+		printer.PrintTitle(endpointLabel(e), util.EmojiLink)
+		printKeyResults(printer, keys, 0)
+		printKeyErrors(printer, keys, 0)
+	}
+}
+
+// endpointLabel formats the endpoint as "name (url)"
+func endpointLabel(e EndpointResults) string {
+	if e.Name == "" {
+		return e.URL
+	}
+	return fmt.Sprintf("%s (%s)", e.Name, e.URL)
+}
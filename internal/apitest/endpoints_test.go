@@ -0,0 +1,33 @@
+package apitest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintEndpoints(t *testing.T) {
+	key := "sk-endpoint000000000000000000000000"
+	a := &Channel{Key: key, URL: "https://a.example.com", Endpoint: "主站"}
+	b := &Channel{Key: key, URL: "https://b.example.com"}
+	results := []TestResult{
+		{Channel: a, Model: "gpt-4o", Success: true, Latency: 1},
+		{Channel: b, Model: "gpt-4o"},
+		{Channel: a, Model: "gpt-4o-mini"},
+	}
+
+	groups := GroupByEndpoint(results)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, EndpointStats{Keys: 1, Success: 1, Total: 2, Latency: 1}, groups[0].Stats())
+
+	var buf bytes.Buffer
+	PrintEndpoints(util.NewPrinter(&buf), groups)
+	out := buf.String()
+	assert.Contains(t, out, "主站 (https://a.example.com)")
+	assert.Contains(t, out, "成功 1/2 (50%)")
+	// The same key is listed once per endpoint
+	assert.Equal(t, 2, strings.Count(out, "[1] "+util.ColorYellow+util.MaskKey(key)))
+}
@@ -34,6 +34,23 @@ type OpenAIError struct {
 
 // formatErrorMessage extracts and formats the main error message from an API error response
 func formatErrorMessage(status int, errBody string) string {
+	if msg, ok := formatAnthropicError(status, errBody); ok {
+		return msg
+	}
+	if msg, ok := formatGoogleError(errBody); ok {
+		return msg
+	}
+	// DashScope errors also carry a top-level message, check them before Cohere
+	if msg, ok := formatDashScopeError(status, errBody); ok {
+		return msg
+	}
+	if msg, ok := formatZhipuError(status, errBody); ok {
+		return msg
+	}
+	if msg, ok := formatCohereError(status, errBody); ok {
+		return msg
+	}
+
 	var msg string
 
 	var openaiErr OpenAIError
@@ -0,0 +1,97 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// GeminiRequest represents a generateContent request to the Gemini API
+type GeminiRequest struct {
+	Contents         []GeminiContent         `json:"contents"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GeminiContent represents a content item in the Gemini request
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart represents a part of a Gemini content item
+type GeminiPart struct {
+	Text       string            `json:"text,omitempty"`
+	InlineData *GeminiInlineData `json:"inlineData,omitempty"`
+}
+
+// GeminiGenerationConfig holds the generation options of a Gemini request
+type GeminiGenerationConfig struct {
+	MaxOutputTokens int `json:"maxOutputTokens,omitempty"`
+}
+
+// GeminiResponse represents the parts of a Gemini response used to validate it
+type GeminiResponse struct {
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+type geminiKeyContextKey struct{}
+
+// withGeminiKey attaches the Gemini key to the request context so it only enters the URL in the transport
+func withGeminiKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, geminiKeyContextKey{}, key)
+}
+
+// geminiEndpoint returns the generateContent URL of the model, without the key
+func geminiEndpoint(baseURL, model string) string {
+	if baseURL == "" {
+		baseURL = config.GeminiTestUrl
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + model + ":generateContent"
+}
+
+// KeyTransport adds the Gemini key to the query string, or the Vertex AI access token
+// to the Authorization header, when the request is sent.
+// The request passed to the client never carries the key, so URLs in *url.Error
+// and anything else built from it are safe to print.
+type KeyTransport struct {
+	Base http.RoundTripper
+}
+
+// NewKeyTransport creates a new KeyTransport, base defaults to http.DefaultTransport
+func NewKeyTransport(base http.RoundTripper) *KeyTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &KeyTransport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *KeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if path, ok := req.Context().Value(vertexAccountContextKey{}).(string); ok && path != "" {
+		token, err := vertexAccessToken(req.Context(), t.Base, path)
+		if err != nil {
+			return nil, err
+		}
+		r := req.Clone(req.Context())
+		r.Header.Set("Authorization", "Bearer "+token)
+		return t.Base.RoundTrip(r)
+	}
+
+	key, ok := req.Context().Value(geminiKeyContextKey{}).(string)
+	if !ok || key == "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the request they are given
+	r := req.Clone(req.Context())
+	q := r.URL.Query()
+	q.Set("key", key)
+	r.URL.RawQuery = q.Encode()
+	return t.Base.RoundTrip(r)
+}
@@ -0,0 +1,46 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGeminiKey = "AIzaSyTestKey0123456789abcdefghij"
+
+func TestGeminiKeyInjectedAtTransport(t *testing.T) {
+	var gotKey, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.URL.Query().Get("key")
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":1,"totalTokenCount":2}}`))
+	}))
+	defer srv.Close()
+
+	ct := NewApiTest(1)
+	result := ct.TestChannel(context.Background(), &TestConfig{
+		Channel: &Channel{Type: ChannelTypeGemini, Key: testGeminiKey, URL: srv.URL + "/v1beta/models"},
+		Model:   "gemini-1.5-flash",
+	})
+
+	assert.True(t, result.Success, "%v", result.Error)
+	assert.Equal(t, testGeminiKey, gotKey)
+	assert.Equal(t, "/v1beta/models/gemini-1.5-flash:generateContent", gotPath)
+}
+
+func TestGeminiKeyNotInErrors(t *testing.T) {
+	// Nothing listens on this address, the connection error includes the request URL
+	ct := NewApiTest(1)
+	result := ct.TestChannel(context.Background(), &TestConfig{
+		Channel: &Channel{Type: ChannelTypeGemini, Key: testGeminiKey, URL: "http://127.0.0.1:1/v1beta/models"},
+		Model:   "gemini-1.5-flash",
+	})
+
+	assert.False(t, result.Success)
+	assert.Error(t, result.Error)
+	assert.False(t, strings.Contains(result.Error.Error(), testGeminiKey), result.Error.Error())
+}
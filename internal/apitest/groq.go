@@ -0,0 +1,99 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GroqHost is the host of the Groq API
+const GroqHost = "api.groq.com"
+
+// GroqKeyPrefix marks Groq API keys
+const GroqKeyPrefix = "gsk_"
+
+// GroqPaceInterval spaces the requests of one Groq key, the free tier allows 30 requests a minute
+// and bursts of the concurrent tester would otherwise fail with 429 on keys that work
+const GroqPaceInterval = 2 * time.Second
+
+// MaxRetryAfter caps how long a paced key waits on a 429 before its one retry
+const MaxRetryAfter = 30 * time.Second
+
+// IsGroqURL reports whether rawURL points to the Groq API
+func IsGroqURL(rawURL string) bool {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), GroqHost)
+}
+
+// paceInterval returns the minimum time between two requests of one key of the channel type, 0 for no pacing
+func paceInterval(channelType ChannelType) time.Duration {
+	if channelType == ChannelTypeGroq {
+		return GroqPaceInterval
+	}
+	return 0
+}
+
+// pacer spaces the requests of each key of rate limited providers
+type pacer struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// wait blocks until key may send its next request and reserves the slot after it,
+// it returns false when ctx is done first
+func (p *pacer) wait(ctx context.Context, key string, interval time.Duration) bool {
+	p.mu.Lock()
+	if p.next == nil {
+		p.next = make(map[string]time.Time)
+	}
+	now := time.Now()
+	at := p.next[key]
+	if at.Before(now) {
+		at = now
+	}
+	p.next[key] = at.Add(interval)
+	p.mu.Unlock()
+	return sleep(ctx, at.Sub(now))
+}
+
+// sleep waits for d, it returns false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryAfter returns how long a rate limited result asks to wait, the Retry-After header in seconds
+// or the pace interval without it, capped at MaxRetryAfter
+func retryAfter(result TestResult, interval time.Duration) time.Duration {
+	wait := interval
+	if result.Detail != nil {
+		if s, err := strconv.ParseFloat(result.Detail.Header.Get("Retry-After"), 64); err == nil && s >= 0 {
+			wait = time.Duration(s * float64(time.Second))
+		}
+	}
+	return min(wait, MaxRetryAfter)
+}
+
+// rateLimited reports whether the result was rejected with 429 Too Many Requests
+func rateLimited(result TestResult) bool {
+	return result.StatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,75 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGroqKey = "gsk_0123456789abcdefghijklmnopqrstuvwxyz0123456789ab"
+
+func TestPacer(t *testing.T) {
+	var p pacer
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.True(t, p.wait(ctx, "a", 20*time.Millisecond))
+	}
+	// Three requests of one key take two intervals, another key is not held up
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	other := time.Now()
+	assert.True(t, p.wait(ctx, "b", 20*time.Millisecond))
+	assert.Less(t, time.Since(other), 10*time.Millisecond)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, p.wait(cancelled, "a", time.Hour))
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "1.5")
+	assert.Equal(t, 1500*time.Millisecond, retryAfter(TestResult{Detail: &ResponseDetail{Header: header}}, time.Second))
+	header.Set("Retry-After", "3600")
+	assert.Equal(t, MaxRetryAfter, retryAfter(TestResult{Detail: &ResponseDetail{Header: header}}, time.Second))
+	assert.Equal(t, GroqPaceInterval, retryAfter(TestResult{}, GroqPaceInterval))
+}
+
+func TestGroqRetriesRateLimit(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"Rate limit reached for model","type":"tokens","code":"rate_limit_exceeded"}}`))
+			return
+		}
+		w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	channel := &Channel{Type: ChannelTypeGroq, Key: testGroqKey, URL: srv.URL, TestModel: []string{"llama-3.1-8b-instant"}}
+	results := NewApiTest(4).TestAllApis(context.Background(), []*Channel{channel})
+	assert.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Other channels report the 429 as is
+	atomic.StoreInt32(&requests, 0)
+	channel = &Channel{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: []string{"gpt-4o-mini"}}
+	results = NewApiTest(4).TestAllApis(context.Background(), []*Channel{channel})
+	assert.False(t, results[0].Success)
+	assert.Equal(t, http.StatusTooManyRequests, results[0].StatusCode)
+}
+
+func TestGroqKey(t *testing.T) {
+	assert.NoError(t, ValidateKey(ChannelTypeGroq, testGroqKey))
+	assert.Error(t, ValidateKey(ChannelTypeGroq, "sk-0123456789abcdef0123456789abcdef"))
+	assert.True(t, IsGroqURL("https://api.groq.com/openai/v1"))
+	assert.False(t, IsGroqURL("https://api.openai.com/v1"))
+}
@@ -0,0 +1,129 @@
+package apitest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Inspect lets the user pick a key by its index in the printed results and shows the full
+// responses of its models, an empty line ends the inspection
+func Inspect(printer *util.Printer, in io.Reader, results []TestResult) {
+	keys := groupByKey(results)
+	if len(keys) == 0 {
+		return
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		printer.Printf("\n%s输入 Key 序号查看完整响应 (1-%d)，回车结束: %s", util.ColorBold, len(keys), util.ColorReset)
+		line, err := reader.ReadString('\n')
+		choice := strings.TrimSpace(line)
+		if choice == "" {
+			return
+		}
+		n, convErr := strconv.Atoi(choice)
+		if convErr != nil || n < 1 || n > len(keys) {
+			printer.Printf("%s%s 无效的序号: %s%s\n", util.ColorYellow, util.EmojiWarning, choice, util.ColorReset)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		PrintDetail(printer, n, keys[n-1].key, results)
+		printKeyIndex(printer, keys)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// PrintDetail prints the status, timing, headers and full body of every model tested with key
+func PrintDetail(printer *util.Printer, index int, key string, results []TestResult) {
+	printer.PrintTitle(fmt.Sprintf("[%d] %s 详情", index, util.MaskKey(key)), util.EmojiAPI)
+
+	var keyResults []TestResult
+	for _, result := range results {
+		if result.Channel != nil && result.Channel.Key == key {
+			keyResults = append(keyResults, result)
+		}
+	}
+
+	mask := func(s string) string { return util.MaskSecrets(logger.Scrub(s), key) }
+	for _, result := range keyResults {
+		status, color := util.EmojiError, util.ColorRed
+		switch {
+		case result.Success:
+			status, color = util.EmojiCheck, util.ColorGreen
+		case result.Skipped:
+			status, color = SkippedLabel, util.ColorGray
+		}
+		printer.Printf("%s%s%s %s", color, result.Model, util.ColorReset, status)
+		if result.StatusCode != 0 {
+			printer.Printf(" 状态码 %d", result.StatusCode)
+		}
+		printer.Printf("\n")
+
+		if result.Error != nil {
+			printer.Printf("│ 错误: %s\n", mask(result.Error.Error()))
+		}
+		d := result.Detail
+		if d == nil {
+			printer.Printf("\n")
+			continue
+		}
+		if d.TTFB > 0 {
+			printer.Printf("│ 耗时: 首字节 %s, 总计 %s\n", util.FormatLatency(d.TTFB), util.FormatLatency(d.Total))
+		} else {
+			printer.Printf("│ 耗时: %s\n", util.FormatLatency(d.Total))
+		}
+
+		if len(d.Header) > 0 {
+			names := make([]string, 0, len(d.Header))
+			for name := range d.Header {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			printer.Printf("│ 响应头:\n")
+			for _, name := range names {
+				printer.Printf("│   %s\n", mask(fmt.Sprintf("%s: %s", name, strings.Join(d.Header[name], ", "))))
+			}
+		}
+		if d.Body != "" {
+			printer.Printf("│ 响应体:\n")
+			for _, line := range strings.Split(strings.TrimRight(mask(d.Body), "\n"), "\n") {
+				printer.Printf("│   %s\n", line)
+			}
+		}
+		printer.Printf("\n")
+	}
+}
+
+// printKeyIndex prints one line per key with the index used by Inspect
+func printKeyIndex(printer *util.Printer, keys []*keyResultInfo) {
+	printer.PrintTitle("测试结果", util.EmojiRocket)
+	for i, kr := range keys {
+		success := 0
+		for _, result := range kr.modelResults {
+			if result.success {
+				success++
+			}
+		}
+		color := util.ColorYellow
+		switch {
+		case kr.malformed || success == 0:
+			color = util.ColorRed
+		case success == len(kr.modelResults):
+			color = util.ColorGreen
+		}
+		printer.Printf("%s[%d]%s %s %s%d/%d可用%s\n",
+			util.ColorBlue, i+1, util.ColorReset, util.MaskKey(kr.key), color, success, len(kr.modelResults), util.ColorReset)
+	}
+}
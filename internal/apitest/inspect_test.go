@@ -0,0 +1,36 @@
+package apitest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	key := "sk-inspect000000000000000000000000000000000000000"
+	ch := &Channel{Key: key}
+	body := `{"error":{"message":"` + strings.Repeat("x", 400) + ` key ` + key + `"}}`
+	results := []TestResult{
+		{Channel: ch, Model: "gpt-4o", Success: true, StatusCode: 200, Latency: 0.6,
+			Detail: &ResponseDetail{Header: http.Header{"X-Request-Id": {"req-1"}}, Body: `{"usage":{}}`, TTFB: 0.5, Total: 0.6}},
+		{Channel: ch, Model: "gpt-4o-mini", StatusCode: 500, Error: errors.New("code: 500"),
+			Detail: &ResponseDetail{Header: http.Header{"Set-Cookie": {"session=secret"}}, Body: body, TTFB: 0.2, Total: 0.3}},
+	}
+
+	var buf bytes.Buffer
+	Inspect(util.NewPrinter(&buf), strings.NewReader("9\n1\n\n"), results)
+	out := buf.String()
+
+	assert.Contains(t, out, "无效的序号: 9")
+	assert.Contains(t, out, "首字节 500ms, 总计 600ms")
+	assert.Contains(t, out, "X-Request-Id: req-1")
+	assert.Contains(t, out, strings.Repeat("x", 400), "the body is not truncated")
+	assert.NotContains(t, out, key)
+	assert.NotContains(t, out, "session=secret")
+	assert.Contains(t, out, "[1]")
+}
@@ -9,7 +9,7 @@ import (
 type APITester interface {
 	TestChannel(context.Context, *TestConfig) TestResult
 	TestAllChannels(context.Context, []*TestConfig) []TestResult
-	TestAllApis([]*Channel) []TestResult
+	TestAllApis(context.Context, []*Channel) []TestResult
 	PrintResults([]TestResult) error
 }
 
@@ -28,6 +28,19 @@ type RequestOptions struct {
 	TopP        float64
 	TopK        int
 	Stream      bool
+	Tools       bool   // 附带工具定义, 要求模型调用
+	Vision      bool   // 附带探测图片, 询问图片颜色
+	Protocol    string // 兼容 OpenAI 的渠道使用的接口: ProtocolChat 或 ProtocolResponses
+}
+
+// DefaultRequestOptions returns the options of the model test request
+func DefaultRequestOptions() RequestOptions {
+	return RequestOptions{
+		MaxTokens:   1,
+		Temperature: 0.7,
+		TopP:        0.95,
+		TopK:        40,
+	}
 }
 
 // RequestBuilder builds HTTP requests for different API types
@@ -47,10 +60,33 @@ type HTTPClient interface {
 
 // TestResult represents the result of an API test
 type TestResult struct {
-	Channel  *Channel
-	Model    string
-	Success  bool
-	Latency  float64
-	Error    error
-	Response interface{}
+	Channel    *Channel
+	Model      string
+	Success    bool
+	StatusCode int
+	Latency    float64
+	Error      error
+	Response   interface{}
+	Skipped    bool            // 同一 Key 已返回 401，未测试该模型
+	Tools      *bool           // 工具调用检测结果, 未检测或检测请求失败时为 nil
+	Vision     string          // 图片输入检测结果 (VisionSupported 等), 未检测时为空
+	TTFT       float64         // 流式测试: 收到首个输出文本的耗时 (秒), 未使用流式或没有文本时为 0
+	Detail     *ResponseDetail // 完整响应，供结果详情查看
 }
+
+// MaxDetailBody limits the response body kept for the result inspector
+const MaxDetailBody = 64 << 10
+
+// ResponseDetail holds the raw response and timing of a test request
+type ResponseDetail struct {
+	Header http.Header
+	Body   string
+	TTFB   float64 // 收到响应头的耗时 (秒)
+	Total  float64 // 读取完整响应的耗时 (秒)
+}
+
+// Labels shown for keys and models that were not tested
+const (
+	SkippedLabel   = "未测试"
+	MalformedLabel = "格式错误"
+)
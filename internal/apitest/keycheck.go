@@ -0,0 +1,104 @@
+package apitest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMalformedKey is returned for keys whose format cannot be valid for their provider
+var ErrMalformedKey = errors.New(MalformedLabel)
+
+// KeyPrefixes are the key prefixes used by OpenAI and common relays
+var KeyPrefixes = []string{"sk-", "key-", "ak-", "token-"}
+
+// Key length bounds, OpenAI project keys are the longest at about 170 characters
+const (
+	MinKeyLength = 20
+	MaxKeyLength = 512
+
+	GeminiKeyPrefix = "AIza"
+	GeminiKeyLength = 39
+)
+
+// ValidateKey checks the length, prefix and charset of the key for the channel type without sending requests
+func ValidateKey(channelType ChannelType, key string) error {
+	if channelType == ChannelTypeLocal {
+		// Local endpoints take no key
+		return nil
+	}
+	if channelType == ChannelTypeVertex {
+		if _, err := LoadServiceAccount(key); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedKey, err)
+		}
+		return nil
+	}
+
+	for _, r := range key {
+		if !isKeyChar(r) {
+			return fmt.Errorf("%w: 包含非法字符 %q", ErrMalformedKey, r)
+		}
+	}
+
+	if channelType == ChannelTypeGemini {
+		if !strings.HasPrefix(key, GeminiKeyPrefix) {
+			return fmt.Errorf("%w: Gemini Key 应以 %s 开头", ErrMalformedKey, GeminiKeyPrefix)
+		}
+		if len(key) != GeminiKeyLength {
+			return fmt.Errorf("%w: Gemini Key 长度应为 %d，实际 %d", ErrMalformedKey, GeminiKeyLength, len(key))
+		}
+		return nil
+	}
+
+	switch {
+	case channelType == ChannelTypeAzure:
+		// Azure keys are hex or base62 strings without a prefix
+	case channelType == ChannelTypeCohere:
+		if len(key) != CohereKeyLength {
+			return fmt.Errorf("%w: Cohere Key 长度应为 %d，实际 %d", ErrMalformedKey, CohereKeyLength, len(key))
+		}
+		return nil
+	case channelType == ChannelTypeZhipu:
+		if _, _, ok := SplitZhipuKey(key); !ok {
+			return fmt.Errorf("%w: 智谱 Key 应为 id.secret 格式", ErrMalformedKey)
+		}
+		return nil
+	case channelType == ChannelTypeDashScope:
+		if !strings.HasPrefix(key, "sk-") {
+			return fmt.Errorf("%w: DashScope Key 应以 sk- 开头", ErrMalformedKey)
+		}
+	case channelType == ChannelTypeGroq:
+		if !strings.HasPrefix(key, GroqKeyPrefix) {
+			return fmt.Errorf("%w: Groq Key 应以 %s 开头", ErrMalformedKey, GroqKeyPrefix)
+		}
+	case channelType == ChannelTypeOpenRouter:
+		if !strings.HasPrefix(key, OpenRouterKeyPrefix) {
+			return fmt.Errorf("%w: OpenRouter Key 应以 %s 开头", ErrMalformedKey, OpenRouterKeyPrefix)
+		}
+	case channelType == ChannelTypeAnthropic:
+		if !strings.HasPrefix(key, AnthropicKeyPrefix) {
+			return fmt.Errorf("%w: Anthropic Key 应以 %s 开头", ErrMalformedKey, AnthropicKeyPrefix)
+		}
+	case !hasKeyPrefix(key):
+		return fmt.Errorf("%w: 应以 %s 开头", ErrMalformedKey, strings.Join(KeyPrefixes, "、"))
+	}
+	if len(key) < MinKeyLength || len(key) > MaxKeyLength {
+		return fmt.Errorf("%w: 长度 %d 不在 %d-%d 之间", ErrMalformedKey, len(key), MinKeyLength, MaxKeyLength)
+	}
+	return nil
+}
+
+// hasKeyPrefix reports whether the key starts with a known prefix
+func hasKeyPrefix(key string) bool {
+	for _, prefix := range KeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyChar reports whether r may appear in a key
+func isKeyChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'
+}
@@ -0,0 +1,36 @@
+package apitest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		channelType ChannelType
+		key         string
+		valid       bool
+	}{
+		{name: "relay key", channelType: ChannelTypeOpenAI, key: "sk-" + strings.Repeat("a", 48), valid: true},
+		{name: "project key", channelType: ChannelTypeOpenAI, key: "sk-proj-" + strings.Repeat("Ab1_", 40), valid: true},
+		{name: "too short", channelType: ChannelTypeOpenAI, key: "sk-abc"},
+		{name: "no prefix", channelType: ChannelTypeOpenAI, key: strings.Repeat("a", 48)},
+		{name: "bad charset", channelType: ChannelTypeOpenAI, key: "sk-" + strings.Repeat("a", 40) + "，"},
+		{name: "gemini key", channelType: ChannelTypeGemini, key: "AIza" + strings.Repeat("B", 35), valid: true},
+		{name: "truncated gemini key", channelType: ChannelTypeGemini, key: "AIza" + strings.Repeat("B", 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKey(tt.channelType, tt.key)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrMalformedKey)
+			}
+		})
+	}
+}
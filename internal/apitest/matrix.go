@@ -0,0 +1,105 @@
+package apitest
+
+import (
+	"fmt"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Vantage holds the results of the same tests run from one network location
+type Vantage struct {
+	Name    string
+	Results []TestResult
+}
+
+// vantageCell summarizes the results of one key from one vantage
+type vantageCell struct {
+	success int
+	total   int
+	latency float64
+}
+
+// String formats the cell as "2/3 850ms", the latency is the average of successful requests
+func (c vantageCell) String() string {
+	if c.total == 0 {
+		return "-"
+	}
+	if c.success == 0 {
+		return fmt.Sprintf("0/%d", c.total)
+	}
+	return fmt.Sprintf("%d/%d %s", c.success, c.total, util.FormatLatency(c.latency/float64(c.success)))
+}
+
+// vantageCells maps keys to their cell in one vantage
+type vantageCells map[string]*vantageCell
+
+// cellString returns the formatted cell of key
+func (cs vantageCells) cellString(key string) string {
+	if c, ok := cs[key]; ok {
+		return c.String()
+	}
+	return "-"
+}
+
+// PrintMatrix prints a key × vantage table of availability and latency
+func PrintMatrix(printer *util.Printer, keys []string, vantages []Vantage) {
+	printer.PrintTitle("多地区测试", util.EmojiLoading)
+
+	cells := make([]vantageCells, len(vantages))
+	for i, v := range vantages {
+		cells[i] = make(vantageCells)
+		for _, result := range v.Results {
+			if result.Channel == nil {
+				continue
+			}
+			c, ok := cells[i][result.Channel.Key]
+			if !ok {
+				c = &vantageCell{}
+				cells[i][result.Channel.Key] = c
+			}
+			c.total++
+			if result.Success {
+				c.success++
+				c.latency += result.Latency
+			}
+		}
+	}
+
+	// Column widths fit the longest cell of each vantage
+	keyWidth := 4
+	for _, key := range keys {
+		keyWidth = util.Max(keyWidth, util.StringWidth(util.MaskKey(key)))
+	}
+	widths := make([]int, len(vantages))
+	for i, v := range vantages {
+		widths[i] = util.StringWidth(v.Name)
+		for _, key := range keys {
+			widths[i] = util.Max(widths[i], util.StringWidth(cells[i].cellString(key)))
+		}
+	}
+
+	printer.Printf("%s", util.PadRight("Key", keyWidth))
+	for i, v := range vantages {
+		printer.Printf("  %s", util.PadRight(v.Name, widths[i]))
+	}
+	printer.Printf("\n")
+
+	for _, key := range keys {
+		printer.Printf("%s%s%s", util.ColorYellow, util.PadRight(util.MaskKey(key), keyWidth), util.ColorReset)
+		for i := range vantages {
+			color := util.ColorGray
+			if c, ok := cells[i][key]; ok {
+				switch {
+				case c.success == c.total:
+					color = util.ColorGreen
+				case c.success == 0:
+					color = util.ColorRed
+				default:
+					color = util.ColorYellow
+				}
+			}
+			printer.Printf("  %s%s%s", color, util.PadRight(cells[i].cellString(key), widths[i]), util.ColorReset)
+		}
+		printer.Printf("\n")
+	}
+}
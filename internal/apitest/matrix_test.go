@@ -0,0 +1,38 @@
+package apitest
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintMatrix(t *testing.T) {
+	key := "sk-abcdefghijklmnop"
+	ch := &Channel{Key: key}
+	vantages := []Vantage{
+		{Name: "直连", Results: []TestResult{
+			{Channel: ch, Model: "gpt-4o", Success: true, Latency: 1},
+			{Channel: ch, Model: "gpt-4o-mini", Success: true, Latency: 2},
+		}},
+		{Name: "hk", Results: []TestResult{
+			{Channel: ch, Model: "gpt-4o", Success: true, Latency: 0.5},
+			{Channel: ch, Model: "gpt-4o-mini", Error: errors.New("403")},
+		}},
+		{Name: "us"},
+	}
+
+	var buf bytes.Buffer
+	PrintMatrix(util.NewPrinter(&buf), []string{key}, vantages)
+	out := buf.String()
+
+	assert.Contains(t, out, "2/2 1.50s")
+	assert.Contains(t, out, "1/2 500ms")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Contains(t, lines[len(lines)-1], util.MaskKey(key))
+	// Vantages without results for the key show a placeholder
+	assert.Contains(t, lines[len(lines)-1], util.ColorGray+"- "+util.ColorReset)
+}
@@ -0,0 +1,63 @@
+package apitest
+
+import (
+	"net/url"
+	"strings"
+)
+
+// OpenRouterHost is the host of the OpenRouter API
+const OpenRouterHost = "openrouter.ai"
+
+// OpenRouterKeyPrefix marks OpenRouter API keys, e.g. sk-or-v1-...
+const OpenRouterKeyPrefix = "sk-or-"
+
+// openRouterProviders maps the start of a bare model name to the provider prefix of its OpenRouter ID
+var openRouterProviders = []struct {
+	prefix, provider string
+}{
+	{"gpt-", "openai"},
+	{"chatgpt-", "openai"},
+	{"o1", "openai"},
+	{"o3", "openai"},
+	{"claude-", "anthropic"},
+	{"gemini-", "google"},
+	{"gemma-", "google"},
+	{"llama-", "meta-llama"},
+	{"deepseek-", "deepseek"},
+	{"qwen", "qwen"},
+	{"mistral-", "mistralai"},
+	{"mixtral-", "mistralai"},
+	{"command-", "cohere"},
+	{"grok-", "x-ai"},
+}
+
+// IsOpenRouterURL reports whether rawURL points to the OpenRouter API
+func IsOpenRouterURL(rawURL string) bool {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), OpenRouterHost)
+}
+
+// OpenRouterModel returns the provider-prefixed OpenRouter ID of a model, so claude-3.5-sonnet is sent as
+// anthropic/claude-3.5-sonnet. IDs that already have a provider and unknown names are returned as is.
+func OpenRouterModel(model string) string {
+	if strings.Contains(model, "/") {
+		return model
+	}
+	for _, p := range openRouterProviders {
+		if strings.HasPrefix(model, p.prefix) {
+			return p.provider + "/" + model
+		}
+	}
+	return model
+}
+
+// modelName strips the provider prefix of an OpenRouter model ID
+func modelName(model string) string {
+	return model[strings.LastIndex(model, "/")+1:]
+}
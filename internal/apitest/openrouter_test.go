@@ -0,0 +1,50 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenRouterModel(t *testing.T) {
+	assert.Equal(t, "anthropic/claude-3.5-sonnet", OpenRouterModel("claude-3.5-sonnet"))
+	assert.Equal(t, "openai/gpt-4o-mini", OpenRouterModel("gpt-4o-mini"))
+	assert.Equal(t, "openai/o3-mini", OpenRouterModel("o3-mini"))
+	assert.Equal(t, "google/gemini-pro-1.5", OpenRouterModel("gemini-pro-1.5"))
+	// Provider-prefixed IDs and unknown names are sent as entered
+	assert.Equal(t, "meta-llama/llama-3.1-70b-instruct", OpenRouterModel("meta-llama/llama-3.1-70b-instruct"))
+	assert.Equal(t, "openrouter/auto", OpenRouterModel("openrouter/auto"))
+	assert.Equal(t, "some-model", OpenRouterModel("some-model"))
+
+	assert.True(t, IsReasoningModel("openai/o1-mini"))
+	assert.False(t, IsReasoningModel("openai/gpt-4o"))
+
+	assert.True(t, IsOpenRouterURL("https://openrouter.ai/api/v1"))
+	assert.True(t, IsOpenRouterURL("openrouter.ai"))
+	assert.False(t, IsOpenRouterURL("https://api.openai.com/v1"))
+}
+
+func TestOpenRouterRequest(t *testing.T) {
+	req, err := NewRequestBuilder().BuildRequest(context.Background(), &TestConfig{
+		Channel:     &Channel{Type: ChannelTypeOpenRouter, Key: "sk-or-v1-0123456789abcdef", URL: config.OpenRouterTestUrl},
+		Model:       "claude-3.5-sonnet",
+		RequestOpts: DefaultRequestOptions(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, config.OpenRouterTestUrl, req.URL.String())
+	assert.Equal(t, "Bearer sk-or-v1-0123456789abcdef", req.Header.Get("Authorization"))
+	assert.Equal(t, config.OpenRouterReferer, req.Header.Get("HTTP-Referer"))
+	assert.Equal(t, config.OpenRouterTitle, req.Header.Get("X-Title"))
+
+	body, _ := io.ReadAll(req.Body)
+	var sent OpenAIRequest
+	assert.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, "anthropic/claude-3.5-sonnet", sent.Model)
+
+	assert.NoError(t, ValidateKey(ChannelTypeOpenRouter, "sk-or-v1-0123456789abcdef"))
+	assert.Error(t, ValidateKey(ChannelTypeOpenRouter, "sk-0123456789abcdef0123"))
+}
@@ -0,0 +1,82 @@
+package apitest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// DefaultPageSize is the number of keys shown per page of results
+const DefaultPageSize = 20
+
+// PageResults shows the results page by page. It reads navigation commands from in:
+// n/p for the next/previous page, a key index to jump to its page and /text to search for a key.
+// An empty line ends the paging.
+func PageResults(printer *util.Printer, in io.Reader, results []TestResult, pageSize int) {
+	keys := groupByKey(results)
+	if len(keys) == 0 {
+		return
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	pages := (len(keys) + pageSize - 1) / pageSize
+
+	reader := bufio.NewReader(in)
+	page := 0
+	for {
+		start := page * pageSize
+		end := util.Min(start+pageSize, len(keys))
+		printer.PrintTitle(fmt.Sprintf("测试结果 (第 %d/%d 页，共 %d 个 Key)", page+1, pages, len(keys)), util.EmojiRocket)
+		printKeyResults(printer, keys[start:end], start)
+		printKeyErrors(printer, keys[start:end], start)
+
+		printer.Printf("\n%sn 下一页  p 上一页  序号 跳转  /关键字 搜索 Key  回车结束: %s", util.ColorBold, util.ColorReset)
+		line, err := reader.ReadString('\n')
+		next, done, warning := navigate(strings.TrimSpace(line), page, pageSize, keys)
+		if done || err != nil {
+			return
+		}
+		if warning != "" {
+			printer.Printf("%s%s %s%s\n", util.ColorYellow, util.EmojiWarning, warning, util.ColorReset)
+		}
+		page = next
+	}
+}
+
+// navigate returns the page to show after cmd, whether paging is done and a warning for invalid commands
+func navigate(cmd string, page, pageSize int, keys []*keyResultInfo) (int, bool, string) {
+	pages := (len(keys) + pageSize - 1) / pageSize
+	switch {
+	case cmd == "":
+		return page, true, ""
+	case cmd == "n":
+		if page+1 >= pages {
+			return page, false, "已是最后一页"
+		}
+		return page + 1, false, ""
+	case cmd == "p":
+		if page == 0 {
+			return page, false, "已是第一页"
+		}
+		return page - 1, false, ""
+	case strings.HasPrefix(cmd, "/"):
+		query := strings.TrimSpace(strings.TrimPrefix(cmd, "/"))
+		for i, kr := range keys {
+			if query != "" && (strings.Contains(kr.key, query) || strings.Contains(util.MaskKey(kr.key), query)) {
+				return i / pageSize, false, ""
+			}
+		}
+		return page, false, fmt.Sprintf("未找到包含 %s 的 Key", query)
+	}
+
+	n, err := strconv.Atoi(cmd)
+	if err != nil || n < 1 || n > len(keys) {
+		return page, false, fmt.Sprintf("无效的输入: %s", cmd)
+	}
+	return (n - 1) / pageSize, false, ""
+}
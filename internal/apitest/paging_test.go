@@ -0,0 +1,60 @@
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavigate(t *testing.T) {
+	var keys []*keyResultInfo
+	for i := 0; i < 25; i++ {
+		keys = append(keys, &keyResultInfo{key: fmt.Sprintf("sk-key%02d", i)})
+	}
+
+	tests := []struct {
+		cmd     string
+		page    int
+		want    int
+		done    bool
+		warning bool
+	}{
+		{cmd: "", page: 1, want: 1, done: true},
+		{cmd: "n", page: 0, want: 1},
+		{cmd: "n", page: 2, want: 2, warning: true},
+		{cmd: "p", page: 1, want: 0},
+		{cmd: "p", page: 0, want: 0, warning: true},
+		{cmd: "23", page: 0, want: 2},
+		{cmd: "26", page: 0, want: 0, warning: true},
+		{cmd: "/key12", page: 0, want: 1},
+		{cmd: "/nothing", page: 0, want: 0, warning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			page, done, warning := navigate(tt.cmd, tt.page, 10, keys)
+			assert.Equal(t, tt.want, page)
+			assert.Equal(t, tt.done, done)
+			assert.Equal(t, tt.warning, warning != "")
+		})
+	}
+}
+
+func TestPageResults(t *testing.T) {
+	var results []TestResult
+	for i := 0; i < 5; i++ {
+		results = append(results, TestResult{Channel: &Channel{Key: fmt.Sprintf("sk-page%02d0000000000000000000000", i)}, Model: "gpt-4o", Success: true, Latency: float64(i + 1)})
+	}
+
+	var buf bytes.Buffer
+	PageResults(util.NewPrinter(&buf), strings.NewReader("n\n"), results, 2)
+	out := buf.String()
+
+	assert.Contains(t, out, "第 1/3 页")
+	assert.Contains(t, out, "第 2/3 页")
+	assert.Contains(t, out, "[3] ")
+	assert.NotContains(t, out, "第 3/3 页")
+}
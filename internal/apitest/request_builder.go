@@ -7,8 +7,15 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/config"
 )
 
+// EstimatedTokensPerRequest is the rough token usage of one test request,
+// the "hi" prompt with its chat formatting plus the few completion tokens allowed
+const EstimatedTokensPerRequest = 20
+
 // DefaultRequestBuilder implements the RequestBuilder interface
 type DefaultRequestBuilder struct{}
 
@@ -22,10 +29,53 @@ func (b *DefaultRequestBuilder) BuildRequest(ctx context.Context, cfg *TestConfi
 	var jsonData []byte
 	var err error
 	var reqURL string
+	contentType := "application/json"
 
-	request := b.buildOpenAIRequest(cfg)
-	jsonData, err = json.Marshal(request)
-	reqURL = cfg.Channel.URL
+	if IsTranscriptionModel(cfg.Model) && servesAudio(cfg.Channel.Type) {
+		// Whisper models are tested by transcribing a short silent clip
+		jsonData, contentType, err = transcriptionForm(cfg.Model)
+		reqURL = transcriptionEndpoint(cfg.Channel.URL)
+	} else if IsSpeechModel(cfg.Model) && servesAudio(cfg.Channel.Type) {
+		// Speech models are tested by synthesizing a couple of characters
+		jsonData, err = speechBody(cfg.Model)
+		reqURL = speechEndpoint(cfg.Channel.URL)
+	} else if usesResponses(cfg) {
+		request := b.buildResponsesRequest(cfg)
+		if cfg.Channel.Type == ChannelTypeOpenRouter {
+			request.Model = OpenRouterModel(cfg.Model)
+		}
+		jsonData, err = json.Marshal(request)
+		reqURL = responsesEndpoint(cfg.Channel.URL)
+	} else if cfg.Channel.Type == ChannelTypeGemini {
+		// The key is added by KeyTransport so it never appears in the request URL
+		ctx = withGeminiKey(ctx, cfg.Channel.Key)
+		jsonData, err = json.Marshal(b.buildGeminiRequest(cfg))
+		reqURL = geminiEndpoint(cfg.Channel.URL, cfg.Model)
+	} else if cfg.Channel.Type == ChannelTypeVertex {
+		// The access token is minted from the service account file by KeyTransport
+		ctx = withVertexAccount(ctx, cfg.Channel.Key)
+		jsonData, err = json.Marshal(b.buildGeminiRequest(cfg))
+		reqURL = geminiEndpoint(cfg.Channel.URL, cfg.Model)
+	} else if cfg.Channel.Type == ChannelTypeAnthropic {
+		jsonData, err = json.Marshal(b.buildAnthropicRequest(cfg))
+		reqURL = anthropicEndpoint(cfg.Channel.URL)
+	} else if cfg.Channel.Type == ChannelTypeCohere {
+		jsonData, err = json.Marshal(b.buildCohereRequest(cfg))
+		reqURL = cohereEndpoint(cfg.Channel.URL)
+	} else if cfg.Channel.Type == ChannelTypeAzure {
+		// The deployment in the path selects the model, Azure ignores the model field
+		jsonData, err = json.Marshal(b.buildOpenAIRequest(cfg))
+		reqURL = azureEndpoint(cfg.Channel.URL, cfg.Model)
+	} else if cfg.Channel.Type == ChannelTypeOpenRouter {
+		// OpenRouter routes by provider-prefixed IDs, bare names from the menu get their provider
+		request := b.buildOpenAIRequest(cfg)
+		request.Model = OpenRouterModel(cfg.Model)
+		jsonData, err = json.Marshal(request)
+		reqURL = cfg.Channel.URL
+	} else {
+		jsonData, err = json.Marshal(b.buildOpenAIRequest(cfg))
+		reqURL = cfg.Channel.URL
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %v", err)
@@ -36,24 +86,124 @@ func (b *DefaultRequestBuilder) BuildRequest(ctx context.Context, cfg *TestConfi
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if cfg.Channel.Type == ChannelTypeOpenAI {
+	req.Header.Set("Content-Type", contentType)
+	switch cfg.Channel.Type {
+	case ChannelTypeOpenAI, ChannelTypeCohere, ChannelTypeDashScope, ChannelTypeGroq:
 		req.Header.Set("Authorization", "Bearer "+cfg.Channel.Key)
+	case ChannelTypeAnthropic:
+		req.Header.Set("x-api-key", cfg.Channel.Key)
+		req.Header.Set("anthropic-version", config.AnthropicVersion)
+	case ChannelTypeAzure:
+		req.Header.Set("api-key", cfg.Channel.Key)
+	case ChannelTypeOpenRouter:
+		// OpenRouter attributes requests to the app named by these optional headers
+		req.Header.Set("Authorization", "Bearer "+cfg.Channel.Key)
+		req.Header.Set("HTTP-Referer", config.OpenRouterReferer)
+		req.Header.Set("X-Title", config.OpenRouterTitle)
+	case ChannelTypeZhipu:
+		// Zhipu takes a short-lived JWT signed with the secret half of the key
+		token, err := ZhipuToken(cfg.Channel.Key, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case ChannelTypeLocal:
+		// Local endpoints take no Authorization header, the placeholder key is never sent
 	}
 
 	return req, nil
 }
 
+// FineTunedPrefix marks OpenAI fine-tuned model IDs, e.g. ft:gpt-4o-mini-2024-07-18:org:name:id
+const FineTunedPrefix = "ft:"
+
+// IsFineTuned reports whether model is a fine-tuned model ID
+func IsFineTuned(model string) bool {
+	return strings.HasPrefix(model, FineTunedPrefix)
+}
+
+// BaseModel returns the model a fine-tuned model was trained from, other models are returned as is
+func BaseModel(model string) string {
+	if !IsFineTuned(model) {
+		return model
+	}
+	base, _, _ := strings.Cut(strings.TrimPrefix(model, FineTunedPrefix), ":")
+	return base
+}
+
+// IsReasoningModel reports whether model is an OpenAI o-series reasoning model (o1, o3-mini, openai/o1-mini, ...),
+// which takes max_completion_tokens instead of max_tokens
+func IsReasoningModel(model string) bool {
+	base := modelName(BaseModel(model))
+	return len(base) > 1 && base[0] == 'o' && base[1] >= '1' && base[1] <= '9'
+}
+
+func (b *DefaultRequestBuilder) buildGeminiRequest(cfg *TestConfig) *GeminiRequest {
+	request := &GeminiRequest{
+		Contents: []GeminiContent{
+			{
+				Role:  "user",
+				Parts: []GeminiPart{{Text: "hi"}},
+			},
+		},
+	}
+	if cfg.RequestOpts.MaxTokens > 0 {
+		request.GenerationConfig = &GeminiGenerationConfig{MaxOutputTokens: cfg.RequestOpts.MaxTokens}
+	}
+	if cfg.RequestOpts.Vision {
+		request.Contents = geminiVisionContents()
+		request.GenerationConfig = &GeminiGenerationConfig{MaxOutputTokens: visionMaxTokens}
+	}
+	return request
+}
+
+func (b *DefaultRequestBuilder) buildAnthropicRequest(cfg *TestConfig) *AnthropicRequest {
+	maxTokens := cfg.RequestOpts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 1
+	}
+	request := &AnthropicRequest{
+		Model:     cfg.Model,
+		MaxTokens: maxTokens,
+		Stream:    cfg.RequestOpts.Stream,
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "hi",
+			},
+		},
+	}
+	if cfg.RequestOpts.Vision {
+		request.Messages = anthropicVisionMessages()
+		request.MaxTokens = visionMaxTokens
+	}
+	return request
+}
+
+func (b *DefaultRequestBuilder) buildCohereRequest(cfg *TestConfig) *CohereRequest {
+	return &CohereRequest{
+		Model:     cfg.Model,
+		MaxTokens: cfg.RequestOpts.MaxTokens,
+		Stream:    cfg.RequestOpts.Stream,
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "hi",
+			},
+		},
+	}
+}
+
 func (b *DefaultRequestBuilder) buildOpenAIRequest(cfg *TestConfig) *OpenAIRequest {
 	maxTokens := cfg.RequestOpts.MaxTokens
 	maxCompletionTokens := 0
 
-	if strings.HasPrefix(cfg.Model, "o1") {
+	if IsReasoningModel(cfg.Model) {
 		maxCompletionTokens = 10
 		maxTokens = 0
 	}
 
-	return &OpenAIRequest{
+	request := &OpenAIRequest{
 		Model:               cfg.Model,
 		Stream:              cfg.RequestOpts.Stream,
 		MaxTokens:           maxTokens,
@@ -65,4 +215,17 @@ func (b *DefaultRequestBuilder) buildOpenAIRequest(cfg *TestConfig) *OpenAIReque
 			},
 		},
 	}
+	if cfg.RequestOpts.Tools {
+		withTools(request)
+	}
+	if cfg.RequestOpts.Vision {
+		request.Messages = visionMessages()
+		if request.MaxTokens > 0 {
+			request.MaxTokens = visionMaxTokens
+		}
+		if request.MaxCompletionTokens > 0 {
+			request.MaxCompletionTokens = visionMaxTokens
+		}
+	}
+	return request
 }
@@ -0,0 +1,46 @@
+package apitest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseModel(t *testing.T) {
+	assert.Equal(t, "gpt-4o-mini-2024-07-18", BaseModel("ft:gpt-4o-mini-2024-07-18:my-org:bot:9xYz"))
+	assert.Equal(t, "gpt-4o", BaseModel("gpt-4o"))
+	assert.True(t, IsFineTuned("ft:o1-mini:org::abc"))
+}
+
+func TestBuildOpenAIRequestFineTuned(t *testing.T) {
+	b := NewRequestBuilder()
+	model := "ft:o1-mini-2024-09-12:my-org::abc123"
+	req := b.buildOpenAIRequest(&TestConfig{Model: model, RequestOpts: RequestOptions{MaxTokens: 1}})
+
+	// The ID is sent as is, the o1 token limits follow the base model
+	assert.Equal(t, model, req.Model)
+	assert.Equal(t, 0, req.MaxTokens)
+	assert.Equal(t, 10, req.MaxCompletionTokens)
+}
+
+func TestIsReasoningModel(t *testing.T) {
+	for _, model := range []string{"o1", "o1-mini", "o3-mini", "o3-mini-2025-01-31", "ft:o1-mini:org::abc"} {
+		assert.True(t, IsReasoningModel(model), model)
+	}
+	for _, model := range []string{"gpt-4o", "omni-moderation-latest", "o", ""} {
+		assert.False(t, IsReasoningModel(model), model)
+	}
+}
+
+func TestBuildRequestLocal(t *testing.T) {
+	req, err := NewRequestBuilder().BuildRequest(context.Background(), &TestConfig{
+		Channel: &Channel{Type: ChannelTypeLocal, Key: util.NoAuthKey, URL: "http://localhost:11434/v1/chat/completions"},
+		Model:   "llama3.2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:11434/v1/chat/completions", req.URL.String())
+	assert.Empty(t, req.Header.Get("Authorization"))
+	assert.NoError(t, ValidateKey(ChannelTypeLocal, util.NoAuthKey))
+}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
 )
 
 // DefaultResultProcessor implements the ResultProcessor interface
@@ -28,9 +30,10 @@ func (p *DefaultResultProcessor) ProcessResponse(resp *http.Response) TestResult
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return TestResult{
-			Success: false,
-			Error:   fmt.Errorf("failed to read response body: %v", err),
-			Latency: time.Since(startTime).Seconds(),
+			Success:    false,
+			StatusCode: resp.StatusCode,
+			Error:      fmt.Errorf("failed to read response body: %v", err),
+			Latency:    time.Since(startTime).Seconds(),
 		}
 	}
 	defer resp.Body.Close()
@@ -38,9 +41,93 @@ func (p *DefaultResultProcessor) ProcessResponse(resp *http.Response) TestResult
 	if resp.StatusCode != http.StatusOK {
 		errMsg := formatErrorMessage(resp.StatusCode, string(body))
 		return TestResult{
-			Success: false,
-			Error:   fmt.Errorf("%s", errMsg),
-			Latency: time.Since(startTime).Seconds(),
+			Success:    false,
+			StatusCode: resp.StatusCode,
+			Error:      fmt.Errorf("%s", errMsg),
+			Latency:    time.Since(startTime).Seconds(),
+		}
+	}
+
+	// Transcriptions carry only the text, an empty text is the answer to the silent clip
+	if IsTranscriptionModel(p.model) {
+		var transcription TranscriptionResponse
+		if err := json.Unmarshal(body, &transcription); err == nil && transcription.Text != nil {
+			return TestResult{
+				Success:    true,
+				StatusCode: resp.StatusCode,
+				Response:   transcription,
+				Latency:    time.Since(startTime).Seconds(),
+			}
+		}
+	}
+
+	// Speech is binary audio, a JSON body with status 200 is a relay error in disguise
+	if IsSpeechModel(p.model) {
+		format := audioFormat(resp.Header.Get("Content-Type"), body)
+		if format == "" {
+			return TestResult{
+				Success:    false,
+				StatusCode: resp.StatusCode,
+				Error:      fmt.Errorf("返回的不是音频 (Content-Type: %s): %s", resp.Header.Get("Content-Type"), util.Truncate(formatErrorMessage(resp.StatusCode, string(body)), 200)),
+				Latency:    time.Since(startTime).Seconds(),
+			}
+		}
+		return TestResult{
+			Success:    true,
+			StatusCode: resp.StatusCode,
+			Response:   SpeechResponse{Format: format, Bytes: len(body)},
+			Latency:    time.Since(startTime).Seconds(),
+		}
+	}
+
+	// Responses API bodies carry a usage object too, a failed response is an error despite the status 200
+	var responsesResp ResponsesResponse
+	if err := json.Unmarshal(body, &responsesResp); err == nil && responsesResp.Object == "response" {
+		if responsesResp.Status == "failed" {
+			message := responsesResp.Status
+			if responsesResp.Error != nil {
+				message = fmt.Sprintf("%s: %s", responsesResp.Error.Code, responsesResp.Error.Message)
+			}
+			return TestResult{
+				Success:    false,
+				StatusCode: resp.StatusCode,
+				Error:      fmt.Errorf("响应失败 (%s)", message),
+				Latency:    time.Since(startTime).Seconds(),
+			}
+		}
+		if responsesResp.Usage != nil {
+			return TestResult{
+				Success:    true,
+				StatusCode: resp.StatusCode,
+				Response:   responsesResp,
+				Latency:    time.Since(startTime).Seconds(),
+			}
+		}
+	}
+
+	// Cohere responses carry a usage object too, check them before OpenAI
+	var cohereResp CohereResponse
+	if err := json.Unmarshal(body, &cohereResp); err == nil {
+		if cohereResp.Message != nil && cohereResp.FinishReason != "" && cohereResp.Usage != nil {
+			return TestResult{
+				Success:    true,
+				StatusCode: resp.StatusCode,
+				Response:   cohereResp,
+				Latency:    time.Since(startTime).Seconds(),
+			}
+		}
+	}
+
+	// Anthropic responses carry a usage object too, check them before OpenAI
+	var anthropicResp AnthropicResponse
+	if err := json.Unmarshal(body, &anthropicResp); err == nil {
+		if anthropicResp.Type == "message" && anthropicResp.Usage != nil {
+			return TestResult{
+				Success:    true,
+				StatusCode: resp.StatusCode,
+				Response:   anthropicResp,
+				Latency:    time.Since(startTime).Seconds(),
+			}
 		}
 	}
 
@@ -48,16 +135,30 @@ func (p *DefaultResultProcessor) ProcessResponse(resp *http.Response) TestResult
 	if err := json.Unmarshal(body, &openAIResp); err == nil {
 		if openAIResp.Usage != nil {
 			return TestResult{
-				Success:  true,
-				Response: openAIResp,
-				Latency:  time.Since(startTime).Seconds(),
+				Success:    true,
+				StatusCode: resp.StatusCode,
+				Response:   openAIResp,
+				Latency:    time.Since(startTime).Seconds(),
+			}
+		}
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err == nil {
+		if geminiResp.UsageMetadata != nil {
+			return TestResult{
+				Success:    true,
+				StatusCode: resp.StatusCode,
+				Response:   geminiResp,
+				Latency:    time.Since(startTime).Seconds(),
 			}
 		}
 	}
 
 	return TestResult{
-		Success: false,
-		Error:   fmt.Errorf("%s", formatErrorMessage(resp.StatusCode, string(body))),
-		Latency: time.Since(startTime).Seconds(),
+		Success:    false,
+		StatusCode: resp.StatusCode,
+		Error:      fmt.Errorf("%s", formatErrorMessage(resp.StatusCode, string(body))),
+		Latency:    time.Since(startTime).Seconds(),
 	}
 }
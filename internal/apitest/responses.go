@@ -0,0 +1,187 @@
+package apitest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Protocols of the OpenAI compatible channels
+const (
+	ProtocolChat      = "chat"      // /v1/chat/completions
+	ProtocolResponses = "responses" // /v1/responses
+)
+
+// responsesMinTokens is the smallest max_output_tokens the Responses API accepts
+const responsesMinTokens = 16
+
+// ParseProtocol checks the protocol of -protocol, empty means chat
+func ParseProtocol(s string) (string, error) {
+	switch s {
+	case "", ProtocolChat:
+		return ProtocolChat, nil
+	case ProtocolResponses:
+		return ProtocolResponses, nil
+	}
+	return "", fmt.Errorf("无效的协议: %s (可选: chat, responses)", s)
+}
+
+// WithProtocol sends the requests of the OpenAI compatible channels with protocol, ProtocolChat by default
+func WithProtocol(protocol string) ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.protocol = protocol
+	}
+}
+
+// ResponsesRequest represents a request to the OpenAI Responses API
+type ResponsesRequest struct {
+	Model           string               `json:"model"`
+	Input           interface{}          `json:"input"` // 文本, 或多模态消息的 []ResponsesMessage
+	Stream          bool                 `json:"stream"`
+	MaxOutputTokens int                  `json:"max_output_tokens,omitempty"`
+	Tools           []ResponsesTool      `json:"tools,omitempty"`
+	ToolChoice      *ResponsesToolChoice `json:"tool_choice,omitempty"`
+	Store           bool                 `json:"store"` // 不在服务端保存测试请求
+}
+
+// ResponsesMessage is a message of the Responses API input
+type ResponsesMessage struct {
+	Role    string                 `json:"role"`
+	Content []ResponsesContentPart `json:"content"`
+}
+
+// ResponsesContentPart is a part of a Responses API message
+type ResponsesContentPart struct {
+	Type     string `json:"type"` // input_text 或 input_image
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// ResponsesTool is a function tool of the Responses API, flattened compared to the chat format
+type ResponsesTool struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ResponsesToolChoice forces the model to call the named function
+type ResponsesToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// ResponsesResponse represents a response of the Responses API
+type ResponsesResponse struct {
+	Object string            `json:"object"`
+	Status string            `json:"status"` // completed, incomplete, failed …
+	Output []ResponsesOutput `json:"output"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Usage *ResponsesUsage `json:"usage"`
+}
+
+// ResponsesOutput is an output item, a message or a function call
+type ResponsesOutput struct {
+	Type    string `json:"type"` // message, function_call, reasoning …
+	Name    string `json:"name,omitempty"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content,omitempty"`
+}
+
+// ResponsesUsage represents the token usage of a Responses API response
+type ResponsesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// speaksResponses reports whether the channel type serves the Responses API next to chat/completions.
+// Azure routes by deployment and the other providers have their own formats.
+func speaksResponses(channelType ChannelType) bool {
+	switch channelType {
+	case ChannelTypeOpenAI, ChannelTypeLocal, ChannelTypeOpenRouter, ChannelTypeGroq:
+		return true
+	}
+	return false
+}
+
+// usesResponses reports whether the request of cfg goes to the Responses API, selected by the protocol
+// or by an endpoint that only serves /v1/responses
+func usesResponses(cfg *TestConfig) bool {
+	if !speaksResponses(cfg.Channel.Type) || IsTranscriptionModel(cfg.Model) || IsSpeechModel(cfg.Model) {
+		return false
+	}
+	return cfg.RequestOpts.Protocol == ProtocolResponses || strings.HasSuffix(strings.TrimRight(cfg.Channel.URL, "/"), "/responses")
+}
+
+// responsesEndpoint returns the Responses API URL next to the chat completions URL apiURL
+func responsesEndpoint(apiURL string) string {
+	if strings.HasSuffix(strings.TrimRight(apiURL, "/"), "/responses") {
+		return apiURL
+	}
+	if base, ok := strings.CutSuffix(strings.TrimRight(apiURL, "/"), "/chat/completions"); ok {
+		return base + "/responses"
+	}
+	return strings.TrimRight(util.BaseURL(apiURL), "/") + "/v1/responses"
+}
+
+func (b *DefaultRequestBuilder) buildResponsesRequest(cfg *TestConfig) *ResponsesRequest {
+	request := &ResponsesRequest{
+		Model:           cfg.Model,
+		Input:           "hi",
+		Stream:          cfg.RequestOpts.Stream,
+		MaxOutputTokens: max(cfg.RequestOpts.MaxTokens, responsesMinTokens),
+	}
+	if IsReasoningModel(cfg.Model) {
+		// The budget covers the reasoning tokens too
+		request.MaxOutputTokens = 16 * responsesMinTokens
+	}
+	if cfg.RequestOpts.Tools {
+		request.Tools = []ResponsesTool{{
+			Type:        weatherTool.Type,
+			Name:        weatherTool.Function.Name,
+			Description: weatherTool.Function.Description,
+			Parameters:  weatherTool.Function.Parameters,
+		}}
+		request.ToolChoice = &ResponsesToolChoice{Type: "function", Name: ToolName}
+		request.Input = "What is the weather in Paris?"
+		request.MaxOutputTokens = max(request.MaxOutputTokens, toolMaxTokens)
+	}
+	if cfg.RequestOpts.Vision {
+		request.Input = []ResponsesMessage{{Role: "user", Content: []ResponsesContentPart{
+			{Type: "input_text", Text: visionPrompt},
+			{Type: "input_image", ImageURL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(visionPNG)},
+		}}}
+		request.MaxOutputTokens = max(request.MaxOutputTokens, visionMaxTokens)
+	}
+	return request
+}
+
+// text returns the text of the message output items
+func (r *ResponsesResponse) text() string {
+	var text []string
+	for _, item := range r.Output {
+		for _, c := range item.Content {
+			text = append(text, c.Text)
+		}
+	}
+	return strings.Join(text, " ")
+}
+
+// calledFunction reports whether the output contains a call of the named function
+func (r *ResponsesResponse) calledFunction(name string) bool {
+	for _, item := range r.Output {
+		if item.Type == "function_call" && item.Name == name {
+			return true
+		}
+	}
+	return false
+}
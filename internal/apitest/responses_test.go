@@ -0,0 +1,104 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponsesEndpoint(t *testing.T) {
+	assert.Equal(t, "https://api.openai.com/v1/responses", responsesEndpoint("https://api.openai.com/v1/chat/completions"))
+	assert.Equal(t, "https://api.groq.com/openai/v1/responses", responsesEndpoint("https://api.groq.com/openai/v1/chat/completions"))
+	assert.Equal(t, "https://relay.example.com/v1/responses", responsesEndpoint("https://relay.example.com"))
+	assert.Equal(t, "https://relay.example.com/v1/responses", responsesEndpoint("https://relay.example.com/v1/responses"))
+
+	// A detected /v1/responses endpoint implies the protocol
+	cfg := &TestConfig{Channel: &Channel{Type: ChannelTypeOpenAI, URL: "https://relay.example.com/v1/responses"}, Model: "gpt-4o"}
+	assert.True(t, usesResponses(cfg))
+	cfg.Model = "whisper-1"
+	assert.False(t, usesResponses(cfg))
+
+	_, err := ParseProtocol("completions")
+	assert.Error(t, err)
+	p, err := ParseProtocol("")
+	assert.NoError(t, err)
+	assert.Equal(t, ProtocolChat, p)
+}
+
+func TestResponsesProtocol(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/responses", r.URL.Path)
+		var req ResponsesRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.False(t, req.Store)
+		switch {
+		case len(req.Tools) > 0:
+			assert.Equal(t, ToolName, req.ToolChoice.Name)
+			assert.Equal(t, toolMaxTokens, req.MaxOutputTokens)
+			w.Write([]byte(`{"object":"response","status":"completed","output":[{"type":"function_call","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}],"usage":{"input_tokens":50,"output_tokens":10,"total_tokens":60}}`))
+		case req.Model == "gpt-4o":
+			assert.Equal(t, "hi", req.Input)
+			assert.Equal(t, responsesMinTokens, req.MaxOutputTokens)
+			w.Write([]byte(`{"object":"response","status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"Hello"}]}],"usage":{"input_tokens":8,"output_tokens":2,"total_tokens":10}}`))
+		default:
+			// Relays that map the route to a broken upstream answer 200 with a failed response
+			w.Write([]byte(`{"object":"response","status":"failed","error":{"code":"server_error","message":"upstream unavailable"},"usage":null}`))
+		}
+	}))
+	defer srv.Close()
+
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL + "/v1/chat/completions", TestModel: []string{"gpt-4o", "gpt-4.1"}}}
+	ct := NewApiTest(2, WithProtocol(ProtocolResponses), WithToolCheck())
+	results := ct.TestAllApis(context.Background(), channels)
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Success, "%v", results[0].Error)
+		if assert.NotNil(t, results[0].Tools) {
+			assert.True(t, *results[0].Tools)
+		}
+		assert.ErrorContains(t, results[1].Error, "upstream unavailable")
+	}
+
+	// The protocol of the channel overrides the option
+	channels[0].Protocol, channels[0].TestModel = ProtocolResponses, []string{"gpt-4o"}
+	results = NewApiTest(1).TestAllApis(context.Background(), channels)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Success, "%v", results[0].Error)
+	}
+}
+
+func TestResponsesRequestShape(t *testing.T) {
+	builder := NewRequestBuilder()
+	opts := DefaultRequestOptions()
+	opts.Protocol = ProtocolResponses
+	opts.Vision = true
+
+	req, err := builder.BuildRequest(context.Background(), &TestConfig{
+		Channel:     &Channel{Type: ChannelTypeOpenAI, Key: "sk-test", URL: "https://api.openai.com/v1/chat/completions"},
+		Model:       "gpt-4o",
+		RequestOpts: opts,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.openai.com/v1/responses", req.URL.String())
+	var body struct {
+		Input []ResponsesMessage `json:"input"`
+	}
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+	if assert.Len(t, body.Input, 1) && assert.Len(t, body.Input[0].Content, 2) {
+		assert.Equal(t, "input_image", body.Input[0].Content[1].Type)
+	}
+
+	// Azure routes by deployment and keeps chat/completions
+	req, err = builder.BuildRequest(context.Background(), &TestConfig{
+		Channel:     &Channel{Type: ChannelTypeAzure, Key: "azure-key", URL: "https://res.openai.azure.com"},
+		Model:       "gpt-4o",
+		RequestOpts: opts,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, req.URL.Path, "/chat/completions")
+
+	assert.True(t, sawImage(answerText([]byte(`{"object":"response","output":[{"type":"message","content":[{"type":"output_text","text":"Red"}]}]}`))))
+}
@@ -1,16 +1,19 @@
 package apitest
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/httpclient"
 	"github.com/go-coders/check-gpt/pkg/logger"
 	"github.com/go-coders/check-gpt/pkg/util"
 )
@@ -43,6 +46,14 @@ type ChannelTest struct {
 	done            chan struct{}
 	printer         *util.Printer
 	config          *ChannelTestConfig
+	failFastPerKey  bool
+	skipKeyCheck    bool
+	toolCheck       bool
+	visionCheck     bool
+	streamTest      bool
+	protocol        string // 兼容 OpenAI 的渠道使用的接口, 为空时为 chat/completions
+	control         <-chan Command
+	pacer           pacer // 限流厂商按 Key 控制请求间隔
 }
 
 // ChannelTestOption defines a function type for configuring ChannelTest
@@ -76,6 +87,58 @@ func WithPrinter(printer *util.Printer) ChannelTestOption {
 	}
 }
 
+// WithProxy sends all requests through the given proxy
+func WithProxy(proxy *url.URL) ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.client = &http.Client{
+			Timeout:   ct.config.Timeout,
+			Transport: NewKeyTransport(httpclient.NewProxyRoundTripper(proxy)),
+		}
+	}
+}
+
+// WithFailFastPerKey skips the remaining models of a key once its first model is rejected with 401
+func WithFailFastPerKey() ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.failFastPerKey = true
+	}
+}
+
+// WithoutKeyCheck sends requests for every key, even when its format looks invalid
+func WithoutKeyCheck() ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.skipKeyCheck = true
+	}
+}
+
+// WithToolCheck sends every model that passed a second request with a trivial tool and records whether it was called
+func WithToolCheck() ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.toolCheck = true
+	}
+}
+
+// WithVisionCheck sends every model that passed a tiny image and records whether it saw the image
+func WithVisionCheck() ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.visionCheck = true
+	}
+}
+
+// WithStreamTest sends the test requests with stream=true and records the time to the first token
+func WithStreamTest() ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.streamTest = true
+	}
+}
+
+// WithControl pauses, resumes or aborts the dispatch of tests with the commands sent on control
+func WithControl(control <-chan Command) ChannelTestOption {
+	return func(ct *ChannelTest) {
+		ct.control = control
+	}
+}
+
 // WithConfig sets the configuration
 func WithConfig(config *ChannelTestConfig) ChannelTestOption {
 	return func(ct *ChannelTest) {
@@ -90,7 +153,8 @@ func NewChannelTest(maxConcurrency int, w io.Writer) *ChannelTest {
 
 	ct := &ChannelTest{
 		client: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: NewKeyTransport(httpclient.NewRoundTripper()),
 		},
 		requestBuilder:  NewRequestBuilder(),
 		resultProcessor: NewResultProcessor("", ""), // Empty key and model for now
@@ -111,7 +175,8 @@ func NewApiTest(maxConcurrency int, opts ...ChannelTestOption) APITester {
 
 	ct := &ChannelTest{
 		client: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: NewKeyTransport(httpclient.NewRoundTripper()),
 		},
 		requestBuilder:  NewRequestBuilder(),
 		resultProcessor: NewResultProcessor("", ""), // Empty key and model for now
@@ -134,8 +199,14 @@ func NewApiTest(maxConcurrency int, opts ...ChannelTestOption) APITester {
 func (ct *ChannelTest) TestChannel(ctx context.Context, cfg *TestConfig) TestResult {
 	start := time.Now()
 
-	// Update result processor with current key and model
-	ct.resultProcessor = NewResultProcessor(cfg.Channel.Key, cfg.Model)
+	// Each request gets its own processor, the tests of a run share ct and the processor depends on the model
+	processor := NewResultProcessor(cfg.Channel.Key, cfg.Model)
+	if ct.streamTest && streams(cfg) {
+		streamCfg := *cfg
+		streamCfg.RequestOpts.Stream = true
+		streamCfg.RequestOpts.MaxTokens = max(streamCfg.RequestOpts.MaxTokens, streamMaxTokens)
+		cfg = &streamCfg
+	}
 
 	req, err := ct.requestBuilder.BuildRequest(ctx, cfg)
 	if err != nil {
@@ -147,6 +218,11 @@ func (ct *ChannelTest) TestChannel(ctx context.Context, cfg *TestConfig) TestRes
 		}
 	}
 
+	if cfg.Channel.Type != ChannelTypeLocal {
+		logger.AddSecret(cfg.Channel.Key)
+	}
+	logger.DebugRequest(req)
+
 	resp, err := ct.client.Do(req)
 	if err != nil {
 		return TestResult{
@@ -154,13 +230,49 @@ func (ct *ChannelTest) TestChannel(ctx context.Context, cfg *TestConfig) TestRes
 			Model:   cfg.Model,
 			Success: false,
 			Error:   fmt.Errorf("request failed: %v", err),
+			Detail:  &ResponseDetail{Total: time.Since(start).Seconds()},
 		}
 	}
+	logger.DebugResponse(resp)
+	detail := &ResponseDetail{
+		Header: resp.Header.Clone(),
+		TTFB:   time.Since(start).Seconds(),
+	}
 
-	result := ct.resultProcessor.ProcessResponse(resp)
+	// Keep the raw body for the result inspector, the processor reads it from the buffer
+	var body []byte
+	var ttft float64
+	var chunks int
+	if cfg.RequestOpts.Stream {
+		body, ttft, chunks, err = readSSE(io.LimitReader(resp.Body, MaxDetailBody), start)
+	} else {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, MaxDetailBody))
+	}
+	resp.Body.Close()
+	if err != nil {
+		return TestResult{
+			Channel:    cfg.Channel,
+			Model:      cfg.Model,
+			StatusCode: resp.StatusCode,
+			Error:      fmt.Errorf("failed to read response body: %v", err),
+			Detail:     detail,
+		}
+	}
+	detail.Body = string(body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var result TestResult
+	if cfg.RequestOpts.Stream && resp.StatusCode == http.StatusOK {
+		result = streamResult(resp.Header, body, chunks)
+		result.TTFT = ttft
+	} else {
+		result = processor.ProcessResponse(resp)
+	}
 	result.Channel = cfg.Channel
 	result.Model = cfg.Model
 	result.Latency = time.Since(start).Seconds()
+	detail.Total = result.Latency
+	result.Detail = detail
 
 	return result
 }
@@ -183,27 +295,169 @@ func (ct *ChannelTest) TestAllChannels(ctx context.Context, configs []*TestConfi
 		close(done)
 	}()
 
+	stop := make(chan struct{})
+	defer close(stop)
+	g := newGate(ct.control, stop)
+	all := configs
+
+	// Malformed keys are reported without sending requests
+	if !ct.skipKeyCheck {
+		configs = rejectMalformedKeys(configs, resultChan)
+	}
+
 	// Test each channel with each model concurrently
-	for _, cfg := range configs {
-		wg.Add(1)
-		go func(cfg *TestConfig) {
-			defer wg.Done()
-			sem <- struct{}{}        // Acquire semaphore
-			defer func() { <-sem }() // Release semaphore
-			result := ct.TestChannel(ctx, cfg)
-			resultChan <- result
-		}(cfg)
+	if ct.failFastPerKey {
+		for _, keyConfigs := range groupByChannel(configs) {
+			wg.Add(1)
+			go func(keyConfigs []*TestConfig) {
+				defer wg.Done()
+				ct.testKeyFailFast(ctx, keyConfigs, sem, g, resultChan)
+			}(keyConfigs)
+		}
+	} else {
+		for _, cfg := range configs {
+			wg.Add(1)
+			go func(cfg *TestConfig) {
+				defer wg.Done()
+				resultChan <- ct.dispatch(ctx, cfg, sem, g)
+			}(cfg)
+		}
 	}
 
 	wg.Wait()
 	close(resultChan)
 	<-done
 
+	sortByInput(results, all)
 	return results
 }
 
+// resultID identifies the test of one model with one channel
+type resultID struct {
+	channel *Channel
+	model   string
+}
+
+// sortByInput orders the results like the configs they were tested for instead of by completion,
+// so two runs with the same input produce the same output
+func sortByInput(results []TestResult, configs []*TestConfig) {
+	pos := make(map[resultID]int, len(configs))
+	for i, cfg := range configs {
+		id := resultID{cfg.Channel, cfg.Model}
+		if _, ok := pos[id]; !ok {
+			pos[id] = i
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return pos[resultID{results[i].Channel, results[i].Model}] < pos[resultID{results[j].Channel, results[j].Model}]
+	})
+}
+
+// testKeyFailFast tests the first model of a key and only tests the others when the key was not rejected
+func (ct *ChannelTest) testKeyFailFast(ctx context.Context, configs []*TestConfig, sem chan struct{}, g *gate, resultChan chan<- TestResult) {
+	first := ct.dispatch(ctx, configs[0], sem, g)
+	resultChan <- first
+
+	if first.StatusCode == http.StatusUnauthorized {
+		logger.Debug("Key %s rejected, skipping %d models", util.MaskKey(configs[0].Channel.Key), len(configs)-1)
+		for _, cfg := range configs[1:] {
+			resultChan <- TestResult{Channel: cfg.Channel, Model: cfg.Model, Skipped: true}
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, cfg := range configs[1:] {
+		wg.Add(1)
+		go func(cfg *TestConfig) {
+			defer wg.Done()
+			resultChan <- ct.dispatch(ctx, cfg, sem, g)
+		}(cfg)
+	}
+	wg.Wait()
+}
+
+// dispatch tests cfg once a slot is free and the run is not paused,
+// configs left when the run is aborted or ctx is done are reported as skipped.
+// Keys of rate limited providers are paced before taking a slot, so a waiting key does not hold up the others,
+// and a paced key that still hits the limit is retried once after the wait it asks for.
+func (ct *ChannelTest) dispatch(ctx context.Context, cfg *TestConfig, sem chan struct{}, g *gate) TestResult {
+	skipped := TestResult{Channel: cfg.Channel, Model: cfg.Model, Skipped: true}
+	interval := paceInterval(cfg.Channel.Type)
+	if interval > 0 && !ct.pacer.wait(ctx, cfg.Channel.Key, interval) {
+		return skipped
+	}
+	result, ok := ct.attempt(ctx, cfg, sem, g)
+	if ok && interval > 0 && rateLimited(result) {
+		wait := retryAfter(result, interval)
+		logger.Debug("Key %s rate limited on %s, retrying in %s", util.MaskKey(cfg.Channel.Key), cfg.Model, wait)
+		if !sleep(ctx, wait) {
+			return skipped
+		}
+		result, ok = ct.attempt(ctx, cfg, sem, g)
+	}
+	if !ok {
+		return skipped
+	}
+	return result
+}
+
+// attempt sends one test request in a slot of sem, ok is false when the run was aborted or ctx is done
+func (ct *ChannelTest) attempt(ctx context.Context, cfg *TestConfig, sem chan struct{}, g *gate) (TestResult, bool) {
+	select {
+	case sem <- struct{}{}: // Acquire semaphore
+	case <-ctx.Done():
+		return TestResult{}, false
+	}
+	defer func() { <-sem }() // Release semaphore
+	if !g.wait(ctx) {
+		return TestResult{}, false
+	}
+	result := ct.TestChannel(ctx, cfg)
+	// A request cut off by cancellation says nothing about the key
+	if result.Error != nil && ctx.Err() != nil {
+		return TestResult{}, false
+	}
+	if ct.toolCheck && result.Success && checksTools(cfg) {
+		result.Tools = ct.checkTools(ctx, cfg)
+	}
+	if ct.visionCheck && result.Success && checksVision(cfg) {
+		result.Vision = ct.checkVision(ctx, cfg)
+	}
+	return result, true
+}
+
+// rejectMalformedKeys sends a failed result for every config whose key format is invalid and returns the others
+func rejectMalformedKeys(configs []*TestConfig, resultChan chan<- TestResult) []*TestConfig {
+	valid := make([]*TestConfig, 0, len(configs))
+	for _, cfg := range configs {
+		if err := ValidateKey(cfg.Channel.Type, cfg.Channel.Key); err != nil {
+			resultChan <- TestResult{Channel: cfg.Channel, Model: cfg.Model, Error: err}
+			continue
+		}
+		valid = append(valid, cfg)
+	}
+	return valid
+}
+
+// groupByChannel groups the configs by channel, keeping the order of their first appearance
+func groupByChannel(configs []*TestConfig) [][]*TestConfig {
+	index := make(map[*Channel]int)
+	var groups [][]*TestConfig
+	for _, cfg := range configs {
+		i, ok := index[cfg.Channel]
+		if !ok {
+			i = len(groups)
+			index[cfg.Channel] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], cfg)
+	}
+	return groups
+}
+
 // TestAllApis is a compatibility method that calls TestAllChannels
-func (ct *ChannelTest) TestAllApis(channels []*Channel) []TestResult {
+func (ct *ChannelTest) TestAllApis(ctx context.Context, channels []*Channel) []TestResult {
 	var configs []*TestConfig
 	for _, channel := range channels {
 		for _, model := range channel.TestModel {
@@ -211,19 +465,19 @@ func (ct *ChannelTest) TestAllApis(channels []*Channel) []TestResult {
 			if model == "" {
 				continue
 			}
+			opts := DefaultRequestOptions()
+			opts.Protocol = ct.protocol
+			if channel.Protocol != "" {
+				opts.Protocol = channel.Protocol
+			}
 			configs = append(configs, &TestConfig{
-				Channel: channel,
-				Model:   model,
-				RequestOpts: RequestOptions{
-					MaxTokens:   1,
-					Temperature: 0.7,
-					TopP:        0.95,
-					TopK:        40,
-				},
+				Channel:     channel,
+				Model:       model,
+				RequestOpts: opts,
 			})
 		}
 	}
-	return ct.TestAllChannels(context.Background(), configs)
+	return ct.TestAllChannels(ctx, configs)
 }
 
 // PrintResults prints the test results in a formatted way
@@ -232,7 +486,53 @@ func (ct *ChannelTest) PrintResults(results []TestResult) error {
 
 	// Group results by key
 	ct.printer.PrintTitle("测试结果", util.EmojiRocket)
+	sortedResults := groupByKey(results)
+
+	printKeyResults(ct.printer, sortedResults, 0)
+	printKeyErrors(ct.printer, sortedResults, 0)
+
+	return nil
+}
+
+// latencyLabel returns the latency of a model, streamed models show the time to the first token and the total
+func latencyLabel(result modelResult) string {
+	if result.ttft <= 0 {
+		return util.FormatLatency(result.latency)
+	}
+	return fmt.Sprintf("首字 %s 总计 %s", util.FormatLatency(result.ttft), util.FormatLatency(result.latency))
+}
+
+// toolsLabel returns the tool calling verdict shown after the latency, empty when the model was not checked
+func toolsLabel(tools *bool) string {
+	switch {
+	case tools == nil:
+		return ""
+	case *tools:
+		return fmt.Sprintf(" %s%s%s", util.ColorGreen, ToolsLabel, util.ColorReset)
+	default:
+		return fmt.Sprintf(" %s%s%s", util.ColorYellow, NoToolsLabel, util.ColorReset)
+	}
+}
+
+// visionLabel returns the vision verdict shown after the latency, empty when the model was not checked
+func visionLabel(vision string) string {
+	switch vision {
+	case "":
+		return ""
+	case VisionSupported:
+		return fmt.Sprintf(" %s%s%s", util.ColorGreen, visionLabels[vision], util.ColorReset)
+	case VisionUnknown:
+		return fmt.Sprintf(" %s%s%s", util.ColorGray, visionLabels[vision], util.ColorReset)
+	default:
+		return fmt.Sprintf(" %s%s%s", util.ColorYellow, visionLabels[vision], util.ColorReset)
+	}
+}
+
+// groupByKey groups the results by key, sorted by success rate (descending).
+// Keys with the same success rate and the models of a key keep the order of the results.
+func groupByKey(results []TestResult) []*keyResultInfo {
 	keyResults := make(map[string]*keyResultInfo)
+	var sortedResults []*keyResultInfo
 
 	// Process results
 	for _, result := range results {
@@ -242,32 +542,36 @@ func (ct *ChannelTest) PrintResults(results []TestResult) error {
 				key:          result.Channel.Key,
 				totalLatency: 0,
 				errors:       make([]errorInfo, 0),
-				modelResults: make(map[string]struct {
-					success bool
-					latency float64
-				}),
+				modelResults: make(map[string]modelResult),
 			}
 			keyResults[result.Channel.Key] = kr
+			sortedResults = append(sortedResults, kr)
 		}
 		kr.totalLatency += result.Latency
-		if result.Error != nil {
+		malformed := errors.Is(result.Error, ErrMalformedKey)
+		// A malformed key fails every model for the same reason, report it once
+		if result.Error != nil && !(malformed && kr.malformed) {
 			kr.errors = append(kr.errors, errorInfo{
 				model:   result.Model,
-				message: result.Error.Error(),
+				message: util.MaskSecrets(result.Error.Error(), result.Channel.Key),
 			})
 		}
-		kr.modelResults[result.Model] = struct {
-			success bool
-			latency float64
-		}{
+		kr.malformed = kr.malformed || malformed
+		if _, ok := kr.modelResults[result.Model]; !ok {
+			kr.models = append(kr.models, result.Model)
+		}
+		kr.modelResults[result.Model] = modelResult{
 			success: result.Success,
+			skipped: result.Skipped,
 			latency: result.Latency,
+			ttft:    result.TTFT,
+			tools:   result.Tools,
+			vision:  result.Vision,
 		}
 	}
 
-	// Calculate success rates and create sorted slice
-	var sortedResults []*keyResultInfo
-	for _, kr := range keyResults {
+	// Calculate success rates
+	for _, kr := range sortedResults {
 		successCount := 0
 		totalCount := 0
 		for _, result := range kr.modelResults {
@@ -277,18 +581,20 @@ func (ct *ChannelTest) PrintResults(results []TestResult) error {
 			totalCount++
 		}
 		kr.successRate = float64(successCount) / float64(totalCount)
-		sortedResults = append(sortedResults, kr)
 	}
 
-	// Sort results by success rate (descending) and latency (ascending)
-	sort.Slice(sortedResults, func(i, j int) bool {
-		if sortedResults[i].successRate != sortedResults[j].successRate {
-			return sortedResults[i].successRate > sortedResults[j].successRate
-		}
-		return sortedResults[i].totalLatency < sortedResults[j].totalLatency
+	// Latency differs between runs, so it does not decide the order
+	sort.SliceStable(sortedResults, func(i, j int) bool {
+		return sortedResults[i].successRate > sortedResults[j].successRate
 	})
+	return sortedResults
+}
+
+// printKeyResults prints the status and model results of the keys, numbered from offset+1
+func printKeyResults(printer *util.Printer, sortedResults []*keyResultInfo, offset int) {
+	// "│   " + name + " ✅ 99.99s"
+	nameWidth := util.Max(util.TerminalWidth(), util.MinTerminalWidth) - 14
 
-	// Print results
 	for i, kr := range sortedResults {
 		// Calculate success count for status
 		successCount := 0
@@ -303,7 +609,11 @@ func (ct *ChannelTest) PrintResults(results []TestResult) error {
 		var overallStatus string
 		var statusColor string
 		var statusText string
-		if successCount == 0 {
+		if kr.malformed {
+			overallStatus = util.EmojiError
+			statusColor = util.ColorRed
+			statusText = MalformedLabel
+		} else if successCount == 0 {
 			overallStatus = util.EmojiError
 			statusColor = util.ColorRed
 			statusText = "全部不可用"
@@ -317,103 +627,92 @@ func (ct *ChannelTest) PrintResults(results []TestResult) error {
 			statusText = fmt.Sprintf("%d/%d可用", successCount, totalCount)
 		}
 
-		fmt.Printf("%s[%d] %s%s%s\n",
+		printer.Printf("%s[%d] %s%s%s\n",
 			util.ColorBlue,
-			i+1,
+			offset+i+1,
 			util.ColorYellow,
-			kr.key,
+			util.MaskKey(kr.key),
 			util.ColorReset,
 		)
 
-		fmt.Printf("│ 状态: %s%s %s%s\n", statusColor, overallStatus, statusText, util.ColorReset)
+		printer.Printf("│ 状态: %s%s %s%s\n", statusColor, overallStatus, statusText, util.ColorReset)
 
-		// Get all models and sort them according to CommonOpenAIModels
-		var sortedModels []string
-		modelMap := make(map[string]bool)
-
-		// Add all tested models to a map
-		for model := range kr.modelResults {
-			modelMap[model] = true
-		}
-
-		// First add models in the order they appear in CommonOpenAIModels
-		for _, model := range config.CommonOpenAIModels {
-			if modelMap[model] {
-				sortedModels = append(sortedModels, model)
-				delete(modelMap, model)
-			}
-		}
-		// Finally add any remaining models
-		for model := range modelMap {
-			sortedModels = append(sortedModels, model)
-		}
+		sortedModels := kr.models
 
-		// Find the longest model name for alignment
-		maxLen := 0
-		for _, model := range sortedModels {
-			if len(model) > maxLen {
-				maxLen = len(model)
-			}
-		}
+		// Find the longest model name for alignment, leaving room for the prefix and latency columns
+		maxLen := util.Min(util.MaxWidth(sortedModels), nameWidth)
 
-		fmt.Printf("│ 模型:\n")
+		printer.Printf("│ 模型:\n")
 		for _, model := range sortedModels {
 			result := kr.modelResults[model]
+			name := util.PadRight(util.Truncate(model, maxLen), maxLen)
+			if IsFineTuned(model) {
+				// The suffix and ID identify a fine-tuned model, cut the middle instead
+				name = util.PadRight(util.TruncateMiddle(model, maxLen), maxLen)
+			}
 			status := util.EmojiError
 			color := util.ColorRed
 			if result.success {
 				status = util.EmojiCheck
 				color = util.ColorGreen
-				fmt.Printf("│   %s%-*s%s %s %.2fs\n",
+				printer.Printf("│   %s%s%s %s %s%s%s\n",
 					color,
-					maxLen,
-					model,
+					name,
 					util.ColorReset,
 					status,
-					result.latency,
+					latencyLabel(result),
+					toolsLabel(result.tools),
+					visionLabel(result.vision),
+				)
+			} else if result.skipped {
+				printer.Printf("│   %s%s %s%s\n",
+					util.ColorGray,
+					name,
+					SkippedLabel,
+					util.ColorReset,
 				)
 			} else {
-				fmt.Printf("│   %s%-*s%s %s\n",
+				printer.Printf("│   %s%s%s %s\n",
 					color,
-					maxLen,
-					model,
+					name,
 					util.ColorReset,
 					status,
 				)
 			}
 		}
-		fmt.Printf("\n")
+		printer.Printf("\n")
+
+		// One-line verdict for -summary
+		var failedModels []string
+		for _, model := range sortedModels {
+			if !kr.modelResults[model].success {
+				failedModels = append(failedModels, model)
+			}
+		}
+		summary := fmt.Sprintf("[%d] %s %s%s %d/%d%s", offset+i+1, util.MaskKey(kr.key), statusColor, statusText, successCount, totalCount, util.ColorReset)
+		if len(failedModels) > 0 && successCount > 0 {
+			summary += fmt.Sprintf(" 失败: %s", strings.Join(failedModels, ", "))
+		}
+		printer.PrintSummary("%s", summary)
 	}
+}
 
+// printKeyErrors prints the error messages of the keys, numbered from offset+1
+func printKeyErrors(printer *util.Printer, sortedResults []*keyResultInfo, offset int) {
 	// Print all error messages after test results
 	hasErrors := false
 	for i, kr := range sortedResults {
 		if len(kr.errors) > 0 {
 			if !hasErrors {
-				ct.printer.PrintTitle("错误信息", util.EmojiGear)
+				printer.PrintTitle("错误信息", util.EmojiGear)
 				hasErrors = true
 			}
 
-			// Sort errors by model order
-			sort.Slice(kr.errors, func(i, j int) bool {
-				// Get model indices from CommonOpenAIModels
-				getModelIndex := func(model string) int {
-					for i, m := range config.CommonOpenAIModels {
-						if m == model {
-							return i
-						}
-					}
-					return 999 // For unknown models
-				}
-				return getModelIndex(kr.errors[i].model) < getModelIndex(kr.errors[j].model)
-			})
-			ct.printer.PrintError(fmt.Sprintf("[%d] key: %s", i+1, kr.key))
+			printer.PrintError(fmt.Sprintf("[%d] key: %s", offset+i+1, util.MaskKey(kr.key)))
 			for _, err := range kr.errors {
 				// print with red color
-				ct.printer.Print(fmt.Sprintf("    %s[%s] %s%s\n", util.ColorRed, err.model, err.message, util.ColorReset))
+				printer.ErrorPrintf("    %s[%s] %s%s\n", util.ColorRed, err.model, err.message, util.ColorReset)
 			}
 		}
 	}
-
-	return nil
 }
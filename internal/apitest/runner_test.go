@@ -0,0 +1,121 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDeadKey = "sk-dead0000000000000000000000000000000000000000000"
+	testLiveKey = "sk-live0000000000000000000000000000000000000000000"
+)
+
+func TestFailFastPerKey(t *testing.T) {
+	var deadRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer "+testDeadKey {
+			atomic.AddInt32(&deadRequests, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"invalid api key","type":"invalid_request_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	models := []string{"gpt-4o", "gpt-4o-mini", "gpt-3.5-turbo"}
+	channels := []*Channel{
+		{Type: ChannelTypeOpenAI, Key: testDeadKey, URL: srv.URL, TestModel: models},
+		{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: models},
+	}
+
+	var buf bytes.Buffer
+	ct := NewApiTest(4, WithFailFastPerKey(), WithPrinter(util.NewPrinter(&buf)))
+	results := ct.TestAllApis(context.Background(), channels)
+
+	assert.Len(t, results, 6)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&deadRequests))
+	skipped, success := 0, 0
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+			assert.Equal(t, testDeadKey, result.Channel.Key)
+		}
+		if result.Success {
+			success++
+		}
+	}
+	assert.Equal(t, 2, skipped)
+	assert.Equal(t, 3, success)
+
+	ct.PrintResults(results)
+	assert.Contains(t, buf.String(), SkippedLabel)
+}
+
+func TestMalformedKeyNotSent(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: "sk-short", URL: srv.URL, TestModel: []string{"gpt-4o", "gpt-4o-mini"}}}
+
+	var buf bytes.Buffer
+	ct := NewApiTest(4, WithPrinter(util.NewPrinter(&buf)))
+	results := ct.TestAllApis(context.Background(), channels)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	assert.ErrorIs(t, results[0].Error, ErrMalformedKey)
+
+	ct.PrintResults(results)
+	assert.Contains(t, buf.String(), MalformedLabel)
+	assert.Equal(t, 2, strings.Count(buf.String(), MalformedLabel), "status line and a single error line")
+
+	results = NewApiTest(4, WithoutKeyCheck()).TestAllApis(context.Background(), channels)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestResultsKeepInputOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first model answers last
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"gpt-4o"`) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	models := []string{"gpt-4o", "zz-custom", "gpt-3.5-turbo"}
+	channels := []*Channel{
+		{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: models},
+		{Type: ChannelTypeOpenAI, Key: testDeadKey, URL: srv.URL, TestModel: models},
+	}
+	results := NewApiTest(6).TestAllApis(context.Background(), channels)
+
+	var got []string
+	for _, result := range results {
+		got = append(got, result.Channel.Key[:7]+"/"+result.Model)
+	}
+	assert.Equal(t, []string{
+		"sk-live/gpt-4o", "sk-live/zz-custom", "sk-live/gpt-3.5-turbo",
+		"sk-dead/gpt-4o", "sk-dead/zz-custom", "sk-dead/gpt-3.5-turbo",
+	}, got)
+
+	groups := groupByKey(results)
+	assert.Equal(t, testLiveKey, groups[0].key, "keys with the same success rate keep the input order")
+	assert.Equal(t, models, groups[0].models)
+}
@@ -0,0 +1,71 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// speechInput is the text the speech test synthesizes, a few characters keep the cost negligible
+const speechInput = "hi"
+
+// SpeechRequest is the JSON body of /v1/audio/speech
+type SpeechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+// SpeechResponse describes the audio returned by /v1/audio/speech
+type SpeechResponse struct {
+	Format string `json:"format"` // 识别出的音频格式
+	Bytes  int    `json:"bytes"`
+}
+
+// IsSpeechModel reports whether model is a text-to-speech model (tts-1, tts-1-hd, gpt-4o-mini-tts, ...),
+// which is tested through /v1/audio/speech instead of chat
+func IsSpeechModel(model string) bool {
+	name := strings.ToLower(modelName(model))
+	return strings.HasPrefix(name, "tts-") || strings.HasSuffix(name, "-tts")
+}
+
+// speechEndpoint returns the speech route next to the chat route of apiURL
+func speechEndpoint(apiURL string) string {
+	return strings.TrimRight(util.BaseURL(apiURL), "/") + "/v1/audio/speech"
+}
+
+// speechBody builds the JSON body asking model for a short mp3
+func speechBody(model string) ([]byte, error) {
+	return json.Marshal(SpeechRequest{Model: model, Input: speechInput, Voice: "alloy", ResponseFormat: "mp3"})
+}
+
+// audioFormat identifies the audio container of data by its magic bytes, it returns "" for anything else.
+// Relays often answer audio routes with a JSON or HTML error and status 200, which must not pass as audio.
+func audioFormat(contentType string, data []byte) string {
+	if media, _, err := mime.ParseMediaType(contentType); err == nil {
+		if media == "application/json" || strings.HasPrefix(media, "text/") {
+			return ""
+		}
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return "mp3"
+	case bytes.HasPrefix(data, []byte("RIFF")) && len(data) >= 12 && string(data[8:12]) == "WAVE":
+		return "wav"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "opus"
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return "flac"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xF6 == 0xF0:
+		// ADTS frame sync with layer 0
+		return "aac"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		// MPEG audio frame sync
+		return "mp3"
+	}
+	return ""
+}
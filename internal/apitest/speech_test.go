@@ -0,0 +1,55 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpeech(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        bool
+	}{
+		{"Audio", "audio/mpeg", append([]byte("ID3\x04\x00"), make([]byte, 64)...), true},
+		{"JSONError", "application/json", []byte(`{"error":{"message":"route not found"}}`), false},
+		{"HTMLPage", "text/html", []byte(`<html>ok</html>`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/audio/speech", r.URL.Path)
+				var req SpeechRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, "tts-1", req.Model)
+				assert.Equal(t, speechInput, req.Input)
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write(tt.body)
+			}))
+			defer srv.Close()
+
+			channel := &Channel{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL + "/v1/chat/completions", TestModel: []string{"tts-1"}}
+			results := NewApiTest(1).TestAllApis(context.Background(), []*Channel{channel})
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tt.want, results[0].Success, "%v", results[0].Error)
+			}
+		})
+	}
+}
+
+func TestAudioFormat(t *testing.T) {
+	assert.Equal(t, "mp3", audioFormat("audio/mpeg", []byte{0xFF, 0xFB, 0x90, 0x00}))
+	assert.Equal(t, "wav", audioFormat("", probeWAV))
+	assert.Equal(t, "opus", audioFormat("audio/ogg", []byte("OggS\x00")))
+	assert.Equal(t, "", audioFormat("application/octet-stream", []byte(`{"error":"x"}`)))
+	assert.Equal(t, "", audioFormat("application/json; charset=utf-8", []byte("ID3")))
+	assert.True(t, IsSpeechModel("tts-1-hd"))
+	assert.True(t, IsSpeechModel("gpt-4o-mini-tts"))
+	assert.False(t, IsSpeechModel("gpt-4o-mini"))
+}
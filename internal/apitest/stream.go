@@ -0,0 +1,113 @@
+package apitest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamMaxTokens lets a streamed answer span a few chunks, so the total time says more than the first token
+const streamMaxTokens = 16
+
+// streams reports whether the stream test applies to the model of cfg. Gemini streams through another
+// route, audio models do not stream.
+func streams(cfg *TestConfig) bool {
+	if IsTranscriptionModel(cfg.Model) || IsSpeechModel(cfg.Model) {
+		return false
+	}
+	switch cfg.Channel.Type {
+	case ChannelTypeGemini, ChannelTypeVertex:
+		return false
+	}
+	return true
+}
+
+// readSSE reads a streamed body line by line and returns it with the number of data chunks
+// and the seconds from start to the first chunk carrying output text, 0 when none did
+func readSSE(r io.Reader, start time.Time) ([]byte, float64, int, error) {
+	var body bytes.Buffer
+	var ttft float64
+	chunks := 0
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		body.Write(line)
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if ok && !bytes.Equal(bytes.TrimSpace(data), []byte("[DONE]")) {
+			// message_start, ping and response.created arrive before any token
+			if ttft == 0 && carriesText(data) {
+				ttft = time.Since(start).Seconds()
+			}
+			chunks++
+		}
+		if err == io.EOF {
+			return body.Bytes(), ttft, chunks, nil
+		}
+		if err != nil {
+			return body.Bytes(), ttft, chunks, err
+		}
+	}
+}
+
+// carriesText reports whether the data of a stream event carries output text: an OpenAI chunk with
+// delta content, an Anthropic content_block_delta, a Responses API output_text delta or a Cohere content-delta
+func carriesText(data []byte) bool {
+	var event struct {
+		Type    string `json:"type"`
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+			Text string `json:"text"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal(data, &event) != nil {
+		return false
+	}
+	switch event.Type {
+	case "content_block_delta", "response.output_text.delta", "content-delta":
+		return true
+	}
+	for _, c := range event.Choices {
+		if c.Delta.Content != "" || c.Text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// streamResult checks a streamed 200 response: relays that advertise streaming but break SSE answer
+// with a plain JSON body, an empty stream or an error event
+func streamResult(header http.Header, body []byte, chunks int) TestResult {
+	contentType := header.Get("Content-Type")
+	if chunks == 0 {
+		if !strings.Contains(contentType, "event-stream") {
+			return TestResult{StatusCode: http.StatusOK, Error: fmt.Errorf("流式请求返回的不是 SSE 事件流 (Content-Type: %s)", contentType)}
+		}
+		return TestResult{StatusCode: http.StatusOK, Error: fmt.Errorf("流式响应中没有数据块")}
+	}
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		var event struct {
+			Type  string          `json:"type"`
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal(data, &event) != nil {
+			continue
+		}
+		// The Responses API reports errors as events of their own type
+		failed := event.Type == "error" || event.Type == "response.failed"
+		if failed || len(event.Error) > 0 && string(event.Error) != "null" {
+			return TestResult{StatusCode: http.StatusOK, Error: fmt.Errorf("流式响应中的错误: %s", formatErrorMessage(http.StatusOK, string(bytes.TrimSpace(data))))}
+		}
+	}
+	return TestResult{Success: true, StatusCode: http.StatusOK}
+}
@@ -0,0 +1,78 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+		switch req.Model {
+		case "gpt-4o":
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			for _, token := range []string{"Hel", "lo"} {
+				w.Write([]byte(`data: {"choices":[{"delta":{"content":"` + token + `"}}]}` + "\n\n"))
+				flusher.Flush()
+				time.Sleep(20 * time.Millisecond)
+			}
+			w.Write([]byte("data: [DONE]\n\n"))
+		case "gpt-4o-mini":
+			// A relay that converts the stream into a plain response
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte(`data: {"error":{"message":"upstream stream interrupted","type":"server_error"}}` + "\n\n"))
+		}
+	}))
+	defer srv.Close()
+
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: []string{"gpt-4o", "gpt-4o-mini", "o3-mini"}}}
+	var buf bytes.Buffer
+	ct := NewApiTest(4, WithStreamTest(), WithPrinter(util.NewPrinter(&buf)))
+	results := ct.TestAllApis(context.Background(), channels)
+	if assert.Len(t, results, 3) {
+		assert.True(t, results[0].Success, "%v", results[0].Error)
+		assert.Greater(t, results[0].TTFT, 0.0)
+		assert.Greater(t, results[0].Latency, results[0].TTFT)
+		assert.ErrorContains(t, results[1].Error, "不是 SSE 事件流")
+		assert.ErrorContains(t, results[2].Error, "upstream stream interrupted")
+	}
+	ct.PrintResults(results)
+	assert.Contains(t, buf.String(), "首字 ")
+	assert.Equal(t, 1, strings.Count(buf.String(), "总计"))
+
+	// Anthropic sends message_start and ping before the first token
+	const delay = 50 * time.Millisecond
+	anthropic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n"))
+		w.Write([]byte("event: ping\ndata: {\"type\":\"ping\"}\n\n"))
+		flusher.Flush()
+		time.Sleep(delay)
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\n\n"))
+		w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer anthropic.Close()
+
+	channels = []*Channel{{Type: ChannelTypeAnthropic, Key: "sk-ant-api03-" + strings.Repeat("a", 40), URL: anthropic.URL, TestModel: []string{"claude-3-5-haiku-20241022"}}}
+	results = NewApiTest(1, WithStreamTest()).TestAllApis(context.Background(), channels)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Success, "%v", results[0].Error)
+		assert.GreaterOrEqual(t, results[0].TTFT, delay.Seconds())
+	}
+}
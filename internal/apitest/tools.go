@@ -0,0 +1,142 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+)
+
+// ToolName is the function the tool calling check asks the model to call
+const ToolName = "get_weather"
+
+// toolMaxTokens leaves room for the arguments of the tool call
+const toolMaxTokens = 64
+
+// Labels of the tool calling check shown after the latency of a model
+const (
+	ToolsLabel   = "支持工具调用"
+	NoToolsLabel = "不支持工具调用"
+)
+
+// Tool is a tool definition of an OpenAI chat request
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a function the model may call
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolChoice forces the model to call the named function
+type ToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// ToolCallResponse is the part of an OpenAI chat response carrying the tool calls
+type ToolCallResponse struct {
+	Choices []struct {
+		Message struct {
+			ToolCalls []struct {
+				Function struct {
+					Name string `json:"name"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// weatherTool is the trivial tool of the check
+var weatherTool = Tool{
+	Type: "function",
+	Function: ToolFunction{
+		Name:        ToolName,
+		Description: "Get the current weather of a city",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+	},
+}
+
+// checksTools reports whether the tool calling check applies to the model of cfg,
+// the channels speaking the OpenAI chat format with a chat model
+func checksTools(cfg *TestConfig) bool {
+	if IsTranscriptionModel(cfg.Model) || IsSpeechModel(cfg.Model) {
+		return false
+	}
+	switch cfg.Channel.Type {
+	case ChannelTypeGemini, ChannelTypeVertex, ChannelTypeAnthropic, ChannelTypeCohere:
+		return false
+	}
+	return true
+}
+
+// withTools adds the weather tool to an OpenAI request and forces the model to call it
+func withTools(request *OpenAIRequest) {
+	choice := &ToolChoice{Type: "function"}
+	choice.Function.Name = ToolName
+	request.Tools = []Tool{weatherTool}
+	request.ToolChoice = choice
+	request.Messages = []Message{{Role: "user", Content: "What is the weather in Paris?"}}
+	if request.MaxTokens > 0 {
+		request.MaxTokens = toolMaxTokens
+	}
+	if request.MaxCompletionTokens > 0 {
+		// Reasoning models spend tokens before the call
+		request.MaxCompletionTokens = 16 * toolMaxTokens
+	}
+}
+
+// calledTool reports whether the chat or Responses API body contains a call of the weather tool
+func calledTool(body []byte) bool {
+	var responses ResponsesResponse
+	if err := json.Unmarshal(body, &responses); err == nil && responses.Object == "response" {
+		return responses.calledFunction(ToolName)
+	}
+	var resp ToolCallResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+	for _, choice := range resp.Choices {
+		for _, call := range choice.Message.ToolCalls {
+			if call.Function.Name == ToolName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkTools sends the chat request of cfg with the weather tool and reports whether the model called it.
+// Relays that strip the tools from the request get a plain answer back. The result is nil when the check
+// got no answer, a relay that is rate limited or down says nothing about tool support.
+func (ct *ChannelTest) checkTools(ctx context.Context, cfg *TestConfig) *bool {
+	toolCfg := *cfg
+	toolCfg.RequestOpts.Tools = true
+	toolCfg.RequestOpts.Stream = false
+	req, err := ct.requestBuilder.BuildRequest(ctx, &toolCfg)
+	if err != nil {
+		return nil
+	}
+	resp, err := ct.client.Do(req)
+	if err != nil {
+		logger.Debug("Tool calling check of %s failed: %v", cfg.Model, err)
+		return nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxDetailBody))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		logger.Debug("Tool calling check of %s returned %d: %s", cfg.Model, resp.StatusCode, bytes.TrimSpace(body))
+		return nil
+	}
+	called := calledTool(body)
+	return &called
+}
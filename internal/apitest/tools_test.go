@@ -0,0 +1,52 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		// gpt-4o-mini sits behind a relay that strips the tools
+		if len(req.Tools) > 0 && req.Model == "gpt-4o" {
+			assert.Equal(t, ToolName, req.ToolChoice.Function.Name)
+			w.Write([]byte(`{"choices":[{"message":{"tool_calls":[{"type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+			return
+		}
+		// gpt-4.1 is rate limited by the time the check arrives
+		if len(req.Tools) > 0 && req.Model == "gpt-4.1" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"Sunny"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: []string{"gpt-4o", "gpt-4o-mini", "gpt-4.1"}}}
+	var buf bytes.Buffer
+	ct := NewApiTest(4, WithToolCheck(), WithPrinter(util.NewPrinter(&buf)))
+	results := ct.TestAllApis(context.Background(), channels)
+	if assert.Len(t, results, 3) {
+		assert.True(t, *results[0].Tools)
+		assert.False(t, *results[1].Tools)
+		// No answer to the check is not a verdict on tool support
+		assert.True(t, results[2].Success)
+		assert.Nil(t, results[2].Tools)
+	}
+	ct.PrintResults(results)
+	assert.Contains(t, buf.String(), ToolsLabel)
+	assert.Contains(t, buf.String(), NoToolsLabel)
+
+	// Without the option the models are not checked
+	results = NewApiTest(4).TestAllApis(context.Background(), channels)
+	assert.Nil(t, results[0].Tools)
+}
@@ -1,11 +1,22 @@
 package apitest
 
+import "github.com/go-coders/check-gpt/internal/types"
+
 // ChannelType represents the type of API channel
-type ChannelType int
+type ChannelType = types.ChannelType
 
 const (
-	ChannelTypeGemini ChannelType = iota
-	ChannelTypeOpenAI
+	ChannelTypeGemini     = types.ChannelTypeGemini
+	ChannelTypeOpenAI     = types.ChannelTypeOpenAI
+	ChannelTypeAnthropic  = types.ChannelTypeAnthropic
+	ChannelTypeAzure      = types.ChannelTypeAzure
+	ChannelTypeVertex     = types.ChannelTypeVertex
+	ChannelTypeCohere     = types.ChannelTypeCohere
+	ChannelTypeLocal      = types.ChannelTypeLocal
+	ChannelTypeDashScope  = types.ChannelTypeDashScope
+	ChannelTypeZhipu      = types.ChannelTypeZhipu
+	ChannelTypeOpenRouter = types.ChannelTypeOpenRouter
+	ChannelTypeGroq       = types.ChannelTypeGroq
 )
 
 // Parse OpenAI response
@@ -19,21 +30,25 @@ type Channel struct {
 	TestModel []string    `json:"test_model"`
 	URL       string      `json:"url"`
 	Type      ChannelType `json:"type"`
+	Endpoint  string      `json:"endpoint,omitempty"` // 渠道文件中的端点名称
+	Protocol  string      `json:"protocol,omitempty"` // 兼容 OpenAI 的渠道使用的接口, 为空时使用 WithProtocol 的设置
 }
 
 // OpenAIRequest represents a request to the OpenAI API
 type OpenAIRequest struct {
-	Model               string    `json:"model"`
-	Messages            []Message `json:"messages"`
-	Stream              bool      `json:"stream"`
-	MaxTokens           int       `json:"max_tokens,omitempty"`
-	MaxCompletionTokens int       `json:"max_completion_tokens,omitempty"`
+	Model               string      `json:"model"`
+	Messages            []Message   `json:"messages"`
+	Stream              bool        `json:"stream"`
+	MaxTokens           int         `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int         `json:"max_completion_tokens,omitempty"`
+	Tools               []Tool      `json:"tools,omitempty"`
+	ToolChoice          *ToolChoice `json:"tool_choice,omitempty"`
 }
 
 // Message represents a message in the OpenAI request
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"` // 文本, 或多模态消息的 []ContentPart
 }
 
 // Usage represents the token usage information
@@ -48,11 +63,20 @@ type keyResultInfo struct {
 	key          string
 	totalLatency float64
 	successRate  float64
+	malformed    bool
 	errors       []errorInfo
-	modelResults map[string]struct {
-		success bool
-		latency float64
-	}
+	models       []string // 按结果顺序排列的模型
+	modelResults map[string]modelResult
+}
+
+// modelResult represents the outcome of one model for a key
+type modelResult struct {
+	success bool
+	skipped bool
+	latency float64
+	ttft    float64
+	tools   *bool
+	vision  string
 }
 
 // errorInfo represents error information for a specific model
@@ -17,7 +17,7 @@ func PrintModelMenu(p *util.Printer, title string, models []string, defaultModel
 	// get model title max length and calculate total width needed
 	maxTotalWidth := 0
 	for _, group := range config.ModelGroups {
-		totalWidth := len(fmt.Sprintf("%d. %s", i, group.Title))
+		totalWidth := util.StringWidth(fmt.Sprintf("%d. %s", i, group.Title))
 		if totalWidth > maxTotalWidth {
 			maxTotalWidth = totalWidth
 		}
@@ -29,13 +29,9 @@ func PrintModelMenu(p *util.Printer, title string, models []string, defaultModel
 	// Add padding for consistent alignment
 	for _, group := range config.ModelGroups {
 		prefix := fmt.Sprintf("%d. ", i)
-		currentWidth := len(prefix) + len(group.Title)
-		padding := maxTotalWidth - currentWidth
-		p.Printf("\n%s%s%s%s: %s%s",
+		p.Printf("\n%s%s: %s%s",
 			util.ColorLightBlue,
-			prefix,
-			group.Title,
-			strings.Repeat(" ", padding),
+			util.PadRight(prefix+group.Title, maxTotalWidth),
 			strings.Join(group.Models, ", "),
 			util.ColorReset)
 		i++
@@ -43,13 +39,8 @@ func PrintModelMenu(p *util.Printer, title string, models []string, defaultModel
 
 	p.Printf("\n") // Add a single blank line
 
-	// Calculate the maximum length for model names
-	maxLen := 0
-	for _, model := range models {
-		if len(model) > maxLen {
-			maxLen = len(model)
-		}
-	}
+	// Calculate the maximum width for model names
+	maxLen := util.MaxWidth(models)
 
 	// Print individual models in two columns
 	numWidth := 2 // Fixed width for numbers
@@ -58,8 +49,8 @@ func PrintModelMenu(p *util.Printer, title string, models []string, defaultModel
 	for row := 0; row < rows; row++ {
 		// First column
 		if row < len(models) {
-			format := fmt.Sprintf("%%%dd. %%-%ds", numWidth, maxLen+5) // +5 for spacing between columns
-			p.Printf(format, row+i, models[row])                       // Start from i since previous numbers are used by groups
+			format := fmt.Sprintf("%%%dd. %%s", numWidth)
+			p.Printf(format, row+i, util.PadRight(models[row], maxLen+5)) // +5 for spacing between columns, start from i since previous numbers are used by groups
 		}
 
 		// Second column
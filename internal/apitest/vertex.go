@@ -0,0 +1,223 @@
+package apitest
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// Vertex AI keys are service account JSON files, the OAuth token is minted when the request is sent
+const (
+	VertexKeySuffix   = ".json"
+	vertexScope       = "https://www.googleapis.com/auth/cloud-platform"
+	vertexTokenURI    = "https://oauth2.googleapis.com/token"
+	vertexTokenLeeway = time.Minute // 提前刷新, 避免令牌在请求途中过期
+)
+
+// ServiceAccount holds the fields of a Google service account key file used to mint tokens
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// IsServiceAccountPath reports whether key looks like the path of a service account file rather than a key
+func IsServiceAccountPath(key string) bool {
+	return strings.HasSuffix(strings.ToLower(key), VertexKeySuffix)
+}
+
+// LoadServiceAccount reads and checks a service account key file, a leading ~/ is the home directory
+func LoadServiceAccount(path string) (*ServiceAccount, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取服务账号文件失败: %v", err)
+	}
+	var sa ServiceAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("服务账号文件不是有效的 JSON: %v", err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("%s 不是服务账号密钥文件 (缺少 type、client_email 或 private_key)", path)
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = vertexTokenURI
+	}
+	return &sa, nil
+}
+
+// VertexURL returns the regional models URL of a project, the model and :generateContent are
+// appended per request like Gemini. The global location has no regional host.
+func VertexURL(project, region string) string {
+	if region == "" {
+		region = config.VertexRegion
+	}
+	host := region + "-aiplatform.googleapis.com"
+	if region == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/google/models", host, project, region)
+}
+
+// IsVertexURL reports whether rawURL is a Vertex AI endpoint
+func IsVertexURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && strings.HasSuffix(u.Hostname(), "aiplatform.googleapis.com")
+}
+
+type vertexAccountContextKey struct{}
+
+// withVertexAccount attaches the service account path to the request context, the token is added in the transport
+func withVertexAccount(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, vertexAccountContextKey{}, path)
+}
+
+// vertexToken is a minted access token and when it stops being usable
+type vertexToken struct {
+	value   string
+	expires time.Time
+}
+
+// vertexTokens caches the access token of every service account, shared by all transports of a run
+var vertexTokens = struct {
+	sync.Mutex
+	m map[string]vertexToken
+}{m: make(map[string]vertexToken)}
+
+// vertexAccessToken returns a cached access token of the service account or mints one through base
+func vertexAccessToken(ctx context.Context, base http.RoundTripper, path string) (string, error) {
+	vertexTokens.Lock()
+	defer vertexTokens.Unlock()
+	if t, ok := vertexTokens.m[path]; ok && time.Now().Before(t.expires) {
+		return t.value, nil
+	}
+
+	sa, err := LoadServiceAccount(path)
+	if err != nil {
+		return "", err
+	}
+	t, err := mintVertexToken(ctx, base, sa, time.Now())
+	if err != nil {
+		return "", err
+	}
+	vertexTokens.m[path] = t
+	return t.value, nil
+}
+
+// mintVertexToken exchanges a JWT signed with the service account key for an access token
+func mintVertexToken(ctx context.Context, base http.RoundTripper, sa *ServiceAccount, now time.Time) (vertexToken, error) {
+	assertion, err := sa.signJWT(now)
+	if err != nil {
+		return vertexToken{}, err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sa.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return vertexToken{}, fmt.Errorf("failed to create token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := (&http.Client{Transport: base}).Do(req)
+	if err != nil {
+		return vertexToken{}, fmt.Errorf("获取 Vertex AI 访问令牌失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxDetailBody))
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		if token.Error != "" {
+			return vertexToken{}, fmt.Errorf("获取 Vertex AI 访问令牌失败: %s %s", token.Error, token.ErrorDescription)
+		}
+		return vertexToken{}, fmt.Errorf("获取 Vertex AI 访问令牌失败: code %d %s", resp.StatusCode, strings.Join(strings.Fields(string(body)), " "))
+	}
+	return vertexToken{
+		value:   token.AccessToken,
+		expires: now.Add(time.Duration(token.ExpiresIn)*time.Second - vertexTokenLeeway),
+	}, nil
+}
+
+// signJWT returns the RS256 assertion of the token request
+func (sa *ServiceAccount) signJWT(now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("服务账号私钥不是 PEM 格式")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("解析服务账号私钥失败: %v", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("服务账号私钥不是 RSA 密钥")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": sa.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": vertexScope,
+		"aud":   sa.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// formatGoogleError formats a Google API error body of Gemini or Vertex AI, ok is false for other bodies
+func formatGoogleError(errBody string) (string, bool) {
+	var e GeminiError
+	if err := json.Unmarshal([]byte(errBody), &e); err != nil || e.Error.Code == 0 || e.Error.Message == "" {
+		return "", false
+	}
+	parts := []string{fmt.Sprintf("code: %d", e.Error.Code), fmt.Sprintf("message: %s", e.Error.Message)}
+	if e.Error.Status != "" {
+		parts = append(parts, fmt.Sprintf("status: %s", e.Error.Status))
+	}
+	for _, d := range e.Error.Details {
+		if d.Reason != "" {
+			parts = append(parts, fmt.Sprintf("reason: %s", d.Reason))
+			break
+		}
+	}
+	return strings.Join(parts, " "), true
+}
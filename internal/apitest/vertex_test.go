@@ -0,0 +1,95 @@
+package apitest
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeServiceAccount writes a service account file whose tokens are minted by tokenURI
+func writeServiceAccount(t *testing.T, key *rsa.PrivateKey, tokenURI string) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	data, _ := json.Marshal(ServiceAccount{
+		Type:         "service_account",
+		ProjectID:    "my-project",
+		PrivateKeyID: "kid-1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  "tester@my-project.iam.gserviceaccount.com",
+		TokenURI:     tokenURI,
+	})
+	path := filepath.Join(t.TempDir(), "sa.json")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestVertexTokenMintedAtTransport(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	mints := 0
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mints++
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		if assert.Len(t, parts, 3) {
+			sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig))
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			assert.Contains(t, string(claims), `"iss":"tester@my-project.iam.gserviceaccount.com"`)
+		}
+		w.Write([]byte(`{"access_token":"ya29.test","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer tokens.Close()
+
+	var gotAuth, gotPath string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotPath = r.Header.Get("Authorization"), r.URL.Path
+		w.Write([]byte(`{"candidates":[],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":1,"totalTokenCount":2}}`))
+	}))
+	defer api.Close()
+
+	path := writeServiceAccount(t, key, tokens.URL)
+	assert.NoError(t, ValidateKey(ChannelTypeVertex, path))
+	base := strings.Replace(VertexURL("my-project", "us-central1"), "https://us-central1-aiplatform.googleapis.com", api.URL, 1)
+	ct := NewApiTest(1)
+	for i := 0; i < 2; i++ {
+		result := ct.TestChannel(context.Background(), &TestConfig{
+			Channel: &Channel{Type: ChannelTypeVertex, Key: path, URL: base},
+			Model:   "gemini-1.5-flash",
+		})
+		assert.True(t, result.Success, "%v", result.Error)
+	}
+	assert.Equal(t, "Bearer ya29.test", gotAuth)
+	assert.Equal(t, "/v1/projects/my-project/locations/us-central1/publishers/google/models/gemini-1.5-flash:generateContent", gotPath)
+	assert.Equal(t, 1, mints, "the token is cached")
+}
+
+func TestVertexServiceAccountErrors(t *testing.T) {
+	assert.ErrorIs(t, ValidateKey(ChannelTypeVertex, filepath.Join(t.TempDir(), "missing.json")), ErrMalformedKey)
+
+	path := filepath.Join(t.TempDir(), "key.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"type":"authorized_user"}`), 0o600))
+	assert.ErrorIs(t, ValidateKey(ChannelTypeVertex, path), ErrMalformedKey)
+
+	assert.Equal(t, "https://aiplatform.googleapis.com/v1/projects/p/locations/global/publishers/google/models", VertexURL("p", "global"))
+	assert.True(t, IsVertexURL(VertexURL("p", "")))
+
+	msg := formatErrorMessage(403, `{"error":{"code":403,"message":"Permission denied","status":"PERMISSION_DENIED","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"IAM_PERMISSION_DENIED"}]}}`)
+	assert.Equal(t, "code: 403 message: Permission denied status: PERMISSION_DENIED reason: IAM_PERMISSION_DENIED", msg)
+}
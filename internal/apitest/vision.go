@@ -0,0 +1,197 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+)
+
+// Verdicts of the vision check
+const (
+	VisionSupported = "supported" // 模型看到了图片
+	VisionRejected  = "rejected"  // 请求被拒绝
+	VisionIgnored   = "ignored"   // 请求成功, 但回答与图片不符, 图片可能被中转删除
+	VisionUnknown   = "unknown"   // 请求出错、被限流或服务端错误, 无法判断
+)
+
+// Labels of the vision check shown after the latency of a model
+var visionLabels = map[string]string{
+	VisionSupported: "支持图片输入",
+	VisionRejected:  "不支持图片输入",
+	VisionIgnored:   "图片被忽略",
+	VisionUnknown:   "图片输入未知",
+}
+
+// visionPrompt asks for the color of the probe image, a model that did not see it can only guess
+const visionPrompt = "What is the color of this image? Answer with one word."
+
+// visionMaxTokens caps the answer, the one word answer needs a few tokens but reasoning models think first
+const visionMaxTokens = 256
+
+// visionPNG is the probe image, a 16x16 solid red PNG of about 100 bytes
+var visionPNG = solidPNG(16, color.RGBA{R: 255, A: 255})
+
+// VisionImageURL returns the data URL of the probe image
+func VisionImageURL() string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(visionPNG)
+}
+
+// ContentPart is a part of a multimodal OpenAI or Anthropic message
+type ContentPart struct {
+	Type     string           `json:"type"`
+	Text     string           `json:"text,omitempty"`
+	ImageURL *ContentImageURL `json:"image_url,omitempty"` // OpenAI
+	Source   *ContentSource   `json:"source,omitempty"`    // Anthropic
+}
+
+// ContentImageURL is the image of an OpenAI image_url part
+type ContentImageURL struct {
+	URL string `json:"url"`
+}
+
+// ContentSource is the base64 image of an Anthropic image block
+type ContentSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// GeminiInlineData is the base64 image of a Gemini part
+type GeminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// checksVision reports whether the vision check applies to the model of cfg, the chat channels with an image format
+func checksVision(cfg *TestConfig) bool {
+	if IsTranscriptionModel(cfg.Model) || IsSpeechModel(cfg.Model) {
+		return false
+	}
+	return cfg.Channel.Type != ChannelTypeCohere
+}
+
+// visionMessages returns the user message with the probe image in the OpenAI format
+func visionMessages() []Message {
+	return []Message{{Role: "user", Content: []ContentPart{
+		{Type: "text", Text: visionPrompt},
+		{Type: "image_url", ImageURL: &ContentImageURL{URL: VisionImageURL()}},
+	}}}
+}
+
+// anthropicVisionMessages returns the user message with the probe image in the Anthropic format
+func anthropicVisionMessages() []Message {
+	return []Message{{Role: "user", Content: []ContentPart{
+		{Type: "image", Source: &ContentSource{Type: "base64", MediaType: "image/png", Data: base64.StdEncoding.EncodeToString(visionPNG)}},
+		{Type: "text", Text: visionPrompt},
+	}}}
+}
+
+// geminiVisionContents returns the user content with the probe image in the Gemini format
+func geminiVisionContents() []GeminiContent {
+	return []GeminiContent{{Role: "user", Parts: []GeminiPart{
+		{InlineData: &GeminiInlineData{MimeType: "image/png", Data: base64.StdEncoding.EncodeToString(visionPNG)}},
+		{Text: visionPrompt},
+	}}}
+}
+
+// answerText extracts the answer of an OpenAI, Anthropic or Gemini chat response, or of a Responses API response
+func answerText(body []byte) string {
+	var responses ResponsesResponse
+	if err := json.Unmarshal(body, &responses); err == nil && responses.Object == "response" {
+		return responses.text()
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		Candidates []struct {
+			Content GeminiContent `json:"content"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+	var text []string
+	for _, c := range resp.Choices {
+		text = append(text, c.Message.Content)
+	}
+	for _, c := range resp.Content {
+		text = append(text, c.Text)
+	}
+	for _, c := range resp.Candidates {
+		for _, p := range c.Content.Parts {
+			text = append(text, p.Text)
+		}
+	}
+	return strings.Join(text, " ")
+}
+
+// redWord matches the color as a word, not inside "shared" or "rendered"
+var redWord = regexp.MustCompile(`(?i)\bred\b`)
+
+// sawImage reports whether the answer names the color of the probe image
+func sawImage(answer string) bool {
+	return redWord.MatchString(answer) || strings.Contains(answer, "红")
+}
+
+// checkVision sends the probe image to the model of cfg and returns the vision verdict.
+// Only a client error other than 429 rejects the image, a relay that is rate limited or down is unknown.
+func (ct *ChannelTest) checkVision(ctx context.Context, cfg *TestConfig) string {
+	visionCfg := *cfg
+	visionCfg.RequestOpts.Vision = true
+	visionCfg.RequestOpts.Stream = false
+	req, err := ct.requestBuilder.BuildRequest(ctx, &visionCfg)
+	if err != nil {
+		return VisionRejected
+	}
+	resp, err := ct.client.Do(req)
+	if err != nil {
+		logger.Debug("Vision check of %s failed: %v", cfg.Model, err)
+		return VisionUnknown
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxDetailBody))
+	if err != nil {
+		logger.Debug("Vision check of %s failed: %v", cfg.Model, err)
+		return VisionUnknown
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Debug("Vision check of %s returned %d: %s", cfg.Model, resp.StatusCode, bytes.TrimSpace(body))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return VisionUnknown
+		}
+		return VisionRejected
+	}
+	if !sawImage(answerText(body)) {
+		return VisionIgnored
+	}
+	return VisionSupported
+}
+
+// solidPNG encodes a size x size PNG of a single color
+func solidPNG(size int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
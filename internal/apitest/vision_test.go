@@ -0,0 +1,82 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisionCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		content := string(req.Messages[0].Content)
+		if strings.Contains(content, "image_url") {
+			assert.Contains(t, content, "data:image/png;base64,")
+			switch req.Model {
+			case "gpt-4o":
+				w.Write([]byte(`{"choices":[{"message":{"content":"Red"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+			case "gpt-3.5-turbo":
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":{"message":"image input is not supported"}}`))
+			case "gpt-4.1":
+				// An upstream outage says nothing about image input
+				w.WriteHeader(http.StatusServiceUnavailable)
+			default:
+				// A relay that drops the image part, the model guesses
+				w.Write([]byte(`{"choices":[{"message":{"content":"Blue"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+			}
+			return
+		}
+		w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	channels := []*Channel{{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL, TestModel: []string{"gpt-4o", "gpt-3.5-turbo", "gpt-4o-mini", "gpt-4.1"}}}
+	var buf bytes.Buffer
+	ct := NewApiTest(4, WithVisionCheck(), WithPrinter(util.NewPrinter(&buf)))
+	results := ct.TestAllApis(context.Background(), channels)
+	if assert.Len(t, results, 4) {
+		assert.Equal(t, VisionSupported, results[0].Vision)
+		assert.Equal(t, VisionRejected, results[1].Vision)
+		assert.Equal(t, VisionIgnored, results[2].Vision)
+		assert.Equal(t, VisionUnknown, results[3].Vision)
+	}
+	ct.PrintResults(results)
+	for _, label := range visionLabels {
+		assert.Contains(t, buf.String(), label)
+	}
+}
+
+func TestVisionRequests(t *testing.T) {
+	cfg := &TestConfig{Channel: &Channel{Type: ChannelTypeAnthropic}, Model: "claude-3-5-haiku-20241022", RequestOpts: DefaultRequestOptions()}
+	cfg.RequestOpts.Vision = true
+	body, err := json.Marshal(NewRequestBuilder().buildAnthropicRequest(cfg))
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"source":{"type":"base64","media_type":"image/png"`)
+
+	body, err = json.Marshal(NewRequestBuilder().buildGeminiRequest(cfg))
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"inlineData":{"mimeType":"image/png"`)
+
+	assert.Equal(t, "红色", answerText([]byte(`{"candidates":[{"content":{"parts":[{"text":"红色"}]}}]}`)))
+	assert.True(t, sawImage("Red."))
+	assert.True(t, sawImage("The image is solid red"))
+	assert.True(t, sawImage("红色"))
+	assert.False(t, sawImage("I can't view shared images"))
+	assert.False(t, sawImage("An image is required to answer"))
+	assert.False(t, sawImage("I'm not able to view images that are rendered here"))
+	assert.Equal(t, []byte("\x89PNG"), visionPNG[:4])
+}
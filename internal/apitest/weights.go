@@ -0,0 +1,97 @@
+package apitest
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// MaxWeight is the weight suggested for the best key, others are scaled relative to it
+const MaxWeight = 100
+
+// Weight is the suggested gateway weight of a key
+type Weight struct {
+	Key     string
+	Success int
+	Total   int
+	Latency float64 // 成功请求的平均延迟 (秒)
+	Weight  int
+}
+
+// score returns success rate × inverse latency, 0 when the key never succeeded
+func (w Weight) score() float64 {
+	if w.Success == 0 || w.Latency <= 0 {
+		return 0
+	}
+	return float64(w.Success) / float64(w.Total) / w.Latency
+}
+
+// SuggestWeights computes gateway weights for the keys from their test results.
+// Keys that never succeeded get weight 0, every working key gets at least 1.
+func SuggestWeights(keys []string, results []TestResult) []Weight {
+	index := make(map[string]int, len(keys))
+	weights := make([]Weight, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := index[key]; ok {
+			continue
+		}
+		index[key] = len(weights)
+		weights = append(weights, Weight{Key: key})
+	}
+
+	for _, result := range results {
+		if result.Channel == nil {
+			continue
+		}
+		i, ok := index[result.Channel.Key]
+		if !ok {
+			continue
+		}
+		weights[i].Total++
+		if result.Success {
+			weights[i].Success++
+			weights[i].Latency += result.Latency
+		}
+	}
+
+	best := 0.0
+	for i := range weights {
+		if weights[i].Success > 0 {
+			weights[i].Latency /= float64(weights[i].Success)
+		}
+		best = math.Max(best, weights[i].score())
+	}
+	if best == 0 {
+		return weights
+	}
+	for i := range weights {
+		if score := weights[i].score(); score > 0 {
+			weights[i].Weight = util.Max(1, int(math.Round(score/best*MaxWeight)))
+		}
+	}
+	return weights
+}
+
+// PrintWeights prints the suggested gateway weights
+func PrintWeights(printer *util.Printer, weights []Weight) {
+	printer.PrintTitle("权重建议", util.EmojiGear)
+
+	keyWidth := 4
+	for _, w := range weights {
+		keyWidth = util.Max(keyWidth, util.StringWidth(util.MaskKey(w.Key)))
+	}
+	for _, w := range weights {
+		color := util.ColorGreen
+		stats := fmt.Sprintf("%d/%d %s", w.Success, w.Total, util.FormatLatency(w.Latency))
+		if w.Weight == 0 {
+			color = util.ColorRed
+			stats = fmt.Sprintf("%d/%d", w.Success, w.Total)
+		}
+		printer.Printf("%s%s%s  %s权重 %3d%s  (%s)\n",
+			util.ColorYellow, util.PadRight(util.MaskKey(w.Key), keyWidth), util.ColorReset,
+			color, w.Weight, util.ColorReset, stats)
+	}
+	printer.Printf("%s权重 = 成功率 / 平均延迟，按最优 Key 为 %d 缩放，可直接填入 one-api/new-api 渠道权重%s\n",
+		util.ColorGray, MaxWeight, util.ColorReset)
+}
@@ -0,0 +1,32 @@
+package apitest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestWeights(t *testing.T) {
+	fast := &Channel{Key: "sk-fast"}
+	slow := &Channel{Key: "sk-slow"}
+	flaky := &Channel{Key: "sk-flaky"}
+	dead := &Channel{Key: "sk-dead"}
+	results := []TestResult{
+		{Channel: fast, Success: true, Latency: 0.5},
+		{Channel: fast, Success: true, Latency: 0.5},
+		{Channel: slow, Success: true, Latency: 2},
+		{Channel: slow, Success: true, Latency: 2},
+		{Channel: flaky, Success: true, Latency: 0.5},
+		{Channel: flaky, Error: errors.New("429")},
+		{Channel: dead, Error: errors.New("401")},
+	}
+
+	weights := SuggestWeights([]string{"sk-fast", "sk-slow", "sk-flaky", "sk-dead", "sk-fast"}, results)
+	assert.Len(t, weights, 4)
+	assert.Equal(t, MaxWeight, weights[0].Weight)
+	assert.Equal(t, 25, weights[1].Weight)
+	assert.Equal(t, 50, weights[2].Weight)
+	assert.Equal(t, 0, weights[3].Weight)
+	assert.Equal(t, 2.0, weights[1].Latency)
+}
@@ -0,0 +1,82 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"mime/multipart"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Parameters of the WAV the transcription test uploads: half a second of 16 kHz mono silence, about 16 KB
+const (
+	probeSampleRate = 16000
+	probeSamples    = probeSampleRate / 2
+)
+
+// probeWAV is the audio file of the transcription test
+var probeWAV = silentWAV(probeSampleRate, probeSamples)
+
+// TranscriptionResponse is the JSON body of /v1/audio/transcriptions
+type TranscriptionResponse struct {
+	Text *string `json:"text"`
+}
+
+// IsTranscriptionModel reports whether model is a Whisper speech-to-text model (whisper-1, whisper-large-v3, ...),
+// which is tested through /v1/audio/transcriptions instead of chat
+func IsTranscriptionModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(modelName(model)), "whisper")
+}
+
+// servesAudio reports whether channels of the type are tested with the OpenAI audio routes
+func servesAudio(channelType ChannelType) bool {
+	switch channelType {
+	case ChannelTypeOpenAI, ChannelTypeGroq, ChannelTypeLocal:
+		return true
+	}
+	return false
+}
+
+// transcriptionEndpoint returns the transcription route next to the chat route of apiURL
+func transcriptionEndpoint(apiURL string) string {
+	return strings.TrimRight(util.BaseURL(apiURL), "/") + "/v1/audio/transcriptions"
+}
+
+// transcriptionForm builds the multipart body uploading the probe WAV for model, it returns the body and its content type
+func transcriptionForm(model string) ([]byte, string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("model", model)
+	w.WriteField("response_format", "json")
+	part, err := w.CreateFormFile("file", "check-gpt-probe.wav")
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(probeWAV)
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), w.FormDataContentType(), nil
+}
+
+// silentWAV returns a 16-bit mono PCM WAV file of the given number of silent samples
+func silentWAV(sampleRate, samples int) []byte {
+	const bytesPerSample = 2
+	dataSize := samples * bytesPerSample
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+dataSize))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))                        // fmt chunk size
+	binary.Write(&b, binary.LittleEndian, uint16(1))                         // PCM
+	binary.Write(&b, binary.LittleEndian, uint16(1))                         // mono
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate))                // sample rate
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate*bytesPerSample)) // byte rate
+	binary.Write(&b, binary.LittleEndian, uint16(bytesPerSample))            // block align
+	binary.Write(&b, binary.LittleEndian, uint16(8*bytesPerSample))          // bits per sample
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(dataSize))
+	b.Write(make([]byte, dataSize))
+	return b.Bytes()
+}
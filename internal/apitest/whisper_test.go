@@ -0,0 +1,49 @@
+package apitest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscription(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/audio/transcriptions":
+			assert.Equal(t, "Bearer "+testLiveKey, r.Header.Get("Authorization"))
+			assert.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "whisper-1", r.FormValue("model"))
+			file, _, err := r.FormFile("file")
+			if assert.NoError(t, err) {
+				data, _ := io.ReadAll(file)
+				assert.Equal(t, "RIFF", string(data[:4]))
+				assert.Equal(t, len(probeWAV), len(data))
+			}
+			w.Write([]byte(`{"text":""}`))
+		default:
+			w.Write([]byte(`{"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+		}
+	}))
+	defer srv.Close()
+
+	channel := &Channel{Type: ChannelTypeOpenAI, Key: testLiveKey, URL: srv.URL + "/v1/chat/completions", TestModel: []string{"gpt-4o-mini", "whisper-1"}}
+	results := NewApiTest(4).TestAllApis(context.Background(), []*Channel{channel})
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Success)
+		assert.True(t, results[1].Success, "%v", results[1].Error)
+		assert.Equal(t, "whisper-1", results[1].Model)
+	}
+}
+
+func TestIsTranscriptionModel(t *testing.T) {
+	assert.True(t, IsTranscriptionModel("whisper-1"))
+	assert.True(t, IsTranscriptionModel("whisper-large-v3-turbo"))
+	assert.True(t, IsTranscriptionModel("openai/whisper-1"))
+	assert.False(t, IsTranscriptionModel("gpt-4o-mini"))
+	assert.Equal(t, "https://api.groq.com/openai/v1/audio/transcriptions", transcriptionEndpoint("https://api.groq.com/openai/v1/chat/completions"))
+	assert.Len(t, probeWAV, 44+2*probeSamples)
+}
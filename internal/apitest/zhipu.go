@@ -0,0 +1,109 @@
+package apitest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ZhipuHost is the host of the Zhipu BigModel open platform
+const ZhipuHost = "open.bigmodel.cn"
+
+// ZhipuKeyIDLength is the length of the hex id before the dot of a Zhipu key
+const ZhipuKeyIDLength = 32
+
+// ZhipuTokenTTL is how long the JWT signed for a Zhipu request stays valid
+const ZhipuTokenTTL = 30 * time.Minute
+
+// zhipuCodes explains the Zhipu error codes a key test commonly runs into
+var zhipuCodes = map[string]string{
+	"1000": "鉴权失败",
+	"1001": "未携带鉴权 Token",
+	"1002": "鉴权 Token 无效",
+	"1003": "鉴权 Token 已过期",
+	"1004": "鉴权 Token 签名错误",
+	"1113": "账户已欠费, 请充值",
+	"1211": "模型不存在",
+	"1220": "无权访问该模型",
+	"1261": "上下文超长",
+	"1301": "输入或输出包含敏感内容",
+	"1302": "并发数超限",
+	"1303": "请求频率超限",
+	"1304": "当日调用次数超限",
+	"1305": "服务繁忙, 请稍后重试",
+}
+
+// SplitZhipuKey splits a Zhipu key into the id and secret joined by its dot
+func SplitZhipuKey(key string) (id, secret string, ok bool) {
+	id, secret, ok = strings.Cut(key, ".")
+	return id, secret, ok && id != "" && secret != "" && !strings.Contains(secret, ".")
+}
+
+// IsZhipuKey reports whether key has the shape of a Zhipu API key: a 32 character hex id, a dot and a secret
+func IsZhipuKey(key string) bool {
+	id, secret, ok := SplitZhipuKey(key)
+	if !ok || len(id) != ZhipuKeyIDLength || hasKeyPrefix(key) {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	for _, r := range secret {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// IsZhipuURL reports whether rawURL points to the Zhipu BigModel API
+func IsZhipuURL(rawURL string) bool {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), ZhipuHost)
+}
+
+// ZhipuToken signs the HS256 JWT Zhipu takes in place of the key, the id is the api_key claim
+// and the secret the signing key. Timestamps are in milliseconds.
+func ZhipuToken(key string, now time.Time) (string, error) {
+	id, secret, ok := SplitZhipuKey(key)
+	if !ok {
+		return "", fmt.Errorf("智谱 Key 应为 id.secret 格式")
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "sign_type": "SIGN"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"api_key":   id,
+		"exp":       now.Add(ZhipuTokenTTL).UnixMilli(),
+		"timestamp": now.UnixMilli(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// formatZhipuError formats a Zhipu error body, which takes the OpenAI shape with a numeric code,
+// ok is false for other bodies and unknown codes
+func formatZhipuError(status int, errBody string) (string, bool) {
+	var e OpenAIError
+	if err := json.Unmarshal([]byte(errBody), &e); err != nil || e.Error.Message == "" {
+		return "", false
+	}
+	hint, known := zhipuCodes[e.Error.Code]
+	if !known {
+		return "", false
+	}
+	return fmt.Sprintf("code: %d message: %s code: %s (%s)", status, e.Error.Message, e.Error.Code, hint), true
+}
@@ -0,0 +1,85 @@
+package apitest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testZhipuKey = "0123456789abcdef0123456789abcdef.AbCdEfGhIjKlMnOp"
+
+func TestZhipuKey(t *testing.T) {
+	assert.True(t, IsZhipuKey(testZhipuKey))
+	assert.False(t, IsZhipuKey("sk-0123456789abcdef0123456789abcdef.AbCd"))
+	assert.False(t, IsZhipuKey("0123456789abcdef.AbCdEfGhIjKlMnOp"))
+	assert.False(t, IsZhipuKey(strings.Repeat("a", 40)))
+
+	assert.NoError(t, ValidateKey(ChannelTypeZhipu, testZhipuKey))
+	assert.Error(t, ValidateKey(ChannelTypeZhipu, "sk-0123456789abcdef0123456789abcdef"))
+
+	assert.True(t, IsZhipuURL("https://open.bigmodel.cn/api/paas/v4"))
+	assert.True(t, IsZhipuURL("open.bigmodel.cn"))
+	assert.False(t, IsZhipuURL("https://api.openai.com/v1"))
+}
+
+func TestZhipuToken(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	token, err := ZhipuToken(testZhipuKey, now)
+	assert.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	assert.Len(t, parts, 3)
+	header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	assert.JSONEq(t, `{"alg":"HS256","sign_type":"SIGN"}`, string(header))
+	var claims map[string]interface{}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", claims["api_key"])
+	assert.Equal(t, float64(1700000000000), claims["timestamp"])
+	assert.Equal(t, float64(now.Add(ZhipuTokenTTL).UnixMilli()), claims["exp"])
+
+	mac := hmac.New(sha256.New, []byte("AbCdEfGhIjKlMnOp"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), parts[2])
+
+	_, err = ZhipuToken("sk-nodot", now)
+	assert.Error(t, err)
+}
+
+func TestZhipuRequest(t *testing.T) {
+	req, err := NewRequestBuilder().BuildRequest(context.Background(), &TestConfig{
+		Channel:     &Channel{Type: ChannelTypeZhipu, Key: testZhipuKey, URL: config.ZhipuTestUrl},
+		Model:       "glm-4-flash",
+		RequestOpts: DefaultRequestOptions(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, config.ZhipuTestUrl, req.URL.String())
+	auth := req.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "Bearer "))
+	assert.NotContains(t, auth, "AbCdEfGhIjKlMnOp")
+	assert.Len(t, strings.Split(strings.TrimPrefix(auth, "Bearer "), "."), 3)
+
+	_, err = NewRequestBuilder().BuildRequest(context.Background(), &TestConfig{
+		Channel: &Channel{Type: ChannelTypeZhipu, Key: "nodot", URL: config.ZhipuTestUrl},
+		Model:   "glm-4-flash",
+	})
+	assert.Error(t, err)
+}
+
+func TestZhipuError(t *testing.T) {
+	result := NewResultProcessor(testZhipuKey, "glm-4-flash").ProcessResponse(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"code":"1113","message":"您的账户已欠费，请充值后重试。"}}`)),
+	})
+	assert.Equal(t, "code: 429 message: 您的账户已欠费，请充值后重试。 code: 1113 (账户已欠费, 请充值)", result.Error.Error())
+}
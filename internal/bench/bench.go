@@ -0,0 +1,240 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Significance is the p-value below which a difference between the endpoints is reported as significant
+const Significance = 0.05
+
+// Sample is the outcome of one benchmark request
+type Sample struct {
+	OK      bool
+	Latency float64 // 秒
+	Reset   bool    // 连接被重置或意外关闭
+}
+
+// Request sends one benchmark request
+type Request func(ctx context.Context) Sample
+
+// Stats summarizes the samples of one endpoint
+type Stats struct {
+	Name     string
+	Samples  []Sample
+	Duration time.Duration // 全部请求的墙钟耗时
+}
+
+// Run sends n requests, at most concurrency at a time, and collects their samples.
+// Requests not sent before ctx is cancelled are left out.
+func Run(ctx context.Context, name string, n, concurrency int, request Request) Stats {
+	concurrency = max(1, min(concurrency, n))
+	samples := make([]Sample, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	sent := 0
+	for ; sent < n && ctx.Err() == nil; sent++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			samples[i] = request(ctx)
+		}(sent)
+	}
+	wg.Wait()
+	return Stats{Name: name, Samples: samples[:sent], Duration: time.Since(start)}
+}
+
+// Errors returns the number of failed requests
+func (s Stats) Errors() int {
+	errors := 0
+	for _, sample := range s.Samples {
+		if !sample.OK {
+			errors++
+		}
+	}
+	return errors
+}
+
+// ErrorRate returns the share of failed requests
+func (s Stats) ErrorRate() float64 {
+	if len(s.Samples) == 0 {
+		return 0
+	}
+	return float64(s.Errors()) / float64(len(s.Samples))
+}
+
+// Latencies returns the sorted latencies of the successful requests
+func (s Stats) Latencies() []float64 {
+	var latencies []float64
+	for _, sample := range s.Samples {
+		if sample.OK {
+			latencies = append(latencies, sample.Latency)
+		}
+	}
+	sort.Float64s(latencies)
+	return latencies
+}
+
+// Mean returns the mean latency of the successful requests
+func (s Stats) Mean() float64 {
+	latencies := s.Latencies()
+	if len(latencies) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, l := range latencies {
+		sum += l
+	}
+	return sum / float64(len(latencies))
+}
+
+// Percentile returns the p-th percentile (0-100) latency of the successful requests
+func (s Stats) Percentile(p float64) float64 {
+	latencies := s.Latencies()
+	if len(latencies) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(latencies)))) - 1
+	return latencies[max(0, min(i, len(latencies)-1))]
+}
+
+// Throughput returns the successful requests per second over the whole run
+func (s Stats) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(len(s.Samples)-s.Errors()) / s.Duration.Seconds()
+}
+
+// Comparison is the statistical comparison of two endpoints, B against A
+type Comparison struct {
+	A, B         Stats
+	LatencyDelta float64 // B 与 A 的平均延迟之差 (秒)
+	LatencyP     float64 // 延迟差异的 p 值 (Mann-Whitney U 检验), 样本不足时为 NaN
+	ErrorP       float64 // 错误率差异的 p 值 (双比例 z 检验), 样本不足时为 NaN
+}
+
+// Compare compares the latency and error rate of b against a
+func Compare(a, b Stats) Comparison {
+	return Comparison{
+		A:            a,
+		B:            b,
+		LatencyDelta: b.Mean() - a.Mean(),
+		LatencyP:     mannWhitney(a.Latencies(), b.Latencies()),
+		ErrorP:       twoProportion(a.Errors(), len(a.Samples), b.Errors(), len(b.Samples)),
+	}
+}
+
+// mannWhitney returns the two-sided p-value of the Mann-Whitney U test with the normal approximation,
+// latencies are skewed so a rank test fits better than a t-test
+func mannWhitney(a, b []float64) float64 {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if len(a) < 2 || len(b) < 2 {
+		return math.NaN()
+	}
+	type ranked struct {
+		v     float64
+		first bool
+	}
+	all := make([]ranked, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, ranked{v, true})
+	}
+	for _, v := range b {
+		all = append(all, ranked{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Ties share their average rank and shrink the variance
+	rankSum, ties := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	u := rankSum - n1*(n1+1)/2
+	n := n1 + n2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (u - n1*n2/2) / sigma
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// twoProportion returns the two-sided p-value of the z-test for the difference of two error rates
+func twoProportion(x1, n1, x2, n2 int) float64 {
+	if n1 == 0 || n2 == 0 {
+		return math.NaN()
+	}
+	p := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(p * (1 - p) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1
+	}
+	z := (float64(x1)/float64(n1) - float64(x2)/float64(n2)) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// significance describes a p-value
+func significance(p float64) string {
+	switch {
+	case math.IsNaN(p):
+		return "样本不足, 无法判断"
+	case p < Significance:
+		return fmt.Sprintf("差异显著 (p=%.3f)", p)
+	default:
+		return fmt.Sprintf("差异不显著 (p=%.3f)", p)
+	}
+}
+
+// Print prints the statistics of both endpoints side by side followed by the comparison
+func Print(p *util.Printer, c Comparison) {
+	p.PrintTitle("基准对比", util.EmojiDone)
+	width := max(util.StringWidth(c.A.Name), util.StringWidth(c.B.Name), 12)
+	row := func(label, a, b string) {
+		p.Printf("%s %s %s\n", util.PadRight(label, 10), util.PadRight(a, width), b)
+	}
+	row("", c.A.Name, c.B.Name)
+	row("请求数", fmt.Sprint(len(c.A.Samples)), fmt.Sprint(len(c.B.Samples)))
+	row("错误率", fmt.Sprintf("%.1f%%", c.A.ErrorRate()*100), fmt.Sprintf("%.1f%%", c.B.ErrorRate()*100))
+	row("平均延迟", util.FormatLatency(c.A.Mean()), util.FormatLatency(c.B.Mean()))
+	row("P50", util.FormatLatency(c.A.Percentile(50)), util.FormatLatency(c.B.Percentile(50)))
+	row("P95", util.FormatLatency(c.A.Percentile(95)), util.FormatLatency(c.B.Percentile(95)))
+	row("吞吐量", fmt.Sprintf("%.2f 次/秒", c.A.Throughput()), fmt.Sprintf("%.2f 次/秒", c.B.Throughput()))
+
+	p.Printf("\n延迟: %s\n", c.latencyVerdict())
+	p.Printf("错误率: %s 比 %s %+.1f 个百分点, %s\n", c.B.Name, c.A.Name, (c.B.ErrorRate()-c.A.ErrorRate())*100, significance(c.ErrorP))
+	p.PrintSummary("%s vs %s: 延迟 %s, 错误率 %.1f%% vs %.1f%%", c.A.Name, c.B.Name, c.latencyVerdict(), c.A.ErrorRate()*100, c.B.ErrorRate()*100)
+}
+
+// latencyVerdict describes how much slower or faster B is than A
+func (c Comparison) latencyVerdict() string {
+	if c.A.Mean() == 0 || c.B.Mean() == 0 {
+		return "无成功请求, 无法比较"
+	}
+	word := "慢"
+	if c.LatencyDelta < 0 {
+		word = "快"
+	}
+	return fmt.Sprintf("%s 比 %s %s %s (%.0f%%), %s", c.B.Name, c.A.Name, word,
+		util.FormatLatency(math.Abs(c.LatencyDelta)), math.Abs(c.LatencyDelta)/c.A.Mean()*100, significance(c.LatencyP))
+}
@@ -0,0 +1,85 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var calls, running, peak atomic.Int32
+	s := Run(context.Background(), "a", 10, 3, func(ctx context.Context) Sample {
+		n := calls.Add(1)
+		cur := running.Add(1)
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return Sample{OK: n%5 != 0, Latency: float64(n) / 10}
+	})
+	assert.Len(t, s.Samples, 10)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Equal(t, 2, s.Errors())
+	assert.InDelta(t, 0.2, s.ErrorRate(), 1e-9)
+	assert.Len(t, s.Latencies(), 8)
+
+	// Requests not sent before cancellation are not counted as errors
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s = Run(ctx, "b", 10, 3, func(ctx context.Context) Sample { return Sample{} })
+	assert.Empty(t, s.Samples)
+	assert.Zero(t, s.ErrorRate())
+}
+
+func TestPercentile(t *testing.T) {
+	s := Stats{Samples: []Sample{{OK: true, Latency: 3}, {OK: true, Latency: 1}, {OK: false, Latency: 100}, {OK: true, Latency: 2}, {OK: true, Latency: 4}}}
+	assert.Equal(t, 2.0, s.Percentile(50))
+	assert.Equal(t, 4.0, s.Percentile(95))
+	assert.Equal(t, 2.5, s.Mean())
+	assert.Zero(t, Stats{}.Percentile(50))
+}
+
+func samples(ok bool, latencies ...float64) []Sample {
+	s := make([]Sample, len(latencies))
+	for i, l := range latencies {
+		s[i] = Sample{OK: ok, Latency: l}
+	}
+	return s
+}
+
+func TestCompare(t *testing.T) {
+	fast := Stats{Name: "a", Samples: samples(true, 0.10, 0.12, 0.11, 0.13, 0.09, 0.10, 0.12, 0.11, 0.10, 0.13)}
+	slow := Stats{Name: "b", Samples: samples(true, 0.30, 0.32, 0.31, 0.29, 0.33, 0.30, 0.31, 0.34, 0.28, 0.30)}
+	c := Compare(fast, slow)
+	assert.InDelta(t, 0.2, c.LatencyDelta, 0.01)
+	assert.Less(t, c.LatencyP, Significance)
+	assert.Equal(t, 1.0, c.ErrorP)
+
+	// Identical endpoints show no significant difference
+	c = Compare(fast, fast)
+	assert.Greater(t, c.LatencyP, Significance)
+
+	// Too few successful requests cannot be tested
+	c = Compare(fast, Stats{Samples: append(samples(true, 0.2), samples(false, 0, 0, 0, 0, 0, 0, 0, 0, 0)...)})
+	assert.True(t, math.IsNaN(c.LatencyP))
+	assert.Less(t, c.ErrorP, Significance)
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	a := Stats{Name: "vendor-a", Samples: samples(true, 0.1, 0.1, 0.1), Duration: time.Second}
+	b := Stats{Name: "vendor-b", Samples: samples(true, 0.2, 0.2, 0.2), Duration: time.Second}
+	Print(util.NewPrinter(&buf), Compare(a, b))
+	assert.Contains(t, buf.String(), "vendor-b 比 vendor-a 慢 100ms (100%)")
+	assert.Contains(t, buf.String(), "3.00 次/秒")
+}
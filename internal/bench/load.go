@@ -0,0 +1,172 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Bounds of a load test, it validates the capacity of an endpoint and must not turn into a flood
+const (
+	MaxLoadRPS      = 50
+	MaxLoadDuration = 10 * time.Minute
+
+	// maxInFlight caps the requests waiting for a response, ticks above it are dropped instead of queued
+	maxInFlight = 256
+)
+
+// TimedSample is a sample of a load test with the time its request was sent, from the start of the test
+type TimedSample struct {
+	Sample
+	At time.Duration
+}
+
+// LoadResult holds the samples of a load test
+type LoadResult struct {
+	Name     string
+	RPS      float64       // 目标请求速率
+	Duration time.Duration // 计划时长
+	Elapsed  time.Duration // 实际耗时, 含等待最后的响应
+	Samples  []TimedSample
+	Dropped  int // 在途请求过多而未发送的请求
+}
+
+// ValidRPS reports whether rps is a request rate Load accepts, NaN is not
+func ValidRPS(rps float64) bool {
+	return rps > 0 && rps <= MaxLoadRPS
+}
+
+// Load sends requests at a fixed rate for the duration without waiting for earlier responses,
+// so a slow endpoint shows up as rising latency and errors rather than a lower send rate.
+// It sends nothing for a rate ValidRPS rejects or a non-positive duration.
+func Load(ctx context.Context, name string, rps float64, duration time.Duration, request Request) LoadResult {
+	r := LoadResult{Name: name, RPS: rps, Duration: duration}
+	if !ValidRPS(rps) || duration <= 0 {
+		return r
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxInFlight)
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+	defer ticker.Stop()
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+
+	send := func() {
+		select {
+		case sem <- struct{}{}:
+		default:
+			r.Dropped++
+			return
+		}
+		wg.Add(1)
+		go func(at time.Duration) {
+			defer func() { <-sem; wg.Done() }()
+			s := TimedSample{Sample: request(ctx), At: at}
+			mu.Lock()
+			r.Samples = append(r.Samples, s)
+			mu.Unlock()
+		}(time.Since(start))
+	}
+
+	send()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			// The last tick may race the deadline
+			if time.Since(start) < duration {
+				send()
+			}
+		}
+	}
+	wg.Wait()
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+// Stats returns the samples of the load test as benchmark statistics
+func (r LoadResult) Stats() Stats {
+	samples := make([]Sample, len(r.Samples))
+	for i, s := range r.Samples {
+		samples[i] = s.Sample
+	}
+	return Stats{Name: r.Name, Samples: samples, Duration: r.Elapsed}
+}
+
+// Window is the outcome of the requests sent in one period of a load test
+type Window struct {
+	Start, End time.Duration
+	Stats
+}
+
+// Windows splits the samples into periods of the given width by the time they were sent
+func (r LoadResult) Windows(width time.Duration) []Window {
+	if width <= 0 {
+		return nil
+	}
+	var windows []Window
+	for start := time.Duration(0); start < r.span(); start += width {
+		w := Window{Start: start, End: min(start+width, r.span())}
+		for _, s := range r.Samples {
+			if s.At >= w.Start && s.At < w.End {
+				w.Samples = append(w.Samples, s.Sample)
+			}
+		}
+		w.Duration = w.End - w.Start
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+// span is the time requests were sent for, shorter than planned when the test was cancelled
+func (r LoadResult) span() time.Duration {
+	if r.Elapsed > 0 && r.Elapsed < r.Duration {
+		return r.Elapsed
+	}
+	return r.Duration
+}
+
+// windowWidth divides the test into about ten periods of whole seconds
+func windowWidth(d time.Duration) time.Duration {
+	return max(time.Second, (d / 10).Round(time.Second))
+}
+
+// PrintLoad prints the sustained throughput, the latency distribution and the error rate over time of a load test
+func PrintLoad(p *util.Printer, r LoadResult) {
+	s := r.Stats()
+	p.PrintTitle("压测结果", util.EmojiDone)
+	sent := float64(len(r.Samples)) / r.span().Seconds()
+	p.Printf("目标速率: %.2f 次/秒, 实际发送: %.2f 次/秒 (%d 次请求)\n", r.RPS, sent, len(r.Samples))
+	p.Printf("持续吞吐量: %.2f 次/秒 (成功请求)\n", s.Throughput())
+	p.Printf("错误率: %.1f%% (%d/%d)\n", s.ErrorRate()*100, s.Errors(), len(s.Samples))
+	if r.Dropped > 0 {
+		p.Printf("%s在途请求超过 %d, %d 次请求未发送%s\n", util.ColorYellow, maxInFlight, r.Dropped, util.ColorReset)
+	}
+	p.Printf("延迟分布: P50 %s  P90 %s  P95 %s  P99 %s  最大 %s\n",
+		util.FormatLatency(s.Percentile(50)), util.FormatLatency(s.Percentile(90)), util.FormatLatency(s.Percentile(95)), util.FormatLatency(s.Percentile(99)), util.FormatLatency(s.Percentile(100)))
+
+	p.Printf("\n%s %s %s %s\n", util.PadRight("时间段", 14), util.PadRight("请求数", 8), util.PadRight("P50", 8), "错误率")
+	for _, w := range r.Windows(windowWidth(r.span())) {
+		span := fmt.Sprintf("%ds-%ds", int(w.Start.Seconds()), int(w.End.Seconds()))
+		p.Printf("%s %s %s %5.1f%% %s\n", util.PadRight(span, 14), util.PadRight(fmt.Sprint(len(w.Samples)), 8),
+			util.PadRight(util.FormatLatency(w.Percentile(50)), 8), w.ErrorRate()*100, errorBar(w.ErrorRate()))
+	}
+	p.PrintSummary("%s: %.2f 次/秒, 错误率 %.1f%%, P50 %s, P99 %s", r.Name, s.Throughput(), s.ErrorRate()*100, util.FormatLatency(s.Percentile(50)), util.FormatLatency(s.Percentile(99)))
+}
+
+// errorBar draws an error rate as a bar of up to ten marks
+func errorBar(rate float64) string {
+	n := int(rate*10 + 0.5)
+	return util.ColorRed + strings.Repeat("#", n) + util.ColorReset
+}
@@ -0,0 +1,72 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	r := Load(context.Background(), "a", 50, 200*time.Millisecond, func(ctx context.Context) Sample {
+		// Responses slower than the send interval do not hold back the rate
+		time.Sleep(50 * time.Millisecond)
+		return Sample{OK: true, Latency: 0.05}
+	})
+	assert.InDelta(t, 10, len(r.Samples), 2)
+	assert.Zero(t, r.Dropped)
+	assert.GreaterOrEqual(t, r.Elapsed, 200*time.Millisecond)
+	for _, s := range r.Samples {
+		assert.Less(t, s.At, 200*time.Millisecond)
+	}
+
+	// Invalid rates send nothing instead of panicking in the ticker
+	for _, rps := range []float64{math.NaN(), 0, -1, math.Inf(1), MaxLoadRPS + 1} {
+		assert.False(t, ValidRPS(rps), "%v", rps)
+		r = Load(context.Background(), "a", rps, 50*time.Millisecond, func(ctx context.Context) Sample { return Sample{OK: true} })
+		assert.Empty(t, r.Samples)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	r := LoadResult{Duration: 3 * time.Second, Elapsed: 3 * time.Second, Samples: []TimedSample{
+		{Sample{OK: true, Latency: 0.1}, 100 * time.Millisecond},
+		{Sample{OK: true, Latency: 0.2}, 900 * time.Millisecond},
+		{Sample{OK: false}, 1500 * time.Millisecond},
+		{Sample{OK: true, Latency: 0.4}, 2500 * time.Millisecond},
+		{Sample{OK: false}, 2600 * time.Millisecond},
+	}}
+	windows := r.Windows(time.Second)
+	assert.Len(t, windows, 3)
+	assert.Len(t, windows[0].Samples, 2)
+	assert.Zero(t, windows[0].ErrorRate())
+	assert.Equal(t, 1.0, windows[1].ErrorRate())
+	assert.Equal(t, 0.5, windows[2].ErrorRate())
+
+	// A cancelled test only has windows for the time requests were sent
+	r.Elapsed = 1500 * time.Millisecond
+	assert.Len(t, r.Windows(time.Second), 2)
+
+	assert.Equal(t, 6*time.Second, windowWidth(time.Minute))
+	assert.Equal(t, time.Second, windowWidth(3*time.Second))
+}
+
+func TestPrintLoad(t *testing.T) {
+	var buf bytes.Buffer
+	r := LoadResult{Name: "relay", RPS: 2, Duration: 2 * time.Second, Elapsed: 2 * time.Second, Samples: []TimedSample{
+		{Sample{OK: true, Latency: 0.1}, 0},
+		{Sample{OK: true, Latency: 0.3}, 500 * time.Millisecond},
+		{Sample{OK: false}, time.Second},
+		{Sample{OK: true, Latency: 0.2}, 1500 * time.Millisecond},
+	}}
+	PrintLoad(util.NewPrinter(&buf), r)
+	assert.Contains(t, buf.String(), "实际发送: 2.00 次/秒 (4 次请求)")
+	assert.Contains(t, buf.String(), "持续吞吐量: 1.50 次/秒")
+	assert.Contains(t, buf.String(), "错误率: 25.0% (1/4)")
+	assert.Contains(t, buf.String(), "P50 200ms")
+	assert.Contains(t, buf.String(), "1s-2s")
+}
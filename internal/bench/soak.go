@@ -0,0 +1,270 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/schema"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Kinds of the events recorded during a soak test
+const (
+	EventReset = "connection_reset" // 连接被重置或意外关闭
+	EventDNS   = "dns_change"       // 域名解析结果变化
+)
+
+// MaxSoakEvents caps the events kept in the report of a soak test running for days
+const MaxSoakEvents = 500
+
+// resetMarkers are the error texts of a connection the server or a middlebox dropped
+var resetMarkers = []string{
+	"connection reset",
+	"broken pipe",
+	"unexpected EOF",
+	"server closed idle connection",
+	"forcibly closed",
+	"connection was aborted",
+}
+
+// IsConnReset reports whether a request failed because its connection was reset or closed mid-request
+func IsConnReset(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, m := range resetMarkers {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return strings.HasSuffix(msg, ": EOF")
+}
+
+// SoakEvent is a connection reset or DNS change seen during a soak test
+type SoakEvent struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Checkpoint summarizes the requests of one checkpoint period
+type Checkpoint struct {
+	Time     time.Time `json:"time"`
+	Requests int       `json:"requests"`
+	Errors   int       `json:"errors"`
+	Resets   int       `json:"connection_resets"`
+	Mean     float64   `json:"mean_latency"`  // 秒
+	P95      float64   `json:"p95_latency"`   // 秒
+	Drift    float64   `json:"latency_drift"` // 平均延迟相对首个检查点的变化比例
+}
+
+// SoakReport is the checkpoint report of a soak test, rewritten at every checkpoint
+type SoakReport struct {
+	SchemaVersion string       `json:"schema_version"`
+	Run           *schema.Run  `json:"run,omitempty"`
+	Target        string       `json:"target"`
+	Host          string       `json:"host"`
+	Started       time.Time    `json:"started"`
+	Updated       time.Time    `json:"updated"`
+	Finished      bool         `json:"finished"`
+	Requests      int          `json:"requests"`
+	Errors        int          `json:"errors"`
+	Resets        int          `json:"connection_resets"`
+	DNSChanges    int          `json:"dns_changes"`
+	Addresses     []string     `json:"addresses"` // 最近一次解析结果
+	Checkpoints   []Checkpoint `json:"checkpoints"`
+	Events        []SoakEvent  `json:"events"`
+}
+
+// Save writes the report to path, through a temporary file so a reader never sees half a report
+func (r *SoakReport) Save(path string) error {
+	r.SchemaVersion = schema.Version
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal soak report: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("保存稳定性报告失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("保存稳定性报告失败: %v", err)
+	}
+	return nil
+}
+
+// Soak keeps a small steady request flow to one endpoint for hours. Only the samples of the current
+// checkpoint period are kept in memory, so a run of days stays as small as a run of minutes.
+type Soak struct {
+	Name       string
+	Host       string
+	Interval   time.Duration // 请求间隔
+	Duration   time.Duration
+	Checkpoint time.Duration // 检查点间隔
+	Request    Request
+	Resolve    func(ctx context.Context, host string) ([]string, error)
+	ReportPath string // 检查点报告文件, 为空时不写文件
+	Printer    *util.Printer
+}
+
+// Run sends one request per interval until the duration is over or ctx is cancelled,
+// closing a checkpoint every checkpoint interval and once more at the end
+func (s *Soak) Run(ctx context.Context) *SoakReport {
+	r := &SoakReport{Run: schema.NewRun(s.Name), Target: s.Name, Host: s.Host, Started: time.Now(), Checkpoints: []Checkpoint{}, Events: []SoakEvent{}}
+	var period []Sample
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	checkpoint := time.NewTicker(s.Checkpoint)
+	defer checkpoint.Stop()
+	deadline := time.NewTimer(s.Duration)
+	defer deadline.Stop()
+
+	tick := func() {
+		s.resolve(ctx, r)
+		sample := s.Request(ctx)
+		if ctx.Err() != nil {
+			// A request cut short by cancellation says nothing about the endpoint
+			return
+		}
+		r.Requests++
+		if !sample.OK {
+			r.Errors++
+		}
+		if sample.Reset {
+			r.Resets++
+			r.addEvent(SoakEvent{Time: time.Now(), Kind: EventReset})
+		}
+		period = append(period, sample)
+	}
+
+	tick()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-checkpoint.C:
+			s.checkpoint(r, period)
+			period = nil
+		case <-ticker.C:
+			tick()
+		}
+	}
+	r.Finished = true
+	s.checkpoint(r, period)
+	return r
+}
+
+// resolve looks the host up again and records a DNS change when the addresses differ from the last lookup,
+// failed lookups are left to the request that follows
+func (s *Soak) resolve(ctx context.Context, r *SoakReport) {
+	if s.Resolve == nil || s.Host == "" {
+		return
+	}
+	addrs, err := s.Resolve(ctx, s.Host)
+	if err != nil {
+		return
+	}
+	addrs = append([]string(nil), addrs...)
+	sort.Strings(addrs)
+	if r.Addresses != nil && strings.Join(addrs, ",") != strings.Join(r.Addresses, ",") {
+		r.DNSChanges++
+		r.addEvent(SoakEvent{Time: time.Now(), Kind: EventDNS,
+			Detail: strings.Join(r.Addresses, ", ") + " → " + strings.Join(addrs, ", ")})
+	}
+	r.Addresses = addrs
+}
+
+// addEvent records an event, dropping the oldest above MaxSoakEvents
+func (r *SoakReport) addEvent(e SoakEvent) {
+	r.Events = append(r.Events, e)
+	if len(r.Events) > MaxSoakEvents {
+		r.Events = r.Events[len(r.Events)-MaxSoakEvents:]
+	}
+}
+
+// checkpoint closes a period, prints its line and rewrites the report file
+func (s *Soak) checkpoint(r *SoakReport, period []Sample) {
+	now := time.Now()
+	r.Updated = now
+	if len(period) > 0 {
+		stats := Stats{Samples: period}
+		c := Checkpoint{Time: now, Requests: len(period), Errors: stats.Errors(), Mean: stats.Mean(), P95: stats.Percentile(95)}
+		for _, sample := range period {
+			if sample.Reset {
+				c.Resets++
+			}
+		}
+		if base := r.baseline(); base > 0 && c.Mean > 0 {
+			c.Drift = c.Mean/base - 1
+		}
+		r.Checkpoints = append(r.Checkpoints, c)
+		s.printCheckpoint(r, c)
+	}
+	if s.ReportPath != "" {
+		if err := r.Save(s.ReportPath); err != nil && s.Printer != nil {
+			s.Printer.PrintWarning(err.Error())
+		}
+	}
+}
+
+// baseline returns the mean latency of the first checkpoint with a successful request
+func (r *SoakReport) baseline() float64 {
+	for _, c := range r.Checkpoints {
+		if c.Mean > 0 {
+			return c.Mean
+		}
+	}
+	return 0
+}
+
+// printCheckpoint prints the one-line summary of a checkpoint
+func (s *Soak) printCheckpoint(r *SoakReport, c Checkpoint) {
+	if s.Printer == nil {
+		return
+	}
+	s.Printer.Printf("[%s] 检查点 %d: %d 次请求, 错误 %d, 连接重置 %d, 平均延迟 %s (%+.0f%%), P95 %s\n",
+		util.FormatTime(c.Time), len(r.Checkpoints), c.Requests, c.Errors, c.Resets, util.FormatLatency(c.Mean), c.Drift*100, util.FormatLatency(c.P95))
+}
+
+// PrintSoak prints the totals of a soak test, the latency drift over the run and the recent events
+func PrintSoak(p *util.Printer, r *SoakReport) {
+	p.PrintTitle("稳定性测试结果", util.EmojiDone)
+	elapsed := r.Updated.Sub(r.Started).Round(time.Second)
+	errorRate := 0.0
+	if r.Requests > 0 {
+		errorRate = float64(r.Errors) / float64(r.Requests) * 100
+	}
+	p.Printf("持续时间: %s, 共 %d 次请求\n", elapsed, r.Requests)
+	p.Printf("错误率: %.1f%% (%d/%d)\n", errorRate, r.Errors, r.Requests)
+	p.Printf("连接重置: %d 次\n", r.Resets)
+	p.Printf("DNS 变化: %d 次, 当前解析: %s\n", r.DNSChanges, strings.Join(r.Addresses, ", "))
+	drift := 0.0
+	if n := len(r.Checkpoints); n > 0 {
+		drift = r.Checkpoints[n-1].Drift
+	}
+	p.Printf("延迟漂移: 末个检查点平均延迟比首个 %+.0f%%\n", drift*100)
+
+	const recent = 10
+	if len(r.Events) > 0 {
+		p.Printf("\n最近事件:\n")
+		for _, e := range r.Events[max(0, len(r.Events)-recent):] {
+			label := "连接重置"
+			if e.Kind == EventDNS {
+				label = "DNS 变化: " + e.Detail
+			}
+			p.Printf("   %s %s\n", util.FormatTime(e.Time), label)
+		}
+	}
+	p.PrintSummary("%s: %s, %d 次请求, 错误率 %.1f%%, 连接重置 %d, DNS 变化 %d, 延迟漂移 %+.0f%%",
+		r.Target, elapsed, r.Requests, errorRate, r.Resets, r.DNSChanges, drift*100)
+}
@@ -0,0 +1,98 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/schema"
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConnReset(t *testing.T) {
+	assert.True(t, IsConnReset(errors.New("request failed: read tcp 10.0.0.1:5000->1.2.3.4:443: read: connection reset by peer")))
+	assert.True(t, IsConnReset(errors.New(`request failed: Post "https://relay.example.com/v1/chat/completions": EOF`)))
+	assert.False(t, IsConnReset(errors.New("code: 429 message: Rate limit reached")))
+	assert.False(t, IsConnReset(nil))
+}
+
+func TestSoak(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soak.json")
+	var buf bytes.Buffer
+	calls, lookups := 0, 0
+	s := &Soak{
+		Name:       "relay",
+		Host:       "relay.example.com",
+		Interval:   10 * time.Millisecond,
+		Duration:   120 * time.Millisecond,
+		Checkpoint: 50 * time.Millisecond,
+		Request: func(ctx context.Context) Sample {
+			calls++
+			if calls == 3 {
+				return Sample{Reset: true}
+			}
+			// Latency doubles after the first checkpoint
+			if calls > 5 {
+				return Sample{OK: true, Latency: 0.2}
+			}
+			return Sample{OK: true, Latency: 0.1}
+		},
+		Resolve: func(ctx context.Context, host string) ([]string, error) {
+			lookups++
+			if lookups > 4 {
+				return []string{"5.6.7.8"}, nil
+			}
+			return []string{"1.2.3.4"}, nil
+		},
+		ReportPath: path,
+		Printer:    util.NewPrinter(&buf),
+	}
+	r := s.Run(context.Background())
+
+	assert.True(t, r.Finished)
+	assert.Equal(t, calls, r.Requests)
+	assert.Equal(t, 1, r.Errors)
+	assert.Equal(t, 1, r.Resets)
+	assert.Equal(t, 1, r.DNSChanges)
+	assert.Equal(t, []string{"5.6.7.8"}, r.Addresses)
+	assert.Equal(t, []string{EventReset, EventDNS}, []string{r.Events[0].Kind, r.Events[1].Kind})
+	assert.GreaterOrEqual(t, len(r.Checkpoints), 2)
+	assert.Zero(t, r.Checkpoints[0].Drift)
+	assert.Greater(t, r.Checkpoints[len(r.Checkpoints)-1].Drift, 0.5)
+	assert.Contains(t, buf.String(), "检查点 1:")
+
+	// The report file holds the final state
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var saved SoakReport
+	assert.NoError(t, json.Unmarshal(data, &saved))
+	assert.True(t, saved.Finished)
+	assert.Equal(t, schema.Version, saved.SchemaVersion)
+	assert.Equal(t, r.Requests, saved.Requests)
+
+	buf.Reset()
+	PrintSoak(util.NewPrinter(&buf), r)
+	assert.Contains(t, buf.String(), "连接重置: 1 次")
+	assert.Contains(t, buf.String(), "DNS 变化: 1.2.3.4 → 5.6.7.8")
+}
+
+func TestCheckpointDrift(t *testing.T) {
+	s := &Soak{}
+	r := &SoakReport{}
+	s.checkpoint(r, []Sample{{OK: false}})
+	s.checkpoint(r, []Sample{{OK: true, Latency: 0.2}, {OK: true, Latency: 0.2}})
+	s.checkpoint(r, nil)
+	s.checkpoint(r, []Sample{{OK: true, Latency: 0.3}, {OK: false, Reset: true}})
+
+	// Periods without requests add no checkpoint, the baseline is the first with a successful request
+	assert.Len(t, r.Checkpoints, 3)
+	assert.Zero(t, r.Checkpoints[1].Drift)
+	assert.InDelta(t, 0.5, r.Checkpoints[2].Drift, 1e-9)
+	assert.Equal(t, 1, r.Checkpoints[2].Resets)
+}
@@ -0,0 +1,136 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Balance represents the quota information of a key
+type Balance struct {
+	Total       float64   // 总额度 (USD)
+	Used        float64   // 已用额度 (USD)
+	Remaining   float64   // 剩余额度 (USD)
+	AccessUntil time.Time // 额度有效期，零值表示未知
+}
+
+// subscriptionResponse represents the /v1/dashboard/billing/subscription response
+type subscriptionResponse struct {
+	HardLimitUSD float64 `json:"hard_limit_usd"`
+	AccessUntil  int64   `json:"access_until"`
+}
+
+// usageResponse represents the /v1/dashboard/billing/usage response
+type usageResponse struct {
+	TotalUsage float64 `json:"total_usage"` // 单位: 美分
+}
+
+// Client queries the OpenAI style billing endpoints supported by most relays
+type Client struct {
+	client *http.Client
+}
+
+// NewClient creates a new billing client
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		client: httpclient.New(timeout),
+	}
+}
+
+// Query returns the balance of the key on the given API URL
+func (c *Client) Query(ctx context.Context, url, key string) (*Balance, error) {
+	base := util.BaseURL(url)
+
+	var sub subscriptionResponse
+	if err := c.get(ctx, base+"/v1/dashboard/billing/subscription", key, &sub); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	usageURL := fmt.Sprintf("%s/v1/dashboard/billing/usage?start_date=%s&end_date=%s",
+		base,
+		now.AddDate(0, 0, -99).Format("2006-01-02"),
+		now.AddDate(0, 0, 1).Format("2006-01-02"),
+	)
+	var usage usageResponse
+	if err := c.get(ctx, usageURL, key, &usage); err != nil {
+		return nil, err
+	}
+
+	balance := &Balance{
+		Total: sub.HardLimitUSD,
+		Used:  usage.TotalUsage / 100,
+	}
+	balance.Remaining = balance.Total - balance.Used
+	if sub.AccessUntil > 0 {
+		balance.AccessUntil = time.Unix(sub.AccessUntil, 0)
+	}
+	return balance, nil
+}
+
+// get sends an authorized GET request and decodes the JSON response into v
+func (c *Client) get(ctx context.Context, url, key string, v interface{}) error {
+	_, err := c.fetch(ctx, url, key, v)
+	return err
+}
+
+// fetch is like get but also returns the response headers, which are set whenever a response was received.
+// An empty key sends the request without authorization.
+func (c *Client) fetch(ctx context.Context, url, key string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+		logger.AddSecret(key)
+	}
+	logger.DebugRequest(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	logger.DebugResponse(resp)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Header, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.Header, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       strings.Join(strings.Fields(string(body)), " "),
+		}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return resp.Header, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return resp.Header, nil
+}
+
+// StatusError is returned when the billing endpoint responds with a non-200 status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("code: %d %s", e.StatusCode, e.Body)
+}
+
+// IsUnauthorized reports whether the key was rejected by the endpoint
+func (e *StatusError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
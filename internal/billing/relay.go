@@ -0,0 +1,156 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// DefaultQuotaPerUnit is the number of one-api/new-api quota units per USD
+const DefaultQuotaPerUnit = 500000
+
+// RelayRequestIDHeader is the response header set by one-api and its forks
+const RelayRequestIDHeader = "X-Oneapi-Request-Id"
+
+// ErrNotRelay is returned when the endpoint does not look like a one-api/new-api relay
+var ErrNotRelay = errors.New("not a one-api/new-api relay")
+
+// Relay describes a detected one-api/new-api deployment
+type Relay struct {
+	System       string // 站点名称，如 New API
+	Version      string
+	QuotaPerUnit float64     // 每美元对应的额度
+	Header       http.Header // /api/status 的响应头
+	StatusFields []string    // /api/status 返回的 data 字段, 可区分 one-api 的分支
+}
+
+// RelayQuota represents the token quota reported by a one-api/new-api relay
+type RelayQuota struct {
+	Relay       Relay
+	Name        string             // 令牌名称
+	Unlimited   bool               // 无限额度
+	Total       float64            // 总额度 (USD)
+	Used        float64            // 已用额度 (USD)
+	Remaining   float64            // 剩余额度 (USD)
+	ExpiresAt   time.Time          // 令牌有效期，零值表示永不过期或未知
+	Group       string             // 令牌分组，旧版本不返回
+	GroupRatios map[string]float64 // 各分组倍率
+}
+
+// statusResponse represents the /api/status response
+type statusResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// statusData holds the /api/status data fields shared by one-api and its forks
+type statusData struct {
+	SystemName   string  `json:"system_name"`
+	Version      string  `json:"version"`
+	QuotaPerUnit float64 `json:"quota_per_unit"`
+}
+
+// tokenUsageResponse represents the new-api /api/usage/token response, amounts are in quota units
+type tokenUsageResponse struct {
+	Code bool `json:"code"`
+	Data struct {
+		Name           string  `json:"name"`
+		Group          string  `json:"group"`
+		TotalGranted   float64 `json:"total_granted"`
+		TotalUsed      float64 `json:"total_used"`
+		TotalAvailable float64 `json:"total_available"`
+		UnlimitedQuota bool    `json:"unlimited_quota"`
+		ExpiresAt      int64   `json:"expires_at"`
+	} `json:"data"`
+}
+
+// pricingResponse represents the group ratios in the new-api /api/pricing response
+type pricingResponse struct {
+	Success    bool               `json:"success"`
+	GroupRatio map[string]float64 `json:"group_ratio"`
+}
+
+// DetectRelay checks whether the API URL is served by one-api/new-api
+func (c *Client) DetectRelay(ctx context.Context, url string) (*Relay, error) {
+	var status statusResponse
+	header, err := c.fetch(ctx, util.BaseURL(url)+"/api/status", "", &status)
+	if header == nil && err != nil {
+		return nil, err
+	}
+
+	var data statusData
+	var fields map[string]json.RawMessage
+	if err == nil && status.Success {
+		json.Unmarshal(status.Data, &data)
+		json.Unmarshal(status.Data, &fields)
+	}
+	isRelay := data.SystemName != "" || data.Version != ""
+	if !isRelay && !IsRelayResponse(header) {
+		return nil, ErrNotRelay
+	}
+
+	relay := &Relay{
+		System:       data.SystemName,
+		Version:      data.Version,
+		QuotaPerUnit: data.QuotaPerUnit,
+		Header:       header,
+	}
+	for field := range fields {
+		relay.StatusFields = append(relay.StatusFields, field)
+	}
+	sort.Strings(relay.StatusFields)
+	if relay.QuotaPerUnit <= 0 {
+		relay.QuotaPerUnit = DefaultQuotaPerUnit
+	}
+	return relay, nil
+}
+
+// QueryRelay returns the token quota and group ratios of the key on relay, the one-api/new-api
+// deployment DetectRelay found at the API URL
+func (c *Client) QueryRelay(ctx context.Context, relay *Relay, url, key string) (*RelayQuota, error) {
+	base := util.BaseURL(url)
+	quota := &RelayQuota{Relay: *relay}
+
+	var usage tokenUsageResponse
+	err := c.get(ctx, base+"/api/usage/token", key, &usage)
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		quota.Name = usage.Data.Name
+		quota.Group = usage.Data.Group
+		quota.Unlimited = usage.Data.UnlimitedQuota
+		quota.Total = usage.Data.TotalGranted / relay.QuotaPerUnit
+		quota.Used = usage.Data.TotalUsed / relay.QuotaPerUnit
+		quota.Remaining = usage.Data.TotalAvailable / relay.QuotaPerUnit
+		if usage.Data.ExpiresAt > 0 {
+			quota.ExpiresAt = time.Unix(usage.Data.ExpiresAt, 0)
+		}
+	case errors.As(err, &statusErr) && statusErr.IsUnauthorized():
+		return nil, err
+	default:
+		// one-api only exposes the token quota through the OpenAI style billing endpoints
+		balance, err := c.Query(ctx, url, key)
+		if err != nil {
+			return nil, err
+		}
+		quota.Total, quota.Used, quota.Remaining = balance.Total, balance.Used, balance.Remaining
+		quota.ExpiresAt = balance.AccessUntil
+	}
+
+	// Group ratios are public on new-api, older versions do not have the route
+	var pricing pricingResponse
+	if err := c.get(ctx, base+"/api/pricing", key, &pricing); err == nil && pricing.Success {
+		quota.GroupRatios = pricing.GroupRatio
+	}
+	return quota, nil
+}
+
+// IsRelayResponse reports whether the response headers were set by one-api/new-api
+func IsRelayResponse(header http.Header) bool {
+	return header.Get(RelayRequestIDHeader) != ""
+}
@@ -0,0 +1,84 @@
+package billing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryRelayNewAPI(t *testing.T) {
+	statusCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/status":
+			statusCalls++
+			assert.Empty(t, r.Header.Get("Authorization"))
+			w.Write([]byte(`{"success":true,"data":{"system_name":"New API","version":"v0.4.0","quota_per_unit":500000}}`))
+		case "/api/usage/token":
+			assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"code":true,"data":{"name":"demo","group":"vip","total_granted":5000000,"total_used":1000000,"total_available":4000000,"expires_at":0}}`))
+		case "/api/pricing":
+			w.Write([]byte(`{"success":true,"group_ratio":{"default":1,"vip":0.8}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(5 * time.Second)
+	apiURL := srv.URL + "/v1/chat/completions"
+	relay, err := client.DetectRelay(context.Background(), apiURL)
+	assert.NoError(t, err)
+	quota, err := client.QueryRelay(context.Background(), relay, apiURL, "sk-test")
+	assert.NoError(t, err)
+	// The relay is detected once per endpoint, not per key
+	_, err = client.QueryRelay(context.Background(), relay, apiURL, "sk-test")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, statusCalls)
+	assert.Equal(t, "New API", quota.Relay.System)
+	assert.Equal(t, "demo", quota.Name)
+	assert.Equal(t, "vip", quota.Group)
+	assert.InDelta(t, 10, quota.Total, 1e-9)
+	assert.InDelta(t, 2, quota.Used, 1e-9)
+	assert.InDelta(t, 8, quota.Remaining, 1e-9)
+	assert.True(t, quota.ExpiresAt.IsZero())
+	assert.Equal(t, 0.8, quota.GroupRatios["vip"])
+}
+
+func TestQueryRelayOneAPIFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RelayRequestIDHeader, "20240101000000")
+		switch r.URL.Path {
+		case "/v1/dashboard/billing/subscription":
+			w.Write([]byte(`{"hard_limit_usd":20}`))
+		case "/v1/dashboard/billing/usage":
+			w.Write([]byte(`{"total_usage":500}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(5 * time.Second)
+	relay, err := client.DetectRelay(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	quota, err := client.QueryRelay(context.Background(), relay, srv.URL, "sk-test")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(DefaultQuotaPerUnit), quota.Relay.QuotaPerUnit)
+	assert.InDelta(t, 15, quota.Remaining, 1e-9)
+	assert.Nil(t, quota.GroupRatios)
+}
+
+func TestDetectRelayNotRelay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html></html>`))
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(5*time.Second).DetectRelay(context.Background(), srv.URL)
+	assert.ErrorIs(t, err, ErrNotRelay)
+}
@@ -0,0 +1,63 @@
+package capability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+func init() {
+	register("batches", batchesProbe{})
+}
+
+// batchesProbe checks /v1/batches with a list call, falling back to a request
+// that is rejected during validation so no batch is ever created
+type batchesProbe struct{}
+
+func (batchesProbe) Name() string { return "Batch API" }
+
+func (p batchesProbe) Run(ctx context.Context, c *Client) Result {
+	var list struct {
+		Object string            `json:"object"`
+		Data   []json.RawMessage `json:"data"`
+	}
+	status, data, err := c.GetJSON(ctx, "/v1/batches?limit=1", nil, &list)
+	if err == nil && status == http.StatusOK && list.Object == "list" {
+		return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: "批量任务列表可用"}
+	}
+	if err != nil || status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return classify(p.Name(), status, data, err)
+	}
+
+	// Some relays do not proxy list calls, a validation error from the create endpoint still proves it exists
+	body, _ := json.Marshal(map[string]string{
+		"input_file_id":     "file-check-gpt-probe",
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	})
+	header := http.Header{"Content-Type": {"application/json"}}
+	status, data, err = c.Do(ctx, http.MethodPost, "/v1/batches", bytes.NewReader(body), header)
+	if err == nil && (status == http.StatusBadRequest || status == http.StatusNotFound) && mentionsInputFile(data) {
+		return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: "创建接口可用 (校验请求被拒绝)"}
+	}
+	if err == nil && status == http.StatusOK {
+		return Result{Name: p.Name(), Status: StatusError, StatusCode: status, Detail: "校验请求意外成功，请检查中转是否正确转发"}
+	}
+	return classify(p.Name(), status, data, err)
+}
+
+// mentionsInputFile reports whether an error response is about the made up input file
+func mentionsInputFile(body []byte) bool {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+			Param   string `json:"param"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return false
+	}
+	return e.Error.Param == "input_file_id" || strings.Contains(strings.ToLower(e.Error.Message), "file")
+}
@@ -0,0 +1,224 @@
+package capability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Status represents the outcome of a capability probe
+type Status string
+
+const (
+	StatusSupported   Status = "supported"
+	StatusUnsupported Status = "unsupported"
+	StatusDenied      Status = "denied"
+	StatusError       Status = "error"
+
+	// Relay quality checks
+	StatusPassed   Status = "passed"
+	StatusTampered Status = "tampered"
+)
+
+// Result represents the outcome of a single probe
+type Result struct {
+	Name       string   `json:"name"`
+	Status     Status   `json:"status"`
+	StatusCode int      `json:"status_code,omitempty"`
+	Detail     string   `json:"detail,omitempty"`
+	Items      []string `json:"items,omitempty"` // 例如微调模型列表
+}
+
+// Probe checks whether an API capability is available through the key and relay
+type Probe interface {
+	Name() string
+	Run(ctx context.Context, c *Client) Result
+}
+
+// probes holds all registered probes by their flag name
+var probes = map[string]Probe{}
+
+// register adds a probe under its flag name
+func register(id string, p Probe) {
+	probes[id] = p
+}
+
+// Names returns the flag names of all probes
+func Names() []string {
+	names := make([]string, 0, len(probes))
+	for id := range probes {
+		names = append(names, id)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse parses a comma separated list of probe names, "all" selects every probe
+func Parse(s string) ([]Probe, error) {
+	var selected []Probe
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(s, ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || seen[id] {
+			continue
+		}
+		if id == "all" {
+			selected = selected[:0]
+			for _, name := range Names() {
+				selected = append(selected, probes[name])
+			}
+			return selected, nil
+		}
+		p, ok := probes[id]
+		if !ok {
+			return nil, fmt.Errorf("未知的能力探测: %s (可选: %s, all)", id, strings.Join(Names(), ", "))
+		}
+		seen[id] = true
+		selected = append(selected, p)
+	}
+	return selected, nil
+}
+
+// Client sends authorized requests to the API base URL
+type Client struct {
+	BaseURL string
+	Key     string
+	Model   string // 对话类探测使用的模型
+	HTTP    *http.Client
+}
+
+// NewClient creates a new Client for the API URL
+func NewClient(apiURL, key, model string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL: util.BaseURL(apiURL),
+		Key:     key,
+		Model:   model,
+		HTTP:    httpclient.New(timeout),
+	}
+}
+
+// Do sends a request to path and returns the status code and body
+func (c *Client) Do(ctx context.Context, method, path string, body io.Reader, header http.Header) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	// Local endpoints are probed without a key
+	if c.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Key)
+		logger.AddSecret(c.Key)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	return resp.StatusCode, data, nil
+}
+
+// GetJSON sends a GET request and decodes a successful response into v
+func (c *Client) GetJSON(ctx context.Context, path string, header http.Header, v interface{}) (int, []byte, error) {
+	status, data, err := c.Do(ctx, http.MethodGet, path, nil, header)
+	if err != nil || status != http.StatusOK || v == nil {
+		return status, data, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return status, data, fmt.Errorf("无法解析响应: %v", err)
+	}
+	return status, data, nil
+}
+
+// classify converts a failed response into a result
+func classify(name string, status int, body []byte, err error) Result {
+	r := Result{Name: name, StatusCode: status}
+	switch {
+	case err != nil:
+		r.Status = StatusError
+		r.Detail = err.Error()
+	case status == http.StatusNotFound || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented:
+		r.Status = StatusUnsupported
+		r.Detail = fmt.Sprintf("接口不存在 (%d)", status)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		r.Status = StatusDenied
+		r.Detail = fmt.Sprintf("无权限 (%d)", status)
+	default:
+		r.Status = StatusError
+		r.Detail = fmt.Sprintf("%d %s", status, util.Truncate(strings.Join(strings.Fields(string(body)), " "), 120))
+	}
+
+	// Relays often answer unknown routes with 200 or 400 and an HTML page or a "not supported" error
+	lower := strings.ToLower(string(body))
+	if r.Status == StatusError && (strings.Contains(lower, "not support") || strings.Contains(lower, "invalid url") || strings.Contains(lower, "<html")) {
+		r.Status = StatusUnsupported
+	}
+	return r
+}
+
+// Run runs all probes with the key
+func Run(ctx context.Context, c *Client, selected []Probe) []Result {
+	results := make([]Result, 0, len(selected))
+	for _, p := range selected {
+		r := p.Run(ctx, c)
+		r.Detail = util.MaskSecrets(r.Detail, c.Key)
+		results = append(results, r)
+	}
+	return results
+}
+
+// Print prints the probe results
+func Print(printer *util.Printer, c *Client, results []Result) {
+	if len(results) == 0 {
+		return
+	}
+	printer.PrintTitle("能力探测", util.EmojiGear)
+	printer.Printf("Key: %s, 模型: %s\n", util.MaskKey(c.Key), c.Model)
+
+	width := 0
+	for _, r := range results {
+		width = util.Max(width, util.StringWidth(r.Name))
+	}
+	for _, r := range results {
+		var emoji, color, label string
+		switch r.Status {
+		case StatusSupported:
+			emoji, color, label = util.EmojiCheck, util.ColorGreen, "支持"
+		case StatusUnsupported:
+			emoji, color, label = util.EmojiError, util.ColorRed, "不支持"
+		case StatusDenied:
+			emoji, color, label = util.EmojiError, util.ColorYellow, "无权限"
+		case StatusPassed:
+			emoji, color, label = util.EmojiCheck, util.ColorGreen, "正常"
+		case StatusTampered:
+			emoji, color, label = util.EmojiError, util.ColorRed, "被篡改"
+		default:
+			emoji, color, label = util.EmojiWarning, util.ColorYellow, "未知"
+		}
+		line := fmt.Sprintf("│ %s %s%s %s%s", emoji, color, util.PadRight(r.Name, width), label, util.ColorReset)
+		if r.Detail != "" {
+			line += fmt.Sprintf(" %s%s%s", util.ColorGray, r.Detail, util.ColorReset)
+		}
+		printer.Printf("%s\n", line)
+		for _, item := range r.Items {
+			printer.Printf("│     %s\n", item)
+		}
+		printer.PrintSummary("%s: %s", r.Name, label)
+	}
+}
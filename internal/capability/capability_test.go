@@ -0,0 +1,365 @@
+package capability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesAndAssistants(t *testing.T) {
+	var deleted string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		assert.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "assistants", r.FormValue("purpose"))
+		w.Write([]byte(`{"id":"file-abc","object":"file"}`))
+	})
+	mux.HandleFunc("/v1/files/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		deleted = r.URL.Path
+		w.Write([]byte(`{"id":"file-abc","deleted":true}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	probes, err := Parse("files,assistants")
+	assert.NoError(t, err)
+
+	c := NewClient(srv.URL, "sk-test", "gpt-4o-mini", 5*time.Second)
+	results := Run(context.Background(), c, probes)
+
+	assert.Equal(t, StatusSupported, results[0].Status)
+	assert.Equal(t, "/v1/files/file-abc", deleted)
+	assert.Equal(t, StatusUnsupported, results[1].Status)
+	assert.Equal(t, http.StatusNotFound, results[1].StatusCode)
+}
+
+func TestParse(t *testing.T) {
+	all, err := Parse("all")
+	assert.NoError(t, err)
+	assert.Len(t, all, len(Names()))
+
+	_, err = Parse("files,unknown")
+	assert.Error(t, err)
+}
+
+func TestBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    Status
+	}{
+		{"List", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"object":"list","data":[]}`))
+		}, StatusSupported},
+		{"Validation", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"No such File object: file-check-gpt-probe","param":"input_file_id"}}`))
+		}, StatusSupported},
+		{"Missing", http.NotFound, StatusUnsupported},
+	}
+
+	probes, err := Parse("batches")
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			results := Run(context.Background(), NewClient(srv.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+			assert.Equal(t, tt.want, results[0].Status)
+		})
+	}
+}
+
+func TestFineTunes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/fine_tuning/jobs", r.URL.Path)
+		if r.URL.Query().Get("after") == "" {
+			w.Write([]byte(`{"object":"list","has_more":true,"data":[
+				{"id":"ftjob-1","model":"gpt-4o-mini","fine_tuned_model":"ft:gpt-4o-mini:org:a:1","status":"succeeded"},
+				{"id":"ftjob-2","model":"gpt-4o-mini","fine_tuned_model":null,"status":"running"}]}`))
+			return
+		}
+		assert.Equal(t, "ftjob-2", r.URL.Query().Get("after"))
+		w.Write([]byte(`{"object":"list","has_more":false,"data":[
+			{"id":"ftjob-3","model":"gpt-4o","fine_tuned_model":"ft:gpt-4o:org:b:2","status":"succeeded"}]}`))
+	}))
+	defer srv.Close()
+
+	probes, err := Parse("finetunes")
+	assert.NoError(t, err)
+	results := Run(context.Background(), NewClient(srv.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+
+	assert.Equal(t, StatusSupported, results[0].Status)
+	assert.Equal(t, []string{"ft:gpt-4o-mini:org:a:1", "ft:gpt-4o:org:b:2"}, results[0].Items)
+	assert.Contains(t, results[0].Detail, "1 个任务进行中")
+}
+
+// chatServer answers chat completions with the content returned by reply
+func chatServer(t *testing.T, reply func(req ChatRequest) (content, finishReason string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		content, finishReason := reply(req)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{
+				"message":       map[string]string{"role": "assistant", "content": content},
+				"finish_reason": finishReason,
+			}},
+		})
+	}))
+}
+
+func TestTemperature(t *testing.T) {
+	probes, err := Parse("temperature")
+	assert.NoError(t, err)
+
+	n := 0
+	honoring := chatServer(t, func(req ChatRequest) (string, string) {
+		n++
+		if *req.Temperature == 0 {
+			return "apple", "stop"
+		}
+		return fmt.Sprintf("word%d", n), "stop"
+	})
+	defer honoring.Close()
+	results := Run(context.Background(), NewClient(honoring.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusPassed, results[0].Status)
+
+	clamped := chatServer(t, func(req ChatRequest) (string, string) { return "apple", "stop" })
+	defer clamped.Close()
+	results = Run(context.Background(), NewClient(clamped.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusTampered, results[0].Status)
+
+	// Reasoning models reject temperature, also behind a provider prefix
+	results = Run(context.Background(), NewClient(clamped.URL, "sk-test", "openai/o4-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusError, results[0].Status)
+}
+
+func TestSystemPrompt(t *testing.T) {
+	probes, err := Parse("system")
+	assert.NoError(t, err)
+
+	canary := func(req ChatRequest) string {
+		fields := strings.Fields(req.Messages[0].Content)
+		return fields[len(fields)-4]
+	}
+	tests := []struct {
+		name  string
+		reply func(req ChatRequest) (string, string)
+		want  Status
+	}{
+		{"Obeyed", func(req ChatRequest) (string, string) { return canary(req) + ".", "stop" }, StatusPassed},
+		{"Stripped", func(req ChatRequest) (string, string) { return "Paris.", "stop" }, StatusTampered},
+		{"Injected", func(req ChatRequest) (string, string) { return canary(req) + " 本站提供低价 API", "stop" }, StatusTampered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := chatServer(t, tt.reply)
+			defer srv.Close()
+			results := Run(context.Background(), NewClient(srv.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+			assert.Equal(t, tt.want, results[0].Status, results[0].Detail)
+		})
+	}
+}
+
+func TestLongOutput(t *testing.T) {
+	probes, err := Parse("long")
+	assert.NoError(t, err)
+
+	sequence := func(n int) string {
+		var parts []string
+		for i := 1; i <= n; i++ {
+			parts = append(parts, fmt.Sprint(i))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	full := chatServer(t, func(req ChatRequest) (string, string) { return sequence(200), "stop" })
+	defer full.Close()
+	results := Run(context.Background(), NewClient(full.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusPassed, results[0].Status)
+
+	cut := chatServer(t, func(req ChatRequest) (string, string) { return sequence(50), "stop" })
+	defer cut.Close()
+	results = Run(context.Background(), NewClient(cut.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusTampered, results[0].Status)
+	assert.Contains(t, results[0].Detail, "交付 50/200 (25%)")
+}
+
+func TestFinishReason(t *testing.T) {
+	probes, err := Parse("finish")
+	assert.NoError(t, err)
+
+	faithful := chatServer(t, func(req ChatRequest) (string, string) {
+		if len(req.Stop) > 0 {
+			return "1 2 3 4 5 6", "stop"
+		}
+		return "1 2", "length"
+	})
+	defer faithful.Close()
+	results := Run(context.Background(), NewClient(faithful.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusPassed, results[0].Status, results[0].Detail)
+
+	rewritten := chatServer(t, func(req ChatRequest) (string, string) {
+		if len(req.Stop) > 0 {
+			return "1 2 3 4 5 6 7 8 9 10", "stop"
+		}
+		return "1 2", "stop"
+	})
+	defer rewritten.Close()
+	results = Run(context.Background(), NewClient(rewritten.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusTampered, results[0].Status)
+	assert.Contains(t, results[0].Detail, "停止序列未生效")
+	assert.Contains(t, results[0].Detail, "finish_reason 为 stop，应为 length")
+}
+
+func TestErrorPassthrough(t *testing.T) {
+	probes, err := Parse("errors")
+	assert.NoError(t, err)
+
+	authentic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "check-gpt-nonexistent-model" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"The model does not exist","type":"invalid_request_error","param":null,"code":"model_not_found"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"max_tokens is too large","type":"invalid_request_error","param":"max_tokens","code":null}}`))
+	}))
+	defer authentic.Close()
+	results := Run(context.Background(), NewClient(authentic.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusPassed, results[0].Status, results[0].Detail)
+
+	wrapped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"message":"当前分组上游负载已饱和","type":"new_api_error"}}`))
+	}))
+	defer wrapped.Close()
+	results = Run(context.Background(), NewClient(wrapped.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+	assert.Equal(t, StatusTampered, results[0].Status)
+	assert.Contains(t, results[0].Detail, "状态码 500，应为 404")
+	assert.Contains(t, results[0].Detail, `错误类型 "new_api_error" 被改写`)
+}
+
+func TestImageGeneration(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    Status
+	}{
+		{"URL", func(w http.ResponseWriter, r *http.Request) {
+			var req map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "256x256", req["size"])
+			assert.Equal(t, float64(1), req["n"])
+			w.Write([]byte(`{"created":1,"data":[{"url":"https://example.com/a.png"}]}`))
+		}, StatusSupported},
+		{"Base64", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"created":1,"data":[{"b64_json":"iVBORw0KGgo="}]}`))
+		}, StatusSupported},
+		{"ChatOnly", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, StatusUnsupported},
+		{"Empty", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":[]}`))
+		}, StatusError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/images/generations", tt.handler)
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			r := imageProbe{}.Run(context.Background(), NewClient(srv.URL, "sk-test", "gpt-4o-mini", 5*time.Second))
+			assert.Equal(t, tt.want, r.Status, r.Detail)
+		})
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	dir := t.TempDir()
+	plugin := `name: 工具调用
+request:
+  path: /v1/chat/completions
+  body: '{"model": "{{model}}", "messages": [{"role": "user", "content": "hi"}]}'
+assert:
+  - status: 200
+  - path: choices.0.message.tool_calls.0.function.name
+    equals: get_weather
+  - contains: tool_calls
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "plugin-tools.yaml"), []byte(plugin), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	ids, err := LoadPlugins(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"plugin-tools"}, ids)
+	probes, err := Parse("plugin-tools")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		reply string
+		want  Status
+	}{
+		{"Pass", `{"choices":[{"message":{"tool_calls":[{"function":{"name":"get_weather"}}]}}]}`, StatusSupported},
+		{"Mismatch", `{"choices":[{"message":{"tool_calls":[{"function":{"name":"search"}}]}}]}`, StatusUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req ChatRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, "gpt-4o-mini", req.Model)
+				w.Write([]byte(tt.reply))
+			}))
+			defer srv.Close()
+
+			results := Run(context.Background(), NewClient(srv.URL, "sk-test", "gpt-4o-mini", 5*time.Second), probes)
+			assert.Equal(t, tt.want, results[0].Status, results[0].Detail)
+			assert.Equal(t, "工具调用", results[0].Name)
+		})
+	}
+
+	// A second load of the same file collides with the registered probe
+	_, err = LoadPlugins(dir)
+	assert.Error(t, err)
+
+	// A bad file is reported and the other plugins are still loaded
+	invalid := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(invalid, "bad.yml"), []byte("request:\n  path: v1/models\nassert:\n  - status: 200\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(invalid, "plugin-usage.yml"), []byte("request:\n  path: /v1/chat/completions\nassert:\n  - path: usage.total_tokens\n"), 0o644))
+	ids, err = LoadPlugins(invalid)
+	assert.ErrorContains(t, err, "request.path")
+	assert.Equal(t, []string{"plugin-usage"}, ids)
+
+	// A bare path fails when the response lacks it
+	usage, err := readPlugin(filepath.Join(invalid, "plugin-usage.yml"))
+	assert.NoError(t, err)
+	assert.Error(t, usage.Assert[0].check(http.StatusOK, []byte(`{"choices":[]}`)))
+	assert.NoError(t, usage.Assert[0].check(http.StatusOK, []byte(`{"usage":{"total_tokens":3}}`)))
+
+	ids, err = LoadPlugins(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
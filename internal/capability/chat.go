@@ -0,0 +1,80 @@
+package capability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ChatMessage represents a message in a chat completion request
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest represents a chat completion request with the sampling parameters probes need
+type ChatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+}
+
+// ChatResponse represents the parts of a chat completion response probes check
+type ChatResponse struct {
+	Choices []struct {
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Content returns the content of the first choice
+func (r *ChatResponse) Content() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(r.Choices[0].Message.Content)
+}
+
+// FinishReason returns the finish reason of the first choice
+func (r *ChatResponse) FinishReason() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].FinishReason
+}
+
+// Chat sends a chat completion request with the client model
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, int, []byte, error) {
+	if req.Model == "" {
+		req.Model = c.Model
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	status, data, err := c.Do(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body), header)
+	if err != nil || status != http.StatusOK {
+		return nil, status, data, err
+	}
+
+	var resp ChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Choices) == 0 {
+		return nil, status, data, fmt.Errorf("无法解析响应: %s", strings.Join(strings.Fields(string(data)), " "))
+	}
+	return &resp, status, data, nil
+}
+
+// Float returns a pointer to f, used for optional request parameters
+func Float(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,97 @@
+package capability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// openAIErrorTypes lists the error types the OpenAI API returns
+var openAIErrorTypes = map[string]bool{
+	"invalid_request_error": true,
+	"authentication_error":  true,
+	"permission_error":      true,
+	"not_found_error":       true,
+	"rate_limit_error":      true,
+	"server_error":          true,
+}
+
+func init() {
+	register("errors", errorPassthroughProbe{})
+}
+
+// errorPassthroughProbe triggers known upstream errors and checks the relay forwards the
+// authentic OpenAI error structure instead of wrapping it in its own
+type errorPassthroughProbe struct{}
+
+func (errorPassthroughProbe) Name() string { return "错误透传" }
+
+// errorCase is an upstream error the probe triggers
+type errorCase struct {
+	name       string
+	req        ChatRequest
+	wantStatus int
+}
+
+func (p errorPassthroughProbe) Run(ctx context.Context, c *Client) Result {
+	cases := []errorCase{
+		{
+			name:       "不存在的模型",
+			req:        ChatRequest{Model: "check-gpt-nonexistent-model", MaxTokens: 1},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "超限 max_tokens",
+			req:        ChatRequest{MaxTokens: 10_000_000},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	var findings []string
+	for _, ec := range cases {
+		ec.req.Messages = []ChatMessage{{Role: "user", Content: "hi"}}
+		resp, status, data, err := c.Chat(ctx, ec.req)
+		if err != nil && status == 0 {
+			return classify(p.Name(), status, data, err)
+		}
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			return classify(p.Name(), status, data, nil)
+		}
+		if resp != nil {
+			findings = append(findings, fmt.Sprintf("%s: 请求意外成功", ec.name))
+			continue
+		}
+		if finding := checkErrorBody(status, ec.wantStatus, data); finding != "" {
+			findings = append(findings, fmt.Sprintf("%s: %s", ec.name, finding))
+		}
+	}
+
+	if len(findings) > 0 {
+		return Result{Name: p.Name(), Status: StatusTampered, Detail: strings.Join(findings, "; ")}
+	}
+	return Result{Name: p.Name(), Status: StatusPassed, Detail: "错误状态码与结构与官方一致"}
+}
+
+// checkErrorBody compares an error response with the OpenAI error structure
+func checkErrorBody(status, wantStatus int, body []byte) string {
+	var e struct {
+		Error *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil || e.Error == nil || e.Error.Message == "" {
+		return fmt.Sprintf("非官方错误结构 (%d)", status)
+	}
+
+	var problems []string
+	if status != wantStatus {
+		problems = append(problems, fmt.Sprintf("状态码 %d，应为 %d", status, wantStatus))
+	}
+	if !openAIErrorTypes[e.Error.Type] {
+		problems = append(problems, fmt.Sprintf("错误类型 %q 被改写", e.Error.Type))
+	}
+	return strings.Join(problems, ", ")
+}
@@ -0,0 +1,74 @@
+package capability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+)
+
+func init() {
+	register("files", filesProbe{})
+	register("assistants", assistantsProbe{})
+}
+
+// filesProbe uploads and deletes a tiny file through /v1/files
+type filesProbe struct{}
+
+func (filesProbe) Name() string { return "Files API" }
+
+func (p filesProbe) Run(ctx context.Context, c *Client) Result {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("purpose", "assistants")
+	part, err := w.CreateFormFile("file", "check-gpt-probe.txt")
+	if err != nil {
+		return Result{Name: p.Name(), Status: StatusError, Detail: err.Error()}
+	}
+	part.Write([]byte("check-gpt capability probe\n"))
+	w.Close()
+
+	header := http.Header{"Content-Type": {w.FormDataContentType()}}
+	status, data, err := c.Do(ctx, http.MethodPost, "/v1/files", &body, header)
+	if err != nil || status != http.StatusOK {
+		return classify(p.Name(), status, data, err)
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil || file.ID == "" {
+		return classify(p.Name(), status, data, fmt.Errorf("上传响应缺少文件 ID"))
+	}
+
+	// Clean up, a file left behind is reported but does not fail the probe
+	status, data, err = c.Do(ctx, http.MethodDelete, "/v1/files/"+file.ID, nil, nil)
+	if err != nil || status != http.StatusOK {
+		r := classify(p.Name(), status, data, err)
+		return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: fmt.Sprintf("已上传 %s，但删除失败: %s", file.ID, r.Detail)}
+	}
+	return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: fmt.Sprintf("上传并删除 %s", file.ID)}
+}
+
+// assistantsProbe lists assistants through /v1/assistants
+type assistantsProbe struct{}
+
+func (assistantsProbe) Name() string { return "Assistants API" }
+
+func (p assistantsProbe) Run(ctx context.Context, c *Client) Result {
+	var list struct {
+		Object string            `json:"object"`
+		Data   []json.RawMessage `json:"data"`
+	}
+	header := http.Header{"OpenAI-Beta": {"assistants=v2"}}
+	status, data, err := c.GetJSON(ctx, "/v1/assistants?limit=100", header, &list)
+	if err != nil || status != http.StatusOK {
+		return classify(p.Name(), status, data, err)
+	}
+	if list.Object != "list" {
+		return classify(p.Name(), status, data, fmt.Errorf("响应格式不符"))
+	}
+	return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: fmt.Sprintf("%d 个助手", len(list.Data))}
+}
@@ -0,0 +1,74 @@
+package capability
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// maxFineTunePages bounds the number of job pages fetched
+const maxFineTunePages = 5
+
+func init() {
+	register("finetunes", fineTunesProbe{})
+}
+
+// fineTunesProbe lists the fine-tuned models of the key through /v1/fine_tuning/jobs
+type fineTunesProbe struct{}
+
+func (fineTunesProbe) Name() string { return "Fine-tuning" }
+
+// fineTuneJob represents a job in the /v1/fine_tuning/jobs list
+type fineTuneJob struct {
+	ID             string `json:"id"`
+	Model          string `json:"model"`
+	FineTunedModel string `json:"fine_tuned_model"`
+	Status         string `json:"status"`
+}
+
+func (p fineTunesProbe) Run(ctx context.Context, c *Client) Result {
+	var jobs []fineTuneJob
+	after := ""
+	for page := 0; page < maxFineTunePages; page++ {
+		path := "/v1/fine_tuning/jobs?limit=100"
+		if after != "" {
+			path += "&after=" + url.QueryEscape(after)
+		}
+
+		var list struct {
+			Object  string        `json:"object"`
+			Data    []fineTuneJob `json:"data"`
+			HasMore bool          `json:"has_more"`
+		}
+		status, data, err := c.GetJSON(ctx, path, nil, &list)
+		if err != nil || status != http.StatusOK {
+			return classify(p.Name(), status, data, err)
+		}
+		if list.Object != "list" {
+			return classify(p.Name(), status, data, fmt.Errorf("响应格式不符"))
+		}
+
+		jobs = append(jobs, list.Data...)
+		if !list.HasMore || len(list.Data) == 0 {
+			break
+		}
+		after = list.Data[len(list.Data)-1].ID
+	}
+
+	r := Result{Name: p.Name(), Status: StatusSupported, StatusCode: http.StatusOK}
+	running := 0
+	for _, job := range jobs {
+		switch {
+		case job.FineTunedModel != "":
+			r.Items = append(r.Items, job.FineTunedModel)
+		case job.Status != "failed" && job.Status != "cancelled":
+			running++
+		}
+	}
+	r.Detail = fmt.Sprintf("%d 个微调模型", len(r.Items))
+	if running > 0 {
+		r.Detail += fmt.Sprintf("，%d 个任务进行中", running)
+	}
+	return r
+}
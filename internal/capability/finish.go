@@ -0,0 +1,75 @@
+package capability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// validFinishReasons lists the finish_reason values of the OpenAI API
+var validFinishReasons = map[string]bool{
+	"stop":           true,
+	"length":         true,
+	"content_filter": true,
+	"tool_calls":     true,
+	"function_call":  true,
+}
+
+func init() {
+	register("finish", finishReasonProbe{})
+}
+
+// finishReasonProbe checks that stop sequences are honored and finish_reason is passed through,
+// content_filter cannot be triggered safely so only its spelling is validated when it appears
+type finishReasonProbe struct{}
+
+func (finishReasonProbe) Name() string { return "停止序列与结束原因" }
+
+func (p finishReasonProbe) Run(ctx context.Context, c *Client) Result {
+	var findings []string
+	count := []ChatMessage{{Role: "user", Content: "Count from 1 to 20, separated by single spaces. Output only the numbers."}}
+
+	// A stop sequence must end the output before it and report "stop"
+	resp, status, data, err := c.Chat(ctx, ChatRequest{
+		Messages:    count,
+		MaxTokens:   100,
+		Temperature: Float(0),
+		Stop:        []string{" 7"},
+	})
+	if resp == nil {
+		return classify(p.Name(), status, data, err)
+	}
+	if n := countSequence(resp.Content()); n >= 7 {
+		findings = append(findings, fmt.Sprintf("停止序列未生效 (输出到 %d)", n))
+	}
+	findings = append(findings, checkFinishReason("停止序列", resp.FinishReason(), "stop")...)
+
+	// Running out of max_tokens must report "length"
+	resp, status, data, err = c.Chat(ctx, ChatRequest{
+		Messages:    count,
+		MaxTokens:   3,
+		Temperature: Float(0),
+	})
+	if resp == nil {
+		return classify(p.Name(), status, data, err)
+	}
+	findings = append(findings, checkFinishReason("max_tokens", resp.FinishReason(), "length")...)
+
+	if len(findings) > 0 {
+		return Result{Name: p.Name(), Status: StatusTampered, Detail: strings.Join(findings, "; ")}
+	}
+	return Result{Name: p.Name(), Status: StatusPassed, Detail: "stop 与 length 均正确返回"}
+}
+
+// checkFinishReason compares the finish_reason of a case with the expected value
+func checkFinishReason(name, got, want string) []string {
+	switch {
+	case got == "":
+		return []string{fmt.Sprintf("%s: 缺少 finish_reason", name)}
+	case !validFinishReasons[got]:
+		return []string{fmt.Sprintf("%s: 非标准 finish_reason %q", name, got)}
+	case got != want:
+		return []string{fmt.Sprintf("%s: finish_reason 为 %s，应为 %s", name, got, want)}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package capability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ImageModel is the model of the image generation probe, the only one offering 256x256, the cheapest size
+const ImageModel = "dall-e-2"
+
+func init() {
+	register("image", imageProbe{})
+}
+
+// imageProbe generates one 256x256 image through /v1/images/generations, keys and relays that only
+// forward chat answer it with an unsupported route or an error
+type imageProbe struct{}
+
+func (imageProbe) Name() string { return "图像生成" }
+
+func (p imageProbe) Run(ctx context.Context, c *Client) Result {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":  ImageModel,
+		"prompt": "A small red circle on a white background",
+		"size":   "256x256",
+		"n":      1,
+	})
+	header := http.Header{"Content-Type": {"application/json"}}
+	status, data, err := c.Do(ctx, http.MethodPost, "/v1/images/generations", bytes.NewReader(body), header)
+	if err != nil || status != http.StatusOK {
+		return classify(p.Name(), status, data, err)
+	}
+
+	var resp struct {
+		Data []struct {
+			URL     string `json:"url"`
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Data) == 0 {
+		return classify(p.Name(), status, data, fmt.Errorf("响应中没有图片"))
+	}
+	format := "URL"
+	if resp.Data[0].URL == "" {
+		if resp.Data[0].B64JSON == "" {
+			return classify(p.Name(), status, data, fmt.Errorf("响应中没有图片"))
+		}
+		format = "base64"
+	}
+	return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: fmt.Sprintf("%s 生成 %d 张图片 (%s)", ImageModel, len(resp.Data), format)}
+}
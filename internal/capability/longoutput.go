@@ -0,0 +1,63 @@
+package capability
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// longOutputCount is the number the model is asked to count to
+const longOutputCount = 200
+
+func init() {
+	register("long", longOutputProbe{})
+}
+
+// longOutputProbe asks for a deterministic long output and checks it is delivered in full,
+// relays that save cost by cutting responses short deliver only a prefix
+type longOutputProbe struct{}
+
+func (longOutputProbe) Name() string { return "长输出完整性" }
+
+func (p longOutputProbe) Run(ctx context.Context, c *Client) Result {
+	resp, status, data, err := c.Chat(ctx, ChatRequest{
+		Messages: []ChatMessage{{
+			Role:    "user",
+			Content: fmt.Sprintf("Count from 1 to %d, separated by single spaces. Output only the numbers.", longOutputCount),
+		}},
+		// Each number is at most two tokens, leave plenty of room
+		MaxTokens:   longOutputCount * 4,
+		Temperature: Float(0),
+	})
+	if resp == nil {
+		return classify(p.Name(), status, data, err)
+	}
+
+	delivered := countSequence(resp.Content())
+	detail := fmt.Sprintf("交付 %d/%d (%.0f%%)", delivered, longOutputCount, float64(delivered)*100/longOutputCount)
+	if reason := resp.FinishReason(); reason != "" {
+		detail += fmt.Sprintf(", finish_reason: %s", reason)
+	}
+	if delivered < longOutputCount {
+		return Result{Name: p.Name(), Status: StatusTampered, Detail: detail}
+	}
+	return Result{Name: p.Name(), Status: StatusPassed, Detail: detail}
+}
+
+// countSequence returns how many numbers of the sequence 1, 2, 3... s contains in order
+func countSequence(s string) int {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	next := 1
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			continue
+		}
+		if n == next {
+			next++
+		}
+	}
+	return next - 1
+}
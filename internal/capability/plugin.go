@@ -0,0 +1,235 @@
+package capability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PluginModelVar is replaced by the probe model in the path and body of a plugin request
+const PluginModelVar = "{{model}}"
+
+// Plugin is a probe defined in a YAML file, a request template and the assertions its response must pass
+type Plugin struct {
+	ID      string            `yaml:"id"`   // -probes 中使用的名称, 默认为文件名
+	Title   string            `yaml:"name"` // 结果中显示的名称
+	Request PluginRequest     `yaml:"request"`
+	Assert  []PluginAssertion `yaml:"assert"`
+}
+
+// PluginRequest is the request template of a plugin probe
+type PluginRequest struct {
+	Method  string            `yaml:"method"` // 默认 POST
+	Path    string            `yaml:"path"`   // 相对 API 根地址, 如 /v1/chat/completions
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+// PluginAssertion checks one property of the response, exactly one of its checks is set
+type PluginAssertion struct {
+	Status   int         `yaml:"status"`   // 状态码
+	Contains string      `yaml:"contains"` // 响应体包含的文本
+	Path     string      `yaml:"path"`     // JSON 路径, 如 choices.0.message.tool_calls.0.function.name
+	Equals   interface{} `yaml:"equals"`   // 路径处的值
+	Exists   *bool       `yaml:"exists"`   // 路径是否存在
+}
+
+// LoadPlugins registers the plugin probes of every .yaml and .yml file in dir, a missing directory has none.
+// It returns the IDs of the registered probes, a bad file is reported in the error and the others are still loaded.
+func LoadPlugins(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("读取探测插件失败: %v", err)
+		}
+		files = append(files, matches...)
+	}
+	var ids []string
+	var errs []error
+	for _, path := range files {
+		p, err := readPlugin(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, exists := probes[p.ID]; exists {
+			errs = append(errs, fmt.Errorf("探测插件 %s: 名称 %s 已被占用", path, p.ID))
+			continue
+		}
+		register(p.ID, p)
+		ids = append(ids, p.ID)
+	}
+	return ids, errors.Join(errs...)
+}
+
+// readPlugin parses and validates the plugin file at path
+func readPlugin(path string) (*Plugin, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取探测插件失败: %v", err)
+	}
+	var p Plugin
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("解析探测插件 %s 失败: %v", path, err)
+	}
+	if p.ID == "" {
+		p.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	p.ID = strings.ToLower(p.ID)
+	if p.Title == "" {
+		p.Title = p.ID
+	}
+	if p.Request.Method == "" {
+		p.Request.Method = http.MethodPost
+	}
+	if !strings.HasPrefix(p.Request.Path, "/") {
+		return nil, fmt.Errorf("探测插件 %s: request.path 应以 / 开头", path)
+	}
+	if len(p.Assert) == 0 {
+		return nil, fmt.Errorf("探测插件 %s: 至少需要一条 assert", path)
+	}
+	for i, a := range p.Assert {
+		if a.Status == 0 && a.Contains == "" && a.Path == "" {
+			return nil, fmt.Errorf("探测插件 %s: 第 %d 条 assert 需要 status、contains 或 path", path, i+1)
+		}
+		// A bare path asserts that it exists
+		if a.Path != "" && a.Exists == nil && a.Equals == nil {
+			exists := true
+			p.Assert[i].Exists = &exists
+		}
+	}
+	if p.Request.Body != "" && !json.Valid([]byte(strings.ReplaceAll(p.Request.Body, PluginModelVar, "model"))) {
+		return nil, fmt.Errorf("探测插件 %s: request.body 不是合法的 JSON", path)
+	}
+	return &p, nil
+}
+
+func (p *Plugin) Name() string { return p.Title }
+
+func (p *Plugin) Run(ctx context.Context, c *Client) Result {
+	path := strings.ReplaceAll(p.Request.Path, PluginModelVar, c.Model)
+	header := http.Header{}
+	for name, value := range p.Request.Headers {
+		header.Set(name, value)
+	}
+	var body io.Reader
+	if p.Request.Body != "" {
+		// The template quotes the model, only its JSON escaping is substituted
+		model, _ := json.Marshal(c.Model)
+		body = strings.NewReader(strings.ReplaceAll(p.Request.Body, PluginModelVar, strings.Trim(string(model), `"`)))
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/json")
+		}
+	}
+
+	status, data, err := c.Do(ctx, p.Request.Method, path, body, header)
+	if err != nil {
+		return classify(p.Name(), status, data, err)
+	}
+
+	// A route that does not exist is reported as such unless the plugin expects that status
+	if !p.expectsStatus() && status >= http.StatusBadRequest {
+		return classify(p.Name(), status, data, nil)
+	}
+	for _, a := range p.Assert {
+		if err := a.check(status, data); err != nil {
+			return Result{Name: p.Name(), Status: StatusUnsupported, StatusCode: status, Detail: err.Error()}
+		}
+	}
+	return Result{Name: p.Name(), Status: StatusSupported, StatusCode: status, Detail: fmt.Sprintf("%d 条断言通过", len(p.Assert))}
+}
+
+// expectsStatus reports whether one of the assertions checks the status code
+func (p *Plugin) expectsStatus() bool {
+	for _, a := range p.Assert {
+		if a.Status != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns an error describing how the response fails the assertion
+func (a PluginAssertion) check(status int, body []byte) error {
+	switch {
+	case a.Status != 0:
+		if status != a.Status {
+			return fmt.Errorf("状态码 %d, 期望 %d", status, a.Status)
+		}
+	case a.Contains != "":
+		if !strings.Contains(string(body), a.Contains) {
+			return fmt.Errorf("响应不包含 %q", a.Contains)
+		}
+	case a.Path != "":
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return fmt.Errorf("响应不是 JSON")
+		}
+		value, found := lookup(doc, a.Path)
+		if a.Exists != nil && found != *a.Exists {
+			if found {
+				return fmt.Errorf("%s 不应存在", a.Path)
+			}
+			return fmt.Errorf("缺少 %s", a.Path)
+		}
+		if a.Equals != nil {
+			if !found {
+				return fmt.Errorf("缺少 %s", a.Path)
+			}
+			if !equalJSON(value, a.Equals) {
+				return fmt.Errorf("%s 为 %v, 期望 %v", a.Path, value, a.Equals)
+			}
+		}
+	}
+	return nil
+}
+
+// lookup follows a dot separated path of object keys and array indexes through a decoded JSON document
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	for _, part := range strings.Split(path, ".") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			doc = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// equalJSON compares a decoded JSON value with a YAML value, numbers by value
+func equalJSON(value, want interface{}) bool {
+	switch w := want.(type) {
+	case int:
+		f, ok := value.(float64)
+		return ok && f == float64(w)
+	case float64:
+		f, ok := value.(float64)
+		return ok && f == w
+	}
+	return reflect.DeepEqual(value, want)
+}
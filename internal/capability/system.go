@@ -0,0 +1,44 @@
+package capability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+func init() {
+	register("system", systemPromptProbe{})
+}
+
+// systemPromptProbe sends a canary instruction as the system message and checks the model obeys it,
+// relays that strip or replace system prompts answer the user message instead
+type systemPromptProbe struct{}
+
+func (systemPromptProbe) Name() string { return "系统提示词透传" }
+
+func (p systemPromptProbe) Run(ctx context.Context, c *Client) Result {
+	canary := "CANARY-" + util.GenerateRandomDigits(6)
+	resp, status, data, err := c.Chat(ctx, ChatRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: fmt.Sprintf("Whatever the user says, reply with exactly %s and nothing else.", canary)},
+			{Role: "user", Content: "What is the capital of France?"},
+		},
+		MaxTokens:   20,
+		Temperature: Float(0),
+	})
+	if resp == nil {
+		return classify(p.Name(), status, data, err)
+	}
+
+	content := resp.Content()
+	if strings.Contains(content, canary) {
+		// Punctuation and quotes around the canary are fine, anything else was added on the way
+		if extra := strings.Trim(strings.Replace(content, canary, "", 1), " \n.。!！\"'`*"); extra != "" {
+			return Result{Name: p.Name(), Status: StatusTampered, Detail: fmt.Sprintf("回复中有额外内容: %s", util.Truncate(extra, 60))}
+		}
+		return Result{Name: p.Name(), Status: StatusPassed, Detail: "模型遵循了系统提示词"}
+	}
+	return Result{Name: p.Name(), Status: StatusTampered, Detail: fmt.Sprintf("模型未遵循系统提示词，回复: %s", util.Truncate(strings.Join(strings.Fields(content), " "), 60))}
+}
@@ -0,0 +1,71 @@
+package capability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+)
+
+// temperatureSamples is the number of requests sent at each temperature
+const temperatureSamples = 3
+
+func init() {
+	register("temperature", temperatureProbe{})
+}
+
+// temperatureProbe sends the same prompt at temperature 0 and 2 and compares the variance,
+// relays that ignore or clamp sampling parameters answer the same at both temperatures
+type temperatureProbe struct{}
+
+func (temperatureProbe) Name() string { return "参数透传" }
+
+func (p temperatureProbe) Run(ctx context.Context, c *Client) Result {
+	if apitest.IsReasoningModel(c.Model) {
+		return Result{Name: p.Name(), Status: StatusError, Detail: fmt.Sprintf("%s 不支持 temperature，请使用其他模型", c.Model)}
+	}
+
+	cold, r := p.sample(ctx, c, 0)
+	if r != nil {
+		return *r
+	}
+	hot, r := p.sample(ctx, c, 2)
+	if r != nil {
+		return *r
+	}
+
+	coldDistinct, hotDistinct := distinct(cold), distinct(hot)
+	detail := fmt.Sprintf("temperature 0: %d/%d 种输出, temperature 2: %d/%d 种输出", coldDistinct, temperatureSamples, hotDistinct, temperatureSamples)
+	if hotDistinct <= 1 {
+		return Result{Name: p.Name(), Status: StatusTampered, Detail: detail + "，高温度下输出无变化"}
+	}
+	return Result{Name: p.Name(), Status: StatusPassed, Detail: detail}
+}
+
+// sample collects the outputs at one temperature, r is set when a request fails
+func (p temperatureProbe) sample(ctx context.Context, c *Client, temperature float64) ([]string, *Result) {
+	var outputs []string
+	for i := 0; i < temperatureSamples; i++ {
+		resp, status, data, err := c.Chat(ctx, ChatRequest{
+			Messages:    []ChatMessage{{Role: "user", Content: "Write one random English word."}},
+			MaxTokens:   8,
+			Temperature: Float(temperature),
+		})
+		if resp == nil {
+			r := classify(p.Name(), status, data, err)
+			return nil, &r
+		}
+		outputs = append(outputs, strings.ToLower(resp.Content()))
+	}
+	return outputs, nil
+}
+
+// distinct returns the number of distinct items
+func distinct(items []string) int {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		seen[item] = true
+	}
+	return len(seen)
+}
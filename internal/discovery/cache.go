@@ -0,0 +1,120 @@
+package discovery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// CacheEntry is the last model list fetched from an endpoint
+type CacheEntry struct {
+	Models  []string  `json:"models"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// Cache keeps the model list of each endpoint and key in a JSON file, so the model menu stays usable
+// while an endpoint's /v1/models is down
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	Entries map[string]CacheEntry `json:"endpoints"`
+}
+
+// LoadCache reads the cache at path, a missing or unreadable file is an empty cache
+func LoadCache(path string) *Cache {
+	c := &Cache{path: path, Entries: make(map[string]CacheEntry)}
+	if path == "" {
+		return c
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	// A broken cache only costs the offline fallback
+	if json.Unmarshal(data, c) != nil || c.Entries == nil {
+		c.Entries = make(map[string]CacheEntry)
+	}
+	return c
+}
+
+// Models lists the models the key can access on the endpoint and caches them. When the list cannot be fetched
+// the cached list of the same key is returned instead, with the time it was fetched; the time is zero for a live list.
+// A rejected key never falls back to the cache.
+func (c *Cache) Models(ctx context.Context, apiURL, key string) ([]string, time.Time, error) {
+	models, err := Models(ctx, apiURL, key)
+	entryKey := cacheKey(apiURL, key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.IsUnauthorized() {
+			return nil, time.Time{}, err
+		}
+		if entry, ok := c.Entries[entryKey]; ok && len(entry.Models) > 0 {
+			return entry.Models, entry.Fetched, nil
+		}
+		return nil, time.Time{}, err
+	}
+	c.Entries[entryKey] = CacheEntry{Models: models, Fetched: time.Now()}
+	if err := c.save(); err != nil {
+		logger.Debug("%v", err)
+	}
+	return models, time.Time{}, nil
+}
+
+// save writes the cache back to its file, through a temporary file so a concurrent reader never sees half of it
+func (c *Cache) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal model cache: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("保存模型列表缓存失败: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("保存模型列表缓存失败: %v", err)
+	}
+	return nil
+}
+
+// cacheKey identifies the endpoint and key of a model list, the chat route and a trailing slash do not matter.
+// The key is stored as a short hash.
+func cacheKey(apiURL, key string) string {
+	endpoint := strings.TrimRight(util.BaseURL(apiURL), "/")
+	if isGemini(apiURL) {
+		endpoint = strings.TrimRight(apiURL, "/")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return endpoint + "#" + hex.EncodeToString(sum[:6])
+}
+
+// CachedAgo describes how long ago a cached list was fetched, e.g. "缓存于 3 天前"
+func CachedAgo(fetched, now time.Time) string {
+	d := now.Sub(fetched)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("缓存于 %d 分钟前", max(1, int(d.Minutes())))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("缓存于 %d 小时前", int(d.Hours()))
+	default:
+		return fmt.Sprintf("缓存于 %d 天前", int(d.Hours()/24))
+	}
+}
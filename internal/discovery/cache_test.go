@@ -0,0 +1,62 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheOffline(t *testing.T) {
+	var down, revoked atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if revoked.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o"},{"id":"gpt-4o-mini"}]}`))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "models.json")
+
+	models, cached, err := LoadCache(path).Models(context.Background(), srv.URL+"/v1/chat/completions", "sk-test")
+	assert.NoError(t, err)
+	assert.True(t, cached.IsZero())
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, models)
+
+	// A later run reuses the list while /v1/models is down, the trailing slash does not matter
+	down.Store(true)
+	models, cached, err = LoadCache(path).Models(context.Background(), srv.URL+"/", "sk-test")
+	assert.NoError(t, err)
+	assert.False(t, cached.IsZero())
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, models)
+
+	_, _, err = LoadCache(path).Models(context.Background(), "http://127.0.0.1:1/v1/chat/completions", "sk-test")
+	assert.Error(t, err, "no cached list for another endpoint")
+	_, _, err = LoadCache(path).Models(context.Background(), srv.URL, "sk-other")
+	assert.Error(t, err, "no cached list for another key")
+
+	// A rejected key is reported even when it has a cached list
+	revoked.Store(true)
+	_, _, err = LoadCache(path).Models(context.Background(), srv.URL, "sk-test")
+	var statusErr *StatusError
+	if assert.ErrorAs(t, err, &statusErr) {
+		assert.True(t, statusErr.IsUnauthorized())
+	}
+}
+
+func TestCachedAgo(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, "缓存于 1 分钟前", CachedAgo(now.Add(-10*time.Second), now))
+	assert.Equal(t, "缓存于 5 小时前", CachedAgo(now.Add(-5*time.Hour), now))
+	assert.Equal(t, "缓存于 3 天前", CachedAgo(now.Add(-75*time.Hour), now))
+}
@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Timeout bounds the model list request
+const Timeout = 15 * time.Second
+
+// MaxBodySize limits how much of the model list is read
+const MaxBodySize = 4 << 20
+
+// geminiHost serves the Gemini API, its model list has a different format
+const geminiHost = "generativelanguage.googleapis.com"
+
+// anthropicHost serves the Anthropic API, it takes the key in x-api-key
+const anthropicHost = "api.anthropic.com"
+
+// StatusError is returned when the endpoint answers the model list with a non-200 status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("获取模型列表失败: [%d] %s", e.StatusCode, util.Truncate(e.Body, 200))
+}
+
+// IsUnauthorized reports whether the key was rejected by the endpoint
+func (e *StatusError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// Models lists the models the key can access on the endpoint, sorted by name.
+// OpenAI compatible and Anthropic endpoints are asked at /v1/models, Gemini at its models route.
+// An empty key lists the models of a local endpoint without an Authorization header.
+func Models(ctx context.Context, apiURL, key string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	gemini, anthropic := isGemini(apiURL), isHost(apiURL, anthropicHost)
+	listURL := util.BaseURL(apiURL) + "/v1/models"
+	switch {
+	case gemini:
+		listURL = strings.TrimRight(apiURL, "/") + "?pageSize=1000"
+	case anthropic:
+		listURL = "https://" + anthropicHost + "/v1/models?limit=1000"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	switch {
+	case gemini:
+		req.Header.Set("x-goog-api-key", key)
+	case anthropic:
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", config.AnthropicVersion)
+	case key != "":
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	logger.AddSecret(key)
+	logger.DebugRequest(req)
+
+	resp, err := httpclient.New(Timeout).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取模型列表失败: %v", err)
+	}
+	defer resp.Body.Close()
+	logger.DebugResponse(resp)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("读取模型列表失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	var models []string
+	if gemini {
+		models, err = parseGemini(body)
+	} else {
+		models, err = parseOpenAI(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("模型列表为空")
+	}
+	return models, nil
+}
+
+// parseOpenAI parses a {"data":[{"id":"gpt-4o"}]} model list
+func parseOpenAI(body []byte) ([]string, error) {
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %v", err)
+	}
+	ids := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		ids = append(ids, m.ID)
+	}
+	return uniqueSorted(ids), nil
+}
+
+// parseGemini parses a Gemini model list, keeping the models that support generateContent
+func parseGemini(body []byte) ([]string, error) {
+	var list struct {
+		Models []struct {
+			Name    string   `json:"name"`
+			Methods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %v", err)
+	}
+	var ids []string
+	for _, m := range list.Models {
+		for _, method := range m.Methods {
+			if method == "generateContent" {
+				ids = append(ids, strings.TrimPrefix(m.Name, "models/"))
+				break
+			}
+		}
+	}
+	return uniqueSorted(ids), nil
+}
+
+// uniqueSorted removes empty and duplicate IDs and sorts the rest
+func uniqueSorted(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isGemini reports whether apiURL is the Gemini API
+func isGemini(apiURL string) bool {
+	return isHost(apiURL, geminiHost)
+}
+
+// isHost reports whether apiURL is served by host
+func isHost(apiURL, host string) bool {
+	u, err := url.Parse(apiURL)
+	return err == nil && u.Hostname() == host
+}
@@ -0,0 +1,41 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"invalid key"}}`))
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o"},{"id":"claude-3-5-sonnet-20241022"},{"id":"gpt-4o"}]}`))
+	}))
+	defer srv.Close()
+
+	models, err := Models(context.Background(), srv.URL+"/v1/chat/completions", "sk-test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"claude-3-5-sonnet-20241022", "gpt-4o"}, models)
+
+	_, err = Models(context.Background(), srv.URL+"/v1/chat/completions", "sk-wrong")
+	assert.ErrorContains(t, err, "[401]")
+}
+
+func TestParseGemini(t *testing.T) {
+	models, err := parseGemini([]byte(`{"models":[
+		{"name":"models/gemini-1.5-pro","supportedGenerationMethods":["generateContent","countTokens"]},
+		{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]},
+		{"name":"models/gemini-1.5-flash","supportedGenerationMethods":["generateContent"]}
+	]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gemini-1.5-flash", "gemini-1.5-pro"}, models)
+	assert.True(t, isGemini("https://generativelanguage.googleapis.com/v1beta/models"))
+}
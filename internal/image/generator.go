@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/dchest/captcha"
 	"github.com/go-coders/check-gpt/internal/interfaces"
@@ -11,54 +12,124 @@ import (
 	"github.com/go-coders/check-gpt/pkg/logger"
 )
 
-const validChars = "0123456789"
+// Characters of the captcha charsets
+const (
+	DigitChars = "0123456789"
+	AlnumChars = "34679ACDEFGHJKMNPQRTUVWXY" // 去掉 0/O、1/I/L、2/Z、5/S、8/B 等易混淆字符
+)
+
+// defaultLength is the length of random captcha text
+const defaultLength = 6
 
 // Generator handles image generation
 type Generator struct {
 	imageType config.ImageType
+	chars     string
+	length    int
+	fontSize  int
+	prompt    string
+}
+
+// Option configures a Generator
+type Option func(*Generator)
+
+// WithChars sets the characters the captcha text is made of
+func WithChars(chars string) Option {
+	return func(g *Generator) {
+		if chars != "" {
+			g.chars = chars
+		}
+	}
+}
+
+// WithLength sets the length of random captcha text
+func WithLength(length int) Option {
+	return func(g *Generator) {
+		if length > 0 {
+			g.length = length
+		}
+	}
+}
+
+// WithFontSize sets the glyph height in pixels, 0 fits the glyphs to the image
+func WithFontSize(size int) Option {
+	return func(g *Generator) {
+		g.fontSize = size
+	}
+}
+
+// WithPrompt sets the question sent along with the captcha image
+func WithPrompt(prompt string) Option {
+	return func(g *Generator) {
+		g.prompt = prompt
+	}
 }
 
-// generateRandomDigits generates random digits of specified length
-func generateRandomDigits(length int) string {
+// Charset returns the characters of the named charset
+func Charset(name string) (string, error) {
+	switch name {
+	case config.CharsetDigits:
+		return DigitChars, nil
+	case config.CharsetAlnum:
+		return AlnumChars, nil
+	default:
+		return "", fmt.Errorf("验证码字符集无效: %s (可选: %s, %s)", name, config.CharsetDigits, config.CharsetAlnum)
+	}
+}
+
+// RandomText returns length random characters of chars
+func RandomText(length int, chars string) string {
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = validChars[rand.Intn(len(validChars))]
+		b[i] = chars[rand.Intn(len(chars))]
 	}
 	return string(b)
 }
 
 // New creates a new image generator
-func New(imageType config.ImageType) *Generator {
-	return &Generator{
+func New(imageType config.ImageType, opts ...Option) *Generator {
+	g := &Generator{
 		imageType: imageType,
+		chars:     DigitChars,
+		length:    defaultLength,
 	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
 }
 
 // GenerateCaptcha generates a captcha image with the provided text
-// If text is empty, it will generate random digits
+// If text is empty, it will generate random characters of the charset
 func (g *Generator) GenerateCaptcha(width, height int, text string) (*interfaces.CaptchaResult, error) {
 	if width <= 0 || height <= 0 {
 		return nil, fmt.Errorf("invalid dimensions: width and height must be positive")
 	}
 
-	var numericText string
-
-	if text != "" {
-		logger.Debug("generate captcha text: %s", text)
-		// Convert text to digits (only keep numeric characters)
-		for _, ch := range text {
-			if ch >= '0' && ch <= '9' {
-				numericText += string(ch)
-			}
+	// Only keep the characters of the charset
+	var captchaText []byte
+	for _, ch := range strings.ToUpper(text) {
+		if strings.ContainsRune(g.chars, ch) {
+			captchaText = append(captchaText, byte(ch))
 		}
-		if numericText == "" {
-			// If no numeric characters found, generate random digits
-			numericText = generateRandomDigits(6)
+	}
+	if len(captchaText) == 0 {
+		captchaText = []byte(RandomText(g.length, g.chars))
+	}
+	logger.Debug("generate captcha text: %s", captchaText)
+
+	// Generate a random ID for this captcha
+	id := fmt.Sprintf("%d", rand.Int63())
+
+	// The digit renderer only draws digits and picks its own font size
+	if g.chars != DigitChars || g.fontSize > 0 {
+		image, err := renderText(width, height, string(captchaText), g.fontSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate captcha image: %v", err)
 		}
-	} else {
-		// Generate random digits
-		numericText = generateRandomDigits(6)
+		return &interfaces.CaptchaResult{Image: image, Text: string(captchaText), ID: id}, nil
 	}
+	numericText := string(captchaText)
 
 	// Convert ASCII digits to numeric values (0-9)
 	digits := make([]byte, len(numericText))
@@ -66,9 +137,6 @@ func (g *Generator) GenerateCaptcha(width, height int, text string) (*interfaces
 		digits[i] = byte(ch - '0') // Convert ASCII digit to actual number
 	}
 
-	// Generate a random ID for this captcha
-	id := fmt.Sprintf("%d", rand.Int63())
-
 	// Create the image directly
 	img := captcha.NewImage(id, digits, width, height)
 
@@ -87,6 +155,17 @@ func (g *Generator) GenerateCaptcha(width, height int, text string) (*interfaces
 	}, nil
 }
 
+// Generate generates a captcha of random text with the question asking for it
+func (g *Generator) Generate(width, height int) (*interfaces.CaptchaResult, error) {
+	result, err := g.GenerateCaptcha(width, height, "")
+	if err != nil {
+		return nil, err
+	}
+	result.Prompt = g.prompt
+	result.Label = "验证码"
+	return result, nil
+}
+
 // VerifyCaptcha verifies the captcha digits
 func (g *Generator) VerifyCaptcha(id string, digits string) bool {
 	return true // Since we're not using the store anymore, verification is always true
@@ -175,3 +175,38 @@ func TestGenerateCaptcha_DifferentResults(t *testing.T) {
 		assert.Contains(t, "0123456789", string(char), "Should only contain digits")
 	}
 }
+
+func TestGenerateCaptchaCharset(t *testing.T) {
+	generator := New(config.PNG, WithChars(AlnumChars), WithLength(8))
+
+	result, err := generator.GenerateCaptcha(100, 50, "")
+	assert.NoError(t, err)
+	assert.Len(t, result.Text, 8)
+	for _, ch := range result.Text {
+		assert.Contains(t, AlnumChars, string(ch))
+	}
+
+	// Lowercase input is accepted, ambiguous characters are dropped
+	result, err = generator.GenerateCaptcha(200, 80, "ab7k0o")
+	assert.NoError(t, err)
+	assert.Equal(t, "A7K", result.Text)
+	img, err := png.Decode(bytes.NewReader(result.Image))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, img.Bounds().Dx())
+	assert.True(t, hasContent(img))
+
+	// Digits with a font size use the bitmap renderer
+	result, err = New(config.PNG, WithFontSize(21)).GenerateCaptcha(200, 80, "4096")
+	assert.NoError(t, err)
+	assert.Equal(t, "4096", result.Text)
+
+	_, err = Charset("hex")
+	assert.Error(t, err)
+}
+
+func TestGlyphsCoverCharsets(t *testing.T) {
+	for _, ch := range DigitChars + AlnumChars {
+		_, ok := glyphs[ch]
+		assert.True(t, ok, "missing glyph %q", ch)
+	}
+}
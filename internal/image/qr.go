@@ -0,0 +1,206 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// A version 1 QR code with error correction level L holds up to 17 bytes
+const (
+	qrSize       = 21
+	qrDataBytes  = 19
+	qrECBytes    = 7
+	qrMaxPayload = 17
+	qrQuietZone  = 4
+)
+
+// qrEncode returns the modules of a version 1-L QR code encoding text in byte mode, true is dark
+func qrEncode(text string) ([][]bool, error) {
+	if len(text) > qrMaxPayload {
+		return nil, fmt.Errorf("QR payload too long: %d bytes (max %d)", len(text), qrMaxPayload)
+	}
+
+	// Mode indicator, character count, data, terminator, then the alternating pad bytes
+	var bits []bool
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, v>>i&1 == 1)
+		}
+	}
+	appendBits(0x4, 4)
+	appendBits(len(text), 8)
+	for i := 0; i < len(text); i++ {
+		appendBits(int(text[i]), 8)
+	}
+	appendBits(0, min(4, qrDataBytes*8-len(bits)))
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	data := make([]byte, 0, qrDataBytes+qrECBytes)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		data = append(data, b)
+	}
+	for pad := byte(0xEC); len(data) < qrDataBytes; pad ^= 0xEC ^ 0x11 {
+		data = append(data, pad)
+	}
+	data = append(data, rsRemainder(data, rsDivisor(qrECBytes))...)
+
+	modules := make([][]bool, qrSize)
+	function := make([][]bool, qrSize)
+	for i := range modules {
+		modules[i] = make([]bool, qrSize)
+		function[i] = make([]bool, qrSize)
+	}
+	set := func(x, y int, dark bool) {
+		modules[y][x] = dark
+		function[y][x] = true
+	}
+
+	// Timing patterns, then the finder patterns with their separators
+	for i := 0; i < qrSize; i++ {
+		set(6, i, i%2 == 0)
+		set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {qrSize - 4, 3}, {3, qrSize - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || x >= qrSize || y < 0 || y >= qrSize {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				set(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+	// Reserve the format areas, the real bits are drawn after masking
+	drawFormat(set, 0)
+
+	// Data modules zigzag upwards and downwards in column pairs from the bottom right, skipping the timing column
+	i := 0
+	for right := qrSize - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qrSize; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = qrSize - 1 - vert
+				}
+				if function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				modules[y][x] = data[i>>3]>>(7-i&7)&1 == 1
+				i++
+			}
+		}
+	}
+
+	// Mask pattern 0 inverts every module with an even x + y
+	for y := 0; y < qrSize; y++ {
+		for x := 0; x < qrSize; x++ {
+			if !function[y][x] && (x+y)%2 == 0 {
+				modules[y][x] = !modules[y][x]
+			}
+		}
+	}
+	drawFormat(set, qrFormatBits(0))
+	return modules, nil
+}
+
+// qrFormatBits returns the 15 format bits of error correction level L with the mask
+func qrFormatBits(mask int) int {
+	data := 1<<3 | mask // Level L is 01
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormat draws both copies of the format bits, along with the dark module
+func drawFormat(set func(x, y int, dark bool), bits int) {
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		set(8, i, bit(i))
+	}
+	set(8, 7, bit(6))
+	set(8, 8, bit(7))
+	set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		set(qrSize-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		set(8, qrSize-15+i, bit(i))
+	}
+	set(8, qrSize-8, true)
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the degree, highest coefficient omitted
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords of data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// drawQR draws the modules with the quiet zone, as large as fits in a side x side square at x, y
+func drawQR(img *image.RGBA, x, y, side int, modules [][]bool) {
+	n := len(modules) + 2*qrQuietZone
+	scale := max(side/n, 1)
+	offset := (side - n*scale) / 2
+	fillRect(img, x, y, side, side, color.White)
+	for my, row := range modules {
+		for mx, dark := range row {
+			if dark {
+				fillRect(img, x+offset+(mx+qrQuietZone)*scale, y+offset+(my+qrQuietZone)*scale, scale, scale, color.Black)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-M data, from the worked example of the QR specification
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, rsRemainder(data, rsDivisor(10)))
+}
+
+func TestQRFormatBits(t *testing.T) {
+	assert.Equal(t, 0b111011111000100, qrFormatBits(0))
+}
+
+func TestQREncode(t *testing.T) {
+	modules, err := qrEncode("CHECKGPT")
+	assert.NoError(t, err)
+	assert.Len(t, modules, qrSize)
+
+	// Finder pattern corners and the dark module
+	assert.True(t, modules[0][0])
+	assert.True(t, modules[0][qrSize-1])
+	assert.True(t, modules[qrSize-1][0])
+	assert.True(t, modules[qrSize-8][8])
+
+	_, err = qrEncode("this payload is too long")
+	assert.Error(t, err)
+}
@@ -0,0 +1,246 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/interfaces"
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// Renderer names, selected with -probe-image
+const (
+	RendererCaptcha   = "captcha"   // 数字或字母验证码
+	RendererShapes    = "shapes"    // 彩色图形
+	RendererQR        = "qr"        // 二维码
+	RendererWatermark = "watermark" // 文字水印
+)
+
+// renderers creates the image generator of every renderer from the configuration
+var renderers = map[string]func(cfg *config.Config) (interfaces.ImageGenerator, error){
+	RendererCaptcha: func(cfg *config.Config) (interfaces.ImageGenerator, error) {
+		chars, err := Charset(cfg.CaptchaCharset)
+		if err != nil {
+			return nil, err
+		}
+		return New(config.PNG, WithChars(chars), WithLength(cfg.CaptchaLength),
+			WithFontSize(cfg.CaptchaFontSize), WithPrompt(cfg.CaptchaPrompt())), nil
+	},
+	RendererShapes: func(cfg *config.Config) (interfaces.ImageGenerator, error) {
+		return shapesRenderer{prompt: cfg.Localize(shapesPrompt, shapesPromptZH)}, nil
+	},
+	RendererQR: func(cfg *config.Config) (interfaces.ImageGenerator, error) {
+		return qrRenderer{prompt: cfg.Localize(qrPrompt, qrPromptZH)}, nil
+	},
+	RendererWatermark: func(cfg *config.Config) (interfaces.ImageGenerator, error) {
+		return watermarkRenderer{prompt: cfg.Localize(watermarkPrompt, watermarkPromptZH)}, nil
+	},
+}
+
+// Renderers returns the names of all renderers
+func Renderers() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRenderer returns the image generator of the named renderer
+func NewRenderer(name string, cfg *config.Config) (interfaces.ImageGenerator, error) {
+	newRenderer, ok := renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("探测图片类型无效: %s (可选: %s)", name, strings.Join(Renderers(), ", "))
+	}
+	return newRenderer(cfg)
+}
+
+// Minimum sizes of the scene renderers, the captcha size is too small to read shapes or QR modules
+const (
+	minSceneWidth  = 240
+	minSceneHeight = 120
+	minQRSide      = 29 * 4
+)
+
+// Default questions of the scene renderers
+const (
+	shapesPrompt    = "What are the colors of the shapes in the image from left to right? Answer with color names only."
+	qrPrompt        = "What text does the QR code in the image contain?"
+	watermarkPrompt = "What word is written as a watermark in the image?"
+
+	shapesPromptZH    = "图片中从左到右的图形分别是什么颜色? 只回答颜色名称。"
+	qrPromptZH        = "图片中的二维码包含什么文字?"
+	watermarkPromptZH = "图片中作为水印的单词是什么?"
+)
+
+// Colors of the shapes scene and the names the model is expected to answer with, in English and Chinese
+var shapeColors = []struct {
+	name string
+	zh   string
+	rgba color.RGBA
+}{
+	{"red", "红", color.RGBA{220, 30, 30, 255}},
+	{"green", "绿", color.RGBA{30, 160, 40, 255}},
+	{"blue", "蓝", color.RGBA{30, 70, 220, 255}},
+	{"yellow", "黄", color.RGBA{240, 200, 0, 255}},
+	{"purple", "紫", color.RGBA{140, 40, 180, 255}},
+	{"orange", "橙", color.RGBA{250, 130, 0, 255}},
+	{"black", "黑", color.RGBA{20, 20, 20, 255}},
+}
+
+// shapesRenderer draws a row of colored circles, squares and triangles
+type shapesRenderer struct {
+	prompt string
+}
+
+// Generate draws 3 to 4 shapes of different colors and asks for their colors from left to right,
+// an answer with the Chinese color names, with or without 色, is correct as well
+func (r shapesRenderer) Generate(width, height int) (*interfaces.CaptchaResult, error) {
+	width, height = max(width, minSceneWidth), max(height, minSceneHeight)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, color.RGBA{245, 245, 240, 255})
+
+	n := 3 + rand.Intn(2)
+	colors := rand.Perm(len(shapeColors))[:n]
+	cell := width / n
+	size := min(cell, height) * 6 / 10
+	var names, zh, zhShort []string
+	for i, c := range colors {
+		cx := cell*i + cell/2
+		cy := height/2 + rand.Intn(height/5+1) - height/10
+		ink := shapeColors[c].rgba
+		switch rand.Intn(3) {
+		case 0:
+			fillCircle(img, cx, cy, size/2, ink)
+		case 1:
+			fillRect(img, cx-size/2, cy-size/2, size, size, ink)
+		default:
+			fillTriangle(img, cx, cy, size, ink)
+		}
+		names = append(names, shapeColors[c].name)
+		zh = append(zh, shapeColors[c].zh+"色")
+		zhShort = append(zhShort, shapeColors[c].zh)
+	}
+
+	result, err := newResult(img, strings.Join(names, ", "), r.prompt, "彩色图形")
+	if err != nil {
+		return nil, err
+	}
+	result.Alternatives = []string{strings.Join(zh, ", "), strings.Join(zhShort, ", ")}
+	return result, nil
+}
+
+// qrRenderer draws a QR code of random text
+type qrRenderer struct {
+	prompt string
+}
+
+// Generate draws a QR code of 8 random characters and asks for its content
+func (r qrRenderer) Generate(width, height int) (*interfaces.CaptchaResult, error) {
+	text := RandomText(8, AlnumChars)
+	modules, err := qrEncode(text)
+	if err != nil {
+		return nil, err
+	}
+	side := max(min(width, height), minQRSide)
+	img := image.NewRGBA(image.Rect(0, 0, side, side))
+	drawQR(img, 0, 0, side, modules)
+	return newResult(img, text, r.prompt, "二维码")
+}
+
+// watermarkWords are the words hidden in the watermark renderer
+var watermarkWords = []string{
+	"APPLE", "RIVER", "TIGER", "CLOUD", "MANGO", "PIANO", "LEMON", "OCEAN",
+	"ROBOT", "CANDLE", "FOREST", "BRIDGE", "GARDEN", "PLANET", "SILVER", "WINTER",
+}
+
+// watermarkRenderer blends a word into a busy background like a watermark on a photo
+type watermarkRenderer struct {
+	prompt string
+}
+
+// Generate tiles colored blocks as the picture, blends a random word over it and asks for the word
+func (r watermarkRenderer) Generate(width, height int) (*interfaces.CaptchaResult, error) {
+	width, height = max(width, minSceneWidth), max(height, minSceneHeight)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	block := max(height/6, 4)
+	for y := 0; y < height; y += block {
+		for x := 0; x < width; x += block {
+			fillRect(img, x, y, block, block, color.RGBA{uint8(60 + rand.Intn(150)), uint8(60 + rand.Intn(150)), uint8(60 + rand.Intn(150)), 255})
+		}
+	}
+
+	word := watermarkWords[rand.Intn(len(watermarkWords))]
+	dot := fitDot(width*8/10, height/2, len(word), 0)
+	x0 := (width - textWidth(len(word), dot)) / 2
+	y0 := (height - glyphHeight*dot) / 2
+	mark := &blend{img: img, c: color.RGBA{255, 255, 255, 255}, alpha: 0.6}
+	for i, ch := range word {
+		if err := drawGlyph(mark, x0+i*(glyphWidth+1)*dot, y0, dot, ch, mark.c); err != nil {
+			return nil, err
+		}
+	}
+	return newResult(img, word, r.prompt, "文字水印")
+}
+
+// blend draws over img with partial opacity
+type blend struct {
+	img   *image.RGBA
+	c     color.RGBA
+	alpha float64
+}
+
+func (b *blend) ColorModel() color.Model { return b.img.ColorModel() }
+func (b *blend) Bounds() image.Rectangle { return b.img.Bounds() }
+func (b *blend) At(x, y int) color.Color { return b.img.At(x, y) }
+
+// Set mixes the mark color into the pixel instead of replacing it
+func (b *blend) Set(x, y int, _ color.Color) {
+	under := b.img.RGBAAt(x, y)
+	mix := func(top, bottom uint8) uint8 {
+		return uint8(b.alpha*float64(top) + (1-b.alpha)*float64(bottom))
+	}
+	b.img.SetRGBA(x, y, color.RGBA{mix(b.c.R, under.R), mix(b.c.G, under.G), mix(b.c.B, under.B), 255})
+}
+
+// fillCircle fills the circle of radius r centered at cx, cy
+func fillCircle(img *image.RGBA, cx, cy, r int, c color.Color) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				img.Set(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+// fillTriangle fills an upward triangle of the given size centered at cx, cy
+func fillTriangle(img *image.RGBA, cx, cy, size int, c color.Color) {
+	top := cy - size/2
+	for row := 0; row < size; row++ {
+		half := row / 2
+		fillRect(img, cx-half, top+row, half*2+1, 1, c)
+	}
+}
+
+// newResult encodes img and returns it with the expected answer and the question
+func newResult(img image.Image, answer, prompt, label string) (*interfaces.CaptchaResult, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %v", err)
+	}
+	return &interfaces.CaptchaResult{
+		Image:  buf.Bytes(),
+		Text:   answer,
+		ID:     fmt.Sprintf("%d", rand.Int63()),
+		Prompt: prompt,
+		Label:  label,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package image
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderers(t *testing.T) {
+	cfg := &config.Config{CaptchaLength: 6, CaptchaCharset: config.CharsetDigits, Prompt: "what's the number?"}
+	for _, name := range Renderers() {
+		t.Run(name, func(t *testing.T) {
+			gen, err := NewRenderer(name, cfg)
+			assert.NoError(t, err)
+
+			result, err := gen.Generate(100, 50)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, result.Text)
+			assert.NotEmpty(t, result.Prompt)
+			assert.NotEmpty(t, result.Label)
+
+			img, err := png.Decode(bytes.NewReader(result.Image))
+			assert.NoError(t, err)
+			assert.True(t, hasContent(img))
+		})
+	}
+
+	_, err := NewRenderer("unknown", cfg)
+	assert.Error(t, err)
+}
+
+func TestShapesAnswer(t *testing.T) {
+	result, err := shapesRenderer{}.Generate(240, 120)
+	assert.NoError(t, err)
+	colors := bytes.Count([]byte(result.Text), []byte(", ")) + 1
+	assert.True(t, colors == 3 || colors == 4, result.Text)
+}
+
+func TestShapesChineseAnswer(t *testing.T) {
+	gen, err := NewRenderer(RendererShapes, &config.Config{PromptLang: config.PromptLangBoth})
+	assert.NoError(t, err)
+	result, err := gen.Generate(240, 120)
+	assert.NoError(t, err)
+	assert.Equal(t, shapesPrompt+"\n"+shapesPromptZH, result.Prompt)
+	assert.Len(t, result.Alternatives, 2)
+	assert.Contains(t, result.Alternatives[0], "色")
+}
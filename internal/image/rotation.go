@@ -0,0 +1,101 @@
+package image
+
+import (
+	"github.com/go-coders/check-gpt/internal/interfaces"
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// rotationOrder is the order the renderers take turns in a multi-round detection
+var rotationOrder = []string{RendererCaptcha, RendererShapes, RendererQR, RendererWatermark}
+
+// phrase is one question in English and Chinese
+type phrase struct{ en, zh string }
+
+// rephrasings are the questions asked in turn for every renderer, the first one is the default
+var rephrasings = map[string][]phrase{
+	RendererShapes: {
+		{shapesPrompt, shapesPromptZH},
+		{"List the colors of the shapes from left to right, color names only.", "从左到右列出图形的颜色, 只写颜色名称。"},
+		{"Starting from the leftmost shape, name the color of each shape.", "从最左边的图形开始, 说出每个图形的颜色。"},
+	},
+	RendererQR: {
+		{qrPrompt, qrPromptZH},
+		{"Decode the QR code in the image and reply with its content only.", "识别图片中的二维码, 只回复其内容。"},
+		{"Scan this QR code, what does it say?", "扫描这个二维码, 内容是什么?"},
+	},
+	RendererWatermark: {
+		{watermarkPrompt, watermarkPromptZH},
+		{"Which word is hidden in the picture?", "图片中隐藏了哪个单词?"},
+		{"Read the faint word drawn over the picture.", "读出图片上淡淡的单词。"},
+	},
+}
+
+// captchaRephrasings returns the questions of the captcha renderer, the configured prompt first
+func captchaRephrasings(cfg *config.Config) []string {
+	if cfg.CaptchaCharset == config.CharsetAlnum {
+		return []string{cfg.CaptchaPrompt(),
+			cfg.Localize("Read the code in the image.", "读出图片中的验证码。"),
+			cfg.Localize("Which letters and digits are shown in this picture?", "这张图片中显示了哪些字母和数字?")}
+	}
+	return []string{cfg.CaptchaPrompt(),
+		cfg.Localize("Read the number in the image.", "读出图片中的数字。"),
+		cfg.Localize("Which digits are shown in this picture?", "这张图片中显示了哪些数字?")}
+}
+
+// localizedRephrasings returns the questions of a scene renderer in the prompt language
+func localizedRephrasings(name string, cfg *config.Config) []string {
+	var prompts []string
+	for _, p := range rephrasings[name] {
+		prompts = append(prompts, cfg.Localize(p.en, p.zh))
+	}
+	return prompts
+}
+
+// Rotation takes turns between the renderers, asking a different question every round,
+// so a relay cannot pass the detection by learning the answer to a single kind of probe
+type Rotation struct {
+	names   []string
+	gens    []interfaces.ImageGenerator
+	prompts [][]string
+	round   int
+}
+
+// NewRotation returns a rotation starting at the named renderer
+func NewRotation(first string, cfg *config.Config) (*Rotation, error) {
+	start := 0
+	for i, name := range rotationOrder {
+		if name == first {
+			start = i
+		}
+	}
+	r := &Rotation{}
+	for i := range rotationOrder {
+		name := rotationOrder[(start+i)%len(rotationOrder)]
+		gen, err := NewRenderer(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		prompts := localizedRephrasings(name, cfg)
+		if name == RendererCaptcha {
+			prompts = captchaRephrasings(cfg)
+		}
+		r.names = append(r.names, name)
+		r.gens = append(r.gens, gen)
+		r.prompts = append(r.prompts, prompts)
+	}
+	return r, nil
+}
+
+// Generate generates the probe of the next round
+func (r *Rotation) Generate(width, height int) (*interfaces.CaptchaResult, error) {
+	i := r.round % len(r.gens)
+	result, err := r.gens[i].Generate(width, height)
+	if err != nil {
+		return nil, err
+	}
+	// The question changes every round as well, also when the same renderer comes around again
+	prompts := r.prompts[i]
+	result.Prompt = prompts[r.round%len(prompts)]
+	r.round++
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotation(t *testing.T) {
+	cfg := &config.Config{CaptchaLength: 6, CaptchaCharset: config.CharsetDigits, Prompt: "what's the number?"}
+	rotation, err := NewRotation(RendererShapes, cfg)
+	assert.NoError(t, err)
+
+	var labels, prompts []string
+	for i := 0; i < len(rotationOrder)+1; i++ {
+		result, err := rotation.Generate(100, 50)
+		assert.NoError(t, err)
+		labels = append(labels, result.Label)
+		prompts = append(prompts, result.Prompt)
+	}
+
+	// Starts at the configured renderer and wraps around with a different question
+	assert.Equal(t, []string{"彩色图形", "二维码", "文字水印", "验证码", "彩色图形"}, labels)
+	assert.Equal(t, shapesPrompt, prompts[0])
+	assert.Equal(t, "what's the number?", prompts[3])
+	assert.NotEqual(t, prompts[0], prompts[4])
+}
+
+func TestRotationChinese(t *testing.T) {
+	cfg := &config.Config{CaptchaLength: 6, CaptchaCharset: config.CharsetDigits, Prompt: "what's the number?", PromptLang: config.PromptLangZH}
+	rotation, err := NewRotation(RendererCaptcha, cfg)
+	assert.NoError(t, err)
+
+	result, err := rotation.Generate(100, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, config.PromptZH, result.Prompt)
+	result, err = rotation.Generate(100, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, rephrasings[RendererShapes][1].zh, result.Prompt)
+}
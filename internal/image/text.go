@@ -0,0 +1,156 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand"
+)
+
+// glyphs is a 5x7 bitmap font, each row holds 5 bits with the leftmost pixel in bit 4
+var glyphs = map[rune][7]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x0A, 0x04, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+}
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// renderText draws text with the bitmap font on a noisy background.
+// fontSize is the glyph height in pixels, 0 fits the glyphs to the image.
+func renderText(width, height int, text string, fontSize int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Light speckles keep the background from being a flat color
+			v := uint8(215 + rand.Intn(41))
+			img.Set(x, y, color.RGBA{v, v, uint8(200 + rand.Intn(56)), 255})
+		}
+	}
+
+	dot := fitDot(width*9/10, height*7/10, len(text), fontSize)
+	if dot < 1 {
+		return nil, fmt.Errorf("image too small for %d characters", len(text))
+	}
+	x0 := (width - textWidth(len(text), dot)) / 2
+	for i, ch := range text {
+		ink := color.RGBA{uint8(rand.Intn(120)), uint8(rand.Intn(120)), uint8(rand.Intn(120)), 255}
+		gx := x0 + i*(glyphWidth+1)*dot
+		gy := (height-glyphHeight*dot)/2 + rand.Intn(dot*2+1) - dot
+		if err := drawGlyph(img, gx, gy, dot, ch, ink); err != nil {
+			return nil, err
+		}
+	}
+
+	// Strike-through lines make the glyphs harder to segment
+	for i := 0; i < 3; i++ {
+		drawLine(img, 0, rand.Intn(height), width-1, rand.Intn(height),
+			color.RGBA{uint8(rand.Intn(160)), uint8(rand.Intn(160)), uint8(rand.Intn(160)), 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fitDot returns the dot size of glyphs fitting n characters into a width x height box,
+// at most fontSize / 7 when a font size is given
+func fitDot(width, height, n, fontSize int) int {
+	// Every glyph takes 5 dots plus 1 dot of spacing
+	dot := fontSize / glyphHeight
+	if fit := width / (n * (glyphWidth + 1)); dot <= 0 || dot > fit {
+		dot = fit
+	}
+	if fit := height / glyphHeight; dot > fit {
+		dot = fit
+	}
+	return dot
+}
+
+// textWidth returns the width of n glyphs drawn with the dot size
+func textWidth(n, dot int) int {
+	return n*(glyphWidth+1)*dot - dot
+}
+
+// drawGlyph draws ch with its top left corner at x, y
+func drawGlyph(img draw.Image, x, y, dot int, ch rune, c color.Color) error {
+	glyph, ok := glyphs[ch]
+	if !ok {
+		return fmt.Errorf("unsupported character: %q", ch)
+	}
+	for row := 0; row < glyphHeight; row++ {
+		for col := 0; col < glyphWidth; col++ {
+			if glyph[row]&(1<<(glyphWidth-1-col)) == 0 {
+				continue
+			}
+			fillRect(img, x+col*dot, y+row*dot, dot, dot, c)
+		}
+	}
+	return nil
+}
+
+// fillRect fills the w x h rectangle at x, y, clipped to the image
+func fillRect(img draw.Image, x, y, w, h int, c color.Color) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.Set(px, py, c)
+		}
+	}
+}
+
+// drawLine draws a one pixel line from x0, y0 to x1, y1
+func drawLine(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	steps := max(abs(x1-x0), abs(y1-y0), 1)
+	for i := 0; i <= steps; i++ {
+		img.Set(x0+(x1-x0)*i/steps, y0+(y1-y0)*i/steps, c)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -21,16 +21,21 @@ type Tunnel interface {
 	URL() string
 	Close() error
 	Ready() <-chan struct{}
+	Err() error // 隧道创建失败的原因, 就绪前和正常时为 nil
 }
 
-// CaptchaResult contains the generated captcha image and its text
+// CaptchaResult contains the generated probe image, the question asked about it and the expected answer
 type CaptchaResult struct {
-	Image []byte
-	Text  string
-	ID    string
+	Image  []byte
+	Text   string // 期望的回答
+	ID     string
+	Prompt string // 随图片发送的问题
+	Label  string // 图片类型, 如 验证码、二维码
+
+	Alternatives []string // 同样正确的其它回答, 如图形颜色的中文名
 }
 
-// ImageGenerator 定义图片生成器接口
+// ImageGenerator 定义图片生成器接口, 每种渲染器生成一类探测图片
 type ImageGenerator interface {
-	GenerateCaptcha(width, height int, text string) (*CaptchaResult, error)
+	Generate(width, height int) (*CaptchaResult, error)
 }
@@ -0,0 +1,312 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/internal/billing"
+	"github.com/go-coders/check-gpt/internal/runlog"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// DefaultModel is the model used for availability checks when none is configured
+const DefaultModel = "gpt-4o-mini"
+
+// Level represents the severity of a finding
+type Level int
+
+const (
+	LevelOK Level = iota
+	LevelWarning
+	LevelError
+)
+
+// Finding represents a single check result for a watchlist item
+type Finding struct {
+	Level   Level
+	Message string
+}
+
+// Report holds all findings for a watchlist item
+type Report struct {
+	Item     config.WatchItem
+	Findings []Finding
+}
+
+// BalanceQuerier queries the balance of a key
+type BalanceQuerier interface {
+	Query(ctx context.Context, url, key string) (*billing.Balance, error)
+}
+
+// Option defines a function type for configuring Monitor
+type Option func(*Monitor)
+
+// WithBalanceQuerier sets the balance querier
+func WithBalanceQuerier(q BalanceQuerier) Option {
+	return func(m *Monitor) {
+		m.billing = q
+	}
+}
+
+// WithTester sets the API tester
+func WithTester(t apitest.APITester) Option {
+	return func(m *Monitor) {
+		m.tester = t
+	}
+}
+
+// WithNow sets the clock function, mainly for tests
+func WithNow(now func() time.Time) Option {
+	return func(m *Monitor) {
+		m.now = now
+	}
+}
+
+// WithRunLog sets the run log every check is appended to
+func WithRunLog(l *runlog.Logger) Option {
+	return func(m *Monitor) {
+		m.runLog = l
+	}
+}
+
+// Monitor periodically checks the keys in the watchlist
+type Monitor struct {
+	cfg     *config.Config
+	billing BalanceQuerier
+	tester  apitest.APITester
+	printer *util.Printer
+	now     func() time.Time
+	runLog  *runlog.Logger
+}
+
+// New creates a new Monitor
+func New(cfg *config.Config, w io.Writer, opts ...Option) *Monitor {
+	var testOpts []apitest.ChannelTestOption
+	if cfg.NoKeyCheck {
+		testOpts = append(testOpts, apitest.WithoutKeyCheck())
+	}
+	m := &Monitor{
+		cfg:     cfg,
+		billing: billing.NewClient(cfg.Timeout),
+		tester:  apitest.NewApiTest(cfg.MaxConcurrency, testOpts...),
+		printer: util.NewPrinter(w),
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Run checks the watchlist every interval until the context is cancelled
+func (m *Monitor) Run(ctx context.Context) error {
+	if len(m.cfg.Watchlist) == 0 {
+		return fmt.Errorf("监控列表为空，请在配置文件中添加: %s", m.cfg.ConfigPath)
+	}
+
+	for {
+		m.PrintReports(m.Check(ctx))
+		m.printer.Printf("\n%s下次检查: %s%s\n", util.ColorGray,
+			util.FormatTime(m.now().Add(m.cfg.MonitorInterval)), util.ColorReset)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(m.cfg.MonitorInterval):
+		}
+	}
+}
+
+// Check runs all checks once and returns a report per watchlist item
+func (m *Monitor) Check(ctx context.Context) []Report {
+	var channels []*apitest.Channel
+	for _, item := range m.cfg.Watchlist {
+		model := item.Model
+		if model == "" {
+			model = DefaultModel
+		}
+		channels = append(channels, &apitest.Channel{
+			Type:      apitest.ChannelTypeOpenAI,
+			Key:       item.Key,
+			TestModel: []string{model},
+			URL:       util.NormalizeURL(item.URL),
+		})
+	}
+
+	// Results are matched back by channel since they arrive in completion order
+	results := make(map[*apitest.Channel]apitest.TestResult)
+	for _, result := range m.tester.TestAllApis(ctx, channels) {
+		results[result.Channel] = result
+	}
+
+	reports := make([]Report, 0, len(m.cfg.Watchlist))
+	for i, item := range m.cfg.Watchlist {
+		report := Report{Item: item}
+		report.Findings = append(report.Findings, m.checkExpiry(item)...)
+		report.Findings = append(report.Findings, checkAvailability(results[channels[i]]))
+		report.Findings = append(report.Findings, m.checkBalance(ctx, item)...)
+		reports = append(reports, report)
+	}
+
+	if err := m.runLog.Append(m.runLogEntry(reports)); err != nil {
+		logger.Debug("Failed to write run log: %v", err)
+	}
+	return reports
+}
+
+// Level returns the most severe level of the report findings
+func (r Report) Level() Level {
+	worst := LevelOK
+	for _, f := range r.Findings {
+		if f.Level > worst {
+			worst = f.Level
+		}
+	}
+	return worst
+}
+
+// runLogEntry summarizes a check for the run log
+func (m *Monitor) runLogEntry(reports []Report) runlog.Entry {
+	e := runlog.Entry{
+		Time: m.now(),
+		Mode: "monitor",
+		Keys: len(reports),
+	}
+	for _, report := range reports {
+		kv := runlog.KeyVerdict{
+			Key:     util.MaskKey(report.Item.Key),
+			KeyID:   runlog.KeyID(report.Item.Key),
+			Verdict: runlog.VerdictOK,
+		}
+		if report.Level() == LevelError {
+			kv.Verdict = runlog.VerdictFailed
+			e.Failed++
+			for _, f := range report.Findings {
+				if f.Level == LevelError {
+					kv.Failed = append(kv.Failed, f.Message)
+				}
+			}
+		} else {
+			e.Success++
+		}
+		e.Details = append(e.Details, kv)
+	}
+	e.Verdict = runlog.Verdict(e.Success, e.Failed)
+	return e
+}
+
+// checkExpiry warns when the key is expired or about to expire
+func (m *Monitor) checkExpiry(item config.WatchItem) []Finding {
+	expiry, ok := item.Expiry()
+	if !ok {
+		return nil
+	}
+
+	// The key is still valid on its expiry day, daysLeft is 0 until the day ends
+	daysLeft := int(math.Ceil(expiry.Sub(m.now()).Hours() / 24))
+	switch {
+	case daysLeft < 0:
+		return []Finding{{Level: LevelError, Message: fmt.Sprintf("已于 %s 过期", item.ExpiresAt)}}
+	case daysLeft == 0:
+		return []Finding{{Level: LevelWarning, Message: fmt.Sprintf("今天到期 (%s)", item.ExpiresAt)}}
+	case daysLeft <= m.cfg.WarnDays:
+		return []Finding{{Level: LevelWarning, Message: fmt.Sprintf("将于 %d 天后过期 (%s)", daysLeft, item.ExpiresAt)}}
+	default:
+		return []Finding{{Level: LevelOK, Message: fmt.Sprintf("有效期至 %s", item.ExpiresAt)}}
+	}
+}
+
+// checkAvailability reports whether the key still works
+func checkAvailability(result apitest.TestResult) Finding {
+	switch {
+	case result.Success:
+		return Finding{Level: LevelOK, Message: fmt.Sprintf("可用 (%s %s)", result.Model, util.FormatLatency(result.Latency))}
+	case result.StatusCode == http.StatusUnauthorized || result.StatusCode == http.StatusForbidden:
+		return Finding{Level: LevelError, Message: "Key 已失效或被吊销"}
+	case result.Error != nil:
+		return Finding{Level: LevelError, Message: util.MaskSecrets(fmt.Sprintf("不可用: %v", result.Error), result.Channel.Key)}
+	default:
+		return Finding{Level: LevelError, Message: "不可用"}
+	}
+}
+
+// checkBalance warns when the detected balance drops below the threshold
+func (m *Monitor) checkBalance(ctx context.Context, item config.WatchItem) []Finding {
+	balance, err := m.billing.Query(ctx, item.URL, item.Key)
+	if err != nil {
+		logger.Debug("Failed to query balance for %s: %v", util.MaskKey(item.Key), err)
+		var statusErr *billing.StatusError
+		if errors.As(err, &statusErr) && statusErr.IsUnauthorized() {
+			return []Finding{{Level: LevelError, Message: "余额查询被拒绝，Key 可能已失效"}}
+		}
+		if item.MinBalance > 0 {
+			return []Finding{{Level: LevelWarning, Message: "无法获取余额，余额阈值检查已跳过"}}
+		}
+		return nil
+	}
+
+	var findings []Finding
+	msg := fmt.Sprintf("余额 $%.2f (总额 $%.2f，已用 $%.2f)", balance.Remaining, balance.Total, balance.Used)
+	if item.MinBalance > 0 && balance.Remaining < item.MinBalance {
+		findings = append(findings, Finding{Level: LevelWarning, Message: fmt.Sprintf("%s，低于阈值 $%.2f", msg, item.MinBalance)})
+	} else {
+		findings = append(findings, Finding{Level: LevelOK, Message: msg})
+	}
+
+	// Relays often report the quota expiry date, warn about it when no date is configured
+	if _, ok := item.Expiry(); !ok && !balance.AccessUntil.IsZero() {
+		detected := item
+		detected.ExpiresAt = balance.AccessUntil.Format(config.ExpiryDateLayout)
+		findings = append(findings, m.checkExpiry(detected)...)
+	}
+	return findings
+}
+
+// PrintReports prints the reports of a check
+func (m *Monitor) PrintReports(reports []Report) {
+	m.printer.PrintTitle(fmt.Sprintf("Key 监控 %s", util.FormatTime(m.now())), util.EmojiKey)
+
+	for i, report := range reports {
+		name := report.Item.Name
+		if name == "" {
+			name = util.BaseURL(report.Item.URL)
+		}
+		m.printer.Printf("%s[%d] %s%s %s%s\n",
+			util.ColorBlue,
+			i+1,
+			util.ColorYellow,
+			name,
+			util.MaskKey(report.Item.Key),
+			util.ColorReset,
+		)
+		for _, f := range report.Findings {
+			switch f.Level {
+			case LevelError:
+				if util.GetVerbosity() < util.VerbosityNormal {
+					// Without the header line the error needs its own context
+					m.printer.ErrorPrintf("%s%s [%d] %s: %s%s\n", util.ColorRed, util.EmojiError, i+1, name, f.Message, util.ColorReset)
+				} else {
+					m.printer.Printf("│ %s%s %s%s\n", util.ColorRed, util.EmojiError, f.Message, util.ColorReset)
+				}
+			case LevelWarning:
+				m.printer.Printf("│ %s%s %s%s\n", util.ColorYellow, util.EmojiWarning, f.Message, util.ColorReset)
+			default:
+				m.printer.Printf("│ %s%s %s%s\n", util.ColorGreen, util.EmojiCheck, f.Message, util.ColorReset)
+			}
+		}
+		m.printer.Printf("\n")
+
+		verdict := map[Level]string{LevelOK: "正常", LevelWarning: "警告", LevelError: "异常"}[report.Level()]
+		m.printer.PrintSummary("[%d] %s %s %s", i+1, name, util.MaskKey(report.Item.Key), verdict)
+	}
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/billing"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBalance struct {
+	balance *billing.Balance
+	err     error
+}
+
+func (f *fakeBalance) Query(ctx context.Context, url, key string) (*billing.Balance, error) {
+	return f.balance, f.err
+}
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local)
+	m := New(&config.Config{WarnDays: 7}, io.Discard, WithNow(func() time.Time { return now }))
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		want      Level
+	}{
+		{name: "Expired", expiresAt: "2025-01-09", want: LevelError},
+		{name: "Last valid day", expiresAt: "2025-01-10", want: LevelWarning},
+		{name: "Expires soon", expiresAt: "2025-01-15", want: LevelWarning},
+		{name: "Valid", expiresAt: "2025-03-01", want: LevelOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := m.checkExpiry(config.WatchItem{ExpiresAt: tt.expiresAt})
+			assert.Len(t, findings, 1)
+			assert.Equal(t, tt.want, findings[0].Level)
+		})
+	}
+
+	assert.Empty(t, m.checkExpiry(config.WatchItem{}))
+}
+
+func TestCheckBalance(t *testing.T) {
+	cfg := &config.Config{WarnDays: 7}
+
+	tests := []struct {
+		name    string
+		balance *billing.Balance
+		err     error
+		item    config.WatchItem
+		want    []Level
+	}{
+		{
+			name:    "Above threshold",
+			balance: &billing.Balance{Total: 10, Used: 2, Remaining: 8},
+			item:    config.WatchItem{MinBalance: 5},
+			want:    []Level{LevelOK},
+		},
+		{
+			name:    "Below threshold",
+			balance: &billing.Balance{Total: 10, Used: 8, Remaining: 2},
+			item:    config.WatchItem{MinBalance: 5},
+			want:    []Level{LevelWarning},
+		},
+		{
+			name: "Unauthorized",
+			err:  &billing.StatusError{StatusCode: 401},
+			item: config.WatchItem{},
+			want: []Level{LevelError},
+		},
+		{
+			name: "Unsupported without threshold",
+			err:  &billing.StatusError{StatusCode: 404},
+			item: config.WatchItem{},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(cfg, io.Discard, WithBalanceQuerier(&fakeBalance{balance: tt.balance, err: tt.err}))
+			var levels []Level
+			for _, f := range m.checkBalance(context.Background(), tt.item) {
+				levels = append(levels, f.Level)
+			}
+			assert.Equal(t, tt.want, levels)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// CertWarnDays is the number of days before expiry when a certificate is reported
+const CertWarnDays = 14
+
+// Timeout bounds all preflight checks
+const Timeout = 10 * time.Second
+
+// Result holds the outcome of the checks run before a test
+type Result struct {
+	Host     string              `json:"host"`
+	IPs      []string            `json:"ips,omitempty"`
+	Resolver string              `json:"resolver"`
+	Families []FamilyLatency     `json:"families,omitempty"`
+	TLS      *httpclient.TLSInfo `json:"tls,omitempty"`
+	Warnings []string            `json:"warnings,omitempty"`
+	Errors   []string            `json:"errors,omitempty"`
+}
+
+// FamilyLatency is the TCP connect latency to the host over one address family
+type FamilyLatency struct {
+	Family  string  `json:"family"` // IPv4 或 IPv6
+	IP      string  `json:"ip,omitempty"`
+	Latency float64 `json:"latency,omitempty"` // 秒
+	Error   string  `json:"error,omitempty"`
+}
+
+// Run resolves the API host and inspects its certificate chain
+func Run(ctx context.Context, apiURL string) *Result {
+	u, err := url.Parse(util.NormalizeURL(apiURL))
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	r := &Result{Host: u.Hostname(), Resolver: httpclient.ResolverName()}
+	ips, err := httpclient.LookupHost(ctx, r.Host)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return r
+	}
+	r.IPs = ips
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	r.Families = measureFamilies(ctx, r.Host, port)
+
+	if u.Scheme != "https" {
+		r.Warnings = append(r.Warnings, "未使用 HTTPS，Key 以明文传输")
+		return r
+	}
+
+	info, err := httpclient.InspectTLS(ctx, net.JoinHostPort(r.Host, port))
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return r
+	}
+	r.TLS = info
+	r.checkCertificates(time.Now())
+	return r
+}
+
+// measureFamilies measures the connect latency over IPv4 and IPv6 when the host has both
+func measureFamilies(ctx context.Context, host, port string) []FamilyLatency {
+	addrs, err := httpclient.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+
+	var families []FamilyLatency
+	for _, version := range []httpclient.IPVersion{httpclient.IPVersion4, httpclient.IPVersion6} {
+		for _, addr := range addrs {
+			if !version.Matches(addr.IP) {
+				continue
+			}
+			f := FamilyLatency{Family: "IPv" + version.String(), IP: addr.IP.String()}
+			start := time.Now()
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(f.IP, port))
+			if err != nil {
+				f.Error = err.Error()
+			} else {
+				f.Latency = time.Since(start).Seconds()
+				conn.Close()
+			}
+			families = append(families, f)
+			break
+		}
+	}
+	return families
+}
+
+// checkCertificates adds warnings for untrusted, self-signed and expiring certificates
+func (r *Result) checkCertificates(now time.Time) {
+	leaf := r.TLS.Chain[0]
+	if leaf.SelfSigned {
+		r.Warnings = append(r.Warnings, "证书为自签名证书")
+	} else if !r.TLS.Verified {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("证书校验失败: %s", r.TLS.VerifyError))
+	}
+
+	for _, cert := range r.TLS.Chain {
+		daysLeft := int(cert.NotAfter.Sub(now).Hours() / 24)
+		switch {
+		case daysLeft < 0:
+			r.Errors = append(r.Errors, fmt.Sprintf("证书 %s 已于 %s 过期", cert.Subject, cert.NotAfter.Format("2006-01-02")))
+		case daysLeft <= CertWarnDays:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("证书 %s 将于 %d 天后过期 (%s)", cert.Subject, daysLeft, cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+}
+
+// Print prints the preflight result
+func (r *Result) Print(printer *util.Printer) {
+	if r == nil {
+		return
+	}
+	if len(r.IPs) > 0 {
+		printer.Printf("解析地址: %s -> %s (DNS: %s)\n", r.Host, strings.Join(r.IPs, ", "), r.Resolver)
+	}
+
+	if len(r.Families) > 1 || httpclient.GetIPVersion() != httpclient.IPVersionAuto {
+		var parts []string
+		for _, f := range r.Families {
+			if f.Error != "" {
+				parts = append(parts, fmt.Sprintf("%s 不可达", f.Family))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s %s", f.Family, util.FormatLatency(f.Latency)))
+			}
+		}
+		if len(parts) > 0 {
+			printer.Printf("连接延迟: %s (测试使用: %s)\n", strings.Join(parts, ", "), ipVersionLabel(httpclient.GetIPVersion()))
+		}
+	}
+
+	if r.TLS != nil {
+		leaf := r.TLS.Chain[0]
+		printer.Printf("证书: %s, 颁发者: %s, 有效期至 %s\n", leaf.Subject, leaf.Issuer, leaf.NotAfter.Format("2006-01-02"))
+		if len(leaf.SANs) > 0 {
+			printer.Printf("证书域名: %s\n", util.Truncate(strings.Join(leaf.SANs, ", "), util.Max(util.TerminalWidth(), util.MinTerminalWidth)-10))
+		}
+		for _, cert := range r.TLS.Chain[1:] {
+			printer.Printf("  └ %s (颁发者: %s, 有效期至 %s)\n", cert.Subject, cert.Issuer, cert.NotAfter.Format("2006-01-02"))
+		}
+	}
+
+	for _, w := range r.Warnings {
+		printer.PrintWarning(w)
+	}
+	for _, e := range r.Errors {
+		printer.PrintError(e)
+	}
+}
+
+// ipVersionLabel returns a display label for the IP version
+func ipVersionLabel(v httpclient.IPVersion) string {
+	if v == httpclient.IPVersionAuto {
+		return "自动"
+	}
+	return "仅 IPv" + v.String()
+}
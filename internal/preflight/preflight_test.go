@@ -0,0 +1,59 @@
+package preflight
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCertificates(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		tls      httpclient.TLSInfo
+		warnings int
+		errors   int
+	}{
+		{
+			name: "Valid",
+			tls: httpclient.TLSInfo{Verified: true, Chain: []httpclient.Certificate{
+				{Subject: "api.example.com", NotAfter: now.AddDate(0, 3, 0)},
+				{Subject: "R3", NotAfter: now.AddDate(1, 0, 0)},
+			}},
+		},
+		{
+			name: "Self-signed",
+			tls: httpclient.TLSInfo{VerifyError: "x509: certificate signed by unknown authority", Chain: []httpclient.Certificate{
+				{Subject: "relay", SelfSigned: true, NotAfter: now.AddDate(1, 0, 0)},
+			}},
+			warnings: 1,
+		},
+		{
+			name: "Expiring soon",
+			tls: httpclient.TLSInfo{Verified: true, Chain: []httpclient.Certificate{
+				{Subject: "api.example.com", NotAfter: now.AddDate(0, 0, 5)},
+			}},
+			warnings: 1,
+		},
+		{
+			name: "Expired",
+			tls: httpclient.TLSInfo{VerifyError: "x509: certificate has expired", Chain: []httpclient.Certificate{
+				{Subject: "api.example.com", NotAfter: now.AddDate(0, 0, -2)},
+			}},
+			warnings: 1,
+			errors:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Result{TLS: &tt.tls}
+			r.checkCertificates(now)
+			assert.Len(t, r.Warnings, tt.warnings)
+			assert.Len(t, r.Errors, tt.errors)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/secrets"
+	"github.com/go-coders/check-gpt/pkg/config"
+)
+
+// Prefix marks a profile reference in the key input, e.g. @work
+const Prefix = "@"
+
+// Profile represents a saved test configuration
+type Profile struct {
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	KeyCount int       `json:"key_count"`
+	SavedAt  time.Time `json:"saved_at"`
+	Keys     []string  `json:"-"` // 保存在密钥存储中
+}
+
+// Manager manages saved profiles, the keys are kept in a secrets.Store
+type Manager struct {
+	path    string
+	secrets secrets.Store
+	mu      sync.Mutex
+}
+
+// NewManager creates a new Manager storing profile metadata in dir
+func NewManager(dir string, store secrets.Store) *Manager {
+	return &Manager{
+		path:    filepath.Join(dir, "profiles.json"),
+		secrets: store,
+	}
+}
+
+// Default creates a Manager in the default configuration directory
+func Default(passphrase secrets.PassphraseFunc) *Manager {
+	dir := config.Dir()
+	return NewManager(dir, secrets.New(dir, passphrase))
+}
+
+// StoreName returns the name of the secrets store in use
+func (m *Manager) StoreName() string {
+	return m.secrets.Name()
+}
+
+// List returns all saved profiles without keys, sorted by name
+func (m *Manager) List() ([]Profile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profiles, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles, nil
+}
+
+// Load returns the named profile including its keys
+func (m *Manager) Load(name string) (*Profile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profiles, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		if p.Name != name {
+			continue
+		}
+		secret, err := m.secrets.Get(account(name))
+		if err != nil {
+			return nil, fmt.Errorf("读取配置 %s 的 Key 失败: %v", name, err)
+		}
+		p.Keys = strings.Fields(secret)
+		return &p, nil
+	}
+	return nil, fmt.Errorf("未找到配置: %s", name)
+}
+
+// Save stores the profile, replacing any profile with the same name
+func (m *Manager) Save(p *Profile) error {
+	if err := ValidateName(p.Name); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profiles, err := m.load()
+	if err != nil {
+		return err
+	}
+
+	if err := m.secrets.Set(account(p.Name), strings.Join(p.Keys, "\n")); err != nil {
+		return err
+	}
+
+	saved := *p
+	saved.KeyCount = len(p.Keys)
+	saved.SavedAt = time.Now()
+
+	replaced := false
+	for i := range profiles {
+		if profiles[i].Name == p.Name {
+			profiles[i] = saved
+			replaced = true
+		}
+	}
+	if !replaced {
+		profiles = append(profiles, saved)
+	}
+	return m.save(profiles)
+}
+
+// Delete removes the named profile and its keys
+func (m *Manager) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profiles, err := m.load()
+	if err != nil {
+		return err
+	}
+
+	var kept []Profile
+	for _, p := range profiles {
+		if p.Name != name {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(profiles) {
+		return fmt.Errorf("未找到配置: %s", name)
+	}
+
+	if err := m.secrets.Delete(account(name)); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return err
+	}
+	return m.save(kept)
+}
+
+// ValidateName checks that the profile name can be referenced from the key input
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("配置名称不能为空")
+	}
+	if strings.ContainsAny(name, " \t,") || strings.HasPrefix(name, Prefix) {
+		return fmt.Errorf("配置名称不能包含空格、逗号或以 %s 开头: %s", Prefix, name)
+	}
+	return nil
+}
+
+// account returns the secrets store account for a profile
+func account(name string) string {
+	return "profile:" + name
+}
+
+func (m *Manager) load() ([]Profile, error) {
+	raw, err := os.ReadFile(m.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取配置列表失败: %v", err)
+	}
+	var profiles []Profile
+	if err := json.Unmarshal(raw, &profiles); err != nil {
+		return nil, fmt.Errorf("解析配置列表失败: %v", err)
+	}
+	return profiles, nil
+}
+
+func (m *Manager) save(profiles []Profile) error {
+	raw, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(m.path, raw, 0o600); err != nil {
+		return fmt.Errorf("写入配置列表失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package profile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir, secrets.NewFileStore(filepath.Join(dir, "secrets.json")))
+
+	err := m.Save(&Profile{Name: "work", URL: "https://api.example.com/v1/chat/completions", Keys: []string{"sk-a", "sk-b"}})
+	assert.NoError(t, err)
+
+	p, err := m.Load("work")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sk-a", "sk-b"}, p.Keys)
+	assert.Equal(t, 2, p.KeyCount)
+
+	profiles, err := m.List()
+	assert.NoError(t, err)
+	assert.Len(t, profiles, 1)
+	assert.Empty(t, profiles[0].Keys, "keys must not be stored with the metadata")
+
+	assert.NoError(t, m.Delete("work"))
+	_, err = m.Load("work")
+	assert.Error(t, err)
+
+	assert.Error(t, m.Save(&Profile{Name: "bad name"}))
+}
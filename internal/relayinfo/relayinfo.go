@@ -0,0 +1,187 @@
+package relayinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/billing"
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Timeout bounds the detection requests
+const Timeout = 10 * time.Second
+
+// Known relay software
+const (
+	SoftwareOneAPI = "one-api"
+	SoftwareNewAPI = "new-api"
+)
+
+// MaxBodySize limits how much of an info route response is read
+const MaxBodySize = 64 << 10
+
+// Software describes the relay software detected behind an endpoint
+type Software struct {
+	Name       string   `json:"name,omitempty"` // one-api、new-api 等，未识别时为空
+	Version    string   `json:"version,omitempty"`
+	SystemName string   `json:"system_name,omitempty"` // 站点自定义的名称
+	Server     string   `json:"server,omitempty"`      // Server / X-Powered-By 响应头
+	Evidence   []string `json:"evidence,omitempty"`    // 识别依据
+}
+
+// String formats the software as "new-api v0.4.0 (站点名称)"
+func (s *Software) String() string {
+	name := s.Name
+	if name == "" {
+		name = "未知程序"
+	}
+	if s.Version != "" {
+		name += " " + s.Version
+	}
+	if s.SystemName != "" && !strings.EqualFold(s.SystemName, s.Name) {
+		name += fmt.Sprintf(" (%s)", s.SystemName)
+	}
+	return name
+}
+
+// newAPIStatusFields are /api/status data fields that only exist in new-api
+var newAPIStatusFields = []string{"self_use_mode_enabled", "quota_display_type", "demo_site_enabled"}
+
+// Detect identifies the relay software from relay, the one-api/new-api deployment billing.DetectRelay
+// found at the endpoint (nil when it found none), and from the about routes and response headers.
+// It returns nil when neither the relay software nor the server could be identified.
+func Detect(ctx context.Context, apiURL string, relay *billing.Relay) *Software {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	client := httpclient.New(Timeout)
+	base := util.BaseURL(apiURL)
+	s := &Software{}
+	if relay != nil {
+		s.fromHeader(relay.Header)
+		s.fromRelay(relay)
+	}
+
+	// one-api and its forks serve the about text as {"success":true,"data":"..."}
+	if _, body, err := get(ctx, client, base+"/api/about"); err == nil && isInfoResponse(body) {
+		if s.Name == "" {
+			s.Name = SoftwareOneAPI
+		}
+		s.addEvidence("/api/about")
+	}
+	if header, _, err := get(ctx, client, base+"/about"); err == nil {
+		s.fromHeader(header)
+	}
+
+	if s.Name == "" && s.Server == "" {
+		return nil
+	}
+	return s
+}
+
+// fromHeader identifies the software from the response headers
+func (s *Software) fromHeader(header http.Header) {
+	if header == nil {
+		return
+	}
+	if v := header.Get("X-New-Api-Version"); v != "" {
+		s.Name, s.Version = SoftwareNewAPI, v
+		s.addEvidence("X-New-Api-Version")
+	}
+	if billing.IsRelayResponse(header) {
+		if s.Name == "" {
+			s.Name = SoftwareOneAPI
+		}
+		s.addEvidence(billing.RelayRequestIDHeader)
+	}
+	if s.Server == "" {
+		s.Server = strings.TrimSpace(strings.Join([]string{header.Get("Server"), header.Get("X-Powered-By")}, " "))
+	}
+}
+
+// fromRelay identifies the software from the /api/status response of one-api and its forks
+func (s *Software) fromRelay(relay *billing.Relay) {
+	if relay.System == "" && relay.Version == "" {
+		return
+	}
+
+	s.addEvidence("/api/status")
+	s.SystemName = relay.System
+	if relay.Version != "" {
+		s.Version = relay.Version
+	}
+
+	lower := strings.ToLower(relay.System)
+	switch {
+	case strings.Contains(lower, "new api") || strings.Contains(lower, "new-api"):
+		s.Name = SoftwareNewAPI
+	case s.Name == SoftwareNewAPI:
+	case hasAnyField(relay.StatusFields, newAPIStatusFields):
+		s.Name = SoftwareNewAPI
+	default:
+		s.Name = SoftwareOneAPI
+	}
+}
+
+// addEvidence records why the software was identified
+func (s *Software) addEvidence(e string) {
+	for _, existing := range s.Evidence {
+		if existing == e {
+			return
+		}
+	}
+	s.Evidence = append(s.Evidence, e)
+}
+
+// hasAnyField reports whether present contains one of the fields
+func hasAnyField(present, fields []string) bool {
+	for _, f := range fields {
+		for _, p := range present {
+			if p == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isInfoResponse reports whether body is a one-api style {"success":true,"data":...} response
+func isInfoResponse(body []byte) bool {
+	var resp struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	return json.Unmarshal(body, &resp) == nil && resp.Success && len(resp.Data) > 0
+}
+
+// get fetches url without authorization, returning the headers whenever a response was received
+func get(ctx context.Context, client *http.Client, url string) (http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	logger.DebugRequest(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	logger.DebugResponse(resp)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodySize))
+	if err != nil {
+		return resp.Header, nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.Header, nil, fmt.Errorf("code: %d", resp.StatusCode)
+	}
+	return resp.Header, body, nil
+}
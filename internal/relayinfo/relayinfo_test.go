@@ -0,0 +1,68 @@
+package relayinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/billing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected *Software
+	}{
+		{
+			name: "new-api by status fields",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/status" {
+					w.Write([]byte(`{"success":true,"data":{"system_name":"某某中转","version":"v0.4.8","quota_display_type":"USD"}}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			expected: &Software{Name: SoftwareNewAPI, Version: "v0.4.8", SystemName: "某某中转", Evidence: []string{"/api/status"}},
+		},
+		{
+			name: "one-api by header and about",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Oneapi-Request-Id", "2024")
+				w.Header().Set("Server", "nginx")
+				if r.URL.Path == "/api/about" {
+					w.Write([]byte(`{"success":true,"data":"关于"}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			expected: &Software{Name: SoftwareOneAPI, Server: "nginx", Evidence: []string{"X-Oneapi-Request-Id", "/api/about"}},
+		},
+		{
+			name: "server header only",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Server", "cloudflare")
+				w.Write([]byte(`<html></html>`))
+			},
+			expected: &Software{Server: "cloudflare"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			apiURL := srv.URL + "/v1/chat/completions"
+			relay, _ := billing.NewClient(Timeout).DetectRelay(context.Background(), apiURL)
+			assert.Equal(t, tt.expected, Detect(context.Background(), apiURL, relay))
+		})
+	}
+}
+
+func TestSoftwareString(t *testing.T) {
+	assert.Equal(t, "new-api v0.4.8 (某某中转)", (&Software{Name: SoftwareNewAPI, Version: "v0.4.8", SystemName: "某某中转"}).String())
+	assert.Equal(t, "one-api", (&Software{Name: SoftwareOneAPI, SystemName: "One-API"}).String())
+	assert.Equal(t, "未知程序", (&Software{Server: "nginx"}).String())
+}
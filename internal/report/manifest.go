@@ -0,0 +1,58 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestSuffix is appended to the report path for the SHA256 manifest
+const ManifestSuffix = ".sha256"
+
+// SignatureSuffix is appended to the report path by minisign
+const SignatureSuffix = ".minisig"
+
+// WriteManifest writes a detached SHA256 manifest next to the report.
+// The format is compatible with `sha256sum -c`.
+func WriteManifest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开报告失败: %v", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("计算哈希失败: %v", err)
+	}
+
+	manifestPath := path + ManifestSuffix
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(h.Sum(nil)), filepath.Base(path))
+	if err := os.WriteFile(manifestPath, []byte(line), 0o644); err != nil {
+		return "", fmt.Errorf("写入校验文件失败: %v", err)
+	}
+	return manifestPath, nil
+}
+
+// Sign creates a detached minisign signature of the report with the secret key file.
+// minisign may prompt for the key password on the terminal.
+func Sign(path, secretKeyPath string) (string, error) {
+	if _, err := exec.LookPath("minisign"); err != nil {
+		return "", fmt.Errorf("未找到 minisign，请先安装: https://jedisct1.github.io/minisign/")
+	}
+
+	cmd := exec.Command("minisign", "-S", "-s", secretKeyPath, "-m", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("签名失败: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return path + SignatureSuffix, nil
+}
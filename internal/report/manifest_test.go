@@ -0,0 +1,22 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	assert.NoError(t, os.WriteFile(path, []byte("abc"), 0o644))
+
+	manifestPath, err := WriteManifest(path)
+	assert.NoError(t, err)
+	assert.Equal(t, path+ManifestSuffix, manifestPath)
+
+	data, err := os.ReadFile(manifestPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  report.json\n", string(data))
+}
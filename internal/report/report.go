@@ -0,0 +1,204 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/internal/capability"
+	"github.com/go-coders/check-gpt/internal/preflight"
+	"github.com/go-coders/check-gpt/internal/relayinfo"
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/schema"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Report represents an exported test report
+type Report struct {
+	SchemaVersion string              `json:"schema_version"`
+	Run           *schema.Run         `json:"run,omitempty"` // 生成报告的运行信息
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Mode          string              `json:"mode"`
+	URL           string              `json:"url"`
+	Connection    *Connection         `json:"connection,omitempty"`
+	Software      *relayinfo.Software `json:"software,omitempty"`
+	Preflight     *preflight.Result   `json:"preflight,omitempty"`
+	Capability    []capability.Result `json:"capability,omitempty"`
+	Endpoints     []Endpoint          `json:"endpoints,omitempty"` // 多端点测试时按端点 → Key → 模型分组
+	Results       []Result            `json:"results"`
+	Requests      []Reproduction      `json:"requests,omitempty"` // 每个端点和模型的测试请求, 供厂商复现
+
+	SkippedRequests []SkippedReproduction `json:"skipped_requests,omitempty"` // 无法导出测试请求的端点和模型
+}
+
+// Endpoint holds the aggregate stats and per-key results of one endpoint
+type Endpoint struct {
+	Name        string      `json:"name,omitempty"`
+	URL         string      `json:"url"`
+	Keys        int         `json:"keys"`
+	Success     int         `json:"success"`
+	Total       int         `json:"total"`
+	SuccessRate float64     `json:"success_rate"`
+	Latency     float64     `json:"latency"` // 成功请求的平均延迟
+	Results     []KeyResult `json:"results"`
+}
+
+// KeyResult holds the model results of one key on an endpoint
+type KeyResult struct {
+	Key     string   `json:"key"` // 按掩码策略处理后的 Key
+	Success int      `json:"success"`
+	Total   int      `json:"total"`
+	Models  []Result `json:"models"`
+}
+
+// Connection describes how the endpoint was reached during the test
+type Connection struct {
+	httpclient.Connection
+	Network string `json:"network,omitempty"` // Cloudflare, OpenAI 等已知网段
+}
+
+// Result represents a single model test result in a report
+type Result struct {
+	Key        string  `json:"key"` // 按掩码策略处理后的 Key
+	Model      string  `json:"model"`
+	Success    bool    `json:"success"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Latency    float64 `json:"latency"`
+	Error      string  `json:"error,omitempty"`
+	Vantage    string  `json:"vantage,omitempty"`  // 代理池中的地区，直连时为空
+	Skipped    bool    `json:"skipped,omitempty"`  // 未测试
+	Endpoint   string  `json:"endpoint,omitempty"` // 多端点测试时的端点 URL
+}
+
+// FromResults creates a report from API test results
+func FromResults(url string, results []apitest.TestResult) *Report {
+	r := &Report{
+		GeneratedAt: time.Now(),
+		Mode:        "apitest",
+		URL:         url,
+	}
+	r.AddResults("", results)
+	r.AddReproductions(results)
+	return r
+}
+
+// FromEndpoints creates a report of a multi-endpoint run, grouped by endpoint, key and model
+func FromEndpoints(results []apitest.TestResult) *Report {
+	r := &Report{
+		GeneratedAt: time.Now(),
+		Mode:        "channels",
+	}
+	for _, e := range apitest.GroupByEndpoint(results) {
+		stats := e.Stats()
+		endpoint := Endpoint{
+			Name:        e.Name,
+			URL:         e.URL,
+			Keys:        stats.Keys,
+			Success:     stats.Success,
+			Total:       stats.Total,
+			SuccessRate: stats.SuccessRate(),
+			Latency:     stats.Latency,
+		}
+
+		index := make(map[string]int)
+		for _, result := range e.Results {
+			item := newResult("", result)
+			flat := item
+			flat.Endpoint = e.URL
+			r.Results = append(r.Results, flat)
+
+			i, ok := index[result.Channel.Key]
+			if !ok {
+				i = len(endpoint.Results)
+				index[result.Channel.Key] = i
+				endpoint.Results = append(endpoint.Results, KeyResult{Key: item.Key})
+			}
+			kr := &endpoint.Results[i]
+			kr.Total++
+			if result.Success {
+				kr.Success++
+			}
+			kr.Models = append(kr.Models, item)
+		}
+		r.Endpoints = append(r.Endpoints, endpoint)
+	}
+	r.AddReproductions(results)
+	return r
+}
+
+// AddResults adds the results of a test run from the given vantage to the report
+func (r *Report) AddResults(vantage string, results []apitest.TestResult) {
+	for _, result := range results {
+		r.Results = append(r.Results, newResult(vantage, result))
+	}
+}
+
+// newResult converts a test result, masking the key
+func newResult(vantage string, result apitest.TestResult) Result {
+	item := Result{
+		Model:      result.Model,
+		Success:    result.Success,
+		StatusCode: result.StatusCode,
+		Latency:    result.Latency,
+		Vantage:    vantage,
+		Skipped:    result.Skipped,
+	}
+	if result.Channel != nil {
+		item.Key = util.MaskKey(result.Channel.Key)
+		if result.Error != nil {
+			item.Error = util.MaskSecrets(result.Error.Error(), result.Channel.Key)
+		}
+	}
+	return item
+}
+
+// Write writes the report as JSON to path
+func Write(path string, r *Report) error {
+	r.SchemaVersion = schema.Version
+	if r.Run == nil {
+		r.Run = schema.NewRun(r.URL)
+	}
+	return writeJSON(path, r)
+}
+
+// ChannelWeight is a suggested channel weight in the one-api/new-api channel format
+type ChannelWeight struct {
+	Name   string `json:"name"` // 按掩码策略处理后的 Key
+	Weight int    `json:"weight"`
+}
+
+// Weights is the gateway weights file
+type Weights struct {
+	SchemaVersion string          `json:"schema_version"`
+	Run           *schema.Run     `json:"run,omitempty"`
+	Channels      []ChannelWeight `json:"channels"`
+}
+
+// WriteWeights writes the suggested gateway weights of the keys of target as JSON to path
+func WriteWeights(path, target string, weights []apitest.Weight) error {
+	channels := make([]ChannelWeight, 0, len(weights))
+	for _, w := range weights {
+		channels = append(channels, ChannelWeight{Name: util.MaskKey(w.Key), Weight: w.Weight})
+	}
+	return writeJSON(path, Weights{SchemaVersion: schema.Version, Run: schema.NewRun(target), Channels: channels})
+}
+
+// writeJSON writes v as indented JSON to path, creating the directory when needed
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %v", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入报告失败: %v", err)
+	}
+	return nil
+}
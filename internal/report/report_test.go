@@ -0,0 +1,81 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/pkg/schema"
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights", "weights.json")
+	key := "sk-abcdefghijklmnop"
+	err := WriteWeights(path, "https://relay.example.com", []apitest.Weight{{Key: key, Weight: 100}, {Key: "sk-dead", Weight: 0}})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), key)
+
+	var weights map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(data, &weights))
+	assert.JSONEq(t, `"`+schema.Version+`"`, string(weights["schema_version"]))
+	assert.JSONEq(t, `[{"name":"`+util.MaskKey(key)+`","weight":100},{"name":"`+util.MaskKey("sk-dead")+`","weight":0}]`, string(weights["channels"]))
+
+	var run schema.Run
+	assert.NoError(t, json.Unmarshal(weights["run"], &run))
+	assert.Equal(t, "https://relay.example.com", run.Target)
+	assert.False(t, run.Timestamp.IsZero())
+}
+
+func TestFromEndpoints(t *testing.T) {
+	key := "sk-abcdefghijklmnop"
+	a := &apitest.Channel{Key: key, URL: "https://a.example.com/v1/chat/completions", Endpoint: "A"}
+	b := &apitest.Channel{Key: key, URL: "https://b.example.com/v1/chat/completions"}
+	r := FromEndpoints([]apitest.TestResult{
+		{Channel: a, Model: "gpt-4o", Success: true, Latency: 1},
+		{Channel: b, Model: "gpt-4o"},
+		{Channel: a, Model: "gpt-4o-mini", Success: true, Latency: 3},
+	})
+
+	assert.Equal(t, "channels", r.Mode)
+	assert.Len(t, r.Results, 3)
+	assert.Equal(t, b.URL, r.Results[2].Endpoint, "flat results are ordered by endpoint")
+	if assert.Len(t, r.Endpoints, 2) {
+		assert.Equal(t, "A", r.Endpoints[0].Name)
+		assert.Equal(t, 2, r.Endpoints[0].Success)
+		assert.Equal(t, 2.0, r.Endpoints[0].Latency)
+		assert.Equal(t, 1.0, r.Endpoints[0].SuccessRate)
+		assert.Equal(t, util.MaskKey(key), r.Endpoints[0].Results[0].Key)
+		assert.Len(t, r.Endpoints[0].Results[0].Models, 2)
+		assert.Equal(t, 0.0, r.Endpoints[1].SuccessRate)
+	}
+}
+
+func TestReportSchema(t *testing.T) {
+	data, err := schema.Get("report")
+	assert.NoError(t, err)
+	var doc struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+
+	r := FromEndpoints([]apitest.TestResult{{Channel: &apitest.Channel{Key: "sk-abcdefghijklmnop"}, Model: "gpt-4o"}})
+	r.Connection = &Connection{}
+	path := filepath.Join(t.TempDir(), "report.json")
+	assert.NoError(t, Write(path, r))
+
+	out, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var fields map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(out, &fields))
+	assert.Equal(t, `"`+schema.Version+`"`, string(fields["schema_version"]))
+	for field := range fields {
+		assert.Contains(t, doc.Properties, field, "field missing from report.schema.json")
+	}
+}
@@ -0,0 +1,278 @@
+package report
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// APIKeyPlaceholder replaces the key in exported requests, set it in the shell before running the cURL command
+const APIKeyPlaceholder = "$API_KEY"
+
+// ZhipuTokenPlaceholder replaces the token of Zhipu requests, a JWT signed with the secret half of the key
+// that expires within minutes, so it is generated before running the cURL command
+const ZhipuTokenPlaceholder = "$ZHIPU_JWT"
+
+// placeholders are the shell variables an exported request may hold
+var placeholders = []string{APIKeyPlaceholder, ZhipuTokenPlaceholder}
+
+// Reproduction is the outbound request of one model test, exported so vendors can replay the check
+type Reproduction struct {
+	URL   string   `json:"url"`
+	Model string   `json:"model"`
+	Curl  string   `json:"curl"` // 可直接运行的 cURL 命令, Key 以 $API_KEY 代替
+	HAR   HAREntry `json:"har"`  // HAR 1.2 条目, 可导入浏览器开发者工具或 Postman
+}
+
+// SkippedReproduction is a model test whose request could not be exported
+type SkippedReproduction struct {
+	URL    string `json:"url"`
+	Model  string `json:"model"`
+	Reason string `json:"reason"`
+}
+
+// HAREntry is an entry of the HAR 1.2 log format
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // 毫秒
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request of a HAR entry
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    HARPostData    `json:"postData"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is the response of a HAR entry, status 0 when no response was received
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter of a HAR entry
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the request body of a HAR entry
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the response body of a HAR entry
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARTimings are the phase durations of a HAR entry in milliseconds, -1 when not measured
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// AddReproductions exports the outbound request of every endpoint and model tested.
+// A failed result is preferred, it is the one a vendor is asked to look into.
+func (r *Report) AddReproductions(results []apitest.TestResult) {
+	type target struct{ url, model string }
+	picked := make(map[target]apitest.TestResult)
+	var order []target
+	for _, result := range results {
+		if result.Channel == nil || result.Skipped {
+			continue
+		}
+		t := target{result.Channel.URL, result.Model}
+		prev, ok := picked[t]
+		if !ok {
+			order = append(order, t)
+		}
+		if !ok || (prev.Success && !result.Success) {
+			picked[t] = result
+		}
+	}
+
+	for _, t := range order {
+		repro, err := newReproduction(picked[t], r.GeneratedAt)
+		if err != nil {
+			r.SkippedRequests = append(r.SkippedRequests, SkippedReproduction{URL: t.url, Model: t.model, Reason: err.Error()})
+			continue
+		}
+		r.Requests = append(r.Requests, *repro)
+	}
+}
+
+// newReproduction rebuilds the request of a test result with the key replaced by APIKeyPlaceholder
+func newReproduction(result apitest.TestResult, at time.Time) (*Reproduction, error) {
+	channel := *result.Channel
+	channel.Key = APIKeyPlaceholder
+	if channel.Type == apitest.ChannelTypeZhipu {
+		// Any id.secret key signs a token, it is replaced by ZhipuTokenPlaceholder below
+		channel.Key = "id.secret"
+	}
+	opts := apitest.DefaultRequestOptions()
+	opts.Protocol = channel.Protocol
+	req, err := apitest.NewRequestBuilder().BuildRequest(context.Background(), &apitest.TestConfig{
+		Channel:     &channel,
+		Model:       result.Model,
+		RequestOpts: opts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// KeyTransport adds the Gemini key and the Vertex AI token when the request is sent
+	rawURL := req.URL.String()
+	switch channel.Type {
+	case apitest.ChannelTypeGemini:
+		sep := "?"
+		if req.URL.RawQuery != "" {
+			sep = "&"
+		}
+		rawURL += sep + "key=" + APIKeyPlaceholder
+	case apitest.ChannelTypeVertex:
+		// API_KEY=$(gcloud auth print-access-token)
+		req.Header.Set("Authorization", "Bearer "+APIKeyPlaceholder)
+	case apitest.ChannelTypeZhipu:
+		req.Header.Set("Authorization", "Bearer "+ZhipuTokenPlaceholder)
+	}
+
+	return &Reproduction{
+		URL:   result.Channel.URL,
+		Model: result.Model,
+		Curl:  curlCommand(req.Method, rawURL, req.Header, string(body)),
+		HAR:   harEntry(req, rawURL, string(body), result, at),
+	}, nil
+}
+
+// curlCommand formats a request as a cURL command. Values holding the key placeholder are
+// double quoted so the shell expands it, everything else is single quoted.
+func curlCommand(method, rawURL string, header http.Header, body string) string {
+	lines := []string{"curl -X " + method + " " + shellQuote(rawURL)}
+	for _, name := range sortedKeys(header) {
+		for _, value := range header[name] {
+			lines = append(lines, "  -H "+shellQuote(name+": "+value))
+		}
+	}
+	if body != "" {
+		lines = append(lines, "  -d "+shellQuote(body))
+	}
+	return strings.Join(lines, " \\\n")
+}
+
+// shellQuote quotes s for a POSIX shell, leaving the placeholders to be expanded
+func shellQuote(s string) string {
+	expand := false
+	for _, p := range placeholders {
+		expand = expand || strings.Contains(s, p)
+	}
+	if !expand {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(s)
+	for _, p := range placeholders {
+		escaped = strings.ReplaceAll(escaped, `\`+p, p)
+	}
+	return `"` + escaped + `"`
+}
+
+// harEntry builds the HAR entry of a request and the response recorded in result
+func harEntry(req *http.Request, rawURL, body string, result apitest.TestResult, at time.Time) HAREntry {
+	entry := HAREntry{
+		StartedDateTime: at,
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         rawURL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: []HARNameValue{},
+			PostData:    HARPostData{MimeType: req.Header.Get("Content-Type"), Text: body},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Response: HARResponse{
+			Status:      result.StatusCode,
+			StatusText:  http.StatusText(result.StatusCode),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: HARTimings{Send: 0, Wait: -1, Receive: -1},
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		for _, name := range sortedKeys(u.Query()) {
+			for _, value := range u.Query()[name] {
+				entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: name, Value: value})
+			}
+		}
+	}
+
+	if d := result.Detail; d != nil {
+		entry.Time = d.Total * 1000
+		if result.StatusCode != 0 {
+			text := util.MaskSecrets(d.Body, result.Channel.Key)
+			entry.Response.Headers = harHeaders(d.Header)
+			entry.Response.Content = HARContent{Size: len(text), MimeType: d.Header.Get("Content-Type"), Text: text}
+			entry.Response.BodySize = len(d.Body)
+			entry.Timings.Wait = d.TTFB * 1000
+			entry.Timings.Receive = (d.Total - d.TTFB) * 1000
+		}
+	}
+	return entry
+}
+
+// harHeaders lists header in name order
+func harHeaders(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for _, name := range sortedKeys(header) {
+		for _, value := range header[name] {
+			headers = append(headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// sortedKeys returns the names of a header or query in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddReproductions(t *testing.T) {
+	key := "sk-abcdefghijklmnop"
+	openai := &apitest.Channel{Key: key, Type: apitest.ChannelTypeOpenAI, URL: "https://relay.example.com/v1/chat/completions"}
+	gemini := &apitest.Channel{Key: "AIzaSyabcdefghijklmnop", Type: apitest.ChannelTypeGemini}
+	r := FromResults(openai.URL, []apitest.TestResult{
+		{Channel: openai, Model: "gpt-4o", Success: true},
+		{Channel: openai, Model: "gpt-4o", StatusCode: 401, Detail: &apitest.ResponseDetail{
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   `{"error":"invalid key ` + key + `"}`,
+			TTFB:   0.2,
+			Total:  0.5,
+		}},
+		{Channel: openai, Model: "gpt-4o-mini", Skipped: true},
+		{Channel: gemini, Model: "gemini-1.5-flash"},
+	})
+
+	if !assert.Len(t, r.Requests, 2) {
+		return
+	}
+	repro := r.Requests[0]
+	assert.Equal(t, "gpt-4o", repro.Model)
+	assert.Contains(t, repro.Curl, `-H "Authorization: Bearer $API_KEY"`)
+	assert.Contains(t, repro.Curl, `"max_tokens":1`)
+	assert.NotContains(t, repro.Curl, key)
+	assert.Equal(t, 401, repro.HAR.Response.Status, "the failed result is exported")
+	assert.Equal(t, 500.0, repro.HAR.Time)
+	assert.Equal(t, 200.0, repro.HAR.Timings.Wait)
+	assert.NotContains(t, repro.HAR.Response.Content.Text, key)
+	assert.Contains(t, repro.HAR.Request.Headers, HARNameValue{Name: "Authorization", Value: "Bearer $API_KEY"})
+
+	repro = r.Requests[1]
+	assert.True(t, strings.HasSuffix(strings.Split(repro.Curl, "\n")[0], `:generateContent?key=$API_KEY" \`), repro.Curl)
+	assert.Equal(t, []HARNameValue{{Name: "key", Value: "$API_KEY"}}, repro.HAR.Request.QueryString)
+	assert.Equal(t, 0, repro.HAR.Response.Status)
+	assert.Equal(t, -1.0, repro.HAR.Timings.Wait)
+
+	// Zhipu signs a short-lived token, the request that cannot be rebuilt is recorded
+	zhipu := &apitest.Channel{Key: "abcdef.0123456789", Type: apitest.ChannelTypeZhipu, URL: "https://open.bigmodel.cn/api/paas/v4/chat/completions"}
+	broken := &apitest.Channel{Key: key, Type: apitest.ChannelTypeOpenAI, URL: "://relay"}
+	r = FromResults(zhipu.URL, []apitest.TestResult{{Channel: zhipu, Model: "glm-4-flash"}, {Channel: broken, Model: "gpt-4o"}})
+	if assert.Len(t, r.Requests, 1) {
+		assert.Contains(t, r.Requests[0].Curl, `-H "Authorization: Bearer $ZHIPU_JWT"`)
+		assert.NotContains(t, r.Requests[0].Curl, "0123456789")
+	}
+	if assert.Len(t, r.SkippedRequests, 1) {
+		assert.Equal(t, "gpt-4o", r.SkippedRequests[0].Model)
+		assert.NotEmpty(t, r.SkippedRequests[0].Reason)
+	}
+
+	// The reproduction sends the request through the protocol of the test
+	responses := &apitest.Channel{Key: key, Type: apitest.ChannelTypeOpenAI, URL: openai.URL, Protocol: apitest.ProtocolResponses}
+	r = FromResults(responses.URL, []apitest.TestResult{{Channel: responses, Model: "gpt-4o", Success: true}})
+	if assert.Len(t, r.Requests, 1) {
+		assert.Contains(t, r.Requests[0].Curl, "https://relay.example.com/v1/responses")
+		assert.Equal(t, "https://relay.example.com/v1/responses", r.Requests[0].HAR.Request.URL)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	for _, s := range []string{`{"content":"it's \"hi\""}`, "Bearer $API_KEY", "a`b\\c $HOME $API_KEY"} {
+		cmd := exec.Command(sh, "-c", "printf %s "+shellQuote(s))
+		cmd.Env = []string{"API_KEY=sk-test", "HOME=/root"}
+		out, err := cmd.Output()
+		if assert.NoError(t, err) {
+			assert.Equal(t, strings.ReplaceAll(s, APIKeyPlaceholder, "sk-test"), string(out), "only the key placeholder is expanded")
+		}
+	}
+}
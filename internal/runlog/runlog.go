@@ -0,0 +1,199 @@
+package runlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/go-coders/check-gpt/pkg/schema"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Verdicts recorded in the run log
+const (
+	VerdictOK      = "ok"
+	VerdictPartial = "partial"
+	VerdictFailed  = "failed"
+	VerdictError   = "error"
+)
+
+// Entry represents a single run in the log
+type Entry struct {
+	SchemaVersion string       `json:"schema_version"`
+	Time          time.Time    `json:"time"`
+	Mode          string       `json:"mode"`
+	Endpoint      string       `json:"endpoint,omitempty"`
+	Keys          int          `json:"keys"`
+	Models        int          `json:"models,omitempty"`
+	Success       int          `json:"success"`
+	Failed        int          `json:"failed"`
+	Verdict       string       `json:"verdict"`
+	Message       string       `json:"message,omitempty"`
+	Details       []KeyVerdict `json:"details,omitempty"`
+	Run           *schema.Run  `json:"run,omitempty"`
+}
+
+// KeyVerdict records the outcome for a single key, KeyID is stable across runs
+type KeyVerdict struct {
+	Key     string   `json:"key"`
+	KeyID   string   `json:"key_id"`
+	Verdict string   `json:"verdict"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// KeyID returns an identifier that lets runs of the same key be found without storing it
+func KeyID(key string) string {
+	return util.MaskPolicy{Mode: util.MaskModeHash}.Mask(key)
+}
+
+// Store saves the entries of the run log in a shared history, see the store package
+type Store interface {
+	Append(ctx context.Context, e Entry) error
+}
+
+var (
+	store     Store
+	storeLock sync.RWMutex
+)
+
+// SetStore sets the history every Logger also appends to, nil disables it
+func SetStore(s Store) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	store = s
+}
+
+func getStore() Store {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+	return store
+}
+
+// Logger appends entries to the run log as JSON lines, a nil Logger discards them
+type Logger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New creates a new Logger, it returns nil when path is empty or "off" and no store is set
+func New(path string) *Logger {
+	if path == "off" {
+		path = ""
+	}
+	if path == "" && getStore() == nil {
+		return nil
+	}
+	return &Logger{path: path}
+}
+
+// Append writes the entry to the end of the log and to the store
+func (l *Logger) Append(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.SchemaVersion = schema.Version
+	if e.Run == nil {
+		e.Run = schema.NewRun(e.Endpoint)
+	}
+
+	if err := l.write(e); err != nil {
+		return err
+	}
+	if s := getStore(); s != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return s.Append(ctx, e)
+	}
+	return nil
+}
+
+// write appends the entry to the log file, if any
+func (l *Logger) write(e Entry) error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run log entry: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开运行日志失败: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入运行日志失败: %v", err)
+	}
+	return nil
+}
+
+// FromResults creates an entry from API test results
+func FromResults(endpoint string, results []apitest.TestResult) Entry {
+	e := Entry{
+		Mode:     "apitest",
+		Endpoint: endpoint,
+	}
+
+	models := make(map[string]bool)
+	byKey := make(map[string]*KeyVerdict)
+	var order []string
+	success := make(map[string]int)
+	for _, result := range results {
+		if result.Channel == nil {
+			continue
+		}
+		key := result.Channel.Key
+		kv, ok := byKey[key]
+		if !ok {
+			kv = &KeyVerdict{Key: util.MaskKey(key), KeyID: KeyID(key)}
+			byKey[key] = kv
+			order = append(order, key)
+		}
+		models[result.Model] = true
+		if result.Success {
+			e.Success++
+			success[key]++
+		} else {
+			e.Failed++
+			kv.Failed = append(kv.Failed, result.Model)
+		}
+	}
+
+	for _, key := range order {
+		kv := byKey[key]
+		kv.Verdict = Verdict(success[key], len(kv.Failed))
+		e.Details = append(e.Details, *kv)
+	}
+	e.Keys = len(order)
+	e.Models = len(models)
+	e.Verdict = Verdict(e.Success, e.Failed)
+	return e
+}
+
+// Verdict summarizes success and failure counts
+func Verdict(success, failed int) string {
+	switch {
+	case failed == 0 && success > 0:
+		return VerdictOK
+	case success == 0:
+		return VerdictFailed
+	default:
+		return VerdictPartial
+	}
+}
@@ -0,0 +1,89 @@
+package runlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/apitest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromResults(t *testing.T) {
+	good := &apitest.Channel{Key: "sk-good-0123456789"}
+	bad := &apitest.Channel{Key: "sk-bad-0123456789"}
+	results := []apitest.TestResult{
+		{Channel: good, Model: "gpt-4o", Success: true},
+		{Channel: good, Model: "gpt-4o-mini", Success: true},
+		{Channel: bad, Model: "gpt-4o", Error: errors.New("unauthorized")},
+		{Channel: bad, Model: "gpt-4o-mini", Success: true},
+	}
+
+	e := FromResults("https://api.example.com", results)
+	assert.Equal(t, 2, e.Keys)
+	assert.Equal(t, 2, e.Models)
+	assert.Equal(t, 3, e.Success)
+	assert.Equal(t, 1, e.Failed)
+	assert.Equal(t, VerdictPartial, e.Verdict)
+	assert.Len(t, e.Details, 2)
+	assert.Equal(t, VerdictOK, e.Details[0].Verdict)
+	assert.Equal(t, VerdictPartial, e.Details[1].Verdict)
+	assert.Equal(t, []string{"gpt-4o"}, e.Details[1].Failed)
+	assert.Equal(t, KeyID("sk-bad-0123456789"), e.Details[1].KeyID)
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "runs.log")
+	l := New(path)
+	assert.NoError(t, l.Append(Entry{Mode: "apitest", Endpoint: "https://api.example.com", Verdict: VerdictOK}))
+	assert.NoError(t, l.Append(Entry{Mode: "trace", Verdict: VerdictFailed}))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	var modes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		assert.False(t, e.Time.IsZero())
+		if assert.NotNil(t, e.Run) {
+			assert.Equal(t, e.Endpoint, e.Run.Target)
+		}
+		modes = append(modes, e.Mode)
+	}
+	assert.Equal(t, []string{"apitest", "trace"}, modes)
+
+	// A disabled log discards entries
+	assert.Nil(t, New("off"))
+	assert.NoError(t, New("").Append(Entry{}))
+}
+
+type fakeStore struct {
+	entries []Entry
+}
+
+func (s *fakeStore) Append(ctx context.Context, e Entry) error {
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func TestSetStore(t *testing.T) {
+	s := &fakeStore{}
+	SetStore(s)
+	defer SetStore(nil)
+
+	// The store receives entries even when the log file is off
+	l := New("off")
+	assert.NotNil(t, l)
+	assert.NoError(t, l.Append(Entry{Mode: "trace", Verdict: VerdictOK}))
+	assert.Len(t, s.entries, 1)
+	assert.Equal(t, "trace", s.entries[0].Mode)
+	assert.False(t, s.entries[0].Time.IsZero())
+	assert.NotEmpty(t, s.entries[0].SchemaVersion)
+}
@@ -0,0 +1,248 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseFunc returns the passphrase, confirm is true when a new file is created
+type PassphraseFunc func(confirm bool) (string, error)
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	formatV1     = 1
+	kdfScrypt    = "scrypt"
+	cipherAESGCM = "aes-256-gcm"
+)
+
+// encryptedFile is the on-disk format of an EncryptedFileStore
+type encryptedFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Cipher  string `json:"cipher"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// EncryptedFileStore stores secrets in a file encrypted with a passphrase (scrypt + AES-256-GCM)
+type EncryptedFileStore struct {
+	path       string
+	passphrase PassphraseFunc
+	mu         sync.Mutex
+
+	// key and salt are cached after the first successful unlock
+	key  []byte
+	salt []byte
+}
+
+// NewEncryptedFileStore creates a new EncryptedFileStore
+func NewEncryptedFileStore(path string, passphrase PassphraseFunc) *EncryptedFileStore {
+	return &EncryptedFileStore{
+		path:       path,
+		passphrase: passphrase,
+	}
+}
+
+// Name returns the store name
+func (s *EncryptedFileStore) Name() string {
+	return "加密文件 (" + s.path + ")"
+}
+
+// Get returns the secret for the account
+func (s *EncryptedFileStore) Get(account string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := data[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores the secret for the account
+func (s *EncryptedFileStore) Set(account, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	data[account] = secret
+	return s.save(data)
+}
+
+// Delete removes the secret for the account
+func (s *EncryptedFileStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := data[account]; !ok {
+		return ErrNotFound
+	}
+	delete(data, account)
+	return s.save(data)
+}
+
+func (s *EncryptedFileStore) load() (map[string]string, error) {
+	data := make(map[string]string)
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %v", err)
+	}
+	if file.Version != formatV1 || file.KDF != kdfScrypt || file.Cipher != cipherAESGCM {
+		return nil, fmt.Errorf("不支持的密钥文件格式: v%d %s %s", file.Version, file.KDF, file.Cipher)
+	}
+
+	key := s.key
+	if key == nil {
+		passphrase, err := s.passphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		key, err = deriveKey(passphrase, file.Salt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plaintext, err := open(key, file.Nonce, file.Data)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败，口令错误或文件已损坏")
+	}
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %v", err)
+	}
+
+	s.key = key
+	s.salt = file.Salt
+	return data, nil
+}
+
+func (s *EncryptedFileStore) save(data map[string]string) error {
+	// First save creates the file, ask for a new passphrase
+	if s.key == nil {
+		passphrase, err := s.passphrase(true)
+		if err != nil {
+			return err
+		}
+		if passphrase == "" {
+			return fmt.Errorf("口令不能为空")
+		}
+		salt := make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %v", err)
+		}
+		key, err := deriveKey(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		s.key = key
+		s.salt = salt
+	}
+
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %v", err)
+	}
+	nonce, ciphertext, err := seal(s.key, plaintext)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(encryptedFile{
+		Version: formatV1,
+		KDF:     kdfScrypt,
+		Cipher:  cipherAESGCM,
+		Salt:    s.salt,
+		Nonce:   nonce,
+		Data:    ciphertext,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.path, raw, 0o600); err != nil {
+		return fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	return nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	return key, nil
+}
+
+func seal(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func open(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size")
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %v", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc.json")
+	passphrase := func(confirm bool) (string, error) { return "correct horse", nil }
+
+	s := NewEncryptedFileStore(path, passphrase)
+	assert.NoError(t, s.Set("profile:work", "sk-secret-key"))
+
+	raw, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(raw), "sk-secret-key"), "secret must not be stored in plaintext")
+
+	// A new store has to unlock the file with the passphrase again
+	s = NewEncryptedFileStore(path, passphrase)
+	secret, err := s.Get("profile:work")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-secret-key", secret)
+
+	_, err = s.Get("profile:missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	wrong := NewEncryptedFileStore(path, func(confirm bool) (string, error) { return "wrong", nil })
+	_, err = wrong.Get("profile:work")
+	assert.Error(t, err)
+}
+
+func TestMigratingStore(t *testing.T) {
+	dir := t.TempDir()
+	passphrase := func(confirm bool) (string, error) { return "correct horse", nil }
+	legacy := NewFileStore(filepath.Join(dir, "secrets.json"))
+	assert.NoError(t, legacy.Set("profile:old", "sk-old-key"))
+
+	s := &migratingStore{
+		Store:  NewEncryptedFileStore(filepath.Join(dir, "secrets.enc.json"), passphrase),
+		legacy: NewFileStore(filepath.Join(dir, "secrets.json")),
+	}
+	secret, err := s.Get("profile:old")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-old-key", secret)
+
+	// The plaintext file is gone once its secrets are encrypted
+	_, err = os.Stat(filepath.Join(dir, "secrets.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	secret, err = NewEncryptedFileStore(filepath.Join(dir, "secrets.enc.json"), passphrase).Get("profile:old")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-old-key", secret)
+}
+
+func TestMigratingStoreRetry(t *testing.T) {
+	dir := t.TempDir()
+	encPath := filepath.Join(dir, "secrets.enc.json")
+	assert.NoError(t, NewEncryptedFileStore(encPath, func(confirm bool) (string, error) { return "correct horse", nil }).Set("profile:new", "sk-new-key"))
+	assert.NoError(t, NewFileStore(filepath.Join(dir, "secrets.json")).Set("profile:old", "sk-old-key"))
+
+	// The first passphrase is mistyped, the next call asks again instead of failing for good
+	attempts := []string{"wrong", "correct horse"}
+	s := &migratingStore{
+		Store: NewEncryptedFileStore(encPath, func(confirm bool) (string, error) {
+			p := attempts[0]
+			if len(attempts) > 1 {
+				attempts = attempts[1:]
+			}
+			return p, nil
+		}),
+		legacy: NewFileStore(filepath.Join(dir, "secrets.json")),
+	}
+	_, err := s.Get("profile:old")
+	assert.Error(t, err)
+	secret, err := s.Get("profile:old")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-old-key", secret)
+	secret, err = s.Get("profile:new")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-new-key", secret)
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore stores secrets in a plaintext JSON file readable only by the current user
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore creates a new FileStore
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Name returns the store name
+func (s *FileStore) Name() string {
+	return "文件 (" + s.path + ")"
+}
+
+// Get returns the secret for the account
+func (s *FileStore) Get(account string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := data[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores the secret for the account
+func (s *FileStore) Set(account, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	data[account] = secret
+	return s.save(data)
+}
+
+// Delete removes the secret for the account
+func (s *FileStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := data[account]; !ok {
+		return ErrNotFound
+	}
+	delete(data, account)
+	return s.save(data)
+}
+
+func (s *FileStore) load() (map[string]string, error) {
+	data := make(map[string]string)
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %v", err)
+	}
+	return data, nil
+}
+
+func (s *FileStore) save(data map[string]string) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.path, raw, 0o600); err != nil {
+		return fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	return nil
+}
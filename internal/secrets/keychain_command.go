@@ -0,0 +1,23 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keychainSetCommand returns the arguments and stdin of the security command storing secret in the
+// macOS Keychain. The secret goes to stdin in the interactive mode (security -i), on argv it would be
+// visible to other users in ps, and -w without a value prompts on the terminal rather than stdin.
+func keychainSetCommand(account, secret string) ([]string, string, error) {
+	if strings.ContainsAny(secret, "\r\n") || strings.ContainsAny(account, "\r\n") {
+		return nil, "", fmt.Errorf("密钥不能包含换行符")
+	}
+	line := strings.Join([]string{"add-generic-password", "-U", "-s", quoteKeychainArg(Service),
+		"-a", quoteKeychainArg(account), "-w", quoteKeychainArg(secret)}, " ")
+	return []string{"-i"}, line + "\n", nil
+}
+
+// quoteKeychainArg quotes an argument for the command line parser of security -i
+func quoteKeychainArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,20 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeychainSetCommand(t *testing.T) {
+	args, stdin, err := keychainSetCommand("profile:work", `sk-"secret"\key`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-i"}, args)
+	for _, arg := range args {
+		assert.NotContains(t, arg, "secret", "the secret must stay out of argv")
+	}
+	assert.Equal(t, `add-generic-password -U -s "check-gpt" -a "profile:work" -w "sk-\"secret\"\\key"`+"\n", stdin)
+
+	_, _, err = keychainSetCommand("profile:work", "sk-a\nquit")
+	assert.Error(t, err, "a newline would end the command early")
+}
@@ -0,0 +1,66 @@
+//go:build darwin
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychain stores secrets in the macOS Keychain via the security command
+type keychain struct{}
+
+func newKeychain(dir string) Store {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil
+	}
+	return &keychain{}
+}
+
+func (k *keychain) Name() string {
+	return "macOS Keychain"
+}
+
+func (k *keychain) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		// 44: The specified item could not be found in the keychain
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("读取 Keychain 失败: %v", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (k *keychain) Set(account, secret string) error {
+	args, stdin, err := keychainSetCommand(account, secret)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("security", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.CombinedOutput()
+	// The interactive mode reports a failed command on its output and may still exit 0
+	if err != nil {
+		return fmt.Errorf("写入 Keychain 失败: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	if strings.Contains(string(out), "security:") {
+		return fmt.Errorf("写入 Keychain 失败: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k *keychain) Delete(account string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", Service, "-a", account).Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return ErrNotFound
+		}
+		return fmt.Errorf("删除 Keychain 条目失败: %v", err)
+	}
+	return nil
+}
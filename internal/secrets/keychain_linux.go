@@ -0,0 +1,57 @@
+//go:build linux
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// keychain stores secrets in the Secret Service (GNOME Keyring, KWallet) via secret-tool
+type keychain struct{}
+
+func newKeychain(dir string) Store {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil
+	}
+	// secret-tool needs a D-Bus session, which is usually missing on headless servers
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil
+	}
+	return &keychain{}
+}
+
+func (k *keychain) Name() string {
+	return "Secret Service (libsecret)"
+}
+
+func (k *keychain) Get(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", Service, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(out) == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("读取 Secret Service 失败: %v", err)
+	}
+	return string(out), nil
+}
+
+func (k *keychain) Set(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", Service+" "+account, "service", Service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("写入 Secret Service 失败: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k *keychain) Delete(account string) error {
+	if err := exec.Command("secret-tool", "clear", "service", Service, "account", account).Run(); err != nil {
+		return fmt.Errorf("删除 Secret Service 条目失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux && !windows
+
+package secrets
+
+// newKeychain returns nil since no OS keychain is supported on this platform
+func newKeychain(dir string) Store {
+	return nil
+}
@@ -0,0 +1,87 @@
+//go:build windows
+
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = kernel32.NewProc("LocalFree")
+)
+
+// dataBlob mirrors the Win32 DATA_BLOB structure
+type dataBlob struct {
+	cbData uint32
+	pbData *byte
+}
+
+func newBlob(d []byte) *dataBlob {
+	if len(d) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{cbData: uint32(len(d)), pbData: &d[0]}
+}
+
+func (b *dataBlob) bytes() []byte {
+	d := make([]byte, b.cbData)
+	copy(d, unsafe.Slice(b.pbData, b.cbData))
+	return d
+}
+
+// keychain encrypts secrets with DPAPI for the current user and keeps them in a file
+type keychain struct {
+	file *FileStore
+}
+
+func newKeychain(dir string) Store {
+	if err := procCryptProtectData.Find(); err != nil {
+		return nil
+	}
+	return &keychain{file: NewFileStore(filepath.Join(dir, "secrets.dpapi.json"))}
+}
+
+func (k *keychain) Name() string {
+	return "Windows DPAPI"
+}
+
+func (k *keychain) Get(account string) (string, error) {
+	encoded, err := k.file.Get(account)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("解析 DPAPI 数据失败: %v", err)
+	}
+
+	var out dataBlob
+	r, _, err := procCryptUnprotectData.Call(uintptr(unsafe.Pointer(newBlob(encrypted))), 0, 0, 0, 0, 0, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return "", fmt.Errorf("DPAPI 解密失败: %v", err)
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(out.pbData)))
+	return string(out.bytes()), nil
+}
+
+func (k *keychain) Set(account, secret string) error {
+	var out dataBlob
+	r, _, err := procCryptProtectData.Call(uintptr(unsafe.Pointer(newBlob([]byte(secret)))), 0, 0, 0, 0, 0, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return fmt.Errorf("DPAPI 加密失败: %v", err)
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(out.pbData)))
+	return k.file.Set(account, base64.StdEncoding.EncodeToString(out.bytes()))
+}
+
+func (k *keychain) Delete(account string) error {
+	return k.file.Delete(account)
+}
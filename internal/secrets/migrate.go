@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// migratingStore moves the secrets of a legacy store into the current one on first use,
+// so profiles saved before the store changed are kept
+type migratingStore struct {
+	Store
+	legacy *FileStore
+	mu     sync.Mutex
+	done   bool // 迁移成功后不再检查旧文件
+}
+
+// Get returns the secret for the account
+func (s *migratingStore) Get(account string) (string, error) {
+	if err := s.migrate(); err != nil {
+		return "", err
+	}
+	return s.Store.Get(account)
+}
+
+// Set stores the secret for the account
+func (s *migratingStore) Set(account, secret string) error {
+	if err := s.migrate(); err != nil {
+		return err
+	}
+	return s.Store.Set(account, secret)
+}
+
+// Delete removes the secret for the account
+func (s *migratingStore) Delete(account string) error {
+	if err := s.migrate(); err != nil {
+		return err
+	}
+	return s.Store.Delete(account)
+}
+
+// migrate copies the legacy secrets that the current store lacks and removes the legacy file.
+// A failed migration, e.g. after a mistyped passphrase, is retried on the next call.
+func (s *migratingStore) migrate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return nil
+	}
+	if _, err := os.Stat(s.legacy.path); err != nil {
+		s.done = true
+		return nil
+	}
+	s.legacy.mu.Lock()
+	data, err := s.legacy.load()
+	s.legacy.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for account, secret := range data {
+		if _, err := s.Store.Get(account); !errors.Is(err, ErrNotFound) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.Store.Set(account, secret); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(s.legacy.path); err != nil {
+		return fmt.Errorf("删除旧密钥文件失败: %v", err)
+	}
+	s.done = true
+	return nil
+}
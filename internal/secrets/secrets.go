@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// Service is the service name used for all stored secrets
+const Service = "check-gpt"
+
+// ErrNotFound is returned when no secret is stored for the account
+var ErrNotFound = errors.New("secret not found")
+
+// Store defines the interface for storing secrets
+type Store interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+	Name() string
+}
+
+// New returns the OS keychain if available, otherwise a file store in dir.
+// The file is encrypted with a passphrase unless passphrase is nil, the secrets of
+// an earlier plaintext file are then moved into it on first use.
+func New(dir string, passphrase PassphraseFunc) Store {
+	if ks := newKeychain(dir); ks != nil {
+		return ks
+	}
+	if passphrase != nil {
+		return &migratingStore{
+			Store:  NewEncryptedFileStore(filepath.Join(dir, "secrets.enc.json"), passphrase),
+			legacy: NewFileStore(filepath.Join(dir, "secrets.json")),
+		}
+	}
+	return NewFileStore(filepath.Join(dir, "secrets.json"))
+}
@@ -2,10 +2,14 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,12 +37,21 @@ type Server struct {
 	requestID  string
 	imgGen     interfaces.ImageGenerator
 
-	captchaCache     *interfaces.CaptchaResult // 验证码缓存
-	captchaCacheLock sync.RWMutex              // 验证码缓存锁
+	probes     []*interfaces.CaptchaResult // 当前轮的探测图片, 每张图片一个
+	probesLock sync.RWMutex                // 保护探测图片和 requestID
 
-	client *util.Client
+	client  *util.Client
+	session *Session // 连续检测共用的隧道
+
+	port        int
+	eventsToken string       // 实时事件接口的访问令牌，与图片 ID 分开，中转无法获知
+	events      http.Handler // 当前检测的实时事件
+	eventsLock  sync.RWMutex
 }
 
+// EventsPath is the WebSocket route streaming the progress of the running detection
+const EventsPath = "/ws/trace"
+
 // ServerOption represents a server configuration option
 type ServerOption func(*Server)
 
@@ -54,8 +67,18 @@ func New(cfg *config.Config, opts ...ServerOption) *Server {
 		router.Use(gin.Recovery())
 	}
 
-	router.SetTrustedProxies([]string{"127.0.0.1/8", "::1/128"})
-	router.RemoteIPHeaders = []string{"X-Forwarded-For"}
+	// Behind the user's own nginx or Cloudflare Tunnel the client IP comes from the headers it sets
+	trusted, headers := cfg.TrustedProxies, cfg.RemoteIPHeaders
+	if trusted == nil {
+		trusted, _ = config.ParseTrustedProxies(config.DefaultTrustedProxies)
+	}
+	if headers == nil {
+		headers = config.ParseRemoteIPHeaders(config.DefaultRemoteIPHeaders)
+	}
+	if err := router.SetTrustedProxies(trusted); err != nil {
+		logger.Debug("Failed to set trusted proxies: %v", err)
+	}
+	router.RemoteIPHeaders = headers
 
 	// CORS middleware
 	corsConfig := cors.DefaultConfig()
@@ -73,8 +96,19 @@ func New(cfg *config.Config, opts ...ServerOption) *Server {
 		done:      make(chan struct{}),
 		ready:     make(chan struct{}),
 		requestID: util.GenerateRandomString(10),
-		imgGen:    image.New("png"),
-		client:    util.NewClient(cfg.MaxTokens, cfg.Stream, cfg.Timeout),
+
+		eventsToken: newEventsToken(),
+		imgGen:      newImageGenerator(cfg),
+		client:      util.NewClient(cfg.MaxTokens, cfg.Stream, cfg.TraceTimeout),
+	}
+
+	// The flags were validated at startup
+	s.client.Shape, _ = util.ParseRequestShape(cfg.Shape)
+	if cfg.FromCurl != "" {
+		// The replayed request keeps its endpoint and stream mode
+		s.client.Template, _ = util.ParseCurl(cfg.FromCurl)
+		s.client.Shape = s.client.Template.Shape()
+		s.client.Stream = s.client.Template.Stream()
 	}
 
 	// Apply options
@@ -88,28 +122,69 @@ func New(cfg *config.Config, opts ...ServerOption) *Server {
 	return s
 }
 
+// newEventsToken returns the token of the events stream. It comes from crypto/rand, unlike the
+// image request IDs the relay sees, so it cannot be predicted from them.
+func newEventsToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails without an OS entropy source
+		panic(fmt.Sprintf("failed to generate events token: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
 // Start starts the server
 func (s *Server) Start(ctx context.Context) error {
+	// A tunnel kept from the previous detection still forwards to its port
+	port := 0
+	if s.tunnel == nil && s.session != nil {
+		if t, p := s.session.take(); t != nil && util.IsPortAvailable(p) {
+			s.tunnel, port = t, p
+			logger.Debug("Reusing tunnel %s on port %d", t.URL(), p)
+		} else if t != nil {
+			s.session.drop(t)
+		}
+	}
 
-	// Check SSH availability
-	if !tunnel.IsAvailable() {
-		return errors.New("系统中未安装SSH客户端，请先安装OpenSSH客户端")
+	// Check the client of the tunnel is installed, the relay connects over WebSocket instead
+	if s.tunnel == nil && s.config.FRPServer != "" && !tunnel.FRPAvailable() {
+		return errors.New("系统中未找到 frpc，请先安装 frp 客户端 (https://github.com/fatedier/frp)")
+	}
+	if s.tunnel == nil && s.config.Relay == "" && s.config.FRPServer == "" && !tunnel.IsAvailable() {
+		return errors.New("系统中未安装SSH客户端，请先安装OpenSSH客户端，或使用 -relay 连接自建中继")
 	}
 
-	// Find available port
-	port := util.FindAvailablePort(s.config.Port)
 	if port == 0 {
-		return fmt.Errorf("在端口范围 %d-%d 中未找到可用端口", s.config.Port, s.config.Port+9)
+		var err error
+		if port, err = s.listenPort(); err != nil {
+			return err
+		}
 	}
+	s.port = port
 
 	// Start tunnel if not provided
-	if s.tunnel == nil {
+	if s.tunnel == nil && s.config.Relay != "" {
+		t, err := tunnel.NewRelay(s.config.Relay, s.config.RelayToken, port)
+		if err != nil {
+			return err
+		}
+		s.tunnel = t
+	} else if s.tunnel == nil && s.config.FRPServer != "" {
+		t, err := tunnel.NewFRP(s.config.FRPServer, s.config.FRPToken, s.config.FRPDomain, port)
+		if err != nil {
+			return err
+		}
+		s.tunnel = t
+	} else if s.tunnel == nil {
 		t, err := tunnel.New(port)
 		if err != nil {
 			return errors.New("启动隧道失败")
 		}
 		s.tunnel = t
 	}
+	if s.session != nil {
+		s.session.keep(s.tunnel, port)
+	}
 
 	// Create HTTP server if not provided
 	if s.httpServer == nil {
@@ -139,9 +214,24 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 }
 
-// Shutdown gracefully shuts down the server
+// listenPort returns the port of the server, -port pins it, otherwise the first free one of the ten from the default
+func (s *Server) listenPort() (int, error) {
+	if s.config.PinPort {
+		if !util.IsPortAvailable(s.config.Port) {
+			return 0, fmt.Errorf("端口 %d 已被占用", s.config.Port)
+		}
+		return s.config.Port, nil
+	}
+	port := util.FindAvailablePort(s.config.Port)
+	if port == 0 {
+		return 0, fmt.Errorf("在端口范围 %d-%d 中未找到可用端口", s.config.Port, s.config.Port+9)
+	}
+	return port, nil
+}
+
+// Shutdown gracefully shuts down the server, the tunnel of a session stays open for the next detection
 func (s *Server) Shutdown() error {
-	if s.tunnel != nil {
+	if s.tunnel != nil && s.session == nil {
 		s.tunnel.Close()
 	}
 	if s.httpServer != nil {
@@ -177,51 +267,107 @@ func (s *Server) setupRoutes() {
 	})
 
 	s.router.(*gin.Engine).Any(s.config.ImagePath, s.handleImage)
+	s.router.(*gin.Engine).GET(EventsPath, s.handleEvents)
+}
+
+// SetEvents sets the handler streaming the events of the running detection
+func (s *Server) SetEvents(h http.Handler) {
+	s.eventsLock.Lock()
+	defer s.eventsLock.Unlock()
+	s.events = h
+}
+
+// EventsURL returns the local WebSocket URL of the live trace events
+func (s *Server) EventsURL() string {
+	return fmt.Sprintf("ws://127.0.0.1:%d%s?token=%s", s.port, EventsPath, s.eventsToken)
+}
+
+// handleEvents streams the live trace events to clients presenting the events token.
+// The route is reachable through the tunnel as well, so the token is required.
+func (s *Server) handleEvents(c *gin.Context) {
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(s.eventsToken)) != 1 {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	s.eventsLock.RLock()
+	h := s.events
+	s.eventsLock.RUnlock()
+	if h == nil {
+		c.Status(http.StatusServiceUnavailable)
+		return
+	}
+	h.ServeHTTP(c.Writer, c.Request)
+}
+
+// newImageGenerator creates the generator of the configured probe image renderer,
+// multi-round detections rotate through all renderers starting with it
+func newImageGenerator(cfg *config.Config) interfaces.ImageGenerator {
+	// The flags were validated at startup
+	if cfg.Rounds > 1 {
+		rotation, _ := image.NewRotation(cfg.ProbeImage, cfg)
+		return rotation
+	}
+	gen, _ := image.NewRenderer(cfg.ProbeImage, cfg)
+	return gen
 }
 
 // handleImage handles image requests
 func (s *Server) handleImage(c *gin.Context) {
 	requestID := c.Query("id")
-	logger.Debug("Received image request with ID: %s, expected ID: %s", requestID, s.requestID)
+	s.probesLock.RLock()
+	current := s.requestID
+	s.probesLock.RUnlock()
+	logger.Debug("Received image request with ID: %s, expected ID: %s", requestID, current)
 
-	if requestID != s.requestID {
+	if requestID != current {
 		logger.Debug("Invalid request ID: %s", requestID)
 		c.Status(http.StatusNotFound)
 		return
 	}
 
-	// Record the request
+	// The first image is served without a variant, as before multiple images were sent
+	variant := 1
+	if v := c.Query("v"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > max(s.config.Images, 1) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		variant = n
+	}
+
+	// Record the request, stamped with the time it arrived
+	received := time.Now()
 	defer func() {
+		headers := &types.RequestHeaders{
+			UserAgent:    c.GetHeader("User-Agent"),
+			ForwardedFor: c.GetHeader("X-Forwarded-For"),
+			Time:         received,
+			IP:           c.ClientIP(),
+		}
+		// Only a tunnel the user runs guarantees the CF headers were set by Cloudflare
+		if s.config.CloudflareTunnel {
+			headers.EdgeCountry = c.GetHeader(config.CFCountryHeader)
+			headers.EdgeColo = cloudflareColo(c.GetHeader(config.CFRayHeader))
+		}
 		s.msgChan <- types.Message{
-			Type: types.MessageTypeNode,
-			Headers: &types.RequestHeaders{
-				UserAgent:    c.GetHeader("User-Agent"),
-				ForwardedFor: c.GetHeader("X-Forwarded-For"),
-				Time:         time.Now(),
-				IP:           c.ClientIP(),
-			},
+			Type:    types.MessageTypeNode,
+			Headers: headers,
+			Image:   variant,
 		}
 	}()
 
 	// debug ip and request method
 	logger.Debug("receive request from: %s %s", c.ClientIP(), c.Request.Method)
 
-	// Generate or get cached captcha
-	s.captchaCacheLock.Lock()
-	if s.captchaCache == nil {
-		// Generate random digits for the captcha
-		randomDigits := util.GenerateRandomDigits(4) // Generate 6 random digits
-		result, err := s.imgGen.GenerateCaptcha(s.config.ImageWidth, s.config.ImageHeight, randomDigits)
-		if err != nil {
-			logger.Debug("Failed to generate captcha: %v", err)
-			s.captchaCacheLock.Unlock()
-			c.Status(http.StatusInternalServerError)
-			return
-		}
-		s.captchaCache = result
+	// Generate or get cached probe image
+	probes, err := s.currentProbes(1)
+	if err != nil {
+		logger.Debug("Failed to generate captcha: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
 	}
-	captcha := s.captchaCache
-	s.captchaCacheLock.Unlock()
+	captcha := probes[variant-1]
 
 	logger.Debug("generate captcha size: %d", len(captcha.Image))
 
@@ -231,78 +377,149 @@ func (s *Server) handleImage(c *gin.Context) {
 	c.Data(http.StatusOK, "image/png", captcha.Image)
 }
 
-// SendPostRequest sends a POST request to test the API
+// cloudflareColo returns the colo code at the end of a Cf-Ray header, empty when there is none
+func cloudflareColo(ray string) string {
+	_, colo, ok := strings.Cut(ray, "-")
+	if !ok {
+		return ""
+	}
+	return strings.ToUpper(colo)
+}
+
+// SendPostRequest sends a POST request to test the API, once per detection round
 func (s *Server) SendPostRequest(ctx context.Context, url, key, model string, useStream bool) {
 	<-s.tunnel.Ready()
-	// Check if tunnel URL is an error
-	if strings.HasPrefix(s.tunnel.URL(), "Error:") {
+	if err := s.tunnel.Err(); err != nil {
+		s.dropTunnel()
+		s.msgChan <- types.Message{
+			Type:    types.MessageTypeError,
+			Content: err.Error(),
+		}
+		close(s.done)
+		return
+	}
+	// An unreachable tunnel would only show up as a timeout of the API request
+	if err := tunnel.Verify(ctx, s.tunnel.URL(), s.config.TunnelChecker); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		s.dropTunnel()
 		s.msgChan <- types.Message{
 			Type:    types.MessageTypeError,
-			Content: fmt.Sprintf("隧道创建失败: %s", s.tunnel.URL()),
+			Content: err.Error(),
 		}
 		close(s.done)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
-	defer cancel()
+	rounds := max(s.config.Rounds, 1)
+	for round := 1; round <= rounds; round++ {
+		if !s.sendRound(ctx, url, key, model, round, rounds) {
+			return
+		}
+	}
+}
 
-	// Generate captcha if not exists
-	s.captchaCacheLock.Lock()
-	if s.captchaCache == nil {
-		// Generate random digits for the captcha
-		randomDigits := util.GenerateRandomDigits(4)
-		result, err := s.imgGen.GenerateCaptcha(s.config.ImageWidth, s.config.ImageHeight, randomDigits)
-		if err != nil {
-			s.captchaCacheLock.Unlock()
-			s.msgChan <- types.Message{
-				Type:    types.MessageTypeError,
-				Content: fmt.Sprintf("生成验证码失败: %v", err),
+// dropTunnel keeps a failed tunnel from being reused by the next detection
+func (s *Server) dropTunnel() {
+	if s.session != nil {
+		s.session.drop(s.tunnel)
+	}
+}
+
+// currentProbes returns the probe images of a round, generating them when missing. Every round after
+// the first is served under a new image ID, so fetches of an earlier image are not counted for the current one.
+func (s *Server) currentProbes(round int) ([]*interfaces.CaptchaResult, error) {
+	s.probesLock.Lock()
+	defer s.probesLock.Unlock()
+	if round > 1 {
+		s.requestID = util.GenerateRandomString(10)
+		s.probes = nil
+	}
+	if s.probes == nil {
+		probes := make([]*interfaces.CaptchaResult, max(s.config.Images, 1))
+		for i := range probes {
+			result, err := s.imgGen.Generate(s.config.ImageWidth, s.config.ImageHeight)
+			if err != nil {
+				return nil, err
 			}
-			close(s.done)
-			return
+			probes[i] = result
+		}
+		s.probes = probes
+	}
+	return s.probes, nil
+}
+
+// sendRound sends the request of one round, it returns false when the detection ended
+func (s *Server) sendRound(ctx context.Context, url, key, model string, round, rounds int) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.config.TraceTimeout)
+	defer cancel()
+
+	probes, err := s.currentProbes(round)
+	if err != nil {
+		s.msgChan <- types.Message{
+			Type:    types.MessageTypeError,
+			Content: fmt.Sprintf("生成探测图片失败: %v", err),
 		}
-		s.captchaCache = result
+		close(s.done)
+		return false
 	}
-	captchaText := s.captchaCache.Text
-	s.captchaCacheLock.Unlock()
+	probe := combineProbes(probes, s.config)
 
-	// Log the request ID and URL for debugging
-	logger.Debug("Using request ID: %s", s.requestID)
-	imageURL := s.GetTunnelImageUrl()
-	logger.Debug("Full image URL: %s", imageURL)
+	// Log the request ID and URLs for debugging
+	imageURLs := s.imageURLs()
+	logger.Debug("Round %d/%d, image URLs: %v", round, rounds, imageURLs)
 
-	// Show the request message with captcha text
-	requestMsg := fmt.Sprintf("%s (发送验证码图片，验证码: %s)",
-		s.config.Prompt,
-		captchaText,
+	// Show the request message with the expected answer
+	requestMsg := fmt.Sprintf("%s (发送%s图片，答案: %s)",
+		probe.Prompt,
+		probe.Label,
+		probe.Text,
 	)
 
-	response := s.client.ChatRequest(ctx, s.config.Prompt, url, imageURL, key, model)
+	response := s.client.ChatRequest(ctx, probe.Prompt, url, imageURLs, key, model)
 
 	logger.Debug("response: %+v", response)
+	if response.Shape != "" && response.Shape != util.ShapeChat {
+		requestMsg += fmt.Sprintf(" [%s]", util.ShapeURL("/v1/chat/completions", response.Shape))
+	}
 	if response.Error != nil {
-		if errors.Is(response.Error, context.DeadlineExceeded) {
-			s.msgChan <- types.Message{
-				Type:    types.MessageTypeError,
-				Content: fmt.Sprintf("API请求超时,未能获取到响应, 超过%s", s.config.Timeout),
-			}
-		} else {
+		// The nodes seen before the timeout are still reported by the tracer
+		if errors.Is(response.Error, context.DeadlineExceeded) || ctx.Err() != nil {
 			s.msgChan <- types.Message{
-				Type:    types.MessageTypeError,
+				Type:    types.MessageTypeTimeout,
 				Request: requestMsg,
-				Content: fmt.Sprintf("API请求失败: %v", response.Error),
+				Content: fmt.Sprintf("API请求超时, 超过 %s 未收到模型响应", s.config.TraceTimeout),
+				Round:   round,
+				Rounds:  rounds,
 			}
-			close(s.done)
-			return
+			return false
+		}
+		s.msgChan <- types.Message{
+			Type:    types.MessageTypeError,
+			Request: requestMsg,
+			Content: fmt.Sprintf("API请求失败: %v", response.Error),
+			Round:   round,
+			Rounds:  rounds,
 		}
+		close(s.done)
+		return false
 	}
 
+	answers := make([][]string, len(probes))
+	for i, p := range probes {
+		answers[i] = append([]string{p.Text}, p.Alternatives...)
+	}
 	s.msgChan <- types.Message{
 		Type:     types.MessageTypeAPI,
 		Request:  requestMsg,
 		Response: response.Response,
+		Round:    round,
+		Rounds:   rounds,
+		Answers:  answers,
+		Stream:   response.Stream.Verdict(),
 	}
+	return true
 }
 
 // MessageChan returns the message channel
@@ -315,8 +532,44 @@ func (s *Server) Done() <-chan struct{} {
 	return s.done
 }
 
-// GetTunnelURL returns the tunnel URL
+// GetTunnelImageUrl returns the tunnel URL of the first image
 func (s *Server) GetTunnelImageUrl() string {
-	imageURL := s.TunnelURL() + fmt.Sprintf("%s?id=%s", s.config.ImagePath, s.requestID)
-	return imageURL
+	return s.imageURLs()[0]
+}
+
+// imageURLs returns the tunnel URLs of the images of the current round
+func (s *Server) imageURLs() []string {
+	s.probesLock.RLock()
+	defer s.probesLock.RUnlock()
+	urls := []string{s.TunnelURL() + fmt.Sprintf("%s?id=%s", s.config.ImagePath, s.requestID)}
+	for v := 2; v <= s.config.Images; v++ {
+		urls = append(urls, fmt.Sprintf("%s&v=%d", urls[0], v))
+	}
+	return urls
+}
+
+// combineProbes merges the images of a round into one question in the prompt language, a single image is asked as is
+func combineProbes(probes []*interfaces.CaptchaResult, cfg *config.Config) *interfaces.CaptchaResult {
+	if len(probes) == 1 {
+		return probes[0]
+	}
+	var prompt strings.Builder
+	prompt.WriteString(cfg.Localize(
+		fmt.Sprintf("%d images are attached. Answer for every image in order, one line per image.", len(probes)),
+		fmt.Sprintf("附上了 %d 张图片, 请按顺序逐张回答, 每张图片一行。", len(probes))))
+	image := cfg.Localize("Image", "图片")
+	if cfg != nil && cfg.PromptLang == config.PromptLangBoth {
+		image = "Image / 图片"
+	}
+	var answers, labels []string
+	for i, p := range probes {
+		fmt.Fprintf(&prompt, "\n%s %d: %s", image, i+1, p.Prompt)
+		answers = append(answers, fmt.Sprintf("%d: %s", i+1, p.Text))
+		labels = append(labels, p.Label)
+	}
+	return &interfaces.CaptchaResult{
+		Text:   strings.Join(answers, "; "),
+		Prompt: prompt.String(),
+		Label:  strings.Join(labels, "、"),
+	}
 }
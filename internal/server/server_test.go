@@ -0,0 +1,20 @@
+package server
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsToken(t *testing.T) {
+	cfg := &config.Config{ImagePath: "/image"}
+	a, b := New(cfg), New(cfg)
+	assert.NotEqual(t, a.eventsToken, b.eventsToken)
+	for _, token := range []string{a.eventsToken, b.eventsToken} {
+		raw, err := hex.DecodeString(token)
+		assert.NoError(t, err)
+		assert.Len(t, raw, 16)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/go-coders/check-gpt/internal/interfaces"
+)
+
+// Session keeps the tunnel of a detection open for the next detections of the same run.
+// They serve on the same port through the same public URL, instead of starting a new SSH process each time.
+type Session struct {
+	mu     sync.Mutex
+	tunnel interfaces.Tunnel
+	port   int
+}
+
+// WithSession reuses the tunnel of the session and leaves the tunnel open on shutdown
+func WithSession(session *Session) ServerOption {
+	return func(s *Server) {
+		s.session = session
+	}
+}
+
+// take returns the tunnel kept by the session and its local port, nil when there is none or it failed
+func (ss *Session) take() (interfaces.Tunnel, int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.tunnel == nil {
+		return nil, 0
+	}
+	select {
+	case <-ss.tunnel.Ready():
+		if ss.tunnel.Err() != nil {
+			ss.tunnel.Close()
+			ss.tunnel = nil
+			return nil, 0
+		}
+	default:
+	}
+	return ss.tunnel, ss.port
+}
+
+// keep stores the tunnel serving the local port for the next detections
+func (ss *Session) keep(t interfaces.Tunnel, port int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.tunnel, ss.port = t, port
+}
+
+// drop closes the kept tunnel when it is t, the next detection starts a new one
+func (ss *Session) drop(t interfaces.Tunnel) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.tunnel == t && t != nil {
+		t.Close()
+		ss.tunnel = nil
+	}
+}
+
+// Close closes the kept tunnel
+func (ss *Session) Close() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.tunnel == nil {
+		return nil
+	}
+	err := ss.tunnel.Close()
+	ss.tunnel = nil
+	return err
+}
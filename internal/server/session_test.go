@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTunnel is a tunnel that is ready at once
+type fakeTunnel struct {
+	url    string
+	err    error
+	closed bool
+	ready  chan struct{}
+}
+
+func newFakeTunnel(url string) *fakeTunnel {
+	t := &fakeTunnel{url: url, ready: make(chan struct{})}
+	close(t.ready)
+	return t
+}
+
+func (t *fakeTunnel) URL() string            { return t.url }
+func (t *fakeTunnel) Close() error           { t.closed = true; return nil }
+func (t *fakeTunnel) Ready() <-chan struct{} { return t.ready }
+func (t *fakeTunnel) Err() error             { return t.err }
+
+func TestSession(t *testing.T) {
+	session := &Session{}
+	tun, port := session.take()
+	assert.Nil(t, tun)
+	assert.Zero(t, port)
+
+	ok := newFakeTunnel("https://abc.lhr.life")
+	session.keep(ok, 8081)
+	tun, port = session.take()
+	assert.Equal(t, ok, tun)
+	assert.Equal(t, 8081, port)
+
+	// Dropping another tunnel keeps the session's
+	session.drop(newFakeTunnel("https://other.lhr.life"))
+	tun, _ = session.take()
+	assert.Equal(t, ok, tun)
+
+	session.drop(ok)
+	assert.True(t, ok.closed)
+	tun, _ = session.take()
+	assert.Nil(t, tun)
+
+	failed := newFakeTunnel("")
+	failed.err = errors.New("隧道创建超时")
+	session.keep(failed, 8082)
+	tun, _ = session.take()
+	assert.Nil(t, tun)
+	assert.True(t, failed.closed)
+
+	last := newFakeTunnel("https://last.lhr.life")
+	session.keep(last, 8083)
+	assert.NoError(t, session.Close())
+	assert.True(t, last.closed)
+}
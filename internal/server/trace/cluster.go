@@ -0,0 +1,98 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Prefix lengths of the networks nodes are clustered by
+const (
+	ClusterPrefixV4 = 24
+	ClusterPrefixV6 = 48
+)
+
+// Hop is a logical hop of the chain, the nodes of one network
+type Hop struct {
+	Network string // 所在网段，或 OpenAI、Cloudflare 等官方网段
+	Nodes   []types.Node
+}
+
+// Name returns the server name of the first node in the hop
+func (h *Hop) Name() string {
+	return h.Nodes[0].ServerName
+}
+
+// MarshalJSON encodes the hop by its name, network and member IPs
+func (h Hop) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name    string   `json:"name"`
+		Network string   `json:"network"`
+		IPs     []string `json:"ips"`
+	}{h.Name(), h.Network, h.IPs()})
+}
+
+// IPs returns the member IPs of the hop
+func (h *Hop) IPs() []string {
+	ips := make([]string, 0, len(h.Nodes))
+	for _, n := range h.Nodes {
+		ips = append(ips, n.IP)
+	}
+	return ips
+}
+
+// Cluster groups nodes in the same /24 (/48 for IPv6) or official provider range into hops,
+// in the order the networks were first seen. Azure frontends rotate IPs within a range
+// and would otherwise inflate the chain length.
+func Cluster(nodes []types.Node, cfg *config.Config) []Hop {
+	var hops []Hop
+	index := make(map[string]int)
+	for _, n := range nodes {
+		network := clusterNetwork(n.IP, cfg)
+		if i, ok := index[network]; ok {
+			hops[i].Nodes = append(hops[i].Nodes, n)
+			continue
+		}
+		index[network] = len(hops)
+		hops = append(hops, Hop{Network: network, Nodes: []types.Node{n}})
+	}
+	return hops
+}
+
+// clusterNetwork returns the network ip is clustered by
+func clusterNetwork(ip string, cfg *config.Config) string {
+	if cfg != nil {
+		switch network := cfg.IPNetwork(ip); network {
+		case config.NetworkOpenAI, config.NetworkCloudflare:
+			return network
+		}
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(ClusterPrefixV4, 32)), Mask: net.CIDRMask(ClusterPrefixV4, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(ClusterPrefixV6, 128)), Mask: net.CIDRMask(ClusterPrefixV6, 128)}).String()
+}
+
+// formatHops formats the clustered chain with the member IPs of every multi-node hop
+func formatHops(hops []Hop, nodes int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "链路长度: %d 跳 (%d 个节点)\n", len(hops), nodes)
+	for i, h := range hops {
+		fmt.Fprintf(&b, "   跳%2d : %s %s", i+1, util.PadRight(h.Name(), 20), h.Network)
+		if len(h.Nodes) > 1 {
+			fmt.Fprintf(&b, " (%d 个 IP)\n", len(h.Nodes))
+			fmt.Fprintf(&b, "          %s", strings.Join(h.IPs(), ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster(t *testing.T) {
+	cfg := &config.Config{OPENAICIDR: []string{"23.102.140.112/28"}}
+	nodes := []types.Node{
+		{IP: "1.2.3.4", ServerName: "Go服务"},
+		{IP: "23.102.140.113", ServerName: "OpenAI服务"},
+		{IP: "1.2.3.200", ServerName: "Go服务"},
+		{IP: "23.102.140.120", ServerName: "OpenAI服务"},
+		{IP: "1.2.4.1", ServerName: "Python服务"},
+		{IP: "2001:db8:1:2::1", ServerName: "Go服务"},
+		{IP: "2001:db8:1:3::1", ServerName: "Go服务"},
+	}
+
+	hops := Cluster(nodes, cfg)
+	if assert.Len(t, hops, 4) {
+		assert.Equal(t, "1.2.3.0/24", hops[0].Network)
+		assert.Equal(t, []string{"1.2.3.4", "1.2.3.200"}, hops[0].IPs())
+		assert.Equal(t, config.NetworkOpenAI, hops[1].Network)
+		assert.Equal(t, "OpenAI服务", hops[1].Name())
+		assert.Len(t, hops[1].Nodes, 2)
+		assert.Equal(t, "1.2.4.0/24", hops[2].Network)
+		assert.Equal(t, "2001:db8:1::/48", hops[3].Network)
+		assert.Len(t, hops[3].Nodes, 2)
+	}
+
+	out := formatHops(hops, len(nodes))
+	assert.Contains(t, out, "链路长度: 4 跳 (7 个节点)")
+	assert.Contains(t, out, "1.2.3.4, 1.2.3.200")
+}
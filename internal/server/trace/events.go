@@ -0,0 +1,139 @@
+package trace
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"golang.org/x/net/websocket"
+)
+
+// Event types streamed to live view clients
+const (
+	EventNode = "node" // 发现新节点
+	EventDone = "done" // 检测结束
+)
+
+// eventBuffer is the number of events queued per subscriber before new ones are dropped
+const eventBuffer = 64
+
+// Event is a trace progress event, sent as JSON over the WebSocket
+type Event struct {
+	Type    string     `json:"type"`
+	Time    time.Time  `json:"time"`
+	Node    *NodeEvent `json:"node,omitempty"`
+	Nodes   int        `json:"nodes,omitempty"`   // done: 节点数
+	Hops    int        `json:"hops,omitempty"`    // done: 聚合后的跳数
+	Failure string     `json:"failure,omitempty"` // done: 失败原因，成功时为空
+}
+
+// NodeEvent describes a discovered node
+type NodeEvent struct {
+	Index     int    `json:"index"`
+	IP        string `json:"ip"`
+	Server    string `json:"server"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Country   string `json:"country,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Org       string `json:"org,omitempty"`
+	CFCountry string `json:"cf_country,omitempty"` // Cloudflare Tunnel 模式下的 CF-IPCountry
+	CFColo    string `json:"cf_colo,omitempty"`    // Cloudflare Tunnel 模式下接入的机房
+}
+
+// nodeEvent converts a node to its event
+func nodeEvent(n *types.Node) Event {
+	return Event{
+		Type: EventNode,
+		Time: n.Time,
+		Node: &NodeEvent{
+			Index:     n.NodeIndex,
+			IP:        n.IP,
+			Server:    n.ServerName,
+			UserAgent: n.UserAgent,
+			Country:   n.Country,
+			Region:    n.RegionName,
+			Org:       n.Org,
+			CFCountry: n.EdgeCountry,
+			CFColo:    n.EdgeColo,
+		},
+	}
+}
+
+// Subscribe returns a channel of trace events, starting with the nodes found so far.
+// The channel is closed after the done event, cancel stops the subscription early.
+func (t *Manager) Subscribe() (<-chan Event, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ch := make(chan Event, eventBuffer+len(t.nodes)+1)
+	for i := range t.nodes {
+		ch <- nodeEvent(&t.nodes[i])
+	}
+	if t.finished != nil {
+		ch <- *t.finished
+		close(ch)
+		return ch, func() {}
+	}
+
+	if t.subscribers == nil {
+		t.subscribers = make(map[chan Event]struct{})
+	}
+	t.subscribers[ch] = struct{}{}
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends the event to every subscriber, the caller must hold t.mu.
+// Slow subscribers miss events rather than blocking the trace.
+func (t *Manager) publish(e Event) {
+	for ch := range t.subscribers {
+		select {
+		case ch <- e:
+		default:
+			logger.Debug("Dropping trace event for slow subscriber")
+		}
+	}
+}
+
+// finish publishes the done event and closes every subscription, the caller must hold t.mu
+func (t *Manager) finish() {
+	e := Event{
+		Type:    EventDone,
+		Time:    time.Now(),
+		Nodes:   len(t.nodes),
+		Hops:    len(Cluster(t.nodes, t.cfg)),
+		Failure: t.failure,
+	}
+	t.finished = &e
+	for ch := range t.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+		close(ch)
+	}
+	t.subscribers = nil
+}
+
+// WebSocketHandler streams the trace events as JSON messages until the trace is done.
+// Authentication is left to the caller, non-browser clients without an Origin are accepted.
+func (t *Manager) WebSocketHandler() http.Handler {
+	return websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		events, cancel := t.Subscribe()
+		defer cancel()
+		for e := range events {
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				logger.Debug("Trace event client gone: %v", err)
+				return
+			}
+		}
+	}}
+}
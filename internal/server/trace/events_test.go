@@ -0,0 +1,71 @@
+package trace
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketEvents(t *testing.T) {
+	sender := &fakeSender{msgs: make(chan types.Message, 4)}
+	tracer := New(sender, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}), WithOutputWriter(&strings.Builder{}))
+
+	// A node found before the client connects is replayed
+	tracer.handleNodeMessage(types.Message{Headers: &types.RequestHeaders{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1"}})
+
+	srv := httptest.NewServer(tracer.WebSocketHandler())
+	defer srv.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), "", srv.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var e Event
+	assert.NoError(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, EventNode, e.Type)
+	if assert.NotNil(t, e.Node) {
+		assert.Equal(t, "203.0.113.7", e.Node.IP)
+		assert.Equal(t, 1, e.Node.Index)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+	sender.msgs <- types.Message{Type: types.MessageTypeNode, Headers: &types.RequestHeaders{IP: "198.51.100.9", UserAgent: "python-requests/2.31"}}
+	sender.msgs <- types.Message{Type: types.MessageTypeAPI, Request: "what's the number?", Response: "1234"}
+
+	assert.NoError(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, EventNode, e.Type)
+	if assert.NotNil(t, e.Node) {
+		assert.Equal(t, "198.51.100.9", e.Node.IP)
+	}
+
+	assert.NoError(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, EventDone, e.Type)
+	assert.Equal(t, 2, e.Nodes)
+	assert.Empty(t, e.Failure)
+}
+
+func TestSubscribeAfterDone(t *testing.T) {
+	tracer := New(&fakeSender{}, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}))
+	tracer.failure = "未检测到任何节点"
+	tracer.complete()
+
+	events, cancel := tracer.Subscribe()
+	defer cancel()
+	e, ok := <-events
+	assert.True(t, ok)
+	assert.Equal(t, EventDone, e.Type)
+	assert.Equal(t, "未检测到任何节点", e.Failure)
+	_, ok = <-events
+	assert.False(t, ok, "the channel is closed after the done event")
+}
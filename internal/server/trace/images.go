@@ -0,0 +1,95 @@
+package trace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Verdicts of how the images of a multi-image detection reached the model
+const (
+	ImagesDropped       = "部分图片未被任何节点获取, 中转可能丢弃了图片"
+	ImagesPassedThrough = "所有图片均由官方节点获取, 中转原样传递了图片地址"
+	ImagesPartlyRehost  = "只有部分图片由官方节点获取, 中转转存了其余图片"
+	ImagesRehosted      = "图片均由非官方节点获取, 中转可能下载后转存或转为 base64 再交给上游 (上游不在已知官方网段时也会如此)"
+)
+
+// addImage records that image was fetched, 0 is a request without an image number
+func addImage(images []int, image int) []int {
+	if image <= 0 {
+		return images
+	}
+	for _, i := range images {
+		if i == image {
+			return images
+		}
+	}
+	return append(images, image)
+}
+
+// ImageFetchVerdict compares which nodes fetched which of the images sent in every request:
+// a relay passing the URLs upstream untouched leaves the fetches to the provider, a relay
+// re-hosting the images fetches them itself and the provider never calls back
+func ImageFetchVerdict(nodes []types.Node, images int, cfg *config.Config) string {
+	fetched := make(map[int]bool)
+	official := make(map[int]bool)
+	for _, n := range nodes {
+		for _, image := range n.Images {
+			fetched[image] = true
+			if cfg != nil && cfg.IPNetwork(n.IP) == config.NetworkOpenAI {
+				official[image] = true
+			}
+		}
+	}
+	switch {
+	case len(fetched) < images:
+		return ImagesDropped
+	case len(official) == images:
+		return ImagesPassedThrough
+	case len(official) > 0:
+		return ImagesPartlyRehost
+	default:
+		return ImagesRehosted
+	}
+}
+
+// printImageFetches prints the images fetched by every node when several images are sent per request
+func (t *Manager) printImageFetches(nodes []types.Node) {
+	if t.cfg == nil || t.cfg.Images <= 1 {
+		return
+	}
+	t.printer.PrintTitle("图片获取", util.EmojiLink)
+	t.printer.Print(formatImageFetches(nodes, t.cfg.Images))
+	t.printer.Printf("结论: %s\n", ImageFetchVerdict(nodes, t.cfg.Images, t.cfg))
+}
+
+// formatImageFetches lists the images every node fetched, followed by the images nobody fetched
+func formatImageFetches(nodes []types.Node, images int) string {
+	var b strings.Builder
+	fetched := make(map[int]bool)
+	for _, n := range nodes {
+		var list []string
+		for _, image := range n.Images {
+			fetched[image] = true
+			list = append(list, strconv.Itoa(image))
+		}
+		if len(list) == 0 {
+			list = []string{"无"}
+		}
+		fmt.Fprintf(&b, "   节点%2d : %s IP: %s 图片: %s\n", n.NodeIndex, n.ServerName, n.IP, strings.Join(list, ", "))
+	}
+	var missing []string
+	for image := 1; image <= images; image++ {
+		if !fetched[image] {
+			missing = append(missing, strconv.Itoa(image))
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(&b, "   未获取的图片: %s\n", strings.Join(missing, ", "))
+	}
+	return b.String()
+}
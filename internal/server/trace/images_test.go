@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageFetchVerdict(t *testing.T) {
+	cfg := &config.Config{OPENAICIDR: []string{"23.102.140.112/28"}}
+	relay := types.Node{IP: "203.0.113.7"}
+	provider := types.Node{IP: "23.102.140.113"}
+
+	tests := []struct {
+		name          string
+		relay, origin []int
+		want          string
+	}{
+		{"passed through", nil, []int{1, 2}, ImagesPassedThrough},
+		{"rehosted", []int{1, 2}, nil, ImagesRehosted},
+		{"partly rehosted", []int{2}, []int{1}, ImagesPartlyRehost},
+		{"dropped", nil, []int{1}, ImagesDropped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay.Images, provider.Images = tt.relay, tt.origin
+			assert.Equal(t, tt.want, ImageFetchVerdict([]types.Node{relay, provider}, 2, cfg))
+		})
+	}
+}
+
+func TestTraceRecordsFetchedImages(t *testing.T) {
+	tracer := New(&fakeSender{}, WithConfig(&config.Config{Images: 2}), WithIPProvider(fakeIPProvider{}))
+	headers := &types.RequestHeaders{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1"}
+	for _, image := range []int{2, 1, 2} {
+		tracer.handleNodeMessage(types.Message{Type: types.MessageTypeNode, Headers: headers, Image: image})
+	}
+
+	nodes := tracer.GetNodes()
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, []int{2, 1}, nodes[0].Images)
+	assert.Contains(t, formatImageFetches(nodes, 3), "未获取的图片: 3")
+}
@@ -0,0 +1,96 @@
+package trace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-coders/check-gpt/pkg/schema"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Requirements a verdict can be held to by the -trace-policy flag
+const (
+	RequireOfficial = "official" // 链路末端位于官方网段
+	RequireCaptcha  = "captcha"  // 验证码回答正确
+	RequireImage    = "image"    // 有节点获取了图片
+	RequireStream   = "stream"   // 流式响应未被缓冲或转换
+)
+
+// DefaultPolicy passes a trace whose chain ends in the official ranges and whose captcha was read correctly
+const DefaultPolicy = RequireOfficial + "," + RequireCaptcha
+
+// PolicyNone holds the verdict to no requirement, only a trace ending with an error or timeout fails
+const PolicyNone = "none"
+
+// Policy is the list of requirements a trace has to meet to pass
+type Policy []string
+
+// ParsePolicy parses the comma separated -trace-policy flag value
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "":
+		s = DefaultPolicy
+	case PolicyNone:
+		return Policy{}, nil
+	}
+	var p Policy
+	for _, r := range strings.Split(s, ",") {
+		switch r = strings.TrimSpace(r); r {
+		case RequireOfficial, RequireCaptcha, RequireImage, RequireStream:
+			p = append(p, r)
+		case "":
+		default:
+			return nil, fmt.Errorf("无效的链路检测判定条件: %s (可选: official, captcha, image, stream 或 none)", r)
+		}
+	}
+	return p, nil
+}
+
+// Failed returns the requirements the verdict does not meet, empty if it passes
+func (p Policy) Failed(v *Verdict) []string {
+	failed := []string{}
+	for _, r := range p {
+		var ok bool
+		switch r {
+		case RequireOfficial:
+			ok = v.Confidence == ConfidenceHigh
+		case RequireCaptcha:
+			ok = v.Checked && v.Correct
+		case RequireImage:
+			ok = v.Fetched
+		case RequireStream:
+			ok = v.Stream != util.StreamBuffered && v.Stream != util.StreamConverted
+		}
+		if !ok {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Result is the machine readable outcome of a trace printed by -output json
+type Result struct {
+	SchemaVersion string      `json:"schema_version"`
+	Run           *schema.Run `json:"run,omitempty"` // 检测的运行信息
+	Pass          bool        `json:"pass"`
+	Inconclusive  bool        `json:"inconclusive,omitempty"` // 收到了回答但没有节点获取图片, 链路无从判定
+	Failed        []string    `json:"failed"`                 // 未满足的判定条件
+	Error         string      `json:"error,omitempty"`        // 检测失败的原因
+	Endpoint      string      `json:"endpoint"`
+	Model         string      `json:"model"`
+	Verdict       *Verdict    `json:"verdict"` // 检测出错时为 null
+}
+
+// Result judges the finished trace by the policy, a trace that ended with an error or timed out never passes.
+// An answer given without any image fetch is inconclusive, also under a policy that does not require the image.
+func (t *Manager) Result(p Policy, endpoint, model string) Result {
+	r := Result{Failed: []string{}, Error: t.Failure(), Endpoint: endpoint, Model: model, Verdict: t.Verdict()}
+	if r.Verdict == nil {
+		return r
+	}
+	r.Failed = p.Failed(r.Verdict)
+	r.Inconclusive = r.Verdict.Answered && !r.Verdict.Fetched
+	r.Pass = len(r.Failed) == 0 && r.Verdict.Answered && !r.Inconclusive && r.Error == ""
+	return r
+}
@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{RequireOfficial, RequireCaptcha}, p)
+
+	p, err = ParsePolicy("Captcha, stream")
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{RequireCaptcha, RequireStream}, p)
+
+	p, err = ParsePolicy("none")
+	assert.NoError(t, err)
+	assert.Empty(t, p)
+
+	_, err = ParsePolicy("official,fast")
+	assert.Error(t, err)
+}
+
+func TestPolicyFailed(t *testing.T) {
+	v := &Verdict{Confidence: ConfidenceLow, Fetched: true, Answered: true, Checked: true, Correct: true, Stream: util.StreamBuffered}
+	p := Policy{RequireOfficial, RequireCaptcha, RequireImage, RequireStream}
+	assert.Equal(t, []string{RequireOfficial, RequireStream}, p.Failed(v))
+
+	v.Confidence, v.Stream = ConfidenceHigh, util.StreamPassedThrough
+	assert.Empty(t, p.Failed(v))
+
+	v.Checked = false
+	assert.Equal(t, []string{RequireCaptcha}, p.Failed(v))
+}
+
+func TestVerdictJSON(t *testing.T) {
+	v := Verdict{
+		Hops:       Cluster([]types.Node{{IP: "203.0.113.7", ServerName: "Go服务"}}, nil),
+		Confidence: ConfidenceNone,
+		RedFlags:   []string{FlagNoImageFetch},
+	}
+	data, err := json.Marshal(Result{Failed: []string{RequireOfficial}, Verdict: &v})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"pass":false`)
+	assert.Contains(t, string(data), `"hops":[{"name":"Go服务","network":"203.0.113.0/24","ips":["203.0.113.7"]}]`)
+	assert.Contains(t, string(data), `"official_confidence":"none"`)
+	assert.Contains(t, string(data), `"red_flags":["`+FlagNoImageFetch+`"]`)
+}
@@ -0,0 +1,39 @@
+package trace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// printTimeline prints the request timeline when -timeline is set
+func (t *Manager) printTimeline(nodes []types.Node) {
+	if t.cfg == nil || !t.cfg.Timeline {
+		return
+	}
+	t.printer.PrintTitle("请求时间线", util.EmojiWatch)
+	t.printer.Print(formatTimeline(nodes, t.started))
+}
+
+// formatTimeline lists the requests of every node with their arrival time, the offset from start
+// and the gap to the previous request of the node, so bursts, retries and late fetches stand out
+func formatTimeline(nodes []types.Node, start time.Time) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "   节点%2d : %s IP: %s (%d 次请求, 首次 %s)\n", n.NodeIndex, n.ServerName, n.IP, len(n.Requests), util.FormatTime(n.Time))
+		for i, at := range n.Requests {
+			line := fmt.Sprintf("          %s", at.Local().Format("15:04:05.000"))
+			if !start.IsZero() {
+				line += fmt.Sprintf("  +%.3fs", at.Sub(start).Seconds())
+			}
+			if i > 0 {
+				line += fmt.Sprintf("  间隔 %.3fs", at.Sub(n.Requests[i-1]).Seconds())
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
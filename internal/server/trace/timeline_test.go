@@ -0,0 +1,29 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	tracer := New(&fakeSender{}, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}))
+	for _, offset := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond} {
+		headers := &types.RequestHeaders{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1", Time: start.Add(offset)}
+		tracer.handleNodeMessage(types.Message{Type: types.MessageTypeNode, Headers: headers})
+	}
+
+	nodes := tracer.GetNodes()
+	if assert.Len(t, nodes, 1) {
+		assert.Len(t, nodes[0].Requests, 2)
+	}
+
+	out := formatTimeline(nodes, start)
+	assert.Contains(t, out, "(2 次请求, 首次 2024-05-01 12:00:00)")
+	assert.Contains(t, out, "12:00:00.500  +0.500s\n")
+	assert.Contains(t, out, "12:00:01.500  +1.500s  间隔 1.000s\n")
+}
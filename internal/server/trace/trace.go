@@ -3,9 +3,11 @@ package trace
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-coders/check-gpt/internal/interfaces"
 	"github.com/go-coders/check-gpt/internal/ipinfo"
@@ -27,7 +29,20 @@ func WithIPProvider(provider ipinfo.Provider) TraceManagerOption {
 }
 
 // WithOutputWriter sets the output writer
+func WithOutputWriter(w io.Writer) TraceManagerOption {
+	return func(t *Manager) {
+		t.printer = util.NewPrinter(w)
+	}
+}
+
+// WithNodeSignature sets the fields that identify a node
+func WithNodeSignature(sig NodeSignature) TraceManagerOption {
+	return func(t *Manager) {
+		t.signature = sig
+	}
+}
 
+// WithConfig sets the configuration
 func WithConfig(cfg *config.Config) TraceManagerOption {
 	return func(t *Manager) {
 		t.cfg = cfg
@@ -39,6 +54,34 @@ const (
 	OutputNewLine = "\n"
 )
 
+// NodeSignature selects the request fields that identify a node
+type NodeSignature string
+
+// Node signatures, requests with the same signature are counted as one node
+const (
+	SignatureIP      NodeSignature = "ip"
+	SignatureIPUA    NodeSignature = "ip-ua"
+	SignatureIPUAXFF NodeSignature = "ip-ua-xff"
+)
+
+// ParseNodeSignature parses the -node-match flag value
+func ParseNodeSignature(s string) (NodeSignature, error) {
+	switch sig := NodeSignature(strings.ToLower(strings.TrimSpace(s))); sig {
+	case SignatureIP, SignatureIPUA, SignatureIPUAXFF:
+		return sig, nil
+	case "":
+		return SignatureIPUA, nil
+	default:
+		return "", fmt.Errorf("无效的节点匹配方式: %s (可选: ip, ip-ua, ip-ua-xff)", s)
+	}
+}
+
+// NoResponseVerdict is shown when the model did not respond before the timeout
+const NoResponseVerdict = "未收到模型响应"
+
+// NoImageFetchFinding is reported when the API answered but nothing fetched the image
+const NoImageFetchFinding = "模型未获取图片，回答系猜测/缓存"
+
 type Manager struct {
 	mu         sync.RWMutex
 	nodes      []types.Node
@@ -48,6 +91,16 @@ type Manager struct {
 	ipProvider ipinfo.Provider
 	cfg        *config.Config
 	printer    *util.Printer
+	failure    string
+	timedOut   bool
+	started    time.Time
+	signature  NodeSignature
+	verdict    *Verdict
+	// 回答错误的非最终轮次
+	wrongRounds []int
+
+	subscribers map[chan Event]struct{} // 实时查看的客户端
+	finished    *Event
 }
 
 // New creates a new TraceManager with options
@@ -58,6 +111,7 @@ func New(sender interfaces.MessageSender, opts ...TraceManagerOption) *Manager {
 		seen:       make(map[string]bool),
 		ipProvider: ipinfo.NewProvider(),
 		printer:    util.NewPrinter(os.Stdout),
+		signature:  SignatureIPUA,
 	}
 
 	for _, opt := range opts {
@@ -69,6 +123,7 @@ func New(sender interfaces.MessageSender, opts ...TraceManagerOption) *Manager {
 
 // Start starts the trace manager
 func (t *Manager) Start(ctx context.Context) {
+	t.started = time.Now()
 
 	go t.pollMessages(ctx)
 }
@@ -78,7 +133,17 @@ func (t *Manager) nodeMatches(node *types.Node, msg *types.Message) bool {
 	if msg.Headers == nil {
 		return false
 	}
-	return node.IP == msg.Headers.IP && node.UserAgent == msg.Headers.UserAgent
+	if node.IP != msg.Headers.IP {
+		return false
+	}
+	switch t.signature {
+	case SignatureIP:
+		return true
+	case SignatureIPUAXFF:
+		return node.UserAgent == msg.Headers.UserAgent && node.ForwardedFor == msg.Headers.ForwardedFor
+	default:
+		return node.UserAgent == msg.Headers.UserAgent
+	}
 }
 
 // Done returns a channel that is closed when tracing is complete
@@ -89,7 +154,7 @@ func (t *Manager) Done() <-chan struct{} {
 		<-t.done
 		logger.Debug("Trace completed, closing done channel")
 		// Print final newline
-		fmt.Printf("\n")
+		t.printer.Printf("\n")
 		close(done)
 	}()
 	return done
@@ -102,9 +167,50 @@ func (t *Manager) GetNodes() []types.Node {
 
 	result := make([]types.Node, len(t.nodes))
 	copy(result, t.nodes)
+	for i := range result {
+		result[i].Requests = append([]time.Time(nil), result[i].Requests...)
+		result[i].Images = append([]int(nil), result[i].Images...)
+	}
 	return result
 }
 
+// Failure returns the error message of a failed trace, empty if it succeeded
+func (t *Manager) Failure() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.failure
+}
+
+// GetHops returns the nodes clustered into logical hops
+func (t *Manager) GetHops() []Hop {
+	return Cluster(t.GetNodes(), t.cfg)
+}
+
+// printHops prints the clustered chain when some nodes share a network
+func (t *Manager) printHops(nodes []types.Node) {
+	hops := Cluster(nodes, t.cfg)
+	if len(hops) == len(nodes) {
+		return
+	}
+	t.printer.PrintTitle("链路聚合", util.EmojiLink)
+	t.printer.Print(formatHops(hops, len(nodes)))
+}
+
+// complete ends the trace and notifies the live view clients
+func (t *Manager) complete() {
+	t.mu.Lock()
+	t.finish()
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// TimedOut reports whether the trace ended without a model response
+func (t *Manager) TimedOut() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.timedOut
+}
+
 // handleNodeMessage processes a new message and returns the matching or new node
 func (t *Manager) handleNodeMessage(msg types.Message) *types.Node {
 	t.mu.Lock()
@@ -114,6 +220,8 @@ func (t *Manager) handleNodeMessage(msg types.Message) *types.Node {
 	for i := range t.nodes {
 		if t.nodeMatches(&t.nodes[i], &msg) {
 			t.nodes[i].RequestCount++
+			t.nodes[i].Requests = append(t.nodes[i].Requests, msg.Headers.Time)
+			t.nodes[i].Images = addImage(t.nodes[i].Images, msg.Image)
 			t.nodes[i].IsNew = false
 			nodeCopy := t.nodes[i] // Create a copy of the updated node
 			return &nodeCopy
@@ -130,6 +238,10 @@ func (t *Manager) handleNodeMessage(msg types.Message) *types.Node {
 		IsNew:        true,
 		ForwardedFor: msg.Headers.ForwardedFor,
 		RequestCount: 1,
+		Requests:     []time.Time{msg.Headers.Time},
+		Images:       addImage(nil, msg.Image),
+		EdgeCountry:  msg.Headers.EdgeCountry,
+		EdgeColo:     msg.Headers.EdgeColo,
 	}
 
 	// Populate IP info at creation time
@@ -146,6 +258,7 @@ func (t *Manager) handleNodeMessage(msg types.Message) *types.Node {
 	newNode.ServerName = serverInfo
 
 	t.nodes = append(t.nodes, newNode)
+	t.publish(nodeEvent(&newNode))
 
 	return &newNode
 }
@@ -170,35 +283,69 @@ func (t *Manager) pollMessages(ctx context.Context) {
 					if node.NodeIndex == 1 {
 						t.printer.PrintTitle("节点链路", util.EmojiLink)
 					}
-					nodeInfo := formatNodeInfo(node.NodeIndex, node)
+					nodeInfo := formatNodeInfo(node.NodeIndex, node, util.TerminalWidth())
 					t.printer.Print(nodeInfo)
 				}
 
 			case types.MessageTypeAPI:
+				if msg.Round < msg.Rounds {
+					t.printRound(msg)
+					continue
+				}
 				nodes := t.GetNodes()
 				if len(nodes) == 0 {
-					logger.Debug("No nodes detected")
-					t.formatError("未检测到任何节点")
-					close(t.done)
+					logger.Debug("API answered without fetching the image")
+					t.formatNoImageFetch(msg)
+					t.complete()
 					return
 				}
-				t.printer.PrintTitle("请求响应", util.EmojiGear)
+				t.printHops(nodes)
+				t.printTimeline(nodes)
+				t.printImageFetches(nodes)
+				t.printer.PrintTitle(responseTitle(msg), util.EmojiGear)
 				content := t.formatRequest(msg.Request, msg.Response)
 				t.printer.Print(content)
+				t.conclude(nodes, msg, true)
+				t.printer.PrintSummary("节点数: %d 跳数: %d 末端: %s 响应: %s",
+					len(nodes), len(Cluster(nodes, t.cfg)), nodes[len(nodes)-1].ServerName, util.Truncate(strings.Join(strings.Fields(msg.Response), " "), 80))
 
-				close(t.done)
+				t.complete()
+				return
+
+			case types.MessageTypeTimeout:
+				t.formatTimeout(msg)
+				t.complete()
 				return
 
 			case types.MessageTypeError:
 				t.formatError(msg.Content)
 				logger.Debug("Error message processed, closing done channel")
-				close(t.done)
+				t.complete()
 				return
 			}
 		}
 	}
 }
 
+// printRound prints the answer of a round before the last one, the chain is summarized after the last round
+func (t *Manager) printRound(msg types.Message) {
+	t.printer.PrintTitle(responseTitle(msg), util.EmojiGear)
+	t.printer.Print(t.formatRequest(msg.Request, msg.Response))
+	if len(msg.Answers) > 0 && !answerCorrect(msg.Answers, msg.Response) {
+		t.mu.Lock()
+		t.wrongRounds = append(t.wrongRounds, msg.Round)
+		t.mu.Unlock()
+	}
+}
+
+// responseTitle returns the title of a response, numbered in a multi-round detection
+func responseTitle(msg types.Message) string {
+	if msg.Rounds > 1 {
+		return fmt.Sprintf("请求响应 (第 %d/%d 轮)", msg.Round, msg.Rounds)
+	}
+	return "请求响应"
+}
+
 func (t *Manager) formatRequest(request, response string) string {
 	var maxRepson = 300
 	// Format request to single line and truncate
@@ -211,8 +358,8 @@ func (t *Manager) formatRequest(request, response string) string {
 	return fmt.Sprintf("请求: %s\n响应: %s\n", request, response)
 }
 
-// formatNodeInfo formats node information for display
-func formatNodeInfo(index int, node *types.Node) string {
+// formatNodeInfo formats node information for display within the given terminal width
+func formatNodeInfo(index int, node *types.Node, width int) string {
 	var location string
 	if node.RegionName != "" && node.Country != "" {
 		location = fmt.Sprintf("%s,%s", node.RegionName, node.Country)
@@ -227,6 +374,9 @@ func formatNodeInfo(index int, node *types.Node) string {
 	if location != "" || org != "" {
 		locationInfo = fmt.Sprintf(" (%s %s)", location, org)
 	}
+	if edge := edgeInfo(node); edge != "" {
+		locationInfo += " " + edge
+	}
 
 	// Calculate padding for server name using runewidth
 	serverNameWidth := 20 // Width for server name column
@@ -246,13 +396,10 @@ func formatNodeInfo(index int, node *types.Node) string {
 		serverName = serverName + " " + util.EmojiDiamond
 	}
 
-	// Calculate actual width including emoji if present
-	serverNameLen := runewidth.StringWidth(serverName)
-	// Add padding to align the text
-	if serverNameLen < serverNameWidth {
-		padding := serverNameWidth - serverNameLen
-		serverName = serverName + strings.Repeat(" ", padding)
-	}
+	// "   节点 1 : " + server name + " IP: " + ip, long User-Agents are cut to fit the terminal
+	ipWidth := runewidth.StringWidth(" IP: " + node.IP)
+	maxServerNameWidth := util.Max(width, util.MinTerminalWidth) - 12 - ipWidth
+	serverName = util.PadRight(util.Truncate(serverName, util.Max(maxServerNameWidth, serverNameWidth)), serverNameWidth)
 
 	// Format the node index with consistent width
 	indexStr := fmt.Sprintf("%d", index)
@@ -281,24 +428,77 @@ func formatNodeInfo(index int, node *types.Node) string {
 		lineColor = util.ColorReset
 	}
 
-	// Format IP address with fixed width
-	// ipWidth := 15
-	// ipStr := node.IP
-	// if len(ipStr) < ipWidth {
-	// 	ipStr = ipStr + strings.Repeat(" ", ipWidth-len(ipStr))
-	// }
+	// Location info goes last and is the first to be cut on narrow terminals
+	line := fmt.Sprintf("   节点%s : %s IP: %s%s", indexStr, serverName, node.IP, locationInfo)
+	if width >= util.MinTerminalWidth {
+		line = util.Truncate(line, width)
+	}
 
 	// Format the entire line with the same color
-	return fmt.Sprintf("%s   节点%s : %s IP: %s%s%s\n",
-		lineColor,
-		indexStr,
-		serverName,
-		node.IP,
-		locationInfo,
-		util.ColorReset)
+	return fmt.Sprintf("%s%s%s\n", lineColor, line, util.ColorReset)
+}
+
+// edgeInfo formats the Cloudflare colo and country of a node seen through a Cloudflare Tunnel
+func edgeInfo(node *types.Node) string {
+	var parts []string
+	for _, part := range []string{node.EdgeColo, node.EdgeCountry} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "[CF " + strings.Join(parts, "/") + "]"
+}
+
+// formatNoImageFetch reports an answer given without the image ever being requested.
+// The model cannot have seen the captcha, so the answer is a guess or was replayed from a cache.
+func (m *Manager) formatNoImageFetch(msg types.Message) {
+	m.mu.Lock()
+	m.failure = NoImageFetchFinding
+	m.mu.Unlock()
+
+	m.printer.PrintTitle(responseTitle(msg), util.EmojiGear)
+	m.printer.Print(m.formatRequest(msg.Request, msg.Response))
+	m.printer.PrintWarning(NoImageFetchFinding)
+	m.printer.Printf("回调服务器未收到任何图片请求，模型并未看到验证码图片。\n" +
+		"中转可能以其他模型冒充或直接返回缓存的回答，即使回答中的数字正确也不可信。\n")
+	m.conclude(nil, msg, true)
+	m.printer.PrintSummary("节点数: 0 结论: %s 响应: %s",
+		NoImageFetchFinding, util.Truncate(strings.Join(strings.Fields(msg.Response), " "), 80))
+}
+
+// formatTimeout prints the verdict of a trace that timed out, the nodes seen so far are kept as evidence
+func (m *Manager) formatTimeout(msg types.Message) {
+	nodes := m.GetNodes()
+	m.mu.Lock()
+	m.failure = msg.Content
+	m.timedOut = true
+	m.mu.Unlock()
+
+	if len(nodes) > 0 {
+		m.printHops(nodes)
+		m.printTimeline(nodes)
+		m.printImageFetches(nodes)
+	}
+	m.printer.PrintTitle(responseTitle(msg), util.EmojiGear)
+	if msg.Request != "" {
+		m.printer.Print(m.formatRequest(msg.Request, NoResponseVerdict))
+	}
+	m.printer.PrintWarning(msg.Content)
+	m.conclude(nodes, msg, false)
+	if len(nodes) == 0 {
+		m.printer.PrintSummary("节点数: 0 结论: %s", NoResponseVerdict)
+		return
+	}
+	m.printer.PrintSummary("节点数: %d 跳数: %d 末端: %s 结论: %s", len(nodes), len(Cluster(nodes, m.cfg)), nodes[len(nodes)-1].ServerName, NoResponseVerdict)
 }
 
 func (m *Manager) formatError(content string) {
+	m.mu.Lock()
+	m.failure = content
+	m.mu.Unlock()
 	m.printer.PrintTitle("请求响应", util.EmojiGear)
 	m.printer.PrintError(content)
 }
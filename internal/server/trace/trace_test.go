@@ -0,0 +1,159 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/ipinfo"
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSender struct {
+	msgs chan types.Message
+}
+
+func (f *fakeSender) MessageChan() <-chan types.Message { return f.msgs }
+func (f *fakeSender) Done() <-chan struct{}             { return nil }
+
+type fakeIPProvider struct{}
+
+func (fakeIPProvider) GetIPInfo(string) (*ipinfo.Info, error) {
+	return &ipinfo.Info{Country: "US", RegionName: "Virginia", Org: "Example"}, nil
+}
+
+func TestTraceTimeoutKeepsNodes(t *testing.T) {
+	sender := &fakeSender{msgs: make(chan types.Message, 4)}
+	var out bytes.Buffer
+	tracer := New(sender, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}), WithOutputWriter(&out))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+
+	sender.msgs <- types.Message{
+		Type:    types.MessageTypeNode,
+		Headers: &types.RequestHeaders{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1", Time: time.Now()},
+	}
+	sender.msgs <- types.Message{
+		Type:    types.MessageTypeTimeout,
+		Request: "what's the number?",
+		Content: "API请求超时, 超过 30s 未收到模型响应",
+	}
+
+	select {
+	case <-tracer.done:
+	case <-time.After(time.Second):
+		t.Fatal("trace did not finish after the timeout message")
+	}
+
+	assert.True(t, tracer.TimedOut())
+	assert.Len(t, tracer.GetNodes(), 1)
+	assert.Contains(t, tracer.Failure(), "未收到模型响应")
+	assert.Contains(t, out.String(), "203.0.113.7")
+	assert.Contains(t, out.String(), "响应: "+NoResponseVerdict)
+}
+
+func TestTraceReportsMissingImageFetch(t *testing.T) {
+	sender := &fakeSender{msgs: make(chan types.Message, 4)}
+	var out bytes.Buffer
+	tracer := New(sender, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}), WithOutputWriter(&out))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+
+	sender.msgs <- types.Message{Type: types.MessageTypeAPI, Request: "what's the number?", Response: "The number is 1234."}
+
+	select {
+	case <-tracer.done:
+	case <-time.After(time.Second):
+		t.Fatal("trace did not finish after the API response")
+	}
+
+	assert.False(t, tracer.TimedOut())
+	assert.Empty(t, tracer.GetNodes())
+	assert.Equal(t, NoImageFetchFinding, tracer.Failure())
+	assert.Contains(t, out.String(), "The number is 1234.")
+	assert.Contains(t, out.String(), NoImageFetchFinding)
+
+	// Even the none policy does not pass an answer nobody fetched the image for
+	result := tracer.Result(Policy{}, "https://relay.example.com", "gpt-4o")
+	assert.False(t, result.Pass)
+	assert.True(t, result.Inconclusive)
+	assert.Empty(t, result.Failed)
+	assert.Equal(t, NoImageFetchFinding, result.Error)
+}
+
+func TestTraceWaitsForLastRound(t *testing.T) {
+	sender := &fakeSender{msgs: make(chan types.Message, 4)}
+	var out bytes.Buffer
+	tracer := New(sender, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}), WithOutputWriter(&out))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+
+	sender.msgs <- types.Message{
+		Type:    types.MessageTypeNode,
+		Headers: &types.RequestHeaders{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1", Time: time.Now()},
+	}
+	sender.msgs <- types.Message{Type: types.MessageTypeAPI, Request: "what's the number?", Response: "1234", Round: 1, Rounds: 2}
+
+	select {
+	case <-tracer.done:
+		t.Fatal("trace finished before the last round")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sender.msgs <- types.Message{Type: types.MessageTypeAPI, Request: "What text does the QR code contain?", Response: "K7XQ", Round: 2, Rounds: 2}
+	select {
+	case <-tracer.done:
+	case <-time.After(time.Second):
+		t.Fatal("trace did not finish after the last round")
+	}
+
+	assert.Empty(t, tracer.Failure())
+	assert.Contains(t, out.String(), "第 1/2 轮")
+	assert.Contains(t, out.String(), "第 2/2 轮")
+	assert.Contains(t, out.String(), "K7XQ")
+}
+
+func TestNodeSignature(t *testing.T) {
+	sig, err := ParseNodeSignature("")
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureIPUA, sig)
+	_, err = ParseNodeSignature("cidr")
+	assert.Error(t, err)
+
+	requests := []types.RequestHeaders{
+		{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1"},
+		{IP: "203.0.113.7", UserAgent: "python-requests/2.31"},
+		{IP: "203.0.113.7", UserAgent: "python-requests/2.31", ForwardedFor: "198.51.100.1"},
+	}
+	for sig, want := range map[NodeSignature]int{SignatureIP: 1, SignatureIPUA: 2, SignatureIPUAXFF: 3} {
+		tracer := New(&fakeSender{}, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}), WithNodeSignature(sig))
+		for i := range requests {
+			tracer.handleNodeMessage(types.Message{Type: types.MessageTypeNode, Headers: &requests[i]})
+		}
+		assert.Len(t, tracer.GetNodes(), want, string(sig))
+	}
+}
+
+func TestNodeEdgeInfo(t *testing.T) {
+	tracer := New(&fakeSender{}, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}))
+	node := tracer.handleNodeMessage(types.Message{Type: types.MessageTypeNode, Headers: &types.RequestHeaders{
+		IP: "203.0.113.7", UserAgent: "Go-http-client/1.1", EdgeCountry: "HK", EdgeColo: "HKG",
+	}})
+
+	assert.Contains(t, formatNodeInfo(1, node, 200), "[CF HKG/HK]")
+	event := nodeEvent(node).Node
+	assert.Equal(t, "HK", event.CFCountry)
+	assert.Equal(t, "HKG", event.CFColo)
+
+	node.EdgeCountry, node.EdgeColo = "", ""
+	assert.NotContains(t, formatNodeInfo(1, node, 200), "[CF")
+}
@@ -0,0 +1,224 @@
+package trace
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Confidence is how likely the last node of the chain is the official endpoint
+type Confidence string
+
+// Confidence levels, high when the last node is in the official ranges
+const (
+	ConfidenceHigh   Confidence = "high"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceLow    Confidence = "low"
+	ConfidenceNone   Confidence = "none"
+)
+
+var confidenceLabels = map[Confidence]string{
+	ConfidenceHigh:   "高 (末端节点位于 OpenAI 官方网段)",
+	ConfidenceMedium: "中 (末端节点自称 OpenAI/Azure, 但 IP 不在官方网段)",
+	ConfidenceLow:    "低 (末端节点不是官方服务)",
+	ConfidenceNone:   "无 (没有节点获取图片)",
+}
+
+// Label returns the description of the level shown in the verdict block
+func (c Confidence) Label() string {
+	return confidenceLabels[c]
+}
+
+// MaxTrustedHops is the chain length above which the relay chain is flagged as unusually long
+const MaxTrustedHops = 3
+
+// Red flags of the verdict
+const (
+	FlagNoImageFetch = "没有节点获取图片, 模型并未看到验证码"
+	FlagWrongAnswer  = "验证码回答错误"
+	FlagNoResponse   = "未收到模型响应"
+	FlagNotOfficial  = "末端节点不在官方网段"
+	FlagLongChain    = "链路超过 %d 跳"
+	FlagStream       = "流式响应异常: %s"
+)
+
+// Verdict is the standardized conclusion printed at the end of every trace
+type Verdict struct {
+	Hops       []Hop      `json:"hops"`
+	Nodes      int        `json:"nodes"`
+	Confidence Confidence `json:"official_confidence"` // 官方端点可信度
+	Fetched    bool       `json:"image_fetched"`       // 是否有节点获取了图片
+	Answered   bool       `json:"answered"`            // 是否收到模型响应
+	Checked    bool       `json:"captcha_checked"`     // 是否核对了验证码答案, 未收到响应或答案未知时为 false
+	Correct    bool       `json:"captcha_correct"`     // 验证码回答是否正确
+	Stream     string     `json:"stream,omitempty"`    // 流式透传结论
+	Images     string     `json:"images,omitempty"`    // 多图检测时的图片获取结论
+	RedFlags   []string   `json:"red_flags"`           // 可疑之处, 为空表示未发现问题
+}
+
+// NewVerdict concludes a trace from the nodes seen and the final API message, answered is false on timeout
+func NewVerdict(nodes []types.Node, msg types.Message, answered bool, cfg *config.Config) Verdict {
+	v := Verdict{
+		Hops:     Cluster(nodes, cfg),
+		RedFlags: []string{},
+		Nodes:    len(nodes),
+		Fetched:  len(nodes) > 0,
+		Answered: answered,
+		Stream:   msg.Stream,
+	}
+	v.Confidence = officialConfidence(nodes, cfg)
+	if answered && len(msg.Answers) > 0 {
+		v.Checked = true
+		v.Correct = answerCorrect(msg.Answers, msg.Response)
+	}
+	if cfg != nil && cfg.Images > 1 && v.Fetched {
+		v.Images = ImageFetchVerdict(nodes, cfg.Images, cfg)
+	}
+
+	if !v.Fetched {
+		v.RedFlags = append(v.RedFlags, FlagNoImageFetch)
+	}
+	switch {
+	case !answered:
+		v.RedFlags = append(v.RedFlags, FlagNoResponse)
+	case v.Checked && !v.Correct:
+		v.RedFlags = append(v.RedFlags, FlagWrongAnswer)
+	}
+	if v.Fetched && v.Confidence != ConfidenceHigh {
+		v.RedFlags = append(v.RedFlags, FlagNotOfficial)
+	}
+	if len(v.Hops) > MaxTrustedHops {
+		v.RedFlags = append(v.RedFlags, fmt.Sprintf(FlagLongChain, MaxTrustedHops))
+	}
+	if v.Stream == util.StreamBuffered || v.Stream == util.StreamConverted {
+		v.RedFlags = append(v.RedFlags, fmt.Sprintf(FlagStream, v.Stream))
+	}
+	if v.Images == ImagesDropped {
+		v.RedFlags = append(v.RedFlags, ImagesDropped)
+	}
+	return v
+}
+
+// officialConfidence rates how likely the last node of the chain is the official endpoint
+func officialConfidence(nodes []types.Node, cfg *config.Config) Confidence {
+	if len(nodes) == 0 {
+		return ConfidenceNone
+	}
+	last := nodes[len(nodes)-1]
+	switch {
+	case cfg != nil && cfg.IPNetwork(last.IP) == config.NetworkOpenAI:
+		return ConfidenceHigh
+	case strings.Contains(last.ServerName, "OpenAI") || strings.Contains(last.ServerName, "Azure"):
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// answerCorrect reports whether the response contains one of the accepted answers of every image,
+// ignoring case, spaces and punctuation the model may add around the text
+func answerCorrect(answers [][]string, response string) bool {
+	if len(answers) == 0 {
+		return false
+	}
+	got := normalizeAnswer(response)
+	for _, accepted := range answers {
+		if !containsAny(got, accepted) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny reports whether the normalized response contains one of the accepted answers
+func containsAny(got string, accepted []string) bool {
+	for _, a := range accepted {
+		if want := normalizeAnswer(a); want != "" && strings.Contains(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAnswer keeps the lower-cased letters and digits of s
+func normalizeAnswer(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// yesNo formats a boolean verdict item
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
+
+// format prints the verdict block, one item per line followed by the red flags
+func (v Verdict) format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "链路长度: %d 跳 (%d 个节点)\n", len(v.Hops), v.Nodes)
+	if len(v.Hops) > 0 {
+		names := make([]string, len(v.Hops))
+		for i, h := range v.Hops {
+			names[i] = h.Name()
+		}
+		fmt.Fprintf(&b, "跳转列表: %s\n", strings.Join(names, " → "))
+	}
+	fmt.Fprintf(&b, "官方端点可信度: %s\n", v.Confidence.Label())
+	fmt.Fprintf(&b, "图片已获取: %s\n", yesNo(v.Fetched))
+	if v.Images != "" {
+		fmt.Fprintf(&b, "图片获取: %s\n", v.Images)
+	}
+	switch {
+	case v.Checked:
+		fmt.Fprintf(&b, "验证码正确: %s\n", yesNo(v.Correct))
+	case !v.Answered:
+		b.WriteString("验证码正确: 未知 (未收到响应)\n")
+	default:
+		b.WriteString("验证码正确: 未知\n")
+	}
+	if v.Stream != "" {
+		fmt.Fprintf(&b, "流式透传: %s\n", v.Stream)
+	}
+	if len(v.RedFlags) == 0 {
+		b.WriteString("可疑之处: 无\n")
+		return b.String()
+	}
+	b.WriteString("可疑之处:\n")
+	for _, f := range v.RedFlags {
+		fmt.Fprintf(&b, "   - %s\n", f)
+	}
+	return b.String()
+}
+
+// conclude records and prints the verdict block that closes every trace,
+// a wrong answer in an earlier round of a multi-round detection fails the captcha too
+func (t *Manager) conclude(nodes []types.Node, msg types.Message, answered bool) {
+	v := NewVerdict(nodes, msg, answered, t.cfg)
+	t.mu.Lock()
+	if len(t.wrongRounds) > 0 && v.Checked && v.Correct {
+		v.Correct = false
+		v.RedFlags = append(v.RedFlags, FlagWrongAnswer)
+	}
+	t.verdict = &v
+	t.mu.Unlock()
+
+	t.printer.PrintTitle("检测结论", util.EmojiDone)
+	t.printer.Print(v.format())
+}
+
+// Verdict returns the conclusion of a finished trace, nil if it ended with an error
+func (t *Manager) Verdict() *Verdict {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.verdict
+}
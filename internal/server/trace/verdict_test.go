@@ -0,0 +1,85 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/types"
+	"github.com/go-coders/check-gpt/pkg/config"
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerCorrect(t *testing.T) {
+	assert.True(t, answerCorrect([][]string{{"K7XQ"}}, "The text is: k7xq."))
+	assert.True(t, answerCorrect([][]string{{"1234"}, {"AB12"}}, "1: 1234\n2: ab 12"))
+	assert.False(t, answerCorrect([][]string{{"1234"}, {"AB12"}}, "1: 1234\n2: unreadable"))
+	assert.False(t, answerCorrect(nil, "1234"))
+
+	// A Chinese answer to the shapes question matches one of its alternatives
+	shapes := [][]string{{"red, blue, black", "红色, 蓝色, 黑色", "红, 蓝, 黑"}}
+	assert.True(t, answerCorrect(shapes, "红色、蓝色、黑色"))
+	assert.True(t, answerCorrect(shapes, "红，蓝，黑"))
+	assert.False(t, answerCorrect(shapes, "红色、黑色、蓝色"))
+}
+
+func TestNewVerdict(t *testing.T) {
+	cfg := &config.Config{OPENAICIDR: []string{"23.102.140.112/28"}}
+	relay := types.Node{IP: "203.0.113.7", ServerName: "Go服务"}
+	official := types.Node{IP: "23.102.140.115", ServerName: "OpenAI服务"}
+
+	v := NewVerdict([]types.Node{relay, official}, types.Message{
+		Response: "1234", Answers: [][]string{{"1234"}}, Stream: util.StreamPassedThrough,
+	}, true, cfg)
+	assert.Len(t, v.Hops, 2)
+	assert.Equal(t, ConfidenceHigh, v.Confidence)
+	assert.True(t, v.Fetched)
+	assert.True(t, v.Checked && v.Correct)
+	assert.Empty(t, v.RedFlags)
+
+	v = NewVerdict([]types.Node{relay}, types.Message{
+		Response: "5678", Answers: [][]string{{"1234"}}, Stream: util.StreamBuffered,
+	}, true, cfg)
+	assert.Equal(t, ConfidenceLow, v.Confidence)
+	assert.Contains(t, v.RedFlags, FlagWrongAnswer)
+	assert.Contains(t, v.RedFlags, FlagNotOfficial)
+	assert.Contains(t, v.RedFlags, "流式响应异常: "+util.StreamBuffered)
+
+	v = NewVerdict(nil, types.Message{}, false, cfg)
+	assert.Equal(t, ConfidenceNone, v.Confidence)
+	assert.False(t, v.Checked)
+	assert.Equal(t, []string{FlagNoImageFetch, FlagNoResponse}, v.RedFlags)
+	assert.Contains(t, v.format(), "验证码正确: 未知 (未收到响应)")
+}
+
+func TestTraceConcludesWithVerdict(t *testing.T) {
+	sender := &fakeSender{msgs: make(chan types.Message, 4)}
+	var out bytes.Buffer
+	tracer := New(sender, WithConfig(&config.Config{}), WithIPProvider(fakeIPProvider{}), WithOutputWriter(&out))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+
+	sender.msgs <- types.Message{
+		Type:    types.MessageTypeNode,
+		Headers: &types.RequestHeaders{IP: "203.0.113.7", UserAgent: "Go-http-client/1.1", Time: time.Now()},
+	}
+	sender.msgs <- types.Message{Type: types.MessageTypeAPI, Request: "what's the number?", Response: "The number is 1234.", Answers: [][]string{{"1234"}}}
+
+	select {
+	case <-tracer.done:
+	case <-time.After(time.Second):
+		t.Fatal("trace did not finish after the API response")
+	}
+
+	v := tracer.Verdict()
+	if assert.NotNil(t, v) {
+		assert.True(t, v.Correct)
+		assert.Equal(t, []string{FlagNotOfficial}, v.RedFlags)
+	}
+	assert.Contains(t, out.String(), "检测结论")
+	assert.Contains(t, out.String(), "验证码正确: 是")
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/runlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		driver string
+		source string
+	}{
+		{"sqlite:/var/lib/check-gpt/history.db", "sqlite", "/var/lib/check-gpt/history.db"},
+		{"sqlite://history.db", "sqlite", "history.db"},
+		{"history.sqlite", "sqlite", "history.sqlite"},
+		{"postgres://check:secret@db:5432/check?sslmode=disable", "postgres", "postgres://check:secret@db:5432/check?sslmode=disable"},
+		{"postgresql://db/check", "postgres", "postgresql://db/check"},
+	}
+	for _, tt := range tests {
+		d, source, err := parseDSN(tt.dsn)
+		assert.NoError(t, err, tt.dsn)
+		assert.Equal(t, tt.driver, d.driver, tt.dsn)
+		assert.Equal(t, tt.source, source, tt.dsn)
+	}
+
+	_, _, err := parseDSN("mysql://db/check")
+	assert.Error(t, err)
+	assert.Equal(t, "$3", postgres.placeholder(3))
+}
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.db")
+	s, err := Open("sqlite:" + path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.Append(ctx, runlog.Entry{
+		Time: start, Mode: "apitest", Endpoint: "https://a.example.com", Keys: 1, Models: 2,
+		Success: 1, Failed: 1, Verdict: runlog.VerdictPartial,
+		Details: []runlog.KeyVerdict{{Key: "sk-a...1234", KeyID: "hash", Verdict: runlog.VerdictPartial, Failed: []string{"gpt-4o"}}},
+	}))
+	assert.NoError(t, s.Append(ctx, runlog.Entry{
+		Time: start.Add(time.Hour), Mode: "trace", Endpoint: "https://b.example.com", Keys: 1, Models: 1,
+		Success: 1, Verdict: runlog.VerdictOK, Message: "2 nodes, 1 hops",
+	}))
+	assert.NoError(t, s.Close())
+
+	// The history survives reopening
+	s, err = Open("sqlite:" + path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer s.Close()
+
+	entries, err := s.List(ctx, Query{})
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 2) {
+		return
+	}
+	assert.Equal(t, "trace", entries[0].Mode)
+	assert.Equal(t, "2 nodes, 1 hops", entries[0].Message)
+	assert.Empty(t, entries[0].Details)
+	assert.True(t, start.Equal(entries[1].Time))
+	assert.Equal(t, []string{"gpt-4o"}, entries[1].Details[0].Failed)
+
+	entries, err = s.List(ctx, Query{Mode: "apitest"})
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	assert.Equal(t, "https://a.example.com", entries[0].Endpoint)
+
+	entries, err = s.List(ctx, Query{Since: start.Add(time.Minute)})
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	assert.Equal(t, "trace", entries[0].Mode)
+
+	entries, err = s.List(ctx, Query{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
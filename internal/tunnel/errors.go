@@ -0,0 +1,83 @@
+package tunnel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind identifies why a tunnel could not be created
+type ErrorKind int
+
+const (
+	KindUnknown     ErrorKind = iota
+	KindTimeout               // 未在限定时间内获得公网地址
+	KindRefused               // 隧道服务器拒绝连接
+	KindHostKey               // 主机密钥校验失败
+	KindNetwork               // 网络不可达或连接超时
+	KindDNS                   // 无法解析隧道服务器域名
+	KindRateLimited           // 隧道服务限制了连接频率或数量
+	KindAuth                  // 认证失败
+	KindExited                // 隧道进程退出
+)
+
+// errorTexts are the descriptions and remediation hints of the error kinds
+var errorTexts = map[ErrorKind][2]string{
+	KindUnknown:     {"隧道创建失败", "使用 -debug 查看详细输出"},
+	KindTimeout:     {"隧道创建超时", "检查网络是否允许出站 SSH (22 端口)，或改用 -relay / -frp-server"},
+	KindRefused:     {"隧道服务器拒绝连接", "服务可能暂时不可用，稍后重试，或改用 -relay / -frp-server"},
+	KindHostKey:     {"隧道服务器主机密钥校验失败", "从 ~/.ssh/known_hosts 中删除 localhost.run 的旧记录后重试 (ssh-keygen -R localhost.run)"},
+	KindNetwork:     {"无法连接隧道服务器", "网络可能屏蔽了出站 SSH (22 端口)，可改用 -relay 通过 WebSocket 连接自建中继"},
+	KindDNS:         {"无法解析隧道服务器域名", "检查 DNS 设置或网络连接"},
+	KindRateLimited: {"隧道服务限制了连接频率", "等待几分钟后重试，同一次运行中的检测会复用隧道，也可使用 -relay / -frp-server 自建隧道"},
+	KindAuth:        {"隧道认证失败", "检查隧道服务的令牌或 SSH 密钥"},
+	KindExited:      {"隧道进程意外退出", "使用 -debug 查看详细输出"},
+}
+
+// Error is a tunnel failure with the output that identified it
+type Error struct {
+	Kind   ErrorKind
+	Detail string // 隧道程序的原始输出
+}
+
+func (e *Error) Error() string {
+	text := errorTexts[e.Kind]
+	if e.Detail == "" {
+		return fmt.Sprintf("%s。%s", text[0], text[1])
+	}
+	return fmt.Sprintf("%s (%s)。%s", text[0], e.Detail, text[1])
+}
+
+// Hint returns the remediation hint of the error
+func (e *Error) Hint() string {
+	return errorTexts[e.Kind][1]
+}
+
+// sshPatterns map lowercase fragments of ssh and localhost.run output to the error kinds, checked in order
+var sshPatterns = []struct {
+	fragment string
+	kind     ErrorKind
+}{
+	{"host key verification failed", KindHostKey},
+	{"remote host identification has changed", KindHostKey},
+	{"could not resolve hostname", KindDNS},
+	{"connection refused", KindRefused},
+	{"network is unreachable", KindNetwork},
+	{"no route to host", KindNetwork},
+	{"connection timed out", KindNetwork},
+	{"operation timed out", KindNetwork},
+	{"permission denied", KindAuth},
+	{"rate limit", KindRateLimited},
+	{"too many", KindRateLimited},
+	{"limit reached", KindRateLimited},
+}
+
+// classifySSH returns the error reported by a line of ssh output, nil for other lines
+func classifySSH(line string) *Error {
+	lower := strings.ToLower(line)
+	for _, p := range sshPatterns {
+		if strings.Contains(lower, p.fragment) {
+			return &Error{Kind: p.kind, Detail: strings.TrimSpace(line)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifySSH(t *testing.T) {
+	tests := []struct {
+		line string
+		want ErrorKind
+	}{
+		{"ssh: connect to host localhost.run port 22: Connection refused", KindRefused},
+		{"Host key verification failed.", KindHostKey},
+		{"@    WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!     @", KindHostKey},
+		{"ssh: connect to host localhost.run port 22: Network is unreachable", KindNetwork},
+		{"ssh: connect to host localhost.run port 22: Connection timed out", KindNetwork},
+		{"ssh: Could not resolve hostname localhost.run: Temporary failure in name resolution", KindDNS},
+		{"You have reached the rate limit for free tunnels, please try again later", KindRateLimited},
+		{"nokey@localhost.run: Permission denied (publickey).", KindAuth},
+	}
+	for _, tt := range tests {
+		e := classifySSH(tt.line)
+		if assert.NotNil(t, e, tt.line) {
+			assert.Equal(t, tt.want, e.Kind, tt.line)
+			assert.Contains(t, e.Error(), tt.line)
+			assert.NotEmpty(t, e.Hint())
+		}
+	}
+	assert.Nil(t, classifySSH("===============================================================================\n"))
+	assert.Nil(t, classifySSH("Welcome to localhost.run!"))
+
+	e := &Error{Kind: KindTimeout}
+	assert.Equal(t, "隧道创建超时。"+e.Hint(), e.Error())
+}
@@ -0,0 +1,186 @@
+package tunnel
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// DefaultFRPPort is the bindPort of frps when the server address has no port
+const DefaultFRPPort = 7000
+
+// FRP is a tunnel through the user's own frps, run by the frpc client as an http proxy on a custom domain
+type FRP struct {
+	cmd        *exec.Cmd
+	configPath string
+	url        string
+	err        error
+	ready      chan struct{}
+}
+
+// NewFRP starts frpc proxying the local port to http://domain through the frps at server (host or host:port).
+// domain may carry the vhostHTTPPort of frps, e.g. img.example.com:8080.
+func NewFRP(server, token, domain string, port int) (*FRP, error) {
+	if domain == "" {
+		return nil, fmt.Errorf("使用 frp 隧道需要指定自定义域名 (-frp-domain)")
+	}
+	config, err := frpcConfig(server, token, domain, port)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp("", "check-gpt-frpc-*.toml")
+	if err != nil {
+		return nil, fmt.Errorf("创建 frpc 配置失败: %v", err)
+	}
+	f.WriteString(config)
+	f.Close()
+
+	cmd := exec.Command("frpc", "-c", f.Name())
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("创建输出管道失败: %v", err)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("启动 frpc 失败: %v", err)
+	}
+
+	t := &FRP{
+		cmd:        cmd,
+		configPath: f.Name(),
+		ready:      make(chan struct{}),
+	}
+	go t.waitForProxy(stdout, "http://"+domain)
+	return t, nil
+}
+
+// frpcConfig returns the TOML configuration of frpc with a single http proxy for the local port
+func frpcConfig(server, token, domain string, port int) (string, error) {
+	host, serverPort := server, DefaultFRPPort
+	if h, p, err := net.SplitHostPort(server); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return "", fmt.Errorf("frp 服务器地址无效: %s", server)
+		}
+		host, serverPort = h, n
+	}
+	if host == "" {
+		return "", fmt.Errorf("frp 服务器地址无效: %s", server)
+	}
+	// customDomains takes the host only, the port is the vhostHTTPPort of frps
+	domainHost := domain
+	if h, _, err := net.SplitHostPort(domain); err == nil {
+		domainHost = h
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "serverAddr = %s\n", strconv.Quote(host))
+	fmt.Fprintf(&b, "serverPort = %d\n", serverPort)
+	if token != "" {
+		fmt.Fprintf(&b, "auth.token = %s\n", strconv.Quote(token))
+	}
+	b.WriteString("loginFailExit = true\n\n")
+	b.WriteString("[[proxies]]\n")
+	// A unique name lets several detections share one frps
+	fmt.Fprintf(&b, "name = %s\n", strconv.Quote("check-gpt-"+util.GenerateRandomString(8)))
+	b.WriteString("type = \"http\"\n")
+	b.WriteString("localIP = \"127.0.0.1\"\n")
+	fmt.Fprintf(&b, "localPort = %d\n", port)
+	fmt.Fprintf(&b, "customDomains = [%s]\n", strconv.Quote(domainHost))
+	return b.String(), nil
+}
+
+// waitForProxy waits until frpc reports the proxy started, the tunnel then serves at url
+func (t *FRP) waitForProxy(stdout io.Reader, url string) {
+	result := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if started, err := frpcStatus(scanner.Text()); started || err != nil {
+				select {
+				case result <- err:
+				default:
+				}
+			}
+		}
+		select {
+		case result <- errors.New("frpc 已退出"):
+		default:
+		}
+	}()
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.url = url
+		} else {
+			t.err = err
+			t.Close()
+		}
+	case <-time.After(15 * time.Second):
+		t.err = errors.New("frpc 启动超时")
+		t.Close()
+	}
+	close(t.ready)
+}
+
+// frpcStatus classifies a log line of frpc: started when the proxy is up, the error when it failed
+func frpcStatus(line string) (bool, error) {
+	switch {
+	case strings.Contains(line, "start proxy success"):
+		return true, nil
+	case strings.Contains(line, "login to the server failed"), strings.Contains(line, "login to server failed"):
+		return false, errors.New("登录 frp 服务器失败, 请检查服务器地址和 -frp-token")
+	case strings.Contains(line, "start error"):
+		// e.g. [check-gpt-xxx] start error: router config conflict
+		_, reason, _ := strings.Cut(line, "start error:")
+		return false, fmt.Errorf("frp 代理启动失败: %s", strings.TrimSpace(reason))
+	}
+	return false, nil
+}
+
+// Ready returns a channel that's closed when the proxy started or failed
+func (t *FRP) Ready() <-chan struct{} {
+	return t.ready
+}
+
+// Close stops frpc and removes its configuration, which holds the token
+func (t *FRP) Close() error {
+	os.Remove(t.configPath)
+	if t.cmd != nil && t.cmd.Process != nil {
+		return t.cmd.Process.Kill()
+	}
+	return nil
+}
+
+// URL returns the public URL of the proxy, empty when it failed
+func (t *FRP) URL() string {
+	return t.url
+}
+
+// Err returns why the proxy failed, nil until it is ready and when it works
+func (t *FRP) Err() error {
+	select {
+	case <-t.ready:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// FRPAvailable checks if frpc is installed
+func FRPAvailable() bool {
+	_, err := exec.LookPath("frpc")
+	return err == nil
+}
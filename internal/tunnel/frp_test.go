@@ -0,0 +1,42 @@
+package tunnel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFRPCConfig(t *testing.T) {
+	config, err := frpcConfig("frp.example.com", "s3cret", "img.example.com:8080", 8081)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `serverAddr = "frp.example.com"`)
+	assert.Contains(t, config, "serverPort = 7000")
+	assert.Contains(t, config, `auth.token = "s3cret"`)
+	assert.Contains(t, config, "localPort = 8081")
+	assert.Contains(t, config, `customDomains = ["img.example.com"]`)
+	assert.Contains(t, config, `name = "check-gpt-`)
+
+	config, err = frpcConfig("10.0.0.1:7100", "", "img.example.com", 8081)
+	assert.NoError(t, err)
+	assert.Contains(t, config, "serverPort = 7100")
+	assert.False(t, strings.Contains(config, "auth.token"))
+
+	_, err = frpcConfig("frp.example.com:x", "", "img.example.com", 8081)
+	assert.Error(t, err)
+	_, err = NewFRP("frp.example.com", "", "", 8081)
+	assert.Error(t, err)
+}
+
+func TestFRPCStatus(t *testing.T) {
+	started, err := frpcStatus("2024-01-01 10:00:00.000 [I] [proxy_manager.go:177] [check-gpt-ab] start proxy success")
+	assert.True(t, started)
+	assert.NoError(t, err)
+	_, err = frpcStatus("[W] [control.go:172] [check-gpt-ab] start error: router config conflict")
+	assert.EqualError(t, err, "frp 代理启动失败: router config conflict")
+	_, err = frpcStatus("[E] login to the server failed: i/o timeout")
+	assert.ErrorContains(t, err, "登录 frp 服务器失败")
+	started, err = frpcStatus("[I] try to connect to server...")
+	assert.False(t, started)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,220 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"golang.org/x/net/websocket"
+)
+
+// Frame types of the relay protocol
+const (
+	frameHello    = "hello"    // 中继 -> 客户端: 分配的公网地址
+	frameRequest  = "request"  // 中继 -> 客户端: 公网收到的请求
+	frameResponse = "response" // 客户端 -> 中继: 本地服务器的响应
+)
+
+// maxRelayBody caps the bodies carried over the relay, probe images are a few KB
+const maxRelayBody = 4 << 20
+
+// relayFrame is one JSON message on the relay WebSocket
+type relayFrame struct {
+	Type       string      `json:"type"`
+	ID         uint64      `json:"id,omitempty"`
+	URL        string      `json:"url,omitempty"`         // hello: 公网地址
+	Method     string      `json:"method,omitempty"`      // request
+	Path       string      `json:"path,omitempty"`        // request: 含查询参数
+	Header     http.Header `json:"header,omitempty"`      // request, response
+	RemoteAddr string      `json:"remote_addr,omitempty"` // request: 中继看到的客户端 IP
+	Status     int         `json:"status,omitempty"`      // response
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// Relay is a tunnel that connects outbound to a relay run with -relay-serve over WebSocket.
+// The relay forwards the requests to its public URL over the socket, so no SSH client is needed.
+type Relay struct {
+	conn   *websocket.Conn
+	sendMu sync.Mutex
+	port   int
+	url    string
+	err    error
+	ready  chan struct{}
+	once   sync.Once
+	client *http.Client
+}
+
+// NewRelay connects to the relay at relayURL (ws:// or wss://) and serves its requests from the local port asynchronously
+func NewRelay(relayURL, token string, port int) (*Relay, error) {
+	u, err := url.Parse(relayURL)
+	if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		return nil, fmt.Errorf("中继地址无效: %s (应为 ws:// 或 wss:// 开头)", relayURL)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = RelayConnectPath
+	}
+	origin := "http://" + u.Host
+	if u.Scheme == "wss" {
+		origin = "https://" + u.Host
+	}
+	wsConfig, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return nil, fmt.Errorf("中继地址无效: %v", err)
+	}
+	// A header stays out of the access logs of the relay and its reverse proxy, a query string does not
+	if token != "" {
+		wsConfig.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	r := &Relay{
+		port:   port,
+		ready:  make(chan struct{}),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	go r.connect(wsConfig)
+	return r, nil
+}
+
+// connect dials the relay, waits for the public URL and serves requests until the socket closes
+func (r *Relay) connect(wsConfig *websocket.Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	conn, err := wsConfig.DialContext(ctx)
+	if err != nil {
+		r.fail(fmt.Sprintf("连接中继失败: %v", err))
+		return
+	}
+	r.sendMu.Lock()
+	r.conn = conn
+	r.sendMu.Unlock()
+
+	conn.SetReadDeadline(time.Now().Add(15 * time.Second))
+	var hello relayFrame
+	if err := websocket.JSON.Receive(conn, &hello); err != nil || hello.Type != frameHello || hello.URL == "" {
+		r.fail("中继未返回公网地址, 请检查 -relay-token")
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	r.once.Do(func() {
+		r.url = hello.URL
+		close(r.ready)
+	})
+
+	for {
+		var frame relayFrame
+		if err := websocket.JSON.Receive(conn, &frame); err != nil {
+			logger.Debug("Relay connection closed: %v", err)
+			return
+		}
+		if frame.Type == frameRequest {
+			go r.serve(frame)
+		}
+	}
+}
+
+// fail marks the tunnel failed with err unless it is ready already
+func (r *Relay) fail(err string) {
+	r.once.Do(func() {
+		r.err = errors.New(err)
+		close(r.ready)
+	})
+}
+
+// serve replays a forwarded request against the local server and sends back its response
+func (r *Relay) serve(frame relayFrame) {
+	resp := relayFrame{Type: frameResponse, ID: frame.ID, Status: http.StatusBadGateway}
+	defer func() {
+		r.sendMu.Lock()
+		defer r.sendMu.Unlock()
+		if err := websocket.JSON.Send(r.conn, resp); err != nil {
+			logger.Debug("Failed to send relay response: %v", err)
+		}
+	}()
+
+	req, err := http.NewRequest(frame.Method, fmt.Sprintf("http://127.0.0.1:%d%s", r.port, frame.Path), bytes.NewReader(frame.Body))
+	if err != nil {
+		return
+	}
+	for name, values := range frame.Header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	// The local server trusts X-Forwarded-For from loopback, append the address the relay saw as a proxy would
+	if frame.RemoteAddr != "" {
+		if prior := req.Header.Get("X-Forwarded-For"); prior != "" {
+			req.Header.Set("X-Forwarded-For", prior+", "+frame.RemoteAddr)
+		} else {
+			req.Header.Set("X-Forwarded-For", frame.RemoteAddr)
+		}
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		logger.Debug("Relay request to local server failed: %v", err)
+		return
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxRelayBody))
+	if err != nil {
+		return
+	}
+	resp.Status = res.StatusCode
+	resp.Header = res.Header
+	resp.Body = body
+}
+
+// Ready returns a channel that's closed when the relay assigned the public URL or connecting failed
+func (r *Relay) Ready() <-chan struct{} {
+	return r.ready
+}
+
+// URL returns the public URL assigned by the relay, empty until then and when connecting failed
+func (r *Relay) URL() string {
+	select {
+	case <-r.ready:
+		return r.url
+	default:
+		return ""
+	}
+}
+
+// Err returns why connecting to the relay failed, nil until it is ready and when it works
+func (r *Relay) Err() error {
+	select {
+	case <-r.ready:
+		return r.err
+	default:
+		return nil
+	}
+}
+
+// Close disconnects from the relay
+func (r *Relay) Close() error {
+	r.fail("中继连接已关闭")
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	if r.conn != nil {
+		return r.conn.Close()
+	}
+	return nil
+}
+
+// remoteIP returns the IP of a host:port address, the address itself when it has no port
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return strings.TrimSpace(addr)
+	}
+	return host
+}
@@ -0,0 +1,81 @@
+package tunnel
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelay(t *testing.T) {
+	// The local link detection server the client exposes
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	local := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/image", r.URL.Path)
+		assert.NotEmpty(t, r.Header.Get("X-Forwarded-For"))
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprintf(w, "png id=%s", r.URL.Query().Get("id"))
+	})}
+	go local.Serve(ln)
+	defer local.Close()
+
+	relay := NewRelayServer("secret")
+	public := httptest.NewServer(relay)
+	defer public.Close()
+	wsURL := "ws" + strings.TrimPrefix(public.URL, "http")
+
+	client, err := NewRelay(wsURL, "secret", ln.Addr().(*net.TCPAddr).Port)
+	assert.NoError(t, err)
+	defer client.Close()
+	select {
+	case <-client.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay not ready")
+	}
+	assert.NoError(t, client.Err())
+	assert.True(t, strings.HasPrefix(client.URL(), public.URL+relaySessionPrefix), client.URL())
+	assert.Equal(t, 1, relay.Sessions())
+
+	resp, err := http.Get(client.URL() + "/image?id=abc")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "png id=abc", string(body))
+	}
+
+	resp, err = http.Get(public.URL + relaySessionPrefix + "unknown/image")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestRelayToken(t *testing.T) {
+	public := httptest.NewServer(NewRelayServer("secret"))
+	defer public.Close()
+
+	client, err := NewRelay("ws"+strings.TrimPrefix(public.URL, "http"), "wrong", 1)
+	assert.NoError(t, err)
+	<-client.Ready()
+	assert.Error(t, client.Err())
+	assert.Empty(t, client.URL())
+
+	// The token is only accepted in the Authorization header
+	resp, err := http.Get(public.URL + RelayConnectPath + "?token=secret")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+
+	_, err = NewRelay("https://relay.example.com", "", 1)
+	assert.Error(t, err)
+}
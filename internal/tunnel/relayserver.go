@@ -0,0 +1,202 @@
+package tunnel
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"golang.org/x/net/websocket"
+)
+
+// RelayConnectPath is the WebSocket route clients connect to
+const RelayConnectPath = "/connect"
+
+// relaySessionPrefix prefixes the public URL of every connected client, followed by its session ID
+const relaySessionPrefix = "/s/"
+
+// relayTimeout bounds the wait for a client to answer a forwarded request
+const relayTimeout = 30 * time.Second
+
+// RelayServer is the lightweight relay run with -relay-serve on a publicly reachable host. Every client
+// connecting to RelayConnectPath gets its own public URL, requests to it are forwarded over the socket.
+type RelayServer struct {
+	token string
+
+	mu       sync.Mutex
+	sessions map[string]*relaySession
+}
+
+// relaySession is a connected client and its requests waiting for a response
+type relaySession struct {
+	conn    *websocket.Conn
+	sendMu  sync.Mutex
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan relayFrame
+}
+
+// NewRelayServer creates a relay accepting clients presenting token
+func NewRelayServer(token string) *RelayServer {
+	return &RelayServer{
+		token:    token,
+		sessions: make(map[string]*relaySession),
+	}
+}
+
+// ServeHTTP accepts clients on RelayConnectPath and forwards requests to the public URLs of the sessions
+func (s *RelayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == RelayConnectPath {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			http.NotFound(w, r)
+			return
+		}
+		// Clients connect from anywhere, the token replaces the origin check
+		ws := websocket.Server{Handler: func(conn *websocket.Conn) { s.accept(conn, publicBase(r)) }}
+		ws.ServeHTTP(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, relaySessionPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	id, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, relaySessionPrefix), "/")
+	s.mu.Lock()
+	session := s.sessions[id]
+	s.mu.Unlock()
+	if session == nil {
+		http.NotFound(w, r)
+		return
+	}
+	session.forward(w, r, "/"+path)
+}
+
+// Sessions returns the number of connected clients
+func (s *RelayServer) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// accept registers a connected client, sends its public URL and reads its responses until it disconnects
+func (s *RelayServer) accept(conn *websocket.Conn, base string) {
+	// The session ID is all that guards the public URL, so it must not be guessable
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logger.Debug("Failed to generate relay session ID: %v", err)
+		return
+	}
+	id := hex.EncodeToString(b)
+	session := &relaySession{conn: conn, pending: make(map[uint64]chan relayFrame)}
+	s.mu.Lock()
+	s.sessions[id] = session
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		logger.Debug("Relay session %s closed", id)
+	}()
+
+	if err := session.send(relayFrame{Type: frameHello, URL: base + relaySessionPrefix + id}); err != nil {
+		return
+	}
+	logger.Debug("Relay session %s connected from %s", id, conn.Request().RemoteAddr)
+	for {
+		var frame relayFrame
+		if err := websocket.JSON.Receive(conn, &frame); err != nil {
+			return
+		}
+		if frame.Type != frameResponse {
+			continue
+		}
+		session.mu.Lock()
+		ch := session.pending[frame.ID]
+		delete(session.pending, frame.ID)
+		session.mu.Unlock()
+		if ch != nil {
+			ch <- frame
+		}
+	}
+}
+
+// send writes a frame to the client, frames of concurrent requests are serialized
+func (c *relaySession) send(frame relayFrame) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return websocket.JSON.Send(c.conn, frame)
+}
+
+// forward sends a public request to the client and writes back the response of its local server
+func (c *relaySession) forward(w http.ResponseWriter, r *http.Request, path string) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRelayBody))
+	if err != nil {
+		http.Error(w, "read body failed", http.StatusBadRequest)
+		return
+	}
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+
+	ch := make(chan relayFrame, 1)
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	header := r.Header.Clone()
+	header.Del("Connection")
+	err = c.send(relayFrame{
+		Type:       frameRequest,
+		ID:         id,
+		Method:     r.Method,
+		Path:       path,
+		Header:     header,
+		RemoteAddr: remoteIP(r.RemoteAddr),
+		Body:       body,
+	})
+	if err != nil {
+		http.Error(w, "client disconnected", http.StatusBadGateway)
+		return
+	}
+
+	select {
+	case resp := <-ch:
+		for name, values := range resp.Header {
+			// The body is sent whole, the length is set again by the response writer
+			if name == "Content-Length" || name == "Transfer-Encoding" || name == "Connection" {
+				continue
+			}
+			for _, v := range values {
+				w.Header().Add(name, v)
+			}
+		}
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+	case <-time.After(relayTimeout):
+		http.Error(w, "client timeout", http.StatusGatewayTimeout)
+	case <-r.Context().Done():
+	}
+}
+
+// publicBase returns the scheme and host clients reached the relay on, honoring a TLS terminating proxy in front of it
+func publicBase(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
@@ -7,12 +7,15 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
 )
 
 // Tunnel implements interfaces.Tunnel
 type Tunnel struct {
 	cmd    *exec.Cmd
 	url    string
+	err    error
 	stdout io.ReadCloser
 	ready  chan struct{} // Channel to signal when tunnel is ready
 }
@@ -24,6 +27,8 @@ func New(port int) (*Tunnel, error) {
 	if err != nil {
 		return nil, fmt.Errorf("创建输出管道失败: %v", err)
 	}
+	// ssh reports connection failures on stderr, they are classified with the banner lines
+	cmd.Stderr = cmd.Stdout
 
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("启动隧道失败: %v", err)
@@ -47,9 +52,12 @@ func (t *Tunnel) waitForURL() {
 	errChan := make(chan error, 1)
 
 	go func() {
+		var failure *Error
+		var last string
 		scanner := bufio.NewScanner(t.stdout)
 		for scanner.Scan() {
 			line := scanner.Text()
+			logger.Debug("ssh: %s", line)
 			if strings.Contains(line, "https://") {
 				parts := strings.Split(line, "https://")
 				if len(parts) > 1 {
@@ -57,26 +65,32 @@ func (t *Tunnel) waitForURL() {
 					return
 				}
 			}
+			if e := classifySSH(line); e != nil && failure == nil {
+				failure = e
+			}
+			if strings.TrimSpace(line) != "" {
+				last = strings.TrimSpace(line)
+			}
 		}
-		if err := scanner.Err(); err != nil {
-			errChan <- fmt.Errorf("读取隧道URL失败: %v", err)
+		// ssh exited without a URL, the first recognized error explains why
+		if failure == nil {
+			failure = &Error{Kind: KindExited, Detail: last}
 		}
+		errChan <- failure
 	}()
 
 	// Wait for URL or timeout
 	select {
 	case url := <-urlChan:
 		t.url = url
-		close(t.ready) // Signal that tunnel is ready
 	case err := <-errChan:
-		t.url = fmt.Sprintf("Error: %v", err)
+		t.err = err
 		t.Close()
-		close(t.ready)
 	case <-time.After(15 * time.Second):
-		t.url = "Error: Tunnel timeout"
+		t.err = &Error{Kind: KindTimeout}
 		t.Close()
-		close(t.ready)
 	}
+	close(t.ready) // Signal that tunnel is ready
 }
 
 // Ready returns a channel that's closed when the tunnel is ready to use
@@ -92,11 +106,21 @@ func (t *Tunnel) Close() error {
 	return nil
 }
 
-// URL returns the tunnel's public URL
+// URL returns the tunnel's public URL, empty when the tunnel failed
 func (t *Tunnel) URL() string {
 	return t.url
 }
 
+// Err returns why the tunnel failed, nil until it is ready and when it works
+func (t *Tunnel) Err() error {
+	select {
+	case <-t.ready:
+		return t.err
+	default:
+		return nil
+	}
+}
+
 // IsAvailable checks if SSH is available on the system
 func IsAvailable() bool {
 	cmd := exec.Command("ssh", "-V")
@@ -0,0 +1,95 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Attempts of Verify, a fresh tunnel host can take a moment to resolve
+const (
+	verifyAttempts = 3
+	verifyInterval = time.Second
+	verifyTimeout  = 5 * time.Second
+)
+
+// ErrUnreachable prefixes the errors of Verify
+const ErrUnreachable = "隧道不可达"
+
+// Verify fetches the root of the link detection server through its public URL and checks the server itself answered,
+// not a landing page of the tunnel provider. With checker set, the URL is also fetched by that third-party service:
+// the escaped public URL is appended to checker and any 2xx status counts as reachable.
+func Verify(ctx context.Context, publicURL, checker string) error {
+	client := &http.Client{Timeout: verifyTimeout}
+	var err error
+	for attempt := 0; attempt < verifyAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(verifyInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = fetchRoot(ctx, client, publicURL); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", ErrUnreachable, err)
+	}
+
+	if checker == "" {
+		return nil
+	}
+	status, err := get(ctx, client, checker+url.QueryEscape(publicURL))
+	if err != nil {
+		return fmt.Errorf("%s: 外部检测失败: %v", ErrUnreachable, err)
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("%s: 外部检测返回状态码 %d", ErrUnreachable, status)
+	}
+	return nil
+}
+
+// fetchRoot checks the root route of the server answers with its client IP report
+func fetchRoot(ctx context.Context, client *http.Client, publicURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, publicURL+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	var root struct {
+		ClientIP *string `json:"client_ip"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(body, &root); err != nil || root.ClientIP == nil {
+		return fmt.Errorf("响应不是来自本机图片服务器")
+	}
+	return nil
+}
+
+// get returns the status of a GET request to rawURL
+func get(ctx context.Context, client *http.Client, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,41 @@
+package tunnel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"client_ip":"1.2.3.4","remote_ip":"1.2.3.4","headers":{}}`))
+	}))
+	defer server.Close()
+	landing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>tunnel not found</html>`))
+	}))
+	defer landing.Close()
+
+	var checked string
+	checker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checked = r.URL.Query().Get("url")
+		if strings.Contains(checked, "127.0.0.1") {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer checker.Close()
+
+	ctx := context.Background()
+	assert.NoError(t, Verify(ctx, server.URL, ""))
+
+	err := Verify(ctx, landing.URL, "")
+	assert.ErrorContains(t, err, ErrUnreachable)
+
+	err = Verify(ctx, server.URL, checker.URL+"/?url=")
+	assert.ErrorContains(t, err, "外部检测返回状态码 502")
+	assert.Equal(t, server.URL, checked)
+}
@@ -1,45 +1,26 @@
 package types
 
-import (
-	"encoding/json"
-	"time"
-)
+import "time"
 
 // API Types
 
+// ChannelType represents the type of API channel, shared by the menu and the test engine
 type ChannelType int
 
 const (
 	ChannelTypeGemini ChannelType = iota
 	ChannelTypeOpenAI
+	ChannelTypeAnthropic  // 原生 Anthropic Messages API
+	ChannelTypeAzure      // Azure OpenAI, 模型即部署名称
+	ChannelTypeVertex     // Vertex AI Gemini, Key 为服务账号 JSON 文件路径
+	ChannelTypeCohere     // Cohere v2 Chat API
+	ChannelTypeLocal      // 本地 OpenAI 兼容服务 (Ollama、llama.cpp、LM Studio), 不发送鉴权头
+	ChannelTypeDashScope  // 阿里云百炼 DashScope 的 OpenAI 兼容模式
+	ChannelTypeZhipu      // 智谱 GLM, Key 为 id.secret, 请求时签发 JWT
+	ChannelTypeOpenRouter // OpenRouter, 模型 ID 带厂商前缀 (anthropic/claude-3.5-sonnet)
+	ChannelTypeGroq       // Groq, 免费层限流严格, 同一 Key 的请求按间隔发送
 )
 
-type Channel struct {
-	Key       string      `json:"key"`
-	TestModel []string    `json:"test_model"`
-	URL       string      `json:"url"`
-	Type      ChannelType `json:"type"`
-}
-
-type TestResult struct {
-	Key      string
-	Model    string
-	Success  bool
-	Latency  float64
-	ErrorMsg string
-}
-
-type ChatMessage struct {
-	Role    string          `json:"role"`
-	Content json.RawMessage `json:"content"`
-}
-
-type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
-}
-
 // Message Types
 
 type MessageType int
@@ -49,6 +30,7 @@ const (
 	MessageTypeError
 	MessageTypeAPI
 	MessageTypeRequest
+	MessageTypeTimeout // 超时前未收到模型响应
 )
 
 type Message struct {
@@ -58,6 +40,11 @@ type Message struct {
 	Error    error
 	Request  string
 	Response string
+	Round    int        // 多轮链路检测中的轮次, 从 1 开始
+	Rounds   int        // 链路检测的总轮数
+	Image    int        // 节点获取的图片序号, 从 1 开始
+	Answers  [][]string // 每张图片可接受的答案, 命中其一即为正确
+	Stream   string     // 流式透传结论
 }
 
 type RequestHeaders struct {
@@ -65,6 +52,8 @@ type RequestHeaders struct {
 	ForwardedFor string
 	Time         time.Time
 	IP           string
+	EdgeCountry  string // Cloudflare Tunnel 模式下的 CF-IPCountry
+	EdgeColo     string // Cloudflare Tunnel 模式下接入的机房, 来自 Cf-Ray
 }
 
 type Node struct {
@@ -74,10 +63,14 @@ type Node struct {
 	UserAgent    string
 	RequestInfo  string
 	ForwardedFor string
-	RequestCount int // Track number of requests for this node
+	RequestCount int         // Track number of requests for this node
+	Requests     []time.Time // Arrival time of every request, for the timeline
+	Images       []int       // Images fetched by the node, in the order first fetched
 	IsNew        bool
 	NodeIndex    int
 	RegionName   string
 	Org          string
 	ServerName   string
+	EdgeCountry  string // Cloudflare 判定的国家
+	EdgeColo     string // 节点接入的 Cloudflare 机房
 }
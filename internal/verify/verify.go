@@ -0,0 +1,181 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/server/trace"
+	"github.com/go-coders/check-gpt/pkg/util"
+)
+
+// Snapshot is the verdict of one re-verification of a relay
+type Snapshot struct {
+	Time       time.Time        `json:"time"`
+	Pass       bool             `json:"pass"`
+	Error      string           `json:"error,omitempty"`
+	Hops       int              `json:"hops"`
+	Confidence trace.Confidence `json:"official_confidence,omitempty"`
+	Fetched    bool             `json:"image_fetched"`
+	Correct    bool             `json:"captcha_correct"`
+	Stream     string           `json:"stream,omitempty"`
+}
+
+// FromResult takes the snapshot of a trace result
+func FromResult(r trace.Result, at time.Time) Snapshot {
+	s := Snapshot{Time: at, Pass: r.Pass, Error: r.Error}
+	if v := r.Verdict; v != nil {
+		s.Hops = len(v.Hops)
+		s.Confidence = v.Confidence
+		s.Fetched = v.Fetched
+		s.Correct = v.Checked && v.Correct
+		s.Stream = v.Stream
+	}
+	return s
+}
+
+// Record holds the baseline taken at the first verification of a relay and its latest verdict
+type Record struct {
+	Baseline Snapshot `json:"baseline"`
+	Last     Snapshot `json:"last"`
+}
+
+// Store keeps the records of the registered relays by name in a JSON file
+type Store struct {
+	path    string
+	Records map[string]*Record `json:"relays"`
+}
+
+// Load reads the store at path, a missing file is an empty store
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Records: make(map[string]*Record)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("读取中转基准失败: %v", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析中转基准失败: %v", err)
+	}
+	if s.Records == nil {
+		s.Records = make(map[string]*Record)
+	}
+	return s, nil
+}
+
+// Save writes the store back to its file
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal relay baselines: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("保存中转基准失败: %v", err)
+	}
+	return nil
+}
+
+// Outcome is the re-verification of one relay compared with its baseline
+type Outcome struct {
+	Name         string
+	Baseline     Snapshot
+	Current      Snapshot
+	New          bool     // 首次检测, 本次结论即为基准
+	Degradations []string // 与基准相比变差之处
+}
+
+// Add records the current snapshot of a relay. The first successful verification becomes the baseline,
+// so a relay that failed the day it was bought is not held to that failure. A baseline taken before
+// purchased, the purchase date (zero when unknown), is replaced as well: it measured a different purchase.
+func (s *Store) Add(name string, purchased time.Time, current Snapshot) Outcome {
+	rec, ok := s.Records[name]
+	if !ok || (rec.Baseline.Error != "" && current.Error == "") || rec.Baseline.Time.Before(purchased) {
+		s.Records[name] = &Record{Baseline: current, Last: current}
+		return Outcome{Name: name, Baseline: current, Current: current, New: true}
+	}
+	rec.Last = current
+	return Outcome{Name: name, Baseline: rec.Baseline, Current: current, Degradations: Compare(rec.Baseline, current)}
+}
+
+// confidenceRank orders the confidence levels, higher is closer to the official endpoint
+var confidenceRank = map[trace.Confidence]int{
+	trace.ConfidenceNone:   0,
+	trace.ConfidenceLow:    1,
+	trace.ConfidenceMedium: 2,
+	trace.ConfidenceHigh:   3,
+}
+
+// Compare lists what got worse in current since the baseline
+func Compare(baseline, current Snapshot) []string {
+	var d []string
+	if current.Error != "" && baseline.Error == "" {
+		d = append(d, "检测失败: "+current.Error)
+	}
+	if baseline.Pass && !current.Pass {
+		d = append(d, "未通过判定条件")
+	}
+	if confidenceRank[current.Confidence] < confidenceRank[baseline.Confidence] {
+		d = append(d, fmt.Sprintf("官方端点可信度 %s → %s", baseline.Confidence, current.Confidence))
+	}
+	if baseline.Fetched && !current.Fetched {
+		d = append(d, "模型不再获取图片")
+	}
+	if baseline.Correct && !current.Correct {
+		d = append(d, "验证码不再回答正确")
+	}
+	if current.Hops > baseline.Hops && baseline.Hops > 0 {
+		d = append(d, fmt.Sprintf("链路 %d 跳 → %d 跳", baseline.Hops, current.Hops))
+	}
+	if streamTampered(current.Stream) && !streamTampered(baseline.Stream) {
+		d = append(d, fmt.Sprintf("流式 %s → %s", baseline.Stream, current.Stream))
+	}
+	return d
+}
+
+// streamTampered reports whether the stream verdict shows the relay buffering or converting the stream
+func streamTampered(verdict string) bool {
+	return verdict == util.StreamBuffered || verdict == util.StreamConverted
+}
+
+// Print prints one line per relay followed by the degradations, it returns the number of degraded relays
+func Print(p *util.Printer, outcomes []Outcome) int {
+	p.PrintTitle("中转复检", util.EmojiDone)
+	degraded := 0
+	for _, o := range outcomes {
+		status := util.ColorGreen + "正常" + util.ColorReset
+		switch {
+		case o.New:
+			status = util.ColorGray + "已记录基准" + util.ColorReset
+		case len(o.Degradations) > 0:
+			degraded++
+			status = util.ColorRed + "已降级" + util.ColorReset
+		}
+		p.Printf("%s %s 基准: %s\n", util.PadRight(o.Name, 20), status, util.FormatTime(o.Baseline.Time))
+		for _, d := range o.Degradations {
+			p.Printf("   - %s\n", d)
+		}
+		p.PrintSummary("%s: %s", o.Name, summary(o))
+	}
+	return degraded
+}
+
+// summary is the one-line verdict of an outcome shown with -summary
+func summary(o Outcome) string {
+	switch {
+	case o.New:
+		return "已记录基准"
+	case len(o.Degradations) > 0:
+		return "已降级 (" + strings.Join(o.Degradations, "; ") + ")"
+	default:
+		return "正常"
+	}
+}
@@ -0,0 +1,55 @@
+package verify
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-coders/check-gpt/internal/server/trace"
+	"github.com/go-coders/check-gpt/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	baseline := Snapshot{Pass: true, Hops: 2, Confidence: trace.ConfidenceHigh, Fetched: true, Correct: true, Stream: util.StreamPassedThrough}
+	assert.Empty(t, Compare(baseline, baseline))
+
+	current := Snapshot{Hops: 4, Confidence: trace.ConfidenceLow, Fetched: true, Stream: util.StreamBuffered}
+	assert.Equal(t, []string{
+		"未通过判定条件",
+		"官方端点可信度 high → low",
+		"验证码不再回答正确",
+		"链路 2 跳 → 4 跳",
+		"流式 流式透传 → " + util.StreamBuffered,
+	}, Compare(baseline, current))
+
+	// Improvements are not degradations
+	assert.Empty(t, Compare(current, baseline))
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relays.json")
+	s, err := Load(path)
+	assert.NoError(t, err)
+
+	// A failed first check does not become the baseline for good
+	o := s.Add("vendor-a", time.Time{}, Snapshot{Error: "API请求失败"})
+	assert.True(t, o.New)
+	good := Snapshot{Time: time.Now(), Pass: true, Hops: 1, Confidence: trace.ConfidenceHigh, Fetched: true, Correct: true}
+	o = s.Add("vendor-a", time.Time{}, good)
+	assert.True(t, o.New)
+	assert.NoError(t, s.Save())
+
+	s, err = Load(path)
+	assert.NoError(t, err)
+	degraded := Snapshot{Time: time.Now(), Confidence: trace.ConfidenceLow, Fetched: true, Correct: true, Hops: 1}
+	o = s.Add("vendor-a", good.Time.Add(-24*time.Hour), degraded)
+	assert.False(t, o.New)
+	assert.Equal(t, []string{"未通过判定条件", "官方端点可信度 high → low"}, o.Degradations)
+	assert.True(t, s.Records["vendor-a"].Baseline.Pass)
+
+	// A baseline taken before the purchase date is replaced by the first verification after it
+	o = s.Add("vendor-a", degraded.Time.Add(time.Second), Snapshot{Time: degraded.Time.Add(time.Minute), Confidence: trace.ConfidenceLow})
+	assert.True(t, o.New)
+	assert.Equal(t, trace.ConfidenceLow, s.Records["vendor-a"].Baseline.Confidence)
+}
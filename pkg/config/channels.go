@@ -0,0 +1,45 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// Endpoint is an API endpoint and its keys in a channels file
+type Endpoint struct {
+	Name   string   `json:"name,omitempty"`
+	URL    string   `json:"url"`
+	Keys   []string `json:"keys"`
+	Models []string `json:"models,omitempty"` // 为空时使用默认模型
+	// 兼容 OpenAI 的端点使用的接口: chat 或 responses, 为空时使用 -protocol
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// LoadChannels reads a channels file, a JSON array of endpoints tested in one run
+func LoadChannels(path string) ([]Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取渠道文件失败: %v", err)
+	}
+
+	var endpoints []Endpoint
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, fmt.Errorf("解析渠道文件失败: %v", err)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("渠道文件中没有端点: %s", path)
+	}
+
+	for i, e := range endpoints {
+		u, err := url.Parse(e.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("渠道文件第 %d 项 URL 无效: %s", i+1, e.URL)
+		}
+		if len(e.Keys) == 0 {
+			return nil, fmt.Errorf("渠道文件第 %d 项缺少 keys", i+1)
+		}
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWarnDays is the number of days before expiry when the monitor starts warning
+const DefaultWarnDays = 7
+
+// ExpiryDateLayout is the date format used for watchlist expiry dates
+const ExpiryDateLayout = "2006-01-02"
+
+// WatchItem represents a key registered in the watchlist
+type WatchItem struct {
+	Name       string  `json:"name"`
+	Key        string  `json:"key"`
+	URL        string  `json:"url"`
+	Model      string  `json:"model,omitempty"`
+	ExpiresAt  string  `json:"expires_at,omitempty"`  // 格式: 2006-01-02
+	MinBalance float64 `json:"min_balance,omitempty"` // 单位: USD
+}
+
+// Expiry returns the parsed expiry date, ok is false if no valid date is set
+func (w WatchItem) Expiry() (time.Time, bool) {
+	if w.ExpiresAt == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(ExpiryDateLayout, w.ExpiresAt, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// RelayItem represents a purchased relay registered for re-verification
+type RelayItem struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Key         string `json:"key"`
+	Model       string `json:"model,omitempty"`
+	PurchasedAt string `json:"purchased_at,omitempty"` // 格式: 2006-01-02
+}
+
+// Purchased returns the parsed purchase date, ok is false if no valid date is set
+func (r RelayItem) Purchased() (time.Time, bool) {
+	if r.PurchasedAt == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(ExpiryDateLayout, r.PurchasedAt, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Proxy represents a proxy in the vantage pool, usually one per region
+type Proxy struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // http://, https:// 或 socks5://
+}
+
+// ParseProxies parses a comma separated list of name=url proxies
+func ParseProxies(s string) ([]Proxy, error) {
+	var proxies []Proxy
+	for i, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		p := Proxy{URL: part}
+		if name, u, ok := strings.Cut(part, "="); ok {
+			p = Proxy{Name: strings.TrimSpace(name), URL: strings.TrimSpace(u)}
+		}
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("proxy%d", i+1)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, nil
+}
+
+// Validate checks the proxy URL
+func (p Proxy) Validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("代理 %s 地址无效: %s", p.Name, p.URL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	default:
+		return fmt.Errorf("代理 %s 协议不支持: %s (可选: http, https, socks5)", p.Name, u.Scheme)
+	}
+}
+
+// MaskConfig represents the key display settings in the configuration file
+type MaskConfig struct {
+	Mode  string `json:"mode,omitempty"` // partial, hash 或 full
+	First *int   `json:"first,omitempty"`
+	Last  *int   `json:"last,omitempty"`
+}
+
+// CaptchaConfig represents the link detection captcha settings in the configuration file
+type CaptchaConfig struct {
+	Length   int    `json:"length,omitempty"`
+	Charset  string `json:"charset,omitempty"` // digits 或 alnum
+	FontSize int    `json:"font_size,omitempty"`
+	Language string `json:"language,omitempty"` // en, zh 或 both
+}
+
+// FRPConfig represents the frps the link detection server is exposed through
+type FRPConfig struct {
+	Server string `json:"server"` // host:port
+	Token  string `json:"token,omitempty"`
+	Domain string `json:"domain"` // 自定义域名, 可带 vhostHTTPPort
+}
+
+// URLRule rewrites API URLs whose full address matches the regular expression
+type URLRule struct {
+	Match   string `json:"match"`
+	Replace string `json:"replace"` // 支持 $1 等分组引用
+}
+
+// FileConfig represents the optional JSON configuration file
+type FileConfig struct {
+	Watchlist []WatchItem    `json:"watchlist"`
+	Relays    []RelayItem    `json:"relays,omitempty"`
+	WarnDays  int            `json:"warn_days,omitempty"`
+	Mask      *MaskConfig    `json:"mask,omitempty"`
+	Proxies   []Proxy        `json:"proxies,omitempty"`
+	URLRules  []URLRule      `json:"url_rules,omitempty"`
+	Captcha   *CaptchaConfig `json:"captcha,omitempty"`
+	FRP       *FRPConfig     `json:"frp,omitempty"`
+	Store     string         `json:"store,omitempty"`    // 历史记录存储, 同 -store
+	Protocol  string         `json:"protocol,omitempty"` // 兼容 OpenAI 的渠道使用的接口, 同 -protocol
+}
+
+// Dir returns the directory holding the configuration and saved profiles
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "check-gpt")
+}
+
+// DefaultConfigPath returns the default configuration file path
+func DefaultConfigPath() string {
+	dir := Dir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+// StateDir returns the directory holding state such as the run log, following XDG_STATE_HOME
+func StateDir() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "check-gpt")
+}
+
+// DefaultRunLogPath returns the default run log path
+func DefaultRunLogPath() string {
+	dir := StateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "runs.log")
+}
+
+// DefaultVerifyStatePath returns the file holding the baseline verdicts of the registered relays
+func DefaultVerifyStatePath() string {
+	dir := StateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "relays.json")
+}
+
+// DefaultProbeDir returns the directory of the YAML plugin probes
+func DefaultProbeDir() string {
+	dir := Dir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "probes")
+}
+
+// DefaultModelCachePath returns the file caching the model lists discovered per endpoint
+func DefaultModelCachePath() string {
+	dir := StateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "models.json")
+}
+
+// LoadFile loads the configuration file into c, a missing file is not an error
+func (c *Config) LoadFile() error {
+	if c.ConfigPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.ConfigPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var fc FileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	for i, item := range fc.Watchlist {
+		if item.Key == "" || item.URL == "" {
+			return fmt.Errorf("监控列表第 %d 项缺少 key 或 url", i+1)
+		}
+		if _, ok := item.Expiry(); item.ExpiresAt != "" && !ok {
+			return fmt.Errorf("监控列表第 %d 项过期日期格式错误: %s (应为 %s)", i+1, item.ExpiresAt, ExpiryDateLayout)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, relay := range fc.Relays {
+		if relay.Name == "" || relay.Key == "" || relay.URL == "" {
+			return fmt.Errorf("中转列表第 %d 项缺少 name、key 或 url", i+1)
+		}
+		if names[relay.Name] {
+			return fmt.Errorf("中转列表中的名称重复: %s", relay.Name)
+		}
+		names[relay.Name] = true
+		if _, err := time.ParseInLocation(ExpiryDateLayout, relay.PurchasedAt, time.Local); relay.PurchasedAt != "" && err != nil {
+			return fmt.Errorf("中转列表第 %d 项购买日期格式错误: %s (应为 %s)", i+1, relay.PurchasedAt, ExpiryDateLayout)
+		}
+	}
+	c.Relays = fc.Relays
+
+	for _, p := range fc.Proxies {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(fc.Proxies) > 0 && !isFlagSet("proxies") {
+		c.Proxies = fc.Proxies
+	}
+
+	for i, rule := range fc.URLRules {
+		if rule.Match == "" {
+			return fmt.Errorf("URL 改写规则第 %d 项缺少 match", i+1)
+		}
+	}
+	c.URLRules = fc.URLRules
+
+	c.Watchlist = fc.Watchlist
+	if fc.WarnDays > 0 {
+		c.WarnDays = fc.WarnDays
+	}
+
+	// Command line flags take precedence over the file
+	if fc.Captcha != nil {
+		if fc.Captcha.Length > 0 && !isFlagSet("captcha-length") {
+			c.CaptchaLength = fc.Captcha.Length
+		}
+		if fc.Captcha.Charset != "" && !isFlagSet("captcha-charset") {
+			c.CaptchaCharset = fc.Captcha.Charset
+		}
+		if fc.Captcha.FontSize > 0 && !isFlagSet("captcha-font-size") {
+			c.CaptchaFontSize = fc.Captcha.FontSize
+		}
+		if fc.Captcha.Language != "" && !isFlagSet("prompt-lang") {
+			c.PromptLang = fc.Captcha.Language
+		}
+	}
+	if fc.FRP != nil {
+		if fc.FRP.Server != "" && !isFlagSet("frp-server") {
+			c.FRPServer = fc.FRP.Server
+		}
+		if fc.FRP.Token != "" && !isFlagSet("frp-token") {
+			c.FRPToken = fc.FRP.Token
+		}
+		if fc.FRP.Domain != "" && !isFlagSet("frp-domain") {
+			c.FRPDomain = fc.FRP.Domain
+		}
+	}
+	if fc.Store != "" && !isFlagSet("store") {
+		c.Store = fc.Store
+	}
+	if fc.Protocol != "" && !isFlagSet("protocol") {
+		c.Protocol = fc.Protocol
+	}
+	if fc.Mask != nil {
+		if fc.Mask.Mode != "" && !isFlagSet("mask") && !isFlagSet("show-keys") {
+			c.MaskMode = fc.Mask.Mode
+		}
+		if fc.Mask.First != nil && !isFlagSet("mask-first") {
+			c.MaskFirst = *fc.Mask.First
+		}
+		if fc.Mask.Last != nil && !isFlagSet("mask-last") {
+			c.MaskLast = *fc.Mask.Last
+		}
+	}
+	c.fileSettings = fileSettings(&fc)
+	return nil
+}
+
+// fileSettings returns the settings of fc that change the test results, without keys, tokens
+// or proxy URLs, as the config file part of SettingsDigest
+func fileSettings(fc *FileConfig) string {
+	settings := struct {
+		WarnDays int            `json:"warn_days,omitempty"`
+		Mask     *MaskConfig    `json:"mask,omitempty"`
+		Proxies  string         `json:"proxies,omitempty"`
+		URLRules []URLRule      `json:"url_rules,omitempty"`
+		Captcha  *CaptchaConfig `json:"captcha,omitempty"`
+		Protocol string         `json:"protocol,omitempty"`
+	}{fc.WarnDays, fc.Mask, proxyNames(fc.Proxies), fc.URLRules, fc.Captcha, fc.Protocol}
+	data, err := json.Marshal(settings)
+	if err != nil || string(data) == "{}" {
+		return ""
+	}
+	return string(data)
+}
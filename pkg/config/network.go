@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+// Networks an endpoint IP can belong to
+const (
+	NetworkCloudflare = "Cloudflare"
+	NetworkOpenAI     = "OpenAI"
+	NetworkPrivate    = "内网"
+)
+
+// cloudflareCIDR lists the published Cloudflare ranges (https://www.cloudflare.com/ips/)
+var cloudflareCIDR = []string{
+	"173.245.48.0/20",
+	"103.21.244.0/22",
+	"103.22.200.0/22",
+	"103.31.4.0/22",
+	"141.101.64.0/18",
+	"108.162.192.0/18",
+	"190.93.240.0/20",
+	"188.114.96.0/20",
+	"197.234.240.0/22",
+	"198.41.128.0/17",
+	"162.158.0.0/15",
+	"104.16.0.0/13",
+	"104.24.0.0/14",
+	"172.64.0.0/13",
+	"131.0.72.0/22",
+	"2400:cb00::/32",
+	"2606:4700::/32",
+	"2803:f800::/32",
+	"2405:b500::/32",
+	"2405:8100::/32",
+	"2a06:98c0::/29",
+	"2c0f:f248::/32",
+}
+
+// IPNetwork returns the known network ip belongs to, empty if unknown
+func (c *Config) IPNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.IsLoopback() || parsed.IsPrivate() {
+		return NetworkPrivate
+	}
+	if inCIDR(parsed, c.CloudflareCIDR) {
+		return NetworkCloudflare
+	}
+	if inCIDR(parsed, c.OPENAICIDR) {
+		return NetworkOpenAI
+	}
+	return ""
+}
+
+// inCIDR reports whether ip is in any of the ranges
+func inCIDR(ip net.IP, ranges []string) bool {
+	for _, cidr := range ranges {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Defaults of the callback server, the SSH tunnel connects from loopback and forwards the client in X-Forwarded-For
+const (
+	DefaultTrustedProxies  = "127.0.0.1/8,::1/128"
+	DefaultRemoteIPHeaders = "X-Forwarded-For"
+
+	TrustedProxyCloudflare = "cloudflare" // 展开为 Cloudflare 公布的网段
+	TrustedProxyNone       = "none"       // 不信任任何代理, 使用连接的来源地址
+)
+
+// Headers Cloudflare adds to proxied requests
+const (
+	CFConnectingIPHeader = "CF-Connecting-IP"
+	CFCountryHeader      = "CF-IPCountry"
+	CFRayHeader          = "Cf-Ray" // 请求 ID, 以 "-" 后的机房代码结尾, 如 8a1b2c3d4e5f6789-HKG
+)
+
+// ParseTrustedProxies parses the comma separated IPs and CIDRs of -trusted-proxies.
+// "cloudflare" adds the Cloudflare ranges, "none" trusts no proxy and returns an empty list.
+func ParseTrustedProxies(s string) ([]string, error) {
+	proxies := []string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "" || strings.EqualFold(part, TrustedProxyNone):
+			continue
+		case strings.EqualFold(part, TrustedProxyCloudflare):
+			proxies = append(proxies, cloudflareCIDR...)
+			continue
+		}
+		if net.ParseIP(part) == nil {
+			if _, _, err := net.ParseCIDR(part); err != nil {
+				return nil, fmt.Errorf("受信任代理地址无效: %s (应为 IP、CIDR、%s 或 %s)", part, TrustedProxyCloudflare, TrustedProxyNone)
+			}
+		}
+		proxies = append(proxies, part)
+	}
+	return proxies, nil
+}
+
+// ParseRemoteIPHeaders parses the comma separated header names of -remote-ip-headers, first match wins
+func ParseRemoteIPHeaders(s string) []string {
+	var headers []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			headers = append(headers, textproto.CanonicalMIMEHeaderKey(part))
+		}
+	}
+	return headers
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPNetwork(t *testing.T) {
+	c := &Config{OPENAICIDR: getOpenAICIDR(), CloudflareCIDR: cloudflareCIDR}
+
+	assert.Equal(t, NetworkCloudflare, c.IPNetwork("104.18.6.192"))
+	assert.Equal(t, NetworkCloudflare, c.IPNetwork("2606:4700::6812:6c0"))
+	assert.Equal(t, NetworkOpenAI, c.IPNetwork("23.102.140.115"))
+	assert.Equal(t, NetworkPrivate, c.IPNetwork("192.168.1.10"))
+	assert.Equal(t, "", c.IPNetwork("8.8.8.8"))
+	assert.Equal(t, "", c.IPNetwork("not-an-ip"))
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(DefaultTrustedProxies)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1/8", "::1/128"}, proxies)
+
+	proxies, err = ParseTrustedProxies("10.0.0.5, cloudflare")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.5", proxies[0])
+	assert.Len(t, proxies, 1+len(cloudflareCIDR))
+
+	proxies, err = ParseTrustedProxies("none")
+	assert.NoError(t, err)
+	assert.NotNil(t, proxies, "none is an empty list, not the default")
+	assert.Empty(t, proxies)
+
+	_, err = ParseTrustedProxies("127.0.0.1,nginx")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"Cf-Connecting-Ip", "X-Forwarded-For"}, ParseRemoteIPHeaders("cf-connecting-ip, X-Forwarded-For,"))
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DoHResolver resolves host names with DNS over HTTPS (RFC 8484)
+type DoHResolver struct {
+	URL    string
+	client *http.Client
+}
+
+// NewDoHResolver creates a new DoHResolver for the given endpoint
+func NewDoHResolver(url string) *DoHResolver {
+	// The DoH server itself is reached through the system resolver
+	return &DoHResolver{
+		URL:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// LookupIPAddr implements Resolver, it queries A and AAAA records
+func (r *DoHResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		ips, err := r.query(ctx, host, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		addrs = append(addrs, ips...)
+	}
+	if len(addrs) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return addrs, nil
+}
+
+// query sends a single DoH query
+func (r *DoHResolver) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]net.IPAddr, error) {
+	name, err := dnsmessage.NewName(dnsName(host))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host name: %v", err)
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack query: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(packed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("DoH request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH request failed: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DoH response: %v", err)
+	}
+
+	var answer dnsmessage.Message
+	if err := answer.Unpack(body); err != nil {
+		return nil, fmt.Errorf("failed to parse DoH response: %v", err)
+	}
+	if answer.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("DoH query failed: %s", answer.RCode)
+	}
+
+	var addrs []net.IPAddr
+	for _, rr := range answer.Answers {
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, net.IPAddr{IP: net.IP(body.A[:])})
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, net.IPAddr{IP: net.IP(body.AAAA[:])})
+		}
+	}
+	return addrs, nil
+}
+
+// dnsName returns host as a fully qualified name
+func dnsName(host string) string {
+	if len(host) > 0 && host[len(host)-1] == '.' {
+		return host
+	}
+	return host + "."
+}
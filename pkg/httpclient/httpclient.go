@@ -0,0 +1,240 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves host names to IP addresses
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// IPVersion restricts the address family used to connect
+type IPVersion int
+
+const (
+	IPVersionAuto IPVersion = iota
+	IPVersion4
+	IPVersion6
+)
+
+// String returns the flag value of the version
+func (v IPVersion) String() string {
+	switch v {
+	case IPVersion4:
+		return "4"
+	case IPVersion6:
+		return "6"
+	default:
+		return "auto"
+	}
+}
+
+// ParseIPVersion parses 4, 6 or auto
+func ParseIPVersion(s string) (IPVersion, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "auto":
+		return IPVersionAuto, nil
+	case "4", "ipv4":
+		return IPVersion4, nil
+	case "6", "ipv6":
+		return IPVersion6, nil
+	default:
+		return IPVersionAuto, fmt.Errorf("无效的 IP 版本: %s (可选: 4, 6, auto)", s)
+	}
+}
+
+// Matches reports whether ip belongs to the address family of v
+func (v IPVersion) Matches(ip net.IP) bool {
+	switch v {
+	case IPVersion4:
+		return ip.To4() != nil
+	case IPVersion6:
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
+
+var (
+	ipVersion = IPVersionAuto
+
+	resolver     Resolver = net.DefaultResolver
+	resolverName          = "system"
+	resolverLock sync.RWMutex
+
+	resolved     = make(map[string][]string)
+	resolvedLock sync.Mutex
+)
+
+// ParseResolver parses a resolver spec: empty for the system resolver,
+// a DNS server such as 1.1.1.1 or 8.8.8.8:53, or a DoH URL such as https://1.1.1.1/dns-query
+func ParseResolver(spec string) (Resolver, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "" || spec == "system":
+		return net.DefaultResolver, nil
+	case strings.HasPrefix(spec, "https://"):
+		return NewDoHResolver(spec), nil
+	default:
+		server := spec
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		host, _, _ := net.SplitHostPort(server)
+		if net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("无效的 DNS 服务器: %s (应为 IP 地址或 https:// 开头的 DoH 地址)", spec)
+		}
+		return NewDNSResolver(server), nil
+	}
+}
+
+// NewDNSResolver creates a resolver that queries the given DNS server directly
+func NewDNSResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// SetResolver sets the resolver used by all clients, name is shown in reports
+func SetResolver(r Resolver, name string) {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	resolver = r
+	resolverName = name
+}
+
+// SetIPVersion restricts all connections to an address family, it should be called before any request
+func SetIPVersion(v IPVersion) {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	ipVersion = v
+}
+
+// GetIPVersion returns the address family restriction
+func GetIPVersion() IPVersion {
+	resolverLock.RLock()
+	defer resolverLock.RUnlock()
+	return ipVersion
+}
+
+// ResolverName returns the name of the current resolver
+func ResolverName() string {
+	resolverLock.RLock()
+	defer resolverLock.RUnlock()
+	return resolverName
+}
+
+func getResolver() Resolver {
+	resolverLock.RLock()
+	defer resolverLock.RUnlock()
+	return resolver
+}
+
+// LookupHost resolves host with the current resolver and records the result,
+// only addresses of the configured IP version are returned
+func LookupHost(ctx context.Context, host string) ([]string, error) {
+	version := GetIPVersion()
+	if ip := net.ParseIP(host); ip != nil {
+		if !version.Matches(ip) {
+			return nil, fmt.Errorf("%s 不是 IPv%s 地址", host, version)
+		}
+		return []string{ip.String()}, nil
+	}
+
+	addrs, err := getResolver().LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("解析 %s 失败: 无记录", host)
+	}
+
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if version.Matches(addr.IP) {
+			ips = append(ips, addr.IP.String())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s 没有 IPv%s 地址", host, version)
+	}
+
+	resolvedLock.Lock()
+	resolved[host] = ips
+	resolvedLock.Unlock()
+	return ips, nil
+}
+
+// LookupIPAddr resolves host with the current resolver, regardless of the IP version
+func LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+	return getResolver().LookupIPAddr(ctx, host)
+}
+
+// Resolved returns the addresses host last resolved to
+func Resolved(host string) []string {
+	resolvedLock.Lock()
+	defer resolvedLock.Unlock()
+	return resolved[host]
+}
+
+// DialContext dials addr, resolving the host with the current resolver
+func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var d net.Dialer
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// NewTransport creates a transport that resolves hosts with the current resolver
+func NewTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = DialContext
+	return t
+}
+
+// NewProxyRoundTripper creates a round tripper like NewRoundTripper that sends all requests through proxy
+func NewProxyRoundTripper(proxy *url.URL) http.RoundTripper {
+	t := NewTransport()
+	t.Proxy = http.ProxyURL(proxy)
+	return &tracingTransport{base: t}
+}
+
+// New creates an HTTP client that resolves hosts with the current resolver
+// and records the connection used for each host
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewRoundTripper(),
+	}
+}
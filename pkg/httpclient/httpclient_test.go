@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResolver(t *testing.T) {
+	r, err := ParseResolver("")
+	assert.NoError(t, err)
+	assert.Equal(t, net.DefaultResolver, r)
+
+	r, err = ParseResolver("1.1.1.1")
+	assert.NoError(t, err)
+	assert.IsType(t, &net.Resolver{}, r)
+
+	r, err = ParseResolver("[2606:4700:4700::1111]:53")
+	assert.NoError(t, err)
+	assert.IsType(t, &net.Resolver{}, r)
+
+	r, err = ParseResolver("https://1.1.1.1/dns-query")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://1.1.1.1/dns-query", r.(*DoHResolver).URL)
+
+	_, err = ParseResolver("dns.google")
+	assert.Error(t, err)
+}
+
+func TestIPVersion(t *testing.T) {
+	v, err := ParseIPVersion("6")
+	assert.NoError(t, err)
+	assert.Equal(t, IPVersion6, v)
+	assert.True(t, v.Matches(net.ParseIP("2606:4700::1111")))
+	assert.False(t, v.Matches(net.ParseIP("1.1.1.1")))
+
+	v, err = ParseIPVersion("auto")
+	assert.NoError(t, err)
+	assert.True(t, v.Matches(net.ParseIP("1.1.1.1")))
+
+	_, err = ParseIPVersion("5")
+	assert.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"github.com/go-coders/check-gpt/pkg/schema"
+)
+
+// MaxMirrorBody is the number of body bytes kept per request and response
+const MaxMirrorBody = 16 * 1024
+
+// MirrorEntry is a single request/response exchange in the mirror file
+type MirrorEntry struct {
+	SchemaVersion   string              `json:"schema_version"`
+	ID              int                 `json:"id"`
+	Time            time.Time           `json:"time"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	RequestHeaders  map[string][]string `json:"request_headers,omitempty"`
+	RequestBody     string              `json:"request_body,omitempty"`
+	Status          int                 `json:"status,omitempty"`
+	ResponseHeaders map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody    string              `json:"response_body,omitempty"`
+	Truncated       bool                `json:"truncated,omitempty"`
+	HeadersMs       float64             `json:"headers_ms"`  // 收到响应头的耗时
+	DurationMs      float64             `json:"duration_ms"` // 读完响应体的耗时
+	Error           string              `json:"error,omitempty"`
+	Run             *schema.Run         `json:"run,omitempty"`
+}
+
+// Mirror writes every exchange of the shared clients to a JSONL file with credentials redacted
+type Mirror struct {
+	mu  sync.Mutex
+	f   *os.File
+	seq int
+	run *schema.Run // 写入每条记录的运行信息
+}
+
+var (
+	mirror     *Mirror
+	mirrorLock sync.RWMutex
+)
+
+// OpenMirror opens path for appending mirrored traffic
+func OpenMirror(path string) (*Mirror, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建目录失败: %v", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("打开流量镜像文件失败: %v", err)
+	}
+	return &Mirror{f: f, run: schema.NewRun("")}, nil
+}
+
+// Close closes the mirror file
+func (m *Mirror) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.f.Close()
+}
+
+// SetMirror sets the mirror all shared clients write to, nil disables mirroring
+func SetMirror(m *Mirror) {
+	mirrorLock.Lock()
+	defer mirrorLock.Unlock()
+	mirror = m
+}
+
+func getMirror() *Mirror {
+	mirrorLock.RLock()
+	defer mirrorLock.RUnlock()
+	return mirror
+}
+
+// nextID returns the id of the next exchange
+func (m *Mirror) nextID() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	return m.seq
+}
+
+// write appends the entry to the file
+func (m *Mirror) write(e *MirrorEntry) {
+	e.SchemaVersion = schema.Version
+	data, err := json.Marshal(e)
+	if err != nil {
+		logger.Debug("Failed to marshal mirror entry: %v", err)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.f.Write(append(data, '\n')); err != nil {
+		logger.Debug("Failed to write mirror entry: %v", err)
+	}
+}
+
+// roundTrip performs the request through base and mirrors the exchange.
+// The response body is mirrored as the caller reads it, so streaming is not delayed.
+func (m *Mirror) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	e := &MirrorEntry{
+		ID:             m.nextID(),
+		Time:           start,
+		Method:         req.Method,
+		URL:            logger.Scrub(req.URL.String()),
+		RequestHeaders: scrubHeader(req.Header),
+		Run:            m.run,
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, MaxMirrorBody+1))
+			body.Close()
+			e.RequestBody, e.Truncated = redactBody(data)
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	e.HeadersMs = milliseconds(time.Since(start))
+	if err != nil {
+		e.DurationMs = e.HeadersMs
+		e.Error = logger.Scrub(err.Error())
+		m.write(e)
+		return nil, err
+	}
+
+	e.Status = resp.StatusCode
+	e.ResponseHeaders = scrubHeader(resp.Header)
+	resp.Body = &mirrorBody{ReadCloser: resp.Body, mirror: m, entry: e, start: start}
+	return resp, nil
+}
+
+// mirrorBody copies the response body as it is read and writes the entry on Close
+type mirrorBody struct {
+	io.ReadCloser
+	mirror *Mirror
+	entry  *MirrorEntry
+	start  time.Time
+	buf    bytes.Buffer
+	once   sync.Once
+}
+
+func (b *mirrorBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := MaxMirrorBody + 1 - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if err != nil && err != io.EOF {
+		b.entry.Error = logger.Scrub(err.Error())
+	}
+	return n, err
+}
+
+func (b *mirrorBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.entry.DurationMs = milliseconds(time.Since(b.start))
+		var truncated bool
+		b.entry.ResponseBody, truncated = redactBody(b.buf.Bytes())
+		b.entry.Truncated = b.entry.Truncated || truncated
+		b.mirror.write(b.entry)
+	})
+	return err
+}
+
+// scrubHeader returns a copy of h with credentials redacted
+func scrubHeader(h http.Header) map[string][]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			// Scrub with the header name so credential headers are recognized
+			scrubbed := logger.Scrub(name + ": " + v)
+			if rest, ok := strings.CutPrefix(scrubbed, name+": "); ok {
+				scrubbed = rest
+			}
+			out[name] = append(out[name], scrubbed)
+		}
+	}
+	return out
+}
+
+// redactBody scrubs credentials from a body and cuts it to MaxMirrorBody
+func redactBody(data []byte) (string, bool) {
+	truncated := len(data) > MaxMirrorBody
+	if truncated {
+		data = data[:MaxMirrorBody]
+	}
+	return logger.Scrub(string(data)), truncated
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirror(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret-session")
+		w.Write([]byte(`{"usage":{"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "mirror.jsonl")
+	m, err := OpenMirror(path)
+	assert.NoError(t, err)
+	SetMirror(m)
+	defer SetMirror(nil)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", bytes.NewBufferString(`{"model":"gpt-4o"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer sk-abcdefghijklmnop")
+
+	resp, err := New(5 * time.Second).Do(req)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, `{"usage":{"total_tokens":2}}`, string(body))
+	assert.NoError(t, m.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-abcdefghijklmnop")
+	assert.NotContains(t, string(data), "secret-session")
+
+	var e MirrorEntry
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(data))), &e))
+	assert.Equal(t, 1, e.ID)
+	assert.Equal(t, http.MethodPost, e.Method)
+	assert.Equal(t, http.StatusOK, e.Status)
+	assert.Equal(t, `{"model":"gpt-4o"}`, e.RequestBody)
+	assert.Equal(t, `{"usage":{"total_tokens":2}}`, e.ResponseBody)
+	assert.Equal(t, []string{"***"}, e.RequestHeaders["Authorization"])
+	if assert.NotNil(t, e.Run) {
+		assert.NotEmpty(t, e.Run.ToolVersion)
+	}
+}
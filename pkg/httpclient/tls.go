@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Certificate describes a certificate in the chain presented by a server
+type Certificate struct {
+	Subject    string    `json:"subject"`
+	Issuer     string    `json:"issuer"`
+	SANs       []string  `json:"sans,omitempty"`
+	NotAfter   time.Time `json:"not_after"`
+	SelfSigned bool      `json:"self_signed,omitempty"`
+}
+
+// TLSInfo describes the TLS setup of a server
+type TLSInfo struct {
+	Chain       []Certificate `json:"chain"`
+	Verified    bool          `json:"verified"`
+	VerifyError string        `json:"verify_error,omitempty"`
+}
+
+// InspectTLS connects to addr and returns the certificate chain it presents.
+// Verification is done separately so that invalid chains can still be shown.
+func InspectTLS(ctx context.Context, addr string) (*TLSInfo, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer raw.Close()
+
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("TLS 握手失败: %v", err)
+	}
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("服务器未返回证书")
+	}
+
+	info := &TLSInfo{}
+	for _, cert := range certs {
+		info.Chain = append(info.Chain, Certificate{
+			Subject:    certName(cert.Subject.CommonName, cert.Subject.Organization),
+			Issuer:     certName(cert.Issuer.CommonName, cert.Issuer.Organization),
+			SANs:       cert.DNSNames,
+			NotAfter:   cert.NotAfter,
+			SelfSigned: cert.CheckSignatureFrom(cert) == nil,
+		})
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates}); err != nil {
+		info.VerifyError = err.Error()
+	} else {
+		info.Verified = true
+	}
+	return info, nil
+}
+
+// certName returns the common name, falling back to the organization
+func certName(cn string, org []string) string {
+	if cn == "" && len(org) > 0 {
+		return org[0]
+	}
+	return cn
+}
@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+)
+
+// Connection describes the connection actually used to reach a host
+type Connection struct {
+	Host       string `json:"host"`
+	IP         string `json:"ip"`
+	SNI        string `json:"sni,omitempty"`
+	TLSVersion string `json:"tls_version,omitempty"`
+	Subject    string `json:"subject,omitempty"`
+	Issuer     string `json:"issuer,omitempty"`
+}
+
+var (
+	connections     = make(map[string]Connection)
+	connectionsLock sync.Mutex
+)
+
+// LastConnection returns the last connection used to reach host
+func LastConnection(host string) (Connection, bool) {
+	connectionsLock.Lock()
+	defer connectionsLock.Unlock()
+	c, ok := connections[host]
+	return c, ok
+}
+
+// tracingTransport records the connection used by every request
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// NewRoundTripper creates a transport that resolves hosts with the current resolver
+// and records the connection used for each host
+func NewRoundTripper() http.RoundTripper {
+	return &tracingTransport{base: NewTransport()}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			recordConnection(host, info.Conn)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	if m := getMirror(); m != nil {
+		return m.roundTrip(t.base, req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// recordConnection stores the remote address and TLS details of conn
+func recordConnection(host string, conn net.Conn) {
+	c := Connection{Host: host}
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		c.IP = addr.IP.String()
+	} else if conn.RemoteAddr() != nil {
+		c.IP, _, _ = net.SplitHostPort(conn.RemoteAddr().String())
+	}
+
+	if tc, ok := conn.(*tls.Conn); ok {
+		state := tc.ConnectionState()
+		c.SNI = state.ServerName
+		c.TLSVersion = tls.VersionName(state.Version)
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			c.Subject = cert.Subject.CommonName
+			c.Issuer = cert.Issuer.CommonName
+			if c.Issuer == "" && len(cert.Issuer.Organization) > 0 {
+				c.Issuer = cert.Issuer.Organization[0]
+			}
+		}
+	}
+
+	connectionsLock.Lock()
+	connections[host] = c
+	connectionsLock.Unlock()
+}
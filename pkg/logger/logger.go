@@ -16,8 +16,9 @@ func Init(debug bool) {
 	isDebug = debug
 }
 
+// Debug logs a message in debug mode, credentials are scrubbed from the output
 func Debug(format string, v ...interface{}) {
 	if isDebug && debugLogger != nil {
-		debugLogger.Output(2, fmt.Sprintf(format, v...))
+		debugLogger.Output(2, Scrub(fmt.Sprintf(format, v...)))
 	}
 }
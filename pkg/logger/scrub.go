@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces scrubbed values in debug output
+const Redacted = "***"
+
+var (
+	secrets     = make(map[string]bool)
+	secretsLock sync.RWMutex
+
+	// Authorization: Bearer xxx, x-api-key: xxx, Set-Cookie: xxx
+	headerPattern = regexp.MustCompile(`(?i)((?:authorization|proxy-authorization|x-api-key|x-goog-api-key|api-key|cookie|set-cookie)["']?\s*[:=]\s*["'\[]?)(?:bearer\s+|basic\s+)?[^\s"',;\]]+`)
+	// ?key=xxx and &key=xxx in URLs
+	queryPattern = regexp.MustCompile(`(?i)([?&](?:key|api_key|access_token|token)=)[^&\s"']+`)
+	// Bearer xxx outside of headers, e.g. in %+v dumps
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[^\s"',;\]]+`)
+	// Well known key formats
+	keyPattern = regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_\-]{8,}|AIza[A-Za-z0-9_\-]{20,})`)
+)
+
+// AddSecret registers a value that must never appear in debug output
+func AddSecret(s string) {
+	if s == "" {
+		return
+	}
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+	secrets[s] = true
+}
+
+// Scrub removes credentials from s
+func Scrub(s string) string {
+	secretsLock.RLock()
+	registered := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		registered = append(registered, secret)
+	}
+	secretsLock.RUnlock()
+
+	// Longer secrets first so a key is not partially replaced by its prefix
+	sort.Slice(registered, func(i, j int) bool { return len(registered[i]) > len(registered[j]) })
+	for _, secret := range registered {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+
+	s = headerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = queryPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	return keyPattern.ReplaceAllString(s, Redacted)
+}
+
+// DebugRequest logs the method, URL and headers of a request with credentials scrubbed
+func DebugRequest(req *http.Request) {
+	if !isDebug || debugLogger == nil || req == nil {
+		return
+	}
+	debugLogger.Output(2, Scrub("request: "+req.Method+" "+req.URL.String()+formatHeader(req.Header)))
+}
+
+// DebugResponse logs the status and headers of a response with credentials scrubbed
+func DebugResponse(resp *http.Response) {
+	if !isDebug || debugLogger == nil || resp == nil {
+		return
+	}
+	debugLogger.Output(2, Scrub("response: "+resp.Status+formatHeader(resp.Header)))
+}
+
+// formatHeader formats headers in a stable order, one per line
+func formatHeader(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		for _, v := range h[name] {
+			b.WriteString("\n  " + name + ": " + v)
+		}
+	}
+	return b.String()
+}
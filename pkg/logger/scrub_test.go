@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrub(t *testing.T) {
+	AddSecret("my-custom-relay-token")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"Authorization header", "Authorization: Bearer abc.def", "Authorization: ***"},
+		{"Gemini key in URL", "https://generativelanguage.googleapis.com/v1beta/models?key=AIzaSyA-1234567890abcdefgh&alt=sse", "https://generativelanguage.googleapis.com/v1beta/models?key=***&alt=sse"},
+		{"Set-Cookie", "Set-Cookie: session=abcdef; Path=/", "Set-Cookie: ***; Path=/"},
+		{"Bearer in dump", "map[Authorization:[Bearer xyz123]]", "map[Authorization:[***]]"},
+		{"OpenAI key", "key sk-abcdefghijklmnop invalid", "key *** invalid"},
+		{"Registered secret", "Key:my-custom-relay-token URL:x", "Key:*** URL:x"},
+		{"Plain text", "receive request from: 1.2.3.4 GET", "receive request from: 1.2.3.4 GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Scrub(tt.in))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+// Run describes the run an export was written by, so an archived export stays interpretable months later
+type Run struct {
+	ToolVersion  string    `json:"tool_version"`
+	Timestamp    time.Time `json:"timestamp"`
+	HostnameHash string    `json:"hostname_hash,omitempty"` // 主机名的哈希, 区分运行的机器而不暴露主机名
+	ConfigDigest string    `json:"config_digest,omitempty"` // 运行参数 (不含凭据) 的摘要, 摘要相同的运行可直接比较
+	Target       string    `json:"target,omitempty"`        // 测试的端点或中转
+}
+
+// The tool version and config digest of the current process, set once at startup with SetRun
+var toolVersion, configDigest = "dev", ""
+
+// SetRun records the tool version and config digest stamped into every export of the process
+func SetRun(version, digest string) {
+	toolVersion, configDigest = version, digest
+}
+
+// NewRun returns the metadata of the current run for an export about target
+func NewRun(target string) *Run {
+	return &Run{
+		ToolVersion:  toolVersion,
+		Timestamp:    time.Now(),
+		HostnameHash: hostnameHash(),
+		ConfigDigest: configDigest,
+		Target:       target,
+	}
+}
+
+// Digest returns a short sha256 digest of s in the sha256:<hex> form of the exports
+func Digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// hostnameHash returns the digest of the hostname, empty when it is unknown
+func hostnameHash() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return ""
+	}
+	return Digest(name)
+}
@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Version is the version of the export formats, written as schema_version in every export.
+// It changes whenever a field is removed or its meaning changes, new optional fields keep it.
+const Version = "1"
+
+//go:embed *.schema.json
+var files embed.FS
+
+// Names returns the names of the available JSON Schema documents
+func Names() []string {
+	entries, _ := files.ReadDir(".")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".schema.json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the JSON Schema document of the named export
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile(name + ".schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("未知的 schema: %s (可选: %s)", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
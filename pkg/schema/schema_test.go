@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemas(t *testing.T) {
+	assert.Equal(t, []string{"mirror", "report", "runlog", "soak", "trace", "weights"}, Names())
+
+	for _, name := range Names() {
+		data, err := Get(name)
+		assert.NoError(t, err)
+
+		var doc struct {
+			ID         string `json:"$id"`
+			Properties map[string]struct {
+				Const string `json:"const"`
+			} `json:"properties"`
+			Required []string `json:"required"`
+		}
+		if assert.NoError(t, json.Unmarshal(data, &doc), name) {
+			assert.Contains(t, doc.ID, name+".schema.json")
+			assert.Equal(t, Version, doc.Properties["schema_version"].Const, name)
+			assert.Contains(t, doc.Required, "schema_version", name)
+		}
+	}
+
+	_, err := Get("nope")
+	assert.Error(t, err)
+}
+
+func TestNewRun(t *testing.T) {
+	defer SetRun("dev", "")
+	SetRun("v1.2.3", Digest("concurr=8"))
+	run := NewRun("https://relay.example.com")
+	assert.Equal(t, "v1.2.3", run.ToolVersion)
+	assert.Equal(t, Digest("concurr=8"), run.ConfigDigest)
+	assert.Equal(t, "https://relay.example.com", run.Target)
+	assert.False(t, run.Timestamp.IsZero())
+	assert.Regexp(t, `^sha256:[0-9a-f]{12}$`, run.ConfigDigest)
+	assert.NotEqual(t, Digest("concurr=4"), run.ConfigDigest)
+}
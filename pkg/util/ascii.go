@@ -0,0 +1,70 @@
+package util
+
+import (
+	"io"
+	"strings"
+)
+
+// asciiMode replaces emoji and box drawing in all printer output, set with -ascii
+var asciiMode bool
+
+// SetASCII turns the plain ASCII output on or off, it should be called before any output
+func SetASCII(on bool) {
+	asciiMode = on
+}
+
+// ASCII reports whether the output is plain ASCII markers instead of emoji
+func ASCII() bool {
+	return asciiMode
+}
+
+// asciiReplacer maps the emoji and box drawing characters of the output to ASCII markers,
+// the emoji with a variation selector come first so the selector is not left behind
+var asciiReplacer = strings.NewReplacer(
+	EmojiGear, "[*]",
+	EmojiWarning, "[WARN]",
+	EmojiCustom, "[EDIT]",
+	EmojiTool, "[TOOL]",
+	"️", "",
+	EmojiCheck, "[OK]",
+	EmojiError, "[FAIL]",
+	EmojiDone, "[DONE]",
+	EmojiCongratulation, "[OK]",
+	EmojiRocket, "[>>]",
+	EmojiStar, "[*]",
+	EmojiKey, "[KEY]",
+	EmojiLink, "[LINK]",
+	EmojiWave, "[BYE]",
+	EmojiAPI, "[API]",
+	EmojiSelect, "[o]",
+	EmojiLoading, "[..]",
+	EmojiDiamond, "[*]",
+	EmojiOpenAI, "[AI]",
+	EmojiBack, "[BACK]",
+	EmojiWatch, "[WATCH]",
+	"│", "|",
+	"─", "-",
+	"└", "`-",
+	"┌", "+", "┐", "+", "┘", "+", "├", "+", "┤", "+", "┬", "+", "┴", "+", "┼", "+",
+	"→", "->",
+	"…", "...",
+	"×", "x",
+)
+
+// ToASCII replaces emoji and box drawing in s with ASCII markers such as [OK] and [FAIL]
+func ToASCII(s string) string {
+	return asciiReplacer.Replace(s)
+}
+
+// asciiWriter converts everything written through it to ASCII markers
+type asciiWriter struct {
+	w io.Writer
+}
+
+// Write writes the converted text, reporting the length of the original on success
+func (a asciiWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(a.w, ToASCII(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,200 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CurlTemplate is a request parsed from a cURL command. Link detection replays its URL,
+// headers and body fields, only the prompt and images are replaced with the probe.
+type CurlTemplate struct {
+	URL     string
+	Headers http.Header            // 原样发送的请求头, 不含 Content-Type 和 Content-Length
+	Body    map[string]interface{} // 请求体字段, 除提示词和图片外覆盖探测请求的同名字段
+	Model   string
+	Key     string // 来自 Authorization、x-api-key 或 api-key 请求头
+}
+
+// Shape returns the request shape of the template URL
+func (t *CurlTemplate) Shape() RequestShape {
+	switch {
+	case strings.Contains(t.URL, "/responses"):
+		return ShapeResponses
+	case strings.Contains(t.URL, "/completions") && !strings.Contains(t.URL, "/chat/completions"):
+		return ShapeCompletions
+	default:
+		return ShapeChat
+	}
+}
+
+// Stream reports whether the template requests a streamed response
+func (t *CurlTemplate) Stream() bool {
+	stream, _ := t.Body["stream"].(bool)
+	return stream
+}
+
+// curl options followed by a value that does not matter for the replay
+var curlIgnoredValues = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"-x": true, "--proxy": true, "-w": true, "--write-out": true, "--retry": true, "-u": true, "--user": true,
+}
+
+// curl options setting a header
+var curlHeaderOptions = map[string]string{
+	"-A": "User-Agent", "--user-agent": "User-Agent",
+	"-e": "Referer", "--referer": "Referer",
+	"-b": "Cookie", "--cookie": "Cookie",
+}
+
+// ParseCurl parses a cURL command as copied from browser devtools or API docs
+func ParseCurl(cmd string) (*CurlTemplate, error) {
+	args, err := splitShell(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, fmt.Errorf("不是 curl 命令")
+	}
+
+	t := &CurlTemplate{Headers: make(http.Header)}
+	var body string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		name, value, inline := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "--") || !inline {
+			name, value = arg, ""
+		}
+		next := func() (string, error) {
+			if inline {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl 参数 %s 缺少值", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch {
+		case name == "-H" || name == "--header":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			key, val, ok := strings.Cut(v, ":")
+			if !ok {
+				return nil, fmt.Errorf("无效的请求头: %s", v)
+			}
+			t.Headers.Add(strings.TrimSpace(key), strings.TrimSpace(val))
+		case curlHeaderOptions[name] != "":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			t.Headers.Set(curlHeaderOptions[name], v)
+		case name == "-d" || name == "--data" || name == "--data-raw" || name == "--data-binary" || name == "--data-ascii" || name == "--json":
+			if body, err = next(); err != nil {
+				return nil, err
+			}
+		case name == "--url":
+			if t.URL, err = next(); err != nil {
+				return nil, err
+			}
+		case name == "-X" || name == "--request" || curlIgnoredValues[name]:
+			if _, err := next(); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(arg, "-"):
+			// Flags without a value such as --compressed, -s, -k or -L
+		default:
+			t.URL = arg
+		}
+	}
+
+	if t.URL == "" {
+		return nil, fmt.Errorf("curl 命令中缺少 URL")
+	}
+	if body == "" {
+		return nil, fmt.Errorf("curl 命令中缺少请求体 (-d)")
+	}
+	if err := json.Unmarshal([]byte(body), &t.Body); err != nil {
+		return nil, fmt.Errorf("curl 请求体不是 JSON: %v", err)
+	}
+	t.Model, _ = t.Body["model"].(string)
+	if t.Model == "" {
+		return nil, fmt.Errorf("curl 请求体中缺少 model 字段")
+	}
+
+	t.Headers.Del("Content-Type")
+	t.Headers.Del("Content-Length")
+	switch auth := t.Headers.Get("Authorization"); {
+	case strings.HasPrefix(auth, "Bearer "):
+		t.Key = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	case t.Headers.Get("x-api-key") != "":
+		t.Key = t.Headers.Get("x-api-key")
+	case t.Headers.Get("api-key") != "":
+		t.Key = t.Headers.Get("api-key")
+	}
+	if t.Key == "" {
+		return nil, fmt.Errorf("curl 命令中未找到 API Key (Authorization、x-api-key 或 api-key 请求头)")
+	}
+	return t, nil
+}
+
+// splitShell splits a command line like a POSIX shell: quotes, backslash escapes and line continuations
+func splitShell(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			if s[i] == '\n' || s[i] == '\r' {
+				// Line continuation, \r\n from Windows terminals included
+				if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+				continue
+			}
+			cur.WriteByte(s[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("curl 命令中的单引号未闭合")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("curl 命令中的双引号未闭合")
+			}
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
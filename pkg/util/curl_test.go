@@ -0,0 +1,71 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCurl(t *testing.T) {
+	cmd := `curl https://relay.example.com/v1/chat/completions \
+  -H 'Content-Type: application/json' \
+  -H "Authorization: Bearer sk-test" \
+  -A 'my-app/2.1' --compressed \
+  -d '{"model":"gpt-4o","temperature":0.2,"user":"u-42","messages":[{"role":"user","content":"hi"}]}'`
+
+	tmpl, err := ParseCurl(cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://relay.example.com/v1/chat/completions", tmpl.URL)
+	assert.Equal(t, "sk-test", tmpl.Key)
+	assert.Equal(t, "gpt-4o", tmpl.Model)
+	assert.Equal(t, "my-app/2.1", tmpl.Headers.Get("User-Agent"))
+	assert.Empty(t, tmpl.Headers.Get("Content-Type"))
+	assert.Equal(t, ShapeChat, tmpl.Shape())
+	assert.False(t, tmpl.Stream())
+
+	tmpl, err = ParseCurl(`curl --url=https://relay.example.com/v1/responses --header "x-api-key: sk-ant" --data-raw "{\"model\":\"o3\",\"stream\":true}"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-ant", tmpl.Key)
+	assert.Equal(t, ShapeResponses, tmpl.Shape())
+	assert.True(t, tmpl.Stream())
+
+	for _, bad := range []string{
+		`wget https://relay.example.com`,
+		`curl https://relay.example.com -H 'Authorization: Bearer sk-test'`,
+		`curl https://relay.example.com -H 'Authorization: Bearer sk-test' -d '{"messages":[]}'`,
+		`curl https://relay.example.com -d '{"model":"gpt-4o"}'`,
+		`curl https://relay.example.com -d '{"model":"gpt-4o}`,
+	} {
+		_, err := ParseCurl(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestChatRequestReplaysTemplate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-app/2.1", r.Header.Get("User-Agent"))
+		assert.Equal(t, "org-1", r.Header.Get("OpenAI-Organization"))
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, 0.2, req["temperature"])
+		assert.Equal(t, "gpt-4o", req["model"])
+		// The probe replaces the messages of the template
+		assert.Len(t, req["messages"].([]interface{})[0].(map[string]interface{})["content"], 2)
+		w.Write([]byte(`{"choices":[{"message":{"content":"1234"}}]}`))
+	}))
+	defer srv.Close()
+
+	tmpl, err := ParseCurl(`curl ` + srv.URL + `/v1/chat/completions -H 'Authorization: Bearer sk-test' -H 'OpenAI-Organization: org-1' -A my-app/2.1 -d '{"model":"gpt-4o","temperature":0.2,"messages":[{"role":"user","content":"hi"}]}'`)
+	assert.NoError(t, err)
+
+	c := NewClient(20, false, 5*time.Second)
+	c.Template, c.Shape = tmpl, tmpl.Shape()
+	resp := c.ChatRequest(context.Background(), "what's the number?", tmpl.URL, []string{"https://tunnel.example.com/image"}, tmpl.Key, tmpl.Model)
+	assert.NoError(t, resp.Error)
+	assert.Equal(t, "1234", resp.Response)
+}
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/logger"
+)
+
+// EndpointProbeTimeout bounds each endpoint probe
+const EndpointProbeTimeout = 5 * time.Second
+
+// endpointSuffixes are stripped from the input to find the API root, longest first
+var endpointSuffixes = []string{
+	"/v1/chat/completions", "/chat/completions", "/v1/responses", "/responses",
+	"/v1/completions", "/completions", "/v1/chat", "/chat", "/v1",
+}
+
+// azureHostSuffixes identify Azure OpenAI resources
+var azureHostSuffixes = []string{".openai.azure.com", ".cognitiveservices.azure.com"}
+
+// EndpointCandidates returns the endpoints the input URL may refer to, most likely first.
+// An explicit endpoint path is kept as typed, NormalizeURL would append /v1 to versioned bases like /api/paas/v4.
+func EndpointCandidates(raw string) []string {
+	u := strings.TrimRight(strings.TrimSpace(raw), "/ ")
+	if u == "" {
+		return nil
+	}
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		u = "https://" + u
+	}
+	root := u
+	for _, suffix := range endpointSuffixes {
+		if strings.HasSuffix(root, suffix) {
+			root = strings.TrimSuffix(root, suffix)
+			break
+		}
+	}
+
+	var candidates []string
+	add := func(c string) {
+		for _, existing := range candidates {
+			if existing == c {
+				return
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	if isAzureHost(u) {
+		add(root + "/openai/v1/chat/completions")
+	}
+	if strings.HasSuffix(u, "/chat/completions") || strings.HasSuffix(u, "/responses") {
+		add(u)
+	}
+	add(NormalizeURL(u))
+	add(root + "/chat/completions")
+	add(root + "/api/v1/chat/completions")
+	add(root + "/v1/responses")
+	return candidates
+}
+
+// ResolveEndpoint probes the candidate endpoints of the input URL and returns the first one the
+// server serves. It falls back to NormalizeURL when the server is unreachable or answers none.
+func ResolveEndpoint(ctx context.Context, raw string) string {
+	if custom, ok := customURL(raw); ok {
+		return custom
+	}
+	candidates := EndpointCandidates(raw)
+	if len(candidates) == 0 {
+		return ""
+	}
+	fallback := NormalizeURL(raw)
+
+	client := httpclient.New(EndpointProbeTimeout)
+	for _, candidate := range candidates {
+		exists, err := probeEndpoint(ctx, client, candidate)
+		if err != nil {
+			// The host itself is unreachable, the other paths will not answer either
+			logger.Debug("Endpoint probe failed: %v", err)
+			return fallback
+		}
+		if exists {
+			logger.Debug("Endpoint detected: %s", candidate)
+			return candidate
+		}
+	}
+	return fallback
+}
+
+// probeEndpoint sends an empty unauthenticated POST to the endpoint. An existing endpoint rejects it
+// with 400/401/422 and costs nothing, a missing one answers 404/405 or the site's HTML page.
+func probeEndpoint(ctx context.Context, client *http.Client, endpoint string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, EndpointProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader("{}"))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return false, nil
+	}
+	return !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html"), nil
+}
+
+// isAzureHost reports whether the URL points to an Azure OpenAI resource
+func isAzureHost(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, suffix := range azureHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
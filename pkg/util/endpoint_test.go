@@ -0,0 +1,46 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCandidates(t *testing.T) {
+	assert.Equal(t, []string{
+		"https://api.example.com/v1/chat/completions",
+		"https://api.example.com/chat/completions",
+		"https://api.example.com/api/v1/chat/completions",
+		"https://api.example.com/v1/responses",
+	}, EndpointCandidates("api.example.com/v1"))
+
+	// An explicit versioned endpoint is tried as typed first
+	candidates := EndpointCandidates("https://open.example.com/api/paas/v4/chat/completions")
+	assert.Equal(t, "https://open.example.com/api/paas/v4/chat/completions", candidates[0])
+
+	candidates = EndpointCandidates("https://res.openai.azure.com/")
+	assert.Equal(t, "https://res.openai.azure.com/openai/v1/chat/completions", candidates[0])
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/chat/completions", "/v4/chat/completions":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/v1/chat/completions":
+			// SPA fallback of the relay's web page
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	assert.Equal(t, srv.URL+"/api/v1/chat/completions", ResolveEndpoint(context.Background(), srv.URL))
+	assert.Equal(t, srv.URL+"/v4/chat/completions", ResolveEndpoint(context.Background(), srv.URL+"/v4/chat/completions"))
+	assert.Equal(t, srv.URL+"/missing/v1/chat/completions", ResolveEndpoint(context.Background(), srv.URL+"/missing"))
+}
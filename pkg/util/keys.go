@@ -0,0 +1,14 @@
+package util
+
+import "context"
+
+// ListenKeys reads single key presses from the terminal until ctx is done.
+// The terminal leaves line mode while listening, Ctrl+C keeps working, and the channel
+// is closed once the terminal has been restored. It returns nil when stdin is not a
+// terminal or the platform is not supported.
+func ListenKeys(ctx context.Context) <-chan byte {
+	if !IsInteractive() {
+		return nil
+	}
+	return listenKeys(ctx)
+}
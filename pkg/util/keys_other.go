@@ -0,0 +1,10 @@
+//go:build !darwin && !linux
+
+package util
+
+import "context"
+
+// listenKeys returns nil since key presses are only read on Linux and macOS
+func listenKeys(ctx context.Context) <-chan byte {
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build darwin || linux
+
+package util
+
+import (
+	"context"
+	"os"
+
+	"github.com/go-coders/check-gpt/pkg/logger"
+	"golang.org/x/sys/unix"
+)
+
+// listenKeys switches off canonical mode and echo, reads time out every 100ms to notice ctx
+func listenKeys(ctx context.Context) <-chan byte {
+	fd := int(os.Stdin.Fd())
+	old, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		logger.Debug("Failed to read terminal state: %v", err)
+		return nil
+	}
+	raw := *old
+	raw.Lflag &^= unix.ICANON | unix.ECHO
+	raw.Cc[unix.VMIN] = 0
+	raw.Cc[unix.VTIME] = 1
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		logger.Debug("Failed to set terminal state: %v", err)
+		return nil
+	}
+
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		defer unix.IoctlSetTermios(fd, ioctlSetTermios, old)
+		buf := make([]byte, 1)
+		for ctx.Err() == nil {
+			n, err := unix.Read(fd, buf)
+			if err != nil && err != unix.EINTR {
+				return
+			}
+			if n == 0 {
+				continue
+			}
+			select {
+			case keys <- buf[0]:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return keys
+}
@@ -1,24 +1,111 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"sync"
 )
 
-// MaskKey masks a key string by showing only the first and last n characters
-func MaskKey(key string, firstN, lastN int) string {
-	if key == "" {
-		return ""
+// MaskMode represents how keys are displayed
+type MaskMode string
+
+const (
+	MaskModePartial MaskMode = "partial" // 显示首尾 N 个字符
+	MaskModeHash    MaskMode = "hash"    // 仅显示哈希
+	MaskModeFull    MaskMode = "full"    // 显示完整 Key
+)
+
+// MaskPolicy controls how keys are masked in all output
+type MaskPolicy struct {
+	Mode  MaskMode
+	First int
+	Last  int
+}
+
+// DefaultMaskPolicy returns the default mask policy
+func DefaultMaskPolicy() MaskPolicy {
+	return MaskPolicy{
+		Mode:  MaskModePartial,
+		First: 4,
+		Last:  4,
 	}
+}
 
-	keyLen := len(key)
-	if keyLen <= firstN+lastN {
+var (
+	maskPolicy     = DefaultMaskPolicy()
+	maskPolicyLock sync.RWMutex
+)
+
+// ParseMaskMode parses a mask mode name
+func ParseMaskMode(s string) (MaskMode, error) {
+	switch mode := MaskMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case MaskModePartial, MaskModeHash, MaskModeFull:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("无效的掩码模式: %s (可选: partial, hash, full)", s)
+	}
+}
+
+// SetMaskPolicy sets the global mask policy
+func SetMaskPolicy(p MaskPolicy) {
+	maskPolicyLock.Lock()
+	defer maskPolicyLock.Unlock()
+	maskPolicy = p
+}
+
+// GetMaskPolicy returns the global mask policy
+func GetMaskPolicy() MaskPolicy {
+	maskPolicyLock.RLock()
+	defer maskPolicyLock.RUnlock()
+	return maskPolicy
+}
+
+// NoAuthKey stands in for the key of a local endpoint that takes no Authorization header,
+// it is entered at the key prompt and, not being a secret, shown as is
+const NoAuthKey = "local"
+
+// MaskKey masks a key string according to the global mask policy
+func MaskKey(key string) string {
+	return GetMaskPolicy().Mask(key)
+}
+
+// Mask masks a key string according to the policy
+func (p MaskPolicy) Mask(key string) string {
+	if key == "" || key == NoAuthKey {
+		return key
+	}
+
+	switch p.Mode {
+	case MaskModeFull:
 		return key
+	case MaskModeHash:
+		sum := sha256.Sum256([]byte(key))
+		return "sha256:" + hex.EncodeToString(sum[:])[:12]
+	}
+
+	// Negative counts would slice out of range, show nothing of that end instead
+	first, last := max(p.First, 0), max(p.Last, 0)
+	keyLen := len(key)
+	if keyLen <= first+last {
+		return strings.Repeat("*", 3)
 	}
 
-	firstPart := key[:firstN]
-	lastPart := key[keyLen-lastN:]
+	firstPart := key[:first]
+	lastPart := key[keyLen-last:]
 	maskedPart := strings.Repeat("*", 3)
 
 	return fmt.Sprintf("%s%s%s", firstPart, maskedPart, lastPart)
 }
+
+// MaskSecrets replaces every occurrence of the keys in s with their masked form
+func MaskSecrets(s string, keys ...string) string {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, key, MaskKey(key))
+	}
+	return s
+}
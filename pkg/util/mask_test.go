@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy MaskPolicy
+		key    string
+		want   string
+	}{
+		{"Partial", MaskPolicy{Mode: MaskModePartial, First: 4, Last: 4}, "sk-abcdefghijklmnop", "sk-a***mnop"},
+		{"Partial custom", MaskPolicy{Mode: MaskModePartial, First: 6, Last: 2}, "sk-abcdefghijklmnop", "sk-abc***op"},
+		{"Full", MaskPolicy{Mode: MaskModeFull}, "sk-abcdefghijklmnop", "sk-abcdefghijklmnop"},
+		{"Hash", MaskPolicy{Mode: MaskModeHash}, "abc", "sha256:ba7816bf8f01"},
+		{"Short key", MaskPolicy{Mode: MaskModePartial, First: 4, Last: 4}, "sk-abc", "***"},
+		{"No auth", MaskPolicy{Mode: MaskModeHash}, NoAuthKey, NoAuthKey},
+		{"Negative", MaskPolicy{Mode: MaskModePartial, First: -2, Last: 4}, "sk-abcdefghijklmnop", "***mnop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.Mask(tt.key))
+		})
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	defer SetMaskPolicy(DefaultMaskPolicy())
+	SetMaskPolicy(DefaultMaskPolicy())
+
+	msg := "Incorrect API key provided: sk-abcdefghijklmnop"
+	assert.Equal(t, "Incorrect API key provided: sk-a***mnop", MaskSecrets(msg, "sk-abcdefghijklmnop"))
+	assert.Equal(t, msg, MaskSecrets(msg, ""))
+}
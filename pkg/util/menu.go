@@ -17,6 +17,7 @@ const (
 	EmojiTool    = "🛠️"
 	EmojiUpdate  = "🚀"
 	EmojiBack    = "🔙"
+	EmojiWatch   = "⏰"
 )
 
 // MenuItem represents a menu item
@@ -65,12 +66,13 @@ var (
 		Items: []MenuItem{
 			{ID: 1, Label: "API Key 可用性测试", Emoji: EmojiKey},
 			{ID: 2, Label: "API 中转链路检测", Emoji: EmojiLink},
-			{ID: 3, Label: "检查更新", Emoji: EmojiGear},
-			{ID: 4, Label: "退出", Emoji: EmojiExit},
+			{ID: 3, Label: "Key 监控", Emoji: EmojiWatch},
+			{ID: 4, Label: "检查更新", Emoji: EmojiGear},
+			{ID: 5, Label: "退出", Emoji: EmojiExit},
 		},
-		Prompt: "请选择功能 (1-4): ",
+		Prompt: "请选择功能 (1-5): ",
 		ValidChoice: func(choice string) bool {
-			return choice >= "1" && choice <= "4"
+			return choice >= "1" && choice <= "5"
 		},
 	}
 
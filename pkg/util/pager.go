@@ -0,0 +1,50 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// DefaultPager is used when $PAGER is not set, -R keeps the colors
+const DefaultPager = "less -R"
+
+// Page prints content to stdout. When stdout is a terminal and the content is taller
+// than the terminal, it is shown through $PAGER so it doesn't scroll off screen.
+func Page(content string) error {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Print(content)
+		return nil
+	}
+
+	_, height, err := term.GetSize(fd)
+	if err != nil || strings.Count(content, "\n") < height-1 {
+		fmt.Print(content)
+		return nil
+	}
+
+	pager := strings.TrimSpace(os.Getenv("PAGER"))
+	if pager == "" {
+		pager = DefaultPager
+	}
+	args := strings.Fields(pager)
+	if _, err := exec.LookPath(args[0]); err != nil {
+		fmt.Print(content)
+		return nil
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// Fall back to plain output so the report is never lost
+		fmt.Print(content)
+		return fmt.Errorf("分页器运行失败: %v", err)
+	}
+	return nil
+}
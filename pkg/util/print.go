@@ -47,15 +47,39 @@ const (
 	SeparatorWidth = 80
 )
 
-// GetSeparator returns a separator line of standard width
+// GetSeparator returns a separator line of standard width, narrowed to fit the terminal
 func GetSeparator() string {
-	return strings.Repeat(SeparatorChar, SeparatorWidth)
+	return strings.Repeat(SeparatorChar, Min(SeparatorWidth, TerminalWidth()))
 }
 
 func ClearConsole() {
+	if GetVerbosity() < VerbosityNormal {
+		return
+	}
 	fmt.Print("\033[H\033[2J")
 }
 
+// Verbosity represents the output level of all printers
+type Verbosity int
+
+const (
+	VerbosityQuiet   Verbosity = iota // 仅输出错误
+	VerbositySummary                  // 错误、警告和每个 Key 一行的结论
+	VerbosityNormal                   // 完整输出
+)
+
+var verbosity = VerbosityNormal
+
+// SetVerbosity sets the output level, it should be called before any output
+func SetVerbosity(v Verbosity) {
+	verbosity = v
+}
+
+// GetVerbosity returns the output level
+func GetVerbosity() Verbosity {
+	return verbosity
+}
+
 // Printer handles output formatting with configurable writer
 type Printer struct {
 	out io.Writer
@@ -71,7 +95,10 @@ func NewPrinter(w io.Writer) *Printer {
 
 // PrintTitle prints a title with an emoji and separator
 func (p *Printer) PrintTitle(title string, emoji string) {
-	fmt.Fprintf(p.out, "\n%s %s%s%s", emoji, ColorBold, title, ColorReset)
+	if verbosity < VerbosityNormal {
+		return
+	}
+	fmt.Fprintf(p.writer(), "\n%s %s%s%s", emoji, ColorBold, title, ColorReset)
 	p.PrintSeparator()
 }
 
@@ -83,17 +110,36 @@ func (p *Printer) PrintError(message string) {
 	if len(message) > maxErrorLength {
 		message = message[:maxErrorLength-3] + "..."
 	}
-	fmt.Fprintf(p.out, "%s%s %s%s\n", ColorRed, EmojiError, message, ColorReset)
+	fmt.Fprintf(p.writer(), "%s%s %s%s\n", ColorRed, EmojiError, message, ColorReset)
+}
+
+// ErrorPrintf formats and prints error details, shown at every verbosity
+func (p *Printer) ErrorPrintf(format string, args ...interface{}) {
+	fmt.Fprintf(p.writer(), format, args...)
+}
+
+// PrintSummary prints a one-line verdict, shown only at VerbositySummary
+func (p *Printer) PrintSummary(format string, args ...interface{}) {
+	if verbosity != VerbositySummary {
+		return
+	}
+	fmt.Fprintf(p.writer(), format+"\n", args...)
 }
 
 // PrintSuccess prints a success message
 func (p *Printer) PrintSuccess(message string) {
-	fmt.Fprintf(p.out, "\n%s%s %s%s\n", ColorGreen, EmojiDone, message, ColorReset)
+	if verbosity < VerbosityNormal {
+		return
+	}
+	fmt.Fprintf(p.writer(), "\n%s%s %s%s\n", ColorGreen, EmojiDone, message, ColorReset)
 }
 
 // PrintWarning prints a warning message
 func (p *Printer) PrintWarning(message string) {
-	fmt.Fprintf(p.out, "%s%s %s%s\n", ColorYellow, EmojiWarning, message, ColorReset)
+	if verbosity < VerbositySummary {
+		return
+	}
+	fmt.Fprintf(p.writer(), "%s%s %s%s\n", ColorYellow, EmojiWarning, message, ColorReset)
 }
 
 // FormatTitle formats a title with an emoji
@@ -103,17 +149,39 @@ func (p *Printer) FormatTitle(title string, emoji string) string {
 
 // Printf formats and prints a message
 func (p *Printer) Printf(format string, args ...interface{}) {
-	fmt.Fprintf(p.out, format, args...)
+	if verbosity < VerbosityNormal {
+		return
+	}
+	fmt.Fprintf(p.writer(), format, args...)
 }
 
 // Println prints a message with a newline
 func (p *Printer) Println(args ...interface{}) {
-	fmt.Fprintln(p.out, args...)
+	if verbosity < VerbosityNormal {
+		return
+	}
+	fmt.Fprintln(p.writer(), args...)
 }
 
 // Print prints a message
 func (p *Printer) Print(args ...interface{}) {
-	fmt.Fprint(p.out, args...)
+	if verbosity < VerbosityNormal {
+		return
+	}
+	fmt.Fprint(p.writer(), args...)
+}
+
+// Write writes already formatted output regardless of the verbosity
+func (p *Printer) Write(b []byte) (int, error) {
+	return p.writer().Write(b)
+}
+
+// writer returns the output, converting emoji and box drawing with -ascii
+func (p *Printer) writer() io.Writer {
+	if asciiMode {
+		return asciiWriter{p.out}
+	}
+	return p.out
 }
 
 // PrintSeparator prints a separator line
@@ -121,7 +189,8 @@ func (p *Printer) PrintSeparator() {
 	p.Printf("\n%s\n", GetSeparator())
 }
 
+// PrintTesting prints the notice shown while tests are running
 func (p *Printer) PrintTesting() {
 	msg := "测试中,请稍等..."
-	fmt.Printf("\n%s %s\n\n", EmojiLoading, msg)
+	p.Printf("\n%s %s\n\n", EmojiLoading, msg)
 }
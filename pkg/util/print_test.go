@@ -0,0 +1,42 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrinterWritesToWriter(t *testing.T) {
+	var out strings.Builder
+	p := NewPrinter(&out)
+	p.PrintTesting()
+	assert.Contains(t, out.String(), "测试中")
+
+	SetVerbosity(VerbosityQuiet)
+	defer SetVerbosity(VerbosityNormal)
+	out.Reset()
+	p.PrintTesting()
+	assert.Empty(t, out.String())
+}
+
+func TestPrinterASCII(t *testing.T) {
+	defer SetASCII(false)
+	SetASCII(true)
+
+	var out strings.Builder
+	p := NewPrinter(&out)
+	p.PrintTitle("检测结论", EmojiGear)
+	p.PrintError("请求失败")
+	p.PrintWarning("余额不足")
+	p.Printf("│ %s → %s\n", "a", "b")
+	p.Write([]byte(EmojiCheck + " 正常\n"))
+
+	got := out.String()
+	assert.Contains(t, got, "[*] ")
+	assert.Contains(t, got, "[FAIL] 请求失败")
+	assert.Contains(t, got, "[WARN] 余额不足")
+	assert.Contains(t, got, "| a -> b")
+	assert.Contains(t, got, "[OK] 正常")
+	assert.NotContains(t, got, "️")
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequestShape is the API request format used by link detection
+type RequestShape string
+
+// Request shapes, relays that only expose non-chat endpoints can still be traced
+const (
+	ShapeChat        RequestShape = "chat"        // /v1/chat/completions
+	ShapeCompletions RequestShape = "completions" // /v1/completions，旧版接口不支持图片，图片地址放在提示词中
+	ShapeResponses   RequestShape = "responses"   // /v1/responses，以 input_image 发送图片
+	ShapeAuto        RequestShape = "auto"        // chat 接口不存在时依次尝试 responses、completions
+)
+
+// autoShapes is the order shapes are tried in auto mode
+var autoShapes = []RequestShape{ShapeChat, ShapeResponses, ShapeCompletions}
+
+// ParseRequestShape parses the -shape flag value
+func ParseRequestShape(s string) (RequestShape, error) {
+	switch shape := RequestShape(strings.ToLower(strings.TrimSpace(s))); shape {
+	case ShapeChat, ShapeCompletions, ShapeResponses, ShapeAuto:
+		return shape, nil
+	case "":
+		return ShapeAuto, nil
+	default:
+		return "", fmt.Errorf("无效的请求格式: %s (可选: chat, completions, responses, auto)", s)
+	}
+}
+
+// ShapeURL returns the endpoint of shape for a chat completions URL
+func ShapeURL(chatURL string, shape RequestShape) string {
+	const chatPath = "/chat/completions"
+	if shape == ShapeChat || shape == ShapeAuto || !strings.Contains(chatURL, chatPath) {
+		return chatURL
+	}
+	i := strings.LastIndex(chatURL, chatPath)
+	return chatURL[:i] + "/" + string(shape) + chatURL[i+len(chatPath):]
+}
+
+// isMissingEndpoint reports whether the response means the relay does not serve the endpoint.
+// A 404 carrying an API error, such as OpenAI's model_not_found, comes from an endpoint that exists.
+func isMissingEndpoint(resp *APIResponse) bool {
+	return (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed) && !resp.upstream
+}
+
+// shapeRequest sends the image prompt in the given shape
+func (c *Client) shapeRequest(ctx context.Context, shape RequestShape, contxt, url string, imageURLs []string, key, model string) *APIResponse {
+	url = ShapeURL(url, shape)
+	var resp *APIResponse
+	switch shape {
+	case ShapeCompletions:
+		resp = c.completionsRequest(ctx, contxt, url, imageURLs, key, model)
+	case ShapeResponses:
+		resp = c.responsesRequest(ctx, contxt, url, imageURLs, key, model)
+	default:
+		shape = ShapeChat
+		resp = c.chatRequest(ctx, contxt, url, imageURLs, key, model)
+	}
+	resp.Shape = shape
+	return resp
+}
+
+// completionsRequest sends the prompt to the legacy /v1/completions endpoint.
+// It has no image input, so the image URLs are part of the prompt.
+func (c *Client) completionsRequest(ctx context.Context, contxt, url string, imageURLs []string, key, model string) *APIResponse {
+	payload := map[string]interface{}{
+		"model":      model,
+		"prompt":     fmt.Sprintf("%s\n%s", contxt, strings.Join(imageURLs, "\n")),
+		"max_tokens": c.MaxTokens,
+	}
+	body, _, errResp := c.postJSON(ctx, url, key, payload)
+	if errResp != nil {
+		return errResp
+	}
+
+	var completion struct {
+		Choices []struct {
+			Text string `json:"text"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return &APIResponse{StatusCode: http.StatusOK, Error: fmt.Errorf("failed to decode response: %v", err)}
+	}
+	if len(completion.Choices) == 0 {
+		return &APIResponse{StatusCode: http.StatusInternalServerError, Error: fmt.Errorf("no response content received")}
+	}
+	return &APIResponse{StatusCode: http.StatusOK, Response: completion.Choices[0].Text}
+}
+
+// responsesRequest sends the image prompt to the /v1/responses endpoint, every image as an input_image
+func (c *Client) responsesRequest(ctx context.Context, contxt, url string, imageURLs []string, key, model string) *APIResponse {
+	content := []map[string]string{{"type": "input_text", "text": contxt}}
+	for _, imageURL := range imageURLs {
+		content = append(content, map[string]string{"type": "input_image", "image_url": imageURL})
+	}
+	payload := map[string]interface{}{
+		"model": model,
+		"input": []map[string]interface{}{{
+			"role":    "user",
+			"content": content,
+		}},
+		"max_output_tokens": c.MaxTokens,
+	}
+	body, _, errResp := c.postJSON(ctx, url, key, payload)
+	if errResp != nil {
+		return errResp
+	}
+
+	text, err := responsesText(body)
+	if err != nil {
+		return &APIResponse{StatusCode: http.StatusOK, Error: err}
+	}
+	return &APIResponse{StatusCode: http.StatusOK, Response: text}
+}
+
+// responsesText joins the output_text parts of a Responses API response
+func responsesText(body []byte) (string, error) {
+	var resp struct {
+		Output []struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"output"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %v", err)
+	}
+	var text strings.Builder
+	for _, item := range resp.Output {
+		for _, part := range item.Content {
+			if part.Type == "output_text" {
+				text.WriteString(part.Text)
+			}
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response content received")
+	}
+	return text.String(), nil
+}
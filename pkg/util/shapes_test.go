@@ -0,0 +1,126 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeURL(t *testing.T) {
+	url := "https://api.example.com/v1/chat/completions"
+	assert.Equal(t, url, ShapeURL(url, ShapeChat))
+	assert.Equal(t, "https://api.example.com/v1/completions", ShapeURL(url, ShapeCompletions))
+	assert.Equal(t, "https://api.example.com/v1/responses", ShapeURL(url, ShapeResponses))
+	assert.Equal(t, "https://api.example.com/custom", ShapeURL("https://api.example.com/custom", ShapeResponses))
+
+	shape, err := ParseRequestShape("")
+	assert.NoError(t, err)
+	assert.Equal(t, ShapeAuto, shape)
+	_, err = ParseRequestShape("embeddings")
+	assert.Error(t, err)
+}
+
+func TestChatRequestAutoShape(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/v1/responses" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Input []struct {
+				Content []map[string]string `json:"content"`
+			} `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "input_image", req.Input[0].Content[1]["type"])
+		assert.Equal(t, "https://tunnel.example.com/image", req.Input[0].Content[1]["image_url"])
+		w.Write([]byte(`{"output":[{"type":"message","content":[{"type":"output_text","text":"The number is 1234."}]}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(20, false, 5*time.Second)
+	c.Shape = ShapeAuto
+	resp := c.ChatRequest(context.Background(), "what's the number?", srv.URL+"/v1/chat/completions", []string{"https://tunnel.example.com/image"}, "sk-test", "gpt-4o")
+
+	assert.NoError(t, resp.Error)
+	assert.Equal(t, ShapeResponses, resp.Shape)
+	assert.Equal(t, "The number is 1234.", resp.Response)
+	assert.Equal(t, []string{"/v1/chat/completions", "/v1/responses"}, paths)
+}
+
+func TestChatRequestAutoUpstreamError(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		if r.URL.Path == "/v1/chat/completions" {
+			w.Write([]byte(`{"error":{"message":"The model gpt-5x does not exist","type":"invalid_request_error","code":"model_not_found"}}`))
+			return
+		}
+		w.Write([]byte("404 page not found"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(20, false, 5*time.Second)
+	c.Shape = ShapeAuto
+	resp := c.ChatRequest(context.Background(), "what's the number?", srv.URL+"/v1/chat/completions", []string{"https://tunnel.example.com/image"}, "sk-test", "gpt-5x")
+
+	// The upstream error is returned as is, no other shape is tried
+	assert.ErrorContains(t, resp.Error, "model_not_found")
+	assert.Equal(t, []string{"/v1/chat/completions"}, paths)
+
+	// Without any shape served, the error of the chat endpoint is shown
+	paths = nil
+	resp = c.ChatRequest(context.Background(), "what's the number?", srv.URL+"/v2/chat/completions", []string{"https://tunnel.example.com/image"}, "sk-test", "gpt-4o")
+	assert.Equal(t, ShapeChat, resp.Shape)
+	assert.Len(t, paths, 3)
+}
+
+func TestChatRequestCompletionsShape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/completions", r.URL.Path)
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Contains(t, req.Prompt, "https://tunnel.example.com/image")
+		w.Write([]byte(`{"choices":[{"text":"1234"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(20, false, 5*time.Second)
+	c.Shape = ShapeCompletions
+	resp := c.ChatRequest(context.Background(), "what's the number?", srv.URL+"/v1/chat/completions", []string{"https://tunnel.example.com/image"}, "sk-test", "gpt-3.5-turbo-instruct")
+
+	assert.NoError(t, resp.Error)
+	assert.Equal(t, ShapeCompletions, resp.Shape)
+	assert.Equal(t, "1234", resp.Response)
+}
+
+func TestChatRequestMultipleImages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		content := req.Messages[0].Content
+		assert.Len(t, content, 3)
+		assert.Equal(t, "https://tunnel.example.com/image?id=a", content[1].ImageURL.URL)
+		assert.Equal(t, "https://tunnel.example.com/image?id=a&v=2", content[2].ImageURL.URL)
+		w.Write([]byte(`{"choices":[{"message":{"content":"1: 1234; 2: K7XQ"}}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(20, false, 5*time.Second)
+	c.Shape = ShapeChat
+	resp := c.ChatRequest(context.Background(), "2 images are attached.", srv.URL+"/v1/chat/completions",
+		[]string{"https://tunnel.example.com/image?id=a", "https://tunnel.example.com/image?id=a&v=2"}, "sk-test", "gpt-4o")
+
+	assert.NoError(t, resp.Error)
+	assert.Equal(t, "1: 1234; 2: K7XQ", resp.Response)
+}
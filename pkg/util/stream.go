@@ -0,0 +1,75 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamBufferedSpread is the spread below which the chunks of a stream are taken as flushed at once:
+// the upstream emits tokens tens of milliseconds apart, a relay buffering the answer sends them together
+const StreamBufferedSpread = 10 * time.Millisecond
+
+// Verdicts of how a relay handled a streaming request
+const (
+	StreamPassedThrough = "流式透传"
+	StreamBuffered      = "中转缓冲后一次性发送"
+	StreamConverted     = "中转将流式请求转为非流式响应"
+	StreamUndetermined  = "数据块过少, 无法判断"
+	StreamOff           = "未使用流式请求"
+)
+
+// StreamStats records when the data chunks of a streaming response arrived
+type StreamStats struct {
+	EventStream bool          // 响应为 SSE 事件流
+	Chunks      int           // 收到的数据块数
+	Spread      time.Duration // 首个与最后一个数据块的间隔
+}
+
+// Verdict tells whether the stream was passed through, nil stats mean streaming was not requested
+func (s *StreamStats) Verdict() string {
+	switch {
+	case s == nil:
+		return StreamOff
+	case !s.EventStream:
+		return StreamConverted
+	case s.Chunks < 2:
+		return StreamUndetermined
+	case s.Spread < StreamBufferedSpread:
+		return StreamBuffered
+	default:
+		return StreamPassedThrough
+	}
+}
+
+// readStream reads an SSE response line by line, recording when every data chunk arrived
+func readStream(resp *http.Response) ([]byte, *StreamStats, error) {
+	stats := &StreamStats{EventStream: strings.Contains(resp.Header.Get("Content-Type"), "event-stream")}
+	var body bytes.Buffer
+	var first, last time.Time
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		body.Write(line)
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if ok && !bytes.Equal(bytes.TrimSpace(data), []byte("[DONE]")) {
+			last = time.Now()
+			if stats.Chunks == 0 {
+				first = last
+			}
+			stats.Chunks++
+			stats.EventStream = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	stats.Spread = last.Sub(first)
+	return body.Bytes(), stats, nil
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStream(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"12\"}}]}\n\n"))
+		time.Sleep(3 * StreamBufferedSpread)
+		pw.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"34\"}}]}\n\ndata: [DONE]\n"))
+		pw.Close()
+	}()
+	resp := &http.Response{Header: http.Header{"Content-Type": {"text/event-stream"}}, Body: pr}
+	body, stats, err := readStream(resp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "[DONE]")
+	assert.Equal(t, 2, stats.Chunks)
+	assert.Equal(t, StreamPassedThrough, stats.Verdict())
+
+	resp = &http.Response{Header: http.Header{"Content-Type": {"text/event-stream"}}, Body: io.NopCloser(strings.NewReader(
+		"data: {}\n\ndata: {}\n\ndata: [DONE]\n"))}
+	_, stats, err = readStream(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, StreamBuffered, stats.Verdict())
+
+	resp = &http.Response{Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"choices":[]}`))}
+	_, stats, err = readStream(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, StreamConverted, stats.Verdict())
+
+	var off *StreamStats
+	assert.Equal(t, StreamOff, off.Verdict())
+}
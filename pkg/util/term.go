@@ -0,0 +1,36 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ReadPassword prints the prompt and reads a line from stdin without echo when it is a terminal
+func ReadPassword(prompt string) (string, error) {
+	fmt.Print(prompt)
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("读取口令失败: %v", err)
+		}
+		return string(b), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("读取口令失败: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// IsInteractive reports whether both stdin and stdout are terminals
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -0,0 +1,33 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTimeFormat is the layout of the timestamps in the output, set with -time-format
+const DefaultTimeFormat = "2006-01-02 15:04:05"
+
+// timeFormat is the layout FormatTime renders with
+var timeFormat = DefaultTimeFormat
+
+// SetTimeFormat sets the Go layout of the timestamps in the output, an empty layout keeps the default
+func SetTimeFormat(layout string) {
+	if layout != "" {
+		timeFormat = layout
+	}
+}
+
+// FormatTime renders t in the local timezone with the configured layout
+func FormatTime(t time.Time) string {
+	return t.Local().Format(timeFormat)
+}
+
+// FormatLatency renders a latency in seconds as milliseconds under a second and as seconds above,
+// e.g. 850ms and 1.24s
+func FormatLatency(seconds float64) string {
+	if seconds < 1 {
+		return fmt.Sprintf("%.0fms", seconds*1000)
+	}
+	return fmt.Sprintf("%.2fs", seconds)
+}
@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatLatency(t *testing.T) {
+	assert.Equal(t, "850ms", FormatLatency(0.85))
+	assert.Equal(t, "0ms", FormatLatency(0))
+	assert.Equal(t, "1.00s", FormatLatency(1))
+	assert.Equal(t, "12.35s", FormatLatency(12.345))
+}
+
+func TestFormatTime(t *testing.T) {
+	defer SetTimeFormat(DefaultTimeFormat)
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local)
+	assert.Equal(t, "2024-05-01 12:30:00", FormatTime(at))
+	assert.Equal(t, "2024-05-01 12:30:00", FormatTime(at.UTC()), "timestamps are shown in the local timezone")
+
+	SetTimeFormat("01/02 15:04")
+	assert.Equal(t, "05/01 12:30", FormatTime(at))
+	SetTimeFormat("")
+	assert.Equal(t, "05/01 12:30", FormatTime(at), "an empty layout keeps the current one")
+}
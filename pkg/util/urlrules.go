@@ -0,0 +1,74 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// URLRule rewrites API URLs matching Pattern, the result is used as typed without further normalization
+type URLRule struct {
+	Pattern *regexp.Regexp
+	Replace string // 支持 $1 等分组引用
+}
+
+// URLNormalization controls how NormalizeURL treats the input
+type URLNormalization struct {
+	Raw   bool // 使用原样输入的地址
+	Rules []URLRule
+}
+
+var (
+	urlNormalization     URLNormalization
+	urlNormalizationLock sync.RWMutex
+)
+
+// SetURLNormalization sets the global URL normalization settings
+func SetURLNormalization(n URLNormalization) {
+	urlNormalizationLock.Lock()
+	defer urlNormalizationLock.Unlock()
+	urlNormalization = n
+}
+
+// getURLNormalization returns the global URL normalization settings
+func getURLNormalization() URLNormalization {
+	urlNormalizationLock.RLock()
+	defer urlNormalizationLock.RUnlock()
+	return urlNormalization
+}
+
+// NewURLRule compiles a rewrite rule
+func NewURLRule(pattern, replace string) (URLRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return URLRule{}, fmt.Errorf("URL 改写规则无效: %s: %v", pattern, err)
+	}
+	return URLRule{Pattern: re, Replace: replace}, nil
+}
+
+// customURL applies -raw-url or the first matching rewrite rule, ok is false when neither applies
+func customURL(url string) (string, bool) {
+	n := getURLNormalization()
+	if n.Raw {
+		return strings.TrimSpace(url), true
+	}
+	if len(n.Rules) == 0 {
+		return "", false
+	}
+	url = withScheme(strings.TrimRight(strings.TrimSpace(url), "/ "))
+	for _, rule := range n.Rules {
+		if rule.Pattern.MatchString(url) {
+			return rule.Pattern.ReplaceAllString(url, rule.Replace), true
+		}
+	}
+	return "", false
+}
+
+// withScheme adds https:// to a URL without a scheme
+func withScheme(url string) string {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "https://" + url
+	}
+	return url
+}
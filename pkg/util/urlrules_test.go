@@ -0,0 +1,28 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLNormalization(t *testing.T) {
+	defer SetURLNormalization(URLNormalization{})
+
+	azure := "https://res.example.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21"
+	assert.NotEqual(t, azure, NormalizeURL(azure), "the suffix logic mangles query strings")
+
+	SetURLNormalization(URLNormalization{Raw: true})
+	assert.Equal(t, azure, NormalizeURL(" "+azure+" "))
+	assert.Equal(t, azure, ResolveEndpoint(context.Background(), azure))
+
+	rule, err := NewURLRule(`^(https://gw\.example\.com)/?$`, "$1/openai/v1/chat/completions")
+	assert.NoError(t, err)
+	SetURLNormalization(URLNormalization{Rules: []URLRule{rule}})
+	assert.Equal(t, "https://gw.example.com/openai/v1/chat/completions", NormalizeURL("gw.example.com/"))
+	assert.Equal(t, "https://api.example.com/v1/chat/completions", NormalizeURL("api.example.com"), "unmatched URLs are normalized as usual")
+
+	_, err = NewURLRule(`(`, "")
+	assert.Error(t, err)
+}
@@ -10,6 +10,9 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/go-coders/check-gpt/pkg/httpclient"
+	"github.com/go-coders/check-gpt/pkg/logger"
 )
 
 // Client represents an API client
@@ -19,6 +22,8 @@ type Client struct {
 	MaxTokens int
 	Stream    bool
 	Timeout   time.Duration
+	Shape     RequestShape  // 请求格式，为空时使用 chat
+	Template  *CurlTemplate // 重放的 cURL 请求，为空时使用默认请求头
 }
 
 // APIResponse represents an API response
@@ -26,6 +31,9 @@ type APIResponse struct {
 	StatusCode int
 	Error      error
 	Response   string
+	Shape      RequestShape // 实际使用的请求格式
+	Stream     *StreamStats // 流式响应的数据块时序, 未使用流式请求时为 nil
+	upstream   bool         // 错误响应带有上游的 error 对象, 接口存在
 }
 
 // ChatResponse represents a chat completion response
@@ -71,89 +79,74 @@ func getErrorMessage(statusCode int, body []byte) string {
 	return fmt.Sprintf("[%d] %s", statusCode, string(body)) // Return raw body with status code
 }
 
-// ChatRequest sends a chat request to the API and returns the response
-func (c *Client) ChatRequest(ctx context.Context, contxt string, url, imageURL, key, model string) *APIResponse {
-	messages := []Message{
-		{
-			Role: "user",
-			Content: []MessageContent{
-				{
-					Type: "text",
-					Text: contxt,
-				},
-				{
-					Type: "image_url",
-					ImageURL: &ImageURL{
-						URL: imageURL,
-					},
-				},
-			},
-		},
+// hasErrorObject reports whether body is an API error such as {"error":{"code":"model_not_found",...}}
+func hasErrorObject(body []byte) bool {
+	var errResp struct {
+		Error json.RawMessage `json:"error"`
 	}
-	requestBody := &Request{
-		Model:     model,
-		Messages:  messages,
-		MaxTokens: c.MaxTokens,
-		Stream:    c.Stream,
+	if json.Unmarshal(body, &errResp) != nil {
+		return false
 	}
+	return len(errResp.Error) > 0 && string(errResp.Error) != "null"
+}
 
-	// Marshal request body
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return &APIResponse{
-			StatusCode: http.StatusInternalServerError,
-			Error:      fmt.Errorf("failed to marshal request: %v", err),
-		}
+// ChatRequest sends the image prompt with its images to the API in the client's request shape and returns the response.
+// In auto mode the other shapes are tried when the chat endpoint does not exist.
+func (c *Client) ChatRequest(ctx context.Context, contxt, url string, imageURLs []string, key, model string) *APIResponse {
+	if c.Shape != ShapeAuto {
+		return c.shapeRequest(ctx, c.Shape, contxt, url, imageURLs, key, model)
 	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return &APIResponse{
-			StatusCode: http.StatusInternalServerError,
-			Error:      fmt.Errorf("failed to create request: %v", err),
+	// When no shape is served, the error of the chat endpoint is the one worth showing
+	var first *APIResponse
+	for _, shape := range autoShapes {
+		resp := c.shapeRequest(ctx, shape, contxt, url, imageURLs, key, model)
+		if !isMissingEndpoint(resp) {
+			return resp
+		}
+		logger.Debug("Endpoint for %s shape not found: %v", shape, resp.Error)
+		if first == nil {
+			first = resp
 		}
 	}
+	return first
+}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
-	req.Header.Set("User-Agent", "Apifox/1.0.0 (https://apifox.com)")
-
-	// Create client with timeout
-	client := &http.Client{
-		Timeout: c.Timeout,
+// chatRequest sends the image prompt to /v1/chat/completions
+func (c *Client) chatRequest(ctx context.Context, contxt, url string, imageURLs []string, key, model string) *APIResponse {
+	content := []MessageContent{
+		{
+			Type: "text",
+			Text: contxt,
+		},
 	}
-
-	// Send request
-	resp, err := client.Do(req)
-	if err != nil {
-		return &APIResponse{
-			StatusCode: http.StatusInternalServerError,
-			Error:      fmt.Errorf("failed to send request: %v", err),
-		}
+	for _, imageURL := range imageURLs {
+		content = append(content, MessageContent{
+			Type: "image_url",
+			ImageURL: &ImageURL{
+				URL: imageURL,
+			},
+		})
 	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return &APIResponse{
-			StatusCode: resp.StatusCode,
-			Error:      fmt.Errorf("failed to read response: %w", err),
-		}
+	messages := []Message{
+		{
+			Role:    "user",
+			Content: content,
+		},
 	}
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		errMsg := getErrorMessage(resp.StatusCode, body)
-		return &APIResponse{
-			StatusCode: resp.StatusCode,
-			Error:      fmt.Errorf("%s", errMsg),
-		}
+	requestBody := &Request{
+		Model:     model,
+		Messages:  messages,
+		MaxTokens: c.MaxTokens,
+		Stream:    c.Stream,
 	}
 
-	if c.Stream {
+	body, stream, errResp := c.postJSON(ctx, url, key, requestBody)
+	if errResp != nil {
+		return errResp
+	}
+	// A relay may answer a streaming request with a plain JSON response
+	if stream != nil && stream.EventStream {
 		// Handle streaming response
 		var fullResponse strings.Builder
 		reader := bufio.NewReader(bytes.NewReader(body))
@@ -164,7 +157,7 @@ func (c *Client) ChatRequest(ctx context.Context, contxt string, url, imageURL,
 					break
 				}
 				return &APIResponse{
-					StatusCode: resp.StatusCode,
+					StatusCode: http.StatusOK,
 					Error:      fmt.Errorf("failed to read stream: %v", err),
 				}
 			}
@@ -186,7 +179,7 @@ func (c *Client) ChatRequest(ctx context.Context, contxt string, url, imageURL,
 			var streamResp StreamResponse
 			if err := json.Unmarshal(line, &streamResp); err != nil {
 				return &APIResponse{
-					StatusCode: resp.StatusCode,
+					StatusCode: http.StatusOK,
 					Error:      fmt.Errorf("failed to unmarshal stream response: %v", err),
 				}
 			}
@@ -197,15 +190,16 @@ func (c *Client) ChatRequest(ctx context.Context, contxt string, url, imageURL,
 			}
 		}
 		return &APIResponse{
-			StatusCode: resp.StatusCode,
+			StatusCode: http.StatusOK,
 			Response:   fullResponse.String(),
+			Stream:     stream,
 		}
 	} else {
 		// Handle normal response
 		var chatResp ChatResponse
 		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&chatResp); err != nil {
 			return &APIResponse{
-				StatusCode: resp.StatusCode,
+				StatusCode: http.StatusOK,
 				Error:      fmt.Errorf("failed to decode response: %v", err),
 			}
 		}
@@ -213,8 +207,9 @@ func (c *Client) ChatRequest(ctx context.Context, contxt string, url, imageURL,
 		// Return response content
 		if len(chatResp.Choices) > 0 {
 			return &APIResponse{
-				StatusCode: resp.StatusCode,
+				StatusCode: http.StatusOK,
 				Response:   chatResp.Choices[0].Message.Content,
+				Stream:     stream,
 			}
 		}
 	}
@@ -224,10 +219,103 @@ func (c *Client) ChatRequest(ctx context.Context, contxt string, url, imageURL,
 	}
 }
 
-// MaskString masks a string by showing only the first and last few characters
-func MaskString(s string) string {
-	if len(s) <= 8 {
-		return s
+// probeFields are the body fields carrying the prompt and images, never taken from the template
+var probeFields = map[string]bool{"messages": true, "input": true, "prompt": true}
+
+// marshalPayload encodes payload with the body fields of the template laid over it, so the replayed
+// request keeps its own temperature, max tokens or user while the prompt and images are the probe
+func (c *Client) marshalPayload(payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil || c.Template == nil {
+		return data, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range c.Template.Body {
+		if !probeFields[k] {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// postJSON posts payload to url and returns the body of a 200 response, or the error response otherwise.
+// The chunk timing of a streaming request is returned along with the body.
+func (c *Client) postJSON(ctx context.Context, url, key string, payload interface{}) ([]byte, *StreamStats, *APIResponse) {
+	// Marshal request body
+	jsonData, err := c.marshalPayload(payload)
+	if err != nil {
+		return nil, nil, &APIResponse{
+			StatusCode: http.StatusInternalServerError,
+			Error:      fmt.Errorf("failed to marshal request: %v", err),
+		}
+	}
+
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, &APIResponse{
+			StatusCode: http.StatusInternalServerError,
+			Error:      fmt.Errorf("failed to create request: %v", err),
+		}
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+	req.Header.Set("User-Agent", "Apifox/1.0.0 (https://apifox.com)")
+	if c.Template != nil {
+		for name, values := range c.Template.Headers {
+			req.Header[name] = values
+		}
+	}
+	logger.AddSecret(key)
+	logger.DebugRequest(req)
+
+	// Create client with timeout
+	client := httpclient.New(c.Timeout)
+
+	// Send request
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, &APIResponse{
+			StatusCode: http.StatusInternalServerError,
+			Error:      fmt.Errorf("failed to send request: %w", err),
+		}
+	}
+	defer resp.Body.Close()
+	logger.DebugResponse(resp)
+
+	// Read response body
+	var body []byte
+	var stream *StreamStats
+	if c.Stream && resp.StatusCode == http.StatusOK {
+		body, stream, err = readStream(resp)
+	} else {
+		body, err = io.ReadAll(resp.Body)
 	}
-	return s[:4] + "***" + s[len(s)-4:]
+	if err != nil {
+		return nil, nil, &APIResponse{
+			StatusCode: resp.StatusCode,
+			Error:      fmt.Errorf("failed to read response: %w", err),
+		}
+	}
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		errMsg := getErrorMessage(resp.StatusCode, body)
+		return nil, nil, &APIResponse{
+			StatusCode: resp.StatusCode,
+			Error:      fmt.Errorf("%s", errMsg),
+			upstream:   hasErrorObject(body),
+		}
+	}
+	return body, stream, nil
+}
+
+// MaskString masks a string according to the global mask policy
+func MaskString(s string) string {
+	return MaskKey(s)
 }
@@ -97,12 +97,23 @@ func Min(a, b int) int {
 	return b
 }
 
+// Max returns the maximum of two integers
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // normalizeURL ensures the URL ends with /v1/chat/completions for OpenAI-compatible APIs
 func NormalizeURL(url string) string {
 
 	if url == "" {
 		return ""
 	}
+	if custom, ok := customURL(url); ok {
+		return custom
+	}
 	// chekc if http or https
 	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 		url = "https://" + url
@@ -141,6 +152,12 @@ func NormalizeURL(url string) string {
 	return url + suffix
 }
 
+// BaseURL strips the chat completions path and returns the API base URL
+func BaseURL(url string) string {
+	url = NormalizeURL(url)
+	return strings.TrimSuffix(url, "/v1/chat/completions")
+}
+
 // AddressType 表示地址类型
 type AddressType int
 
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// MinTerminalWidth is the narrowest width the tables are laid out for
+const MinTerminalWidth = 40
+
+// TerminalWidth returns the width of the terminal attached to stdout.
+// It falls back to $COLUMNS and then SeparatorWidth when stdout is not a terminal.
+func TerminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return SeparatorWidth
+}
+
+// StringWidth returns the display width of s, counting CJK characters and emoji as two columns
+func StringWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// PadRight pads s with spaces to the given display width
+func PadRight(s string, width int) string {
+	if w := StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// Truncate shortens s to the given display width, marking the cut with an ellipsis
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if StringWidth(s) <= width {
+		return s
+	}
+	return runewidth.Truncate(s, width, "…")
+}
+
+// TruncateMiddle shortens s to the given display width by cutting out the middle,
+// used for identifiers such as fine-tuned model IDs whose suffix matters
+func TruncateMiddle(s string, width int) string {
+	if StringWidth(s) <= width {
+		return s
+	}
+	if width < 3 {
+		return Truncate(s, width)
+	}
+
+	head := runewidth.Truncate(s, (width-1)/2, "")
+	runes := []rune(s)
+	tailWidth := width - 1 - StringWidth(head)
+	i := len(runes)
+	for i > 0 && runewidth.StringWidth(string(runes[i-1:])) <= tailWidth {
+		i--
+	}
+	return head + "…" + string(runes[i:])
+}
+
+// MaxWidth returns the largest display width among items
+func MaxWidth(items []string) int {
+	max := 0
+	for _, item := range items {
+		if w := StringWidth(item); w > max {
+			max = w
+		}
+	}
+	return max
+}
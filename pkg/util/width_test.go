@@ -0,0 +1,21 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPadRightAndTruncate(t *testing.T) {
+	assert.Equal(t, "gpt-4o    ", PadRight("gpt-4o", 10))
+	assert.Equal(t, "通用模型  ", PadRight("通用模型", 10))
+	assert.Equal(t, 10, StringWidth(PadRight("模型 ✅", 10)))
+	assert.Equal(t, "toolong", PadRight("toolong", 3))
+
+	assert.Equal(t, "gpt-4o", Truncate("gpt-4o", 10))
+	assert.Equal(t, "claude-3-…", Truncate("claude-3-5-sonnet-20241022", 10))
+	assert.Equal(t, "通用模…", Truncate("通用模型测试", 7))
+	assert.Equal(t, "ft:gpt-4o-…g:bot:9xYz", TruncateMiddle("ft:gpt-4o-mini-2024-07-18:my-org:bot:9xYz", 21))
+	assert.Equal(t, "gpt-4o", TruncateMiddle("gpt-4o", 10))
+	assert.Equal(t, 12, MaxWidth([]string{"gpt-4o", "中文模型名称"}))
+}